@@ -1,11 +1,22 @@
 package config
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"net"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
 	"strconv"
+	"strings"
+
+	"golang.org/x/text/encoding/htmlindex"
 )
 
 const (
@@ -17,6 +28,24 @@ const (
 	DefaultServerHost = "0.0.0.0"
 	// DefaultServerPort 是服务器的默认监听端口
 	DefaultServerPort = 8080
+	// DefaultReadHeaderTimeoutSeconds 是读取请求头的默认超时时间（秒）
+	DefaultReadHeaderTimeoutSeconds = 10
+	// DefaultIdleTimeoutSeconds 是 Keep-Alive 空闲连接的默认超时时间（秒）
+	DefaultIdleTimeoutSeconds = 120
+	// DefaultStreamStallTimeoutSeconds 是音频流写出没有进展时断开连接的默认超时时间（秒）
+	DefaultStreamStallTimeoutSeconds = 60
+	// MinStreamBufferSize 是音频流传输缓冲区允许的最小值（4KB）
+	MinStreamBufferSize = 4 * 1024
+	// MaxStreamBufferSize 是音频流传输缓冲区允许的最大值（16MB）
+	MaxStreamBufferSize = 16 * 1024 * 1024
+	// DefaultTagTimeoutSeconds 是单个文件标签解析的默认超时时间（秒）
+	DefaultTagTimeoutSeconds = 10
+	// DefaultDataDir 是持久化数据（如播放进度）的默认存储目录
+	DefaultDataDir = "./data"
+	// DefaultContentSecurityPolicy 是静态页面默认的 Content-Security-Policy，只允许加载同源资源。
+	DefaultContentSecurityPolicy = "default-src 'self'; img-src 'self' data:; media-src 'self' blob:; object-src 'none'; frame-ancestors 'none'; base-uri 'self'"
+	// ContentSecurityPolicyOff 是关闭 Content-Security-Policy 头的特殊配置值。
+	ContentSecurityPolicyOff = "off"
 
 	// MaxAllowedRangeSize 是单次 Range 请求允许的最大字节数上限（500MB）
 	MaxAllowedRangeSize = 500 * 1024 * 1024
@@ -24,10 +53,19 @@ const (
 	MaxAllowedCacheTTL = 1440 // 24 hours
 )
 
+// DefaultSupportedFormats 返回默认支持的音频文件格式列表。
+func DefaultSupportedFormats() []string {
+	return []string{".mp3", ".flac", ".wav", ".m4a", ".m4b", ".ogg", ".opus", ".aac", ".wma", ".aiff", ".aif"}
+}
+
 // Config 定义了应用程序的所有配置项。
 type Config struct {
-	Server ServerConfig `json:"server"`
-	Music  MusicConfig  `json:"music"`
+	// Schema 允许配置文件通过 "$schema" 引用 JSON Schema 供编辑器校验，加载时不使用。
+	Schema  string        `json:"$schema,omitempty"`
+	Server  ServerConfig  `json:"server"`
+	Music   MusicConfig   `json:"music"`
+	Storage StorageConfig `json:"storage"`
+	Audit   AuditConfig   `json:"audit"`
 }
 
 // ServerConfig 定义了服务器相关的配置。
@@ -35,6 +73,88 @@ type ServerConfig struct {
 	Host         string `json:"host"`
 	Port         int    `json:"port"`
 	MaxRangeSize int64  `json:"max_range_size"` // 单次 Range 请求允许的最大字节数
+	// RangeOverLimitBehavior 是 Range 请求超过 MaxRangeSize 时的处理方式：
+	// "reject"（默认）返回 400，"truncate" 将区间截断到上限后返回 206。
+	// 开放区间（如 "bytes=0-"）不受此项影响，总是截断到上限后返回 206。
+	RangeOverLimitBehavior string `json:"range_over_limit_behavior"`
+	// MaxConcurrentStreams 是同时进行的音频流数量上限，0 表示不限制。
+	MaxConcurrentStreams int `json:"max_concurrent_streams"`
+	// MaxStreamsPerIP 是单个客户端 IP 同时进行的音频流数量上限，0 表示不限制。
+	MaxStreamsPerIP int `json:"max_streams_per_ip"`
+	// StreamStallTimeoutSeconds 是音频流写出没有进展的最长时间（秒），超过后断开读取过慢的客户端，0 表示使用默认值。
+	// 每写出一个数据块（64KB 与 StreamBufferSize 中的较大者）重新计时，因此只有长时间几乎不读取数据的客户端会被断开。
+	StreamStallTimeoutSeconds int `json:"stream_stall_timeout_seconds"`
+	// StreamBufferSize 非 0 时音频流强制经过该大小（字节）的用户态缓冲区拷贝，0（默认）表示连接支持时使用 sendfile 零拷贝。
+	// 设置后不再使用 sendfile，适用于无法使用 sendfile 的连接（如 TLS），以较大的缓冲区减少系统调用次数；
+	// 缓冲区从池中复用，内存占用随并发流数量增长。
+	StreamBufferSize int `json:"stream_buffer_size"`
+	// EnablePprof 为 true 时在 /debug/pprof 注册性能分析端点（仅允许本地访问），默认关闭。
+	EnablePprof bool `json:"enable_pprof"`
+	// EnableSwagger 为 true 时在 /swagger 提供 OpenAPI 规格（/swagger/doc.json）与 Swagger UI，默认关闭。
+	EnableSwagger bool `json:"enable_swagger"`
+	// ReadTimeoutSeconds 是读取整个请求（含请求体）的超时时间（秒），0 表示不限制。
+	ReadTimeoutSeconds int `json:"read_timeout_seconds"`
+	// WriteTimeoutSeconds 是写出响应的超时时间（秒），0 表示不限制。
+	// 该超时覆盖整个响应的传输过程，设置过小会中断长时间的音频流。
+	WriteTimeoutSeconds int `json:"write_timeout_seconds"`
+	// IdleTimeoutSeconds 是 Keep-Alive 连接在两次请求之间的最长空闲时间（秒）。
+	IdleTimeoutSeconds int `json:"idle_timeout_seconds"`
+	// ReadHeaderTimeoutSeconds 是读取请求头的超时时间（秒）。
+	ReadHeaderTimeoutSeconds int `json:"read_header_timeout_seconds"`
+	// MaxHeaderBytes 是请求头的最大字节数，0 表示使用 net/http 的默认值（1MB）。
+	MaxHeaderBytes int `json:"max_header_bytes"`
+	// StreamCacheControl 是音频流成功响应的 Cache-Control 头（如 "public, max-age=86400"），为空则不设置。
+	StreamCacheControl string `json:"stream_cache_control"`
+	// WeakETag 为 true 时音频流返回弱 ETag（W/"..."），适用于会改写响应内容（如 gzip 压缩）的代理。
+	// If-None-Match 使用弱比较，仍可命中 304；If-Match 与 If-Range 按 RFC 9110 要求强比较，弱 ETag 不会命中，
+	// 断点续传需改用 Last-Modified。默认使用强 ETag。
+	WeakETag bool `json:"weak_etag"`
+	// XAccelRedirectPrefix 是 nginx internal location 的路径前缀（如 "/protected-music"），为空时关闭。
+	// 非空时音频流请求在校验通过后只返回 "X-Accel-Redirect: <前缀>/<相对路径>" 头与空响应体，
+	// 由 nginx 发送文件并处理 Range；转码与 cue 虚拟歌曲仍由本进程输出。
+	XAccelRedirectPrefix string `json:"x_accel_redirect_prefix"`
+	// PublicBaseURL 是服务对外的访问地址（如 https://music.example.com/zero），
+	// 用于生成歌曲的 stream_url 与 cover_url。为空时根据请求的 scheme 与 host 推断。
+	PublicBaseURL string `json:"public_base_url"`
+	// TimeFormat 是响应中歌曲时间字段（如 added_at）的默认格式：
+	// "rfc3339"（默认）输出 RFC3339 字符串，"unix_ms" 输出 Unix 毫秒时间戳。请求可通过 time_format 参数覆盖。
+	TimeFormat string `json:"time_format"`
+	// PrettyJSON 为 true 时默认输出带缩进的 JSON 响应，便于调试；请求可通过 pretty 参数覆盖，默认紧凑输出。
+	PrettyJSON bool `json:"pretty_json"`
+	// HealthChecks 是健康检查启用的检查项及其失败时的状态（"degraded" 或 "unhealthy"）。
+	// 检查项包括 "music_dir"（音乐目录可访问）、"songs"（缓存的歌曲数不为 0）与 "last_scan"（最近一次扫描成功）。
+	// 未设置时只检查 music_dir，失败时为 degraded；设置为空对象表示不做任何检查。
+	HealthChecks map[string]string `json:"health_checks"`
+	// TrustedProxies 是受信任的反向代理 IP 或 CIDR 列表。只有来自这些地址的请求
+	// 才会使用 X-Forwarded-For 解析客户端 IP；为空表示不信任任何代理。
+	TrustedProxies []string `json:"trusted_proxies"`
+	// GRPCPort 是 gRPC 服务的监听端口，0 表示不启动 gRPC 服务。
+	GRPCPort int `json:"grpc_port"`
+	// AdminAddr 是运维端点（/admin）的独立监听地址（如 "127.0.0.1:8081"）。
+	// 非空时 /admin 路由只注册在该监听上，主端口不再暴露；为空时 /admin 与其他路由共用主端口。
+	AdminAddr string `json:"admin_addr"`
+	// EnableH2C 为 true 时允许客户端通过明文 HTTP/2（h2c）访问，适用于无 TLS 的内网部署。
+	EnableH2C bool `json:"enable_h2c"`
+	// EnableWebUI 为 true 时在根路径提供内置的网页播放器，API 信息移至 /api。
+	EnableWebUI bool `json:"enable_web_ui"`
+	// DisableSecurityHeaders 为 true 时不设置 X-Content-Type-Options、X-Frame-Options、
+	// Referrer-Policy 与 Content-Security-Policy 等安全头，默认设置。
+	DisableSecurityHeaders bool `json:"disable_security_headers"`
+	// ContentSecurityPolicy 是静态页面的 Content-Security-Policy 头，为空时使用 DefaultContentSecurityPolicy，
+	// 为 "off" 时不设置。
+	ContentSecurityPolicy string `json:"content_security_policy"`
+}
+
+// CSP 返回实际使用的 Content-Security-Policy，关闭时返回空字符串。
+func (s ServerConfig) CSP() string {
+	switch s.ContentSecurityPolicy {
+	case "":
+		return DefaultContentSecurityPolicy
+	case ContentSecurityPolicyOff:
+		return ""
+	default:
+		return s.ContentSecurityPolicy
+	}
 }
 
 // MusicConfig 定义了音乐库相关的配置。
@@ -45,36 +165,136 @@ type MusicConfig struct {
 	SupportedFormats []string `json:"supported_formats"`
 	// CacheTTLMinutes 是音乐列表缓存的有效期（分钟）。
 	CacheTTLMinutes int `json:"cache_ttl_minutes"`
+	// IncludeHidden 控制扫描时是否收录隐藏文件和目录（包括 macOS 的 "._" 文件），默认不收录。
+	IncludeHidden bool `json:"include_hidden"`
+	// MinFileSize 是被收录为歌曲的最小文件大小（字节），小于该值的文件会被跳过，0 表示不限制。
+	MinFileSize int64 `json:"min_file_size"`
+	// ScanTimeoutSeconds 是单次扫描的超时时间（秒），0 表示不限制。
+	ScanTimeoutSeconds int `json:"scan_timeout_seconds"`
+	// TagTimeoutSeconds 是单个文件标签解析的超时时间（秒），超时的文件使用文件名作为标题，0 表示使用默认值。
+	TagTimeoutSeconds int `json:"tag_timeout_seconds"`
+	// ScanMode 是扫描模式："full"（默认）在每次扫描时移除已不可见的歌曲，
+	// "additive" 只新增发现的歌曲，直到显式刷新时才移除。
+	ScanMode string `json:"scan_mode"`
+	// ChangeDetection 是判断文件是否修改的方式："mtime"（默认）比较文件大小与修改时间，
+	// "hash" 比较整个文件内容的哈希，只修改了 mtime 的文件不会重新解析标签，但每次扫描都要读取所有文件。
+	ChangeDetection string `json:"change_detection"`
+	// IgnoreMarkers 是目录黑名单标记文件名列表（如 ".nomedia"），包含任一标记文件的目录及其子目录不会被扫描。
+	// 未设置时使用扫描器的默认列表，设置为空数组表示禁用。
+	IgnoreMarkers []string `json:"ignore_markers"`
+	// ArtistSeparators 是拆分多艺术家标签（如 "A; B"、"A feat. B"）的分隔符，英文字母不区分大小写。
+	// 未设置时使用 ";"、"/"、"feat."、"ft."，设置为空数组表示不拆分。
+	ArtistSeparators []string `json:"artist_separators"`
+	// ComputeFingerprint 为 true 时为每首歌曲计算内容指纹（用于重复检测），
+	// 需要读取每个文件的开头，因此默认关闭。配置了 CacheDir 时指纹会被持久缓存。
+	ComputeFingerprint bool `json:"compute_fingerprint"`
+	// ComputeAcousticFingerprint 为 true 时调用 chromaprint 的 fpcalc 为每首歌曲计算声学指纹，
+	// 用于识别同一首歌的不同编码。需要解码音频，开销很大，默认关闭；找不到 fpcalc 时不计算。
+	ComputeAcousticFingerprint bool `json:"compute_acoustic_fingerprint"`
+	// InferFromPath 为 true 时，标签缺失的歌曲按 "艺术家/专辑/曲目" 的目录结构推断艺术家与专辑。
+	InferFromPath bool `json:"infer_from_path"`
+	// FilenamePattern 是标签缺失时从文件名（不含扩展名）解析元数据的正则表达式，
+	// 支持 track、artist、title、album 命名捕获组，如 `(?P<track>\d+) - (?P<artist>.+) - (?P<title>.+)`。为空时不解析。
+	FilenamePattern string `json:"filename_pattern"`
+	// FilenameEncoding 是音乐文件名使用的字符编码（如 "gbk"、"shift-jis"），用于修复从其他系统拷贝的乱码文件名。
+	// 不是合法 UTF-8 的文件名按该编码转码后用作标题与展示，文件路径保持原样。为空或 "utf-8" 时不转换。
+	FilenameEncoding string `json:"filename_encoding"`
+	// IDLength 是歌曲 ID 的字节长度（8-32），ID 为其两倍长度的十六进制字符串，0 表示使用默认的 16 字节。
+	// 修改长度会使已保存的歌曲 ID（置顶、播放进度、客户端收藏等）全部失效。
+	IDLength int `json:"id_length"`
+	// CacheDir 是封面等提取结果的磁盘缓存目录，为空表示不缓存。
+	CacheDir string `json:"cache_dir"`
+	// WarmupOnStart 为 true 时在服务启动时异步扫描一次音乐目录，避免首个请求等待冷扫描。
+	WarmupOnStart bool `json:"warmup_on_start"`
+	// DefaultSort 是歌曲列表在未指定 sort 参数时的排序字段（pinned、title、artist、album、added_at），
+	// 为空时保持扫描顺序。
+	DefaultSort string `json:"default_sort"`
+	// DefaultOrder 是歌曲列表在未指定 order 参数时的排序方向（asc 或 desc），为空时按升序。
+	DefaultOrder string `json:"default_order"`
+}
+
+// StorageConfig 定义了持久化数据相关的配置。
+type StorageConfig struct {
+	// DataDir 是持久化数据（如播放进度）的存储目录。
+	DataDir string `json:"data_dir"`
 }
 
+// AuditConfig 定义了流式传输审计日志相关的配置。
+type AuditConfig struct {
+	// LogFile 是审计日志文件路径，为空时审计记录写入主日志。
+	LogFile string `json:"log_file"`
+	// MaxSizeMB 是审计日志文件轮转前的最大大小（MB），0 表示不轮转。
+	MaxSizeMB int `json:"max_size_mb"`
+	// MaxBackups 是轮转后保留的旧审计日志文件数量。
+	MaxBackups int `json:"max_backups"`
+}
+
+// ConfigJSONEnv 是直接提供完整 JSON 配置内容的环境变量，设置后优先于配置文件。
+const ConfigJSONEnv = "ZERO_MUSIC_CONFIG_JSON"
+
+// configStdin 是 configPath 为 "-" 时读取配置的来源，测试时可替换。
+var configStdin io.Reader = os.Stdin
+
 // Load 从指定的路径加载配置文件。
-// 如果 configPath 为空,则返回默认配置。
+// 设置了 ZERO_MUSIC_CONFIG_JSON 环境变量时直接解析其内容，忽略 configPath；
+// configPath 为 "-" 时从标准输入读取；如果 configPath 为空,则返回默认配置。
+// 以 .gz 结尾的文件会被透明解压后再解析。
 func Load(configPath string) (*Config, error) {
-	if configPath == "" {
+	var data []byte
+	var err error
+	if raw := os.Getenv(ConfigJSONEnv); strings.TrimSpace(raw) != "" {
+		data = []byte(raw)
+		configPath = ConfigJSONEnv
+	} else if configPath == "" {
 		return GetDefaultConfig(), nil
-	}
-
-	// 读取配置文件。
-	data, err := os.ReadFile(configPath)
-	if err != nil {
+	} else if configPath == "-" {
+		// 从标准输入读取配置内容。
+		if data, err = io.ReadAll(configStdin); err != nil {
+			return nil, fmt.Errorf("从标准输入读取配置失败: %v", err)
+		}
+		configPath = "标准输入"
+	} else if data, err = readConfigFile(configPath); err != nil {
+		// 读取配置文件。
 		return nil, err
 	}
 
 	var cfg Config
-	if err := json.Unmarshal(data, &cfg); err != nil {
-		return nil, err
+	if err := decodeConfig(data, &cfg); err != nil {
+		return nil, fmt.Errorf("解析 %s 中的配置失败: %v", configPath, err)
 	}
 
 	// 为空字段设置默认值。
 	if len(cfg.Music.SupportedFormats) == 0 {
-		cfg.Music.SupportedFormats = []string{".mp3", ".flac", ".wav", ".m4a", ".ogg"}
+		cfg.Music.SupportedFormats = DefaultSupportedFormats()
 	}
 	if cfg.Music.CacheTTLMinutes == 0 {
 		cfg.Music.CacheTTLMinutes = DefaultCacheTTLMinutes
 	}
+	if cfg.Music.TagTimeoutSeconds == 0 {
+		cfg.Music.TagTimeoutSeconds = DefaultTagTimeoutSeconds
+	}
 	if cfg.Server.MaxRangeSize == 0 {
 		cfg.Server.MaxRangeSize = DefaultMaxRangeSize
 	}
+	if cfg.Server.ReadHeaderTimeoutSeconds == 0 {
+		cfg.Server.ReadHeaderTimeoutSeconds = DefaultReadHeaderTimeoutSeconds
+	}
+	if cfg.Server.IdleTimeoutSeconds == 0 {
+		cfg.Server.IdleTimeoutSeconds = DefaultIdleTimeoutSeconds
+	}
+	if cfg.Server.StreamStallTimeoutSeconds == 0 {
+		cfg.Server.StreamStallTimeoutSeconds = DefaultStreamStallTimeoutSeconds
+	}
+	if cfg.Storage.DataDir == "" {
+		cfg.Storage.DataDir = DefaultDataDir
+	}
+
+	// 展开路径中的 ~ 与环境变量，展开失败时保留原值，由校验阶段报错。
+	for _, path := range configPaths(&cfg) {
+		if expanded, err := expandPath(*path); err == nil {
+			*path = expanded
+		}
+	}
 
 	// 验证配置的有效性
 	if err := validateConfig(&cfg); err != nil {
@@ -89,12 +309,98 @@ func Load(configPath string) (*Config, error) {
 		}
 	}
 
+	// 将缓存目录的相对路径转换为绝对路径。
+	if cfg.Music.CacheDir != "" && !filepath.IsAbs(cfg.Music.CacheDir) {
+		if absPath, err := filepath.Abs(cfg.Music.CacheDir); err == nil {
+			cfg.Music.CacheDir = absPath
+		}
+	}
+
+	// 将数据目录的相对路径转换为绝对路径。
+	if !filepath.IsAbs(cfg.Storage.DataDir) {
+		if absPath, err := filepath.Abs(cfg.Storage.DataDir); err == nil {
+			cfg.Storage.DataDir = absPath
+		}
+	}
+
 	// 应用环境变量覆盖配置
 	applyEnvOverrides(&cfg)
 
 	return &cfg, nil
 }
 
+// configPaths 返回配置中支持 ~ 与环境变量展开的路径字段。
+func configPaths(cfg *Config) map[string]*string {
+	return map[string]*string{
+		"Directory": &cfg.Music.Directory,
+		"CacheDir":  &cfg.Music.CacheDir,
+		"DataDir":   &cfg.Storage.DataDir,
+	}
+}
+
+// expandPath 将路径开头的 ~ 展开为用户主目录，并将 ${VAR} 与 $VAR 替换为环境变量的值。
+// 引用了未设置的环境变量或无法获取主目录时返回错误。
+func expandPath(path string) (string, error) {
+	var missing []string
+	expanded := os.Expand(path, func(name string) string {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return value
+	})
+	if len(missing) > 0 {
+		return path, fmt.Errorf("环境变量 %s 未设置", strings.Join(missing, ", "))
+	}
+
+	if expanded == "~" || strings.HasPrefix(expanded, "~/") || strings.HasPrefix(expanded, "~"+string(filepath.Separator)) {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return path, fmt.Errorf("无法获取用户主目录: %v", err)
+		}
+		expanded = filepath.Join(homeDir, expanded[1:])
+	}
+	return expanded, nil
+}
+
+// envPath 展开环境变量中的路径（~ 与 $VAR）并转换为绝对路径，与配置文件中的路径保持一致。
+func envPath(path string) (string, error) {
+	expanded, err := expandPath(path)
+	if err != nil {
+		return path, err
+	}
+	if !filepath.IsAbs(expanded) {
+		if absPath, err := filepath.Abs(expanded); err == nil {
+			expanded = absPath
+		}
+	}
+	return expanded, nil
+}
+
+// readConfigFile 读取配置文件的内容。
+// 如果文件以 .gz 结尾，则先进行 gzip 解压。
+func readConfigFile(configPath string) ([]byte, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(strings.ToLower(configPath), ".gz") {
+		return data, nil
+	}
+
+	gr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("解压配置文件 %s 失败: %v", configPath, err)
+	}
+	defer gr.Close()
+
+	decompressed, err := io.ReadAll(gr)
+	if err != nil {
+		return nil, fmt.Errorf("解压配置文件 %s 失败: %v", configPath, err)
+	}
+	return decompressed, nil
+}
+
 // applyEnvOverrides 使用环境变量覆盖配置
 func applyEnvOverrides(cfg *Config) {
 	// 服务器配置
@@ -106,26 +412,196 @@ func applyEnvOverrides(cfg *Config) {
 			cfg.Server.Port = p
 		}
 	}
+	if grpcPort := os.Getenv("ZERO_MUSIC_GRPC_PORT"); grpcPort != "" {
+		if p, err := strconv.Atoi(grpcPort); err == nil && p >= 0 && p <= 65535 {
+			cfg.Server.GRPCPort = p
+		}
+	}
+	if adminAddr := os.Getenv("ZERO_MUSIC_ADMIN_ADDR"); adminAddr != "" {
+		if _, port, err := net.SplitHostPort(adminAddr); err == nil && port != "" {
+			cfg.Server.AdminAddr = adminAddr
+		}
+	}
+	if h2c := os.Getenv("ZERO_MUSIC_ENABLE_H2C"); h2c != "" {
+		if b, err := strconv.ParseBool(h2c); err == nil {
+			cfg.Server.EnableH2C = b
+		}
+	}
+	if webUI := os.Getenv("ZERO_MUSIC_ENABLE_WEB_UI"); webUI != "" {
+		if b, err := strconv.ParseBool(webUI); err == nil {
+			cfg.Server.EnableWebUI = b
+		}
+	}
+	if pprof := os.Getenv("ZERO_MUSIC_ENABLE_PPROF"); pprof != "" {
+		if b, err := strconv.ParseBool(pprof); err == nil {
+			cfg.Server.EnablePprof = b
+		}
+	}
+	if swagger := os.Getenv("ZERO_MUSIC_ENABLE_SWAGGER"); swagger != "" {
+		if b, err := strconv.ParseBool(swagger); err == nil {
+			cfg.Server.EnableSwagger = b
+		}
+	}
+	if prefix := os.Getenv("ZERO_MUSIC_X_ACCEL_REDIRECT_PREFIX"); prefix != "" {
+		if strings.HasPrefix(prefix, "/") {
+			cfg.Server.XAccelRedirectPrefix = prefix
+		}
+	}
+	if timeFormat := os.Getenv("ZERO_MUSIC_TIME_FORMAT"); timeFormat != "" {
+		if timeFormat == "rfc3339" || timeFormat == "unix_ms" {
+			cfg.Server.TimeFormat = timeFormat
+		}
+	}
+	if checks := os.Getenv("ZERO_MUSIC_HEALTH_CHECKS"); checks != "" {
+		if parsed := parseHealthChecks(checks); validateHealthChecks(parsed) == nil {
+			cfg.Server.HealthChecks = parsed
+		}
+	}
+	if pretty := os.Getenv("ZERO_MUSIC_PRETTY_JSON"); pretty != "" {
+		if b, err := strconv.ParseBool(pretty); err == nil {
+			cfg.Server.PrettyJSON = b
+		}
+	}
+	if disable := os.Getenv("ZERO_MUSIC_DISABLE_SECURITY_HEADERS"); disable != "" {
+		if b, err := strconv.ParseBool(disable); err == nil {
+			cfg.Server.DisableSecurityHeaders = b
+		}
+	}
+	if csp := os.Getenv("ZERO_MUSIC_CONTENT_SECURITY_POLICY"); csp != "" {
+		cfg.Server.ContentSecurityPolicy = csp
+	}
 	if maxRange := os.Getenv("ZERO_MUSIC_MAX_RANGE_SIZE"); maxRange != "" {
 		if size, err := strconv.ParseInt(maxRange, 10, 64); err == nil && size > 0 && size <= MaxAllowedRangeSize {
 			cfg.Server.MaxRangeSize = size
 		}
 	}
+	if behavior := os.Getenv("ZERO_MUSIC_RANGE_OVER_LIMIT_BEHAVIOR"); behavior != "" {
+		if behavior == "reject" || behavior == "truncate" {
+			cfg.Server.RangeOverLimitBehavior = behavior
+		}
+	}
+	if baseURL := os.Getenv("ZERO_MUSIC_PUBLIC_BASE_URL"); baseURL != "" {
+		cfg.Server.PublicBaseURL = baseURL
+	}
+	if maxStreams := os.Getenv("ZERO_MUSIC_MAX_CONCURRENT_STREAMS"); maxStreams != "" {
+		if n, err := strconv.Atoi(maxStreams); err == nil && n >= 0 {
+			cfg.Server.MaxConcurrentStreams = n
+		}
+	}
+	if perIP := os.Getenv("ZERO_MUSIC_MAX_STREAMS_PER_IP"); perIP != "" {
+		if n, err := strconv.Atoi(perIP); err == nil && n >= 0 {
+			cfg.Server.MaxStreamsPerIP = n
+		}
+	}
+	if stall := os.Getenv("ZERO_MUSIC_STREAM_STALL_TIMEOUT_SECONDS"); stall != "" {
+		if seconds, err := strconv.Atoi(stall); err == nil && seconds > 0 {
+			cfg.Server.StreamStallTimeoutSeconds = seconds
+		}
+	}
+	if bufferSize := os.Getenv("ZERO_MUSIC_STREAM_BUFFER_SIZE"); bufferSize != "" {
+		if size, err := strconv.Atoi(bufferSize); err == nil && (size == 0 || (size >= MinStreamBufferSize && size <= MaxStreamBufferSize)) {
+			cfg.Server.StreamBufferSize = size
+		}
+	}
+	if weak := os.Getenv("ZERO_MUSIC_WEAK_ETAG"); weak != "" {
+		if b, err := strconv.ParseBool(weak); err == nil {
+			cfg.Server.WeakETag = b
+		}
+	}
+	if proxies := os.Getenv("ZERO_MUSIC_TRUSTED_PROXIES"); proxies != "" {
+		var trusted []string
+		for _, proxy := range strings.Split(proxies, ",") {
+			if proxy = strings.TrimSpace(proxy); proxy != "" && isValidProxy(proxy) {
+				trusted = append(trusted, proxy)
+			}
+		}
+		cfg.Server.TrustedProxies = trusted
+	}
 
 	// 音乐配置
 	if musicDir := os.Getenv("ZERO_MUSIC_MUSIC_DIRECTORY"); musicDir != "" {
-		if !filepath.IsAbs(musicDir) {
-			if absPath, err := filepath.Abs(musicDir); err == nil {
-				musicDir = absPath
-			}
+		if path, err := envPath(musicDir); err == nil {
+			cfg.Music.Directory = path
 		}
-		cfg.Music.Directory = musicDir
 	}
 	if cacheTTL := os.Getenv("ZERO_MUSIC_CACHE_TTL_MINUTES"); cacheTTL != "" {
 		if ttl, err := strconv.Atoi(cacheTTL); err == nil && ttl > 0 && ttl <= MaxAllowedCacheTTL {
 			cfg.Music.CacheTTLMinutes = ttl
 		}
 	}
+	if warmup := os.Getenv("ZERO_MUSIC_WARMUP_ON_START"); warmup != "" {
+		if b, err := strconv.ParseBool(warmup); err == nil {
+			cfg.Music.WarmupOnStart = b
+		}
+	}
+	if fingerprint := os.Getenv("ZERO_MUSIC_COMPUTE_FINGERPRINT"); fingerprint != "" {
+		if b, err := strconv.ParseBool(fingerprint); err == nil {
+			cfg.Music.ComputeFingerprint = b
+		}
+	}
+	if acoustic := os.Getenv("ZERO_MUSIC_COMPUTE_ACOUSTIC_FINGERPRINT"); acoustic != "" {
+		if b, err := strconv.ParseBool(acoustic); err == nil {
+			cfg.Music.ComputeAcousticFingerprint = b
+		}
+	}
+	if infer := os.Getenv("ZERO_MUSIC_INFER_FROM_PATH"); infer != "" {
+		if b, err := strconv.ParseBool(infer); err == nil {
+			cfg.Music.InferFromPath = b
+		}
+	}
+	if pattern := os.Getenv("ZERO_MUSIC_FILENAME_PATTERN"); pattern != "" {
+		if _, err := regexp.Compile(pattern); err == nil {
+			cfg.Music.FilenamePattern = pattern
+		}
+	}
+	if filenameEncoding := os.Getenv("ZERO_MUSIC_FILENAME_ENCODING"); filenameEncoding != "" {
+		if _, err := htmlindex.Get(strings.TrimSpace(filenameEncoding)); err == nil {
+			cfg.Music.FilenameEncoding = filenameEncoding
+		}
+	}
+	if tagTimeout := os.Getenv("ZERO_MUSIC_TAG_TIMEOUT_SECONDS"); tagTimeout != "" {
+		if seconds, err := strconv.Atoi(tagTimeout); err == nil && seconds > 0 {
+			cfg.Music.TagTimeoutSeconds = seconds
+		}
+	}
+	if idLength := os.Getenv("ZERO_MUSIC_ID_LENGTH"); idLength != "" {
+		if n, err := strconv.Atoi(idLength); err == nil && n >= 8 && n <= 32 {
+			cfg.Music.IDLength = n
+		}
+	}
+	if changeDetection := os.Getenv("ZERO_MUSIC_CHANGE_DETECTION"); changeDetection != "" {
+		if changeDetection == "mtime" || changeDetection == "hash" {
+			cfg.Music.ChangeDetection = changeDetection
+		}
+	}
+	if defaultSort := os.Getenv("ZERO_MUSIC_DEFAULT_SORT"); defaultSort != "" {
+		switch defaultSort {
+		case "pinned", "title", "artist", "album", "added_at":
+			cfg.Music.DefaultSort = defaultSort
+		}
+	}
+	if defaultOrder := os.Getenv("ZERO_MUSIC_DEFAULT_ORDER"); defaultOrder != "" {
+		if defaultOrder == "asc" || defaultOrder == "desc" {
+			cfg.Music.DefaultOrder = defaultOrder
+		}
+	}
+	if cacheDir := os.Getenv("ZERO_MUSIC_CACHE_DIR"); cacheDir != "" {
+		if path, err := envPath(cacheDir); err == nil {
+			cfg.Music.CacheDir = path
+		}
+	}
+
+	// 审计日志配置
+	if auditFile := os.Getenv("ZERO_MUSIC_AUDIT_LOG_FILE"); auditFile != "" {
+		cfg.Audit.LogFile = auditFile
+	}
+
+	// 存储配置
+	if dataDir := os.Getenv("ZERO_MUSIC_DATA_DIR"); dataDir != "" {
+		if path, err := envPath(dataDir); err == nil {
+			cfg.Storage.DataDir = path
+		}
+	}
 }
 
 // ProvideConfig 是 Wire 的提供者函数,用于加载配置
@@ -140,24 +616,239 @@ func validateConfig(cfg *Config) error {
 		return fmt.Errorf("端口必须在 1-65535 范围内，当前值: %d", cfg.Server.Port)
 	}
 
+	// 验证 gRPC 端口范围，0 表示不启动
+	if cfg.Server.GRPCPort < 0 || cfg.Server.GRPCPort > 65535 {
+		return fmt.Errorf("gRPC 端口必须在 0-65535 范围内，当前值: %d", cfg.Server.GRPCPort)
+	}
+
+	// 验证 AdminAddr
+	if cfg.Server.AdminAddr != "" {
+		if _, port, err := net.SplitHostPort(cfg.Server.AdminAddr); err != nil || port == "" {
+			return fmt.Errorf("AdminAddr 必须为 host:port 格式，当前值: %s", cfg.Server.AdminAddr)
+		}
+	}
+
 	// 验证 MaxRangeSize
 	if cfg.Server.MaxRangeSize < 0 || cfg.Server.MaxRangeSize > MaxAllowedRangeSize {
 		return fmt.Errorf("MaxRangeSize 必须在 0-%d 范围内，当前值: %d", MaxAllowedRangeSize, cfg.Server.MaxRangeSize)
 	}
 
+	// 验证 RangeOverLimitBehavior
+	if cfg.Server.RangeOverLimitBehavior != "" && cfg.Server.RangeOverLimitBehavior != "reject" && cfg.Server.RangeOverLimitBehavior != "truncate" {
+		return fmt.Errorf("RangeOverLimitBehavior 必须为 reject 或 truncate，当前值: %s", cfg.Server.RangeOverLimitBehavior)
+	}
+
+	// 验证 HealthChecks
+	if err := validateHealthChecks(cfg.Server.HealthChecks); err != nil {
+		return err
+	}
+
+	// 验证 XAccelRedirectPrefix
+	if cfg.Server.XAccelRedirectPrefix != "" && !strings.HasPrefix(cfg.Server.XAccelRedirectPrefix, "/") {
+		return fmt.Errorf("XAccelRedirectPrefix 必须以 / 开头，当前值: %s", cfg.Server.XAccelRedirectPrefix)
+	}
+
+	// 验证 TimeFormat
+	if cfg.Server.TimeFormat != "" && cfg.Server.TimeFormat != "rfc3339" && cfg.Server.TimeFormat != "unix_ms" {
+		return fmt.Errorf("TimeFormat 必须为 rfc3339 或 unix_ms，当前值: %s", cfg.Server.TimeFormat)
+	}
+
+	// 验证 MaxStreamsPerIP
+	if cfg.Server.MaxStreamsPerIP < 0 {
+		return fmt.Errorf("MaxStreamsPerIP 不能为负数，当前值: %d", cfg.Server.MaxStreamsPerIP)
+	}
+
+	// 验证 StreamStallTimeoutSeconds
+	if cfg.Server.StreamStallTimeoutSeconds < 0 {
+		return fmt.Errorf("StreamStallTimeoutSeconds 不能为负数，当前值: %d", cfg.Server.StreamStallTimeoutSeconds)
+	}
+
+	// 验证 StreamBufferSize
+	if cfg.Server.StreamBufferSize != 0 && (cfg.Server.StreamBufferSize < MinStreamBufferSize || cfg.Server.StreamBufferSize > MaxStreamBufferSize) {
+		return fmt.Errorf("StreamBufferSize 必须在 %d 到 %d 字节之间，当前值: %d", MinStreamBufferSize, MaxStreamBufferSize, cfg.Server.StreamBufferSize)
+	}
+
+	// 验证 MaxConcurrentStreams
+	if cfg.Server.MaxConcurrentStreams < 0 {
+		return fmt.Errorf("MaxConcurrentStreams 不能为负数，当前值: %d", cfg.Server.MaxConcurrentStreams)
+	}
+
+	// 验证超时与请求头大小
+	for name, value := range map[string]int{
+		"ReadTimeoutSeconds":       cfg.Server.ReadTimeoutSeconds,
+		"WriteTimeoutSeconds":      cfg.Server.WriteTimeoutSeconds,
+		"IdleTimeoutSeconds":       cfg.Server.IdleTimeoutSeconds,
+		"ReadHeaderTimeoutSeconds": cfg.Server.ReadHeaderTimeoutSeconds,
+		"MaxHeaderBytes":           cfg.Server.MaxHeaderBytes,
+	} {
+		if value < 0 {
+			return fmt.Errorf("%s 不能为负数，当前值: %d", name, value)
+		}
+	}
+
+	// 验证 TrustedProxies
+	for _, proxy := range cfg.Server.TrustedProxies {
+		if !isValidProxy(proxy) {
+			return fmt.Errorf("TrustedProxies 中包含无效的 IP 或 CIDR: %s", proxy)
+		}
+	}
+
 	// 验证 CacheTTL
 	if cfg.Music.CacheTTLMinutes < 0 || cfg.Music.CacheTTLMinutes > MaxAllowedCacheTTL {
 		return fmt.Errorf("CacheTTLMinutes 必须在 0-%d 范围内，当前值: %d", MaxAllowedCacheTTL, cfg.Music.CacheTTLMinutes)
 	}
 
+	// 验证 MinFileSize
+	if cfg.Music.MinFileSize < 0 {
+		return fmt.Errorf("MinFileSize 不能为负数，当前值: %d", cfg.Music.MinFileSize)
+	}
+
+	// 验证 ScanTimeoutSeconds
+	if cfg.Music.ScanTimeoutSeconds < 0 {
+		return fmt.Errorf("ScanTimeoutSeconds 不能为负数，当前值: %d", cfg.Music.ScanTimeoutSeconds)
+	}
+
+	// 验证 TagTimeoutSeconds
+	if cfg.Music.TagTimeoutSeconds < 0 {
+		return fmt.Errorf("TagTimeoutSeconds 不能为负数，当前值: %d", cfg.Music.TagTimeoutSeconds)
+	}
+
+	// 验证 ScanMode
+	if cfg.Music.ScanMode != "" && cfg.Music.ScanMode != "full" && cfg.Music.ScanMode != "additive" {
+		return fmt.Errorf("ScanMode 必须为 full 或 additive，当前值: %s", cfg.Music.ScanMode)
+	}
+
+	// 验证 ChangeDetection
+	if cfg.Music.ChangeDetection != "" && cfg.Music.ChangeDetection != "mtime" && cfg.Music.ChangeDetection != "hash" {
+		return fmt.Errorf("ChangeDetection 必须为 mtime 或 hash，当前值: %s", cfg.Music.ChangeDetection)
+	}
+
+	// 验证 FilenamePattern
+	if cfg.Music.FilenamePattern != "" {
+		if _, err := regexp.Compile(cfg.Music.FilenamePattern); err != nil {
+			return fmt.Errorf("FilenamePattern 不是有效的正则表达式: %v", err)
+		}
+	}
+
+	// 验证 FilenameEncoding
+	if cfg.Music.FilenameEncoding != "" {
+		if _, err := htmlindex.Get(strings.TrimSpace(cfg.Music.FilenameEncoding)); err != nil {
+			return fmt.Errorf("FilenameEncoding 不是支持的字符编码，当前值: %s", cfg.Music.FilenameEncoding)
+		}
+	}
+
+	// 验证 IDLength
+	if cfg.Music.IDLength != 0 && (cfg.Music.IDLength < 8 || cfg.Music.IDLength > 32) {
+		return fmt.Errorf("IDLength 必须在 8 到 32 字节之间，当前值: %d", cfg.Music.IDLength)
+	}
+
+	// 验证 DefaultSort 与 DefaultOrder
+	switch cfg.Music.DefaultSort {
+	case "", "pinned", "title", "artist", "album", "added_at":
+	default:
+		return fmt.Errorf("DefaultSort 必须为 pinned、title、artist、album 或 added_at，当前值: %s", cfg.Music.DefaultSort)
+	}
+	if cfg.Music.DefaultOrder != "" && cfg.Music.DefaultOrder != "asc" && cfg.Music.DefaultOrder != "desc" {
+		return fmt.Errorf("DefaultOrder 必须为 asc 或 desc，当前值: %s", cfg.Music.DefaultOrder)
+	}
+
+	// 验证审计日志轮转参数
+	if cfg.Audit.MaxSizeMB < 0 || cfg.Audit.MaxBackups < 0 {
+		return fmt.Errorf("审计日志的 MaxSizeMB 与 MaxBackups 不能为负数，当前值: %d, %d", cfg.Audit.MaxSizeMB, cfg.Audit.MaxBackups)
+	}
+
+	// 验证路径中的 ~ 与环境变量均可展开
+	for name, path := range configPaths(cfg) {
+		if _, err := expandPath(*path); err != nil {
+			return fmt.Errorf("%s 路径展开失败 (%s): %v", name, *path, err)
+		}
+	}
+
 	// 验证音乐目录是否可读
-	if _, err := os.Stat(cfg.Music.Directory); err != nil {
+	if err := checkMusicDirectory(cfg.Music.Directory); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// checkMusicDirectory 实际打开并读取音乐目录，区分目录不存在、权限不足与不是目录三种情况。
+// 仅使用 os.Stat 时，存在但无读取权限的目录也能通过校验，直到扫描时才失败。
+func checkMusicDirectory(dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return musicDirectoryError(dir, err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return musicDirectoryError(dir, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("音乐目录不是目录: %s", dir)
+	}
+	if _, err := f.Readdirnames(1); err != nil && err != io.EOF {
+		return musicDirectoryError(dir, err)
+	}
+	return nil
+}
+
+// musicDirectoryError 将访问音乐目录时的错误转换为可读的错误信息。
+func musicDirectoryError(dir string, err error) error {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("音乐目录不存在: %s", dir)
+	case errors.Is(err, fs.ErrPermission):
+		return fmt.Errorf("没有读取音乐目录的权限: %s", dir)
+	default:
 		return fmt.Errorf("音乐目录不可访问: %v", err)
 	}
+}
 
+// parseHealthChecks 解析逗号分隔的 "检查项:状态" 列表（如 "music_dir:unhealthy,songs"），省略状态时为 degraded。
+// 值为 "none" 时返回空对象，表示不做任何检查。
+func parseHealthChecks(raw string) map[string]string {
+	checks := make(map[string]string)
+	if strings.TrimSpace(raw) == "none" {
+		return checks
+	}
+	for _, item := range strings.Split(raw, ",") {
+		name, severity, found := strings.Cut(strings.TrimSpace(item), ":")
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		severity = strings.TrimSpace(severity)
+		if !found || severity == "" {
+			severity = "degraded"
+		}
+		checks[name] = severity
+	}
+	return checks
+}
+
+// validateHealthChecks 校验健康检查项名称与失败状态。
+func validateHealthChecks(checks map[string]string) error {
+	for name, severity := range checks {
+		if name != "music_dir" && name != "songs" && name != "last_scan" {
+			return fmt.Errorf("HealthChecks 的检查项必须为 music_dir、songs 或 last_scan，当前值: %s", name)
+		}
+		if severity != "degraded" && severity != "unhealthy" {
+			return fmt.Errorf("HealthChecks 中 %s 的状态必须为 degraded 或 unhealthy，当前值: %s", name, severity)
+		}
+	}
 	return nil
 }
 
+// isValidProxy 判断 proxy 是否为合法的 IP 地址或 CIDR。
+func isValidProxy(proxy string) bool {
+	if net.ParseIP(proxy) != nil {
+		return true
+	}
+	_, _, err := net.ParseCIDR(proxy)
+	return err == nil
+}
+
 // GetDefaultConfig 返回一个包含默认设置的配置实例。
 func GetDefaultConfig() *Config {
 	homeDir, _ := os.UserHomeDir()
@@ -167,16 +858,78 @@ func GetDefaultConfig() *Config {
 		musicDir, _ = filepath.Abs("./music")
 	}
 
+	dataDir, _ := filepath.Abs(DefaultDataDir)
+
 	return &Config{
 		Server: ServerConfig{
-			Host:         DefaultServerHost,
-			Port:         DefaultServerPort,
-			MaxRangeSize: DefaultMaxRangeSize,
+			Host:                      DefaultServerHost,
+			Port:                      DefaultServerPort,
+			MaxRangeSize:              DefaultMaxRangeSize,
+			IdleTimeoutSeconds:        DefaultIdleTimeoutSeconds,
+			ReadHeaderTimeoutSeconds:  DefaultReadHeaderTimeoutSeconds,
+			StreamStallTimeoutSeconds: DefaultStreamStallTimeoutSeconds,
 		},
 		Music: MusicConfig{
-			Directory:        musicDir,
-			SupportedFormats: []string{".mp3", ".flac", ".wav", ".m4a", ".ogg"},
-			CacheTTLMinutes:  DefaultCacheTTLMinutes,
+			Directory:         musicDir,
+			SupportedFormats:  DefaultSupportedFormats(),
+			CacheTTLMinutes:   DefaultCacheTTLMinutes,
+			TagTimeoutSeconds: DefaultTagTimeoutSeconds,
+		},
+		Storage: StorageConfig{
+			DataDir: dataDir,
 		},
 	}
 }
+
+// unknownFieldPrefix 是 encoding/json 报告未知字段时使用的错误前缀。
+const unknownFieldPrefix = "json: unknown field "
+
+// decodeConfig 解析 JSON 配置并拒绝未知字段，避免拼错的字段名被静默忽略而回退到默认值。
+// 未知字段与某个已知字段仅在大小写或分隔符上不同时，错误信息会给出建议的字段名。
+func decodeConfig(data []byte, cfg *Config) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(cfg)
+	if err == nil || !strings.HasPrefix(err.Error(), unknownFieldPrefix) {
+		return err
+	}
+	field, _ := strconv.Unquote(strings.TrimPrefix(err.Error(), unknownFieldPrefix))
+	if suggestion := suggestConfigField(field); suggestion != "" {
+		return fmt.Errorf("未知的配置字段 %q，是否想写 %q？", field, suggestion)
+	}
+	return fmt.Errorf("未知的配置字段 %q", field)
+}
+
+// suggestConfigField 在配置结构的所有 JSON 字段名中查找与 field 忽略大小写、下划线和连字符后相同的字段名，
+// 找不到时返回空字符串。
+func suggestConfigField(field string) string {
+	target := normalizeFieldName(field)
+	if target == "" {
+		return ""
+	}
+	var found string
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for i := 0; i < t.NumField() && found == ""; i++ {
+			f := t.Field(i)
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			if normalizeFieldName(name) == target {
+				found = name
+				return
+			}
+			if f.Type.Kind() == reflect.Struct {
+				walk(f.Type)
+			}
+		}
+	}
+	walk(reflect.TypeOf(Config{}))
+	return found
+}
+
+// normalizeFieldName 将字段名转为小写并去掉下划线和连字符，用于拼写近似比较。
+func normalizeFieldName(name string) string {
+	return strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(name))
+}
@@ -0,0 +1,385 @@
+package config
+
+import (
+	"compress/gzip"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeGzipFile 将内容以 gzip 压缩格式写入指定文件。
+func writeGzipFile(t *testing.T, path string, content []byte) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	gw := gzip.NewWriter(f)
+	if _, err := gw.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestLoad_Gzip 测试是否能加载 gzip 压缩的配置文件。
+func TestLoad_Gzip(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.json.gz")
+	content := []byte(`{"server": {"host": "127.0.0.1", "port": 9000}, "music": {"directory": "` + filepath.ToSlash(tmpDir) + `"}}`)
+	writeGzipFile(t, configPath, content)
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("加载 gzip 配置文件失败: %v", err)
+	}
+	if cfg.Server.Port != 9000 {
+		t.Errorf("期望端口为 9000, 得到 %d", cfg.Server.Port)
+	}
+	if cfg.Server.Host != "127.0.0.1" {
+		t.Errorf("期望地址为 127.0.0.1, 得到 %s", cfg.Server.Host)
+	}
+}
+
+// TestLoad_InvalidGzip 测试当 .gz 文件内容不是合法的 gzip 数据时是否返回错误。
+func TestLoad_InvalidGzip(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.json.gz")
+	if err := os.WriteFile(configPath, []byte(`{"server": {"port": 9000}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(configPath); err == nil {
+		t.Error("期望解压失败时返回错误")
+	}
+}
+
+// TestLoad_TrustedProxies 测试受信任代理列表的加载与校验。
+func TestLoad_TrustedProxies(t *testing.T) {
+	tmpDir := t.TempDir()
+	musicDir := filepath.ToSlash(tmpDir)
+
+	tests := []struct {
+		name    string
+		proxies string
+		wantErr bool
+	}{
+		{"IP 与 CIDR", `["127.0.0.1", "10.0.0.0/8", "::1"]`, false},
+		{"无效地址", `["127.0.0.1", "proxy.local"]`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.json")
+			content := `{"server": {"port": 9000, "trusted_proxies": ` + tt.proxies + `}, "music": {"directory": "` + musicDir + `"}}`
+			if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := Load(configPath)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("期望无效的代理地址导致加载失败")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("加载配置文件失败: %v", err)
+			}
+			if len(cfg.Server.TrustedProxies) != 3 {
+				t.Errorf("期望 3 个受信任代理, 得到 %v", cfg.Server.TrustedProxies)
+			}
+		})
+	}
+}
+
+// TestLoad_ExpandPath 测试配置中的 ~ 与 $HOME 被展开为用户主目录，未设置的环境变量导致加载失败。
+func TestLoad_ExpandPath(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	t.Setenv("ZERO_MUSIC_TEST_SUBDIR", "music")
+	musicDir := filepath.Join(homeDir, "music")
+	if err := os.Mkdir(musicDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		directory string
+		wantErr   bool
+	}{
+		{"波浪号", "~/music", false},
+		{"$HOME", "$HOME/music", false},
+		{"${HOME}", "${HOME}/music", false},
+		{"多个变量", "$HOME/${ZERO_MUSIC_TEST_SUBDIR}", false},
+		{"未设置的变量", "$ZERO_MUSIC_TEST_UNSET/music", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.json")
+			content := `{"server": {"port": 9000}, "music": {"directory": "` + tt.directory + `", "cache_dir": "~/cache"}}`
+			if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := Load(configPath)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("期望未设置的环境变量导致加载失败")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("加载配置文件失败: %v", err)
+			}
+			if cfg.Music.Directory != musicDir {
+				t.Errorf("期望音乐目录为 %s, 得到 %s", musicDir, cfg.Music.Directory)
+			}
+			if want := filepath.Join(homeDir, "cache"); cfg.Music.CacheDir != want {
+				t.Errorf("期望缓存目录为 %s, 得到 %s", want, cfg.Music.CacheDir)
+			}
+		})
+	}
+}
+
+// TestApplyEnvOverrides_ExpandPath 测试环境变量中的路径与配置文件一样展开 ~ 与 $VAR。
+func TestApplyEnvOverrides_ExpandPath(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	t.Setenv("ZERO_MUSIC_MUSIC_DIRECTORY", "~/music")
+	t.Setenv("ZERO_MUSIC_CACHE_DIR", "$HOME/cache")
+	t.Setenv("ZERO_MUSIC_DATA_DIR", "${HOME}/data")
+
+	cfg := GetDefaultConfig()
+	applyEnvOverrides(cfg)
+
+	if want := filepath.Join(homeDir, "music"); cfg.Music.Directory != want {
+		t.Errorf("期望音乐目录为 %s, 得到 %s", want, cfg.Music.Directory)
+	}
+	if want := filepath.Join(homeDir, "cache"); cfg.Music.CacheDir != want {
+		t.Errorf("期望缓存目录为 %s, 得到 %s", want, cfg.Music.CacheDir)
+	}
+	if want := filepath.Join(homeDir, "data"); cfg.Storage.DataDir != want {
+		t.Errorf("期望数据目录为 %s, 得到 %s", want, cfg.Storage.DataDir)
+	}
+}
+
+// TestLoad_MusicDirectoryErrors 测试音乐目录不存在、权限不足或不是目录时返回可区分的错误。
+func TestLoad_MusicDirectoryErrors(t *testing.T) {
+	tmpDir := t.TempDir()
+	notDir := filepath.Join(tmpDir, "music.txt")
+	if err := os.WriteFile(notDir, []byte("not a directory"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	noPerm := filepath.Join(tmpDir, "locked")
+	if err := os.Mkdir(noPerm, 0000); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(noPerm, 0755) })
+
+	tests := []struct {
+		name      string
+		directory string
+		wantErr   string
+		needPerm  bool
+	}{
+		{"不存在", filepath.Join(tmpDir, "missing"), "音乐目录不存在", false},
+		{"不是目录", notDir, "音乐目录不是目录", false},
+		{"权限不足", noPerm, "没有读取音乐目录的权限", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// root 用户不受文件权限限制，无法模拟权限不足。
+			if tt.needPerm && os.Geteuid() == 0 {
+				t.Skip("以 root 运行时无法模拟权限不足")
+			}
+			configPath := filepath.Join(t.TempDir(), "config.json")
+			content := `{"server": {"port": 9000}, "music": {"directory": "` + tt.directory + `"}}`
+			if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := Load(configPath)
+			if err == nil {
+				t.Fatal("期望加载失败")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("期望错误包含 %q, 得到 %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestMusicDirectoryError 测试权限错误被转换为权限不足的提示，不依赖运行用户。
+func TestMusicDirectoryError(t *testing.T) {
+	err := musicDirectoryError("/music", &fs.PathError{Op: "open", Path: "/music", Err: fs.ErrPermission})
+	if !strings.Contains(err.Error(), "没有读取音乐目录的权限") {
+		t.Errorf("期望权限不足的错误, 得到 %v", err)
+	}
+}
+
+// TestLoad_ConfigJSONEnv 测试通过环境变量或标准输入提供的配置内容与同内容的配置文件加载出等价配置，
+// 且环境变量优先于配置文件。
+func TestLoad_ConfigJSONEnv(t *testing.T) {
+	tmpDir := t.TempDir()
+	content := `{"server": {"host": "127.0.0.1", "port": 9000}, "music": {"directory": "` + filepath.ToSlash(tmpDir) + `", "cache_ttl_minutes": 7}}`
+	configPath := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fromFile, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("加载配置文件失败: %v", err)
+	}
+
+	// 标准输入。
+	original := configStdin
+	configStdin = strings.NewReader(content)
+	defer func() { configStdin = original }()
+	fromStdin, err := Load("-")
+	if err != nil {
+		t.Fatalf("从标准输入加载配置失败: %v", err)
+	}
+	if !reflect.DeepEqual(fromStdin, fromFile) {
+		t.Errorf("期望标准输入加载的配置与配置文件等价\n得到 %+v\n期望 %+v", fromStdin, fromFile)
+	}
+
+	// 环境变量优先于配置文件，即使配置文件不存在。
+	t.Setenv(ConfigJSONEnv, content)
+	fromEnv, err := Load(filepath.Join(tmpDir, "missing.json"))
+	if err != nil {
+		t.Fatalf("从环境变量加载配置失败: %v", err)
+	}
+	if !reflect.DeepEqual(fromEnv, fromFile) {
+		t.Errorf("期望环境变量加载的配置与配置文件等价\n得到 %+v\n期望 %+v", fromEnv, fromFile)
+	}
+	if fromEnv.Music.SupportedFormats == nil || fromEnv.Server.MaxRangeSize != DefaultMaxRangeSize {
+		t.Errorf("期望环境变量配置同样填充默认值, 得到 %+v", fromEnv)
+	}
+
+	// 内容无效时返回错误，校验规则同样适用。
+	t.Setenv(ConfigJSONEnv, `{"server": `)
+	if _, err := Load(""); err == nil || !strings.Contains(err.Error(), ConfigJSONEnv) {
+		t.Errorf("期望解析失败的错误包含 %s, 得到 %v", ConfigJSONEnv, err)
+	}
+	t.Setenv(ConfigJSONEnv, `{"server": {"port": 70000}, "music": {"directory": "`+filepath.ToSlash(tmpDir)+`"}}`)
+	if _, err := Load(""); err == nil {
+		t.Error("期望无效端口的配置验证失败")
+	}
+}
+
+// TestServerConfig_CSP 测试 Content-Security-Policy 的默认值、自定义值与关闭。
+func TestServerConfig_CSP(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"", DefaultContentSecurityPolicy},
+		{"default-src 'none'", "default-src 'none'"},
+		{ContentSecurityPolicyOff, ""},
+	}
+	for _, tt := range tests {
+		if got := (ServerConfig{ContentSecurityPolicy: tt.value}).CSP(); got != tt.expected {
+			t.Errorf("ContentSecurityPolicy=%q: 期望 %q, 得到 %q", tt.value, tt.expected, got)
+		}
+	}
+}
+
+func TestLoad_UnknownField(t *testing.T) {
+	tmpDir := t.TempDir()
+	dir := filepath.ToSlash(tmpDir)
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"已知字段", `{"$schema": "./config.schema.json", "server": {"port": 8080, "max_range_size": 1024}, "music": {"directory": "` + dir + `"}}`, ""},
+		{"拼写错误给出建议", `{"server": {"port": 8080, "maxrangesize": 1024}, "music": {"directory": "` + dir + `"}}`, `未知的配置字段 "maxrangesize"，是否想写 "max_range_size"`},
+		{"未知的顶层字段", `{"server": {"port": 8080}, "music": {"directory": "` + dir + `"}, "unknown": true}`, `未知的配置字段 "unknown"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(tmpDir, "config.json")
+			if err := os.WriteFile(configPath, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := Load(configPath)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("期望加载成功, 得到错误 %v", err)
+				}
+				if cfg.Server.MaxRangeSize != 1024 {
+					t.Errorf("期望 max_range_size 为 1024, 得到 %d", cfg.Server.MaxRangeSize)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("期望错误包含 %q, 得到 %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestParseHealthChecks 测试解析 ZERO_MUSIC_HEALTH_CHECKS 的 "检查项:状态" 列表。
+func TestParseHealthChecks(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want map[string]string
+	}{
+		{"music_dir", map[string]string{"music_dir": "degraded"}},
+		{"music_dir:unhealthy, songs ,last_scan:degraded", map[string]string{"music_dir": "unhealthy", "songs": "degraded", "last_scan": "degraded"}},
+		{"none", map[string]string{}},
+	}
+	for _, tt := range tests {
+		if got := parseHealthChecks(tt.raw); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseHealthChecks(%q) = %v, 期望 %v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+// TestApplyEnvOverrides_Invalid 测试无效的环境变量值被忽略，保留配置文件或默认值，而不是带入运行时。
+func TestApplyEnvOverrides_Invalid(t *testing.T) {
+	tests := []struct {
+		env   string
+		value string
+		field func(cfg *Config) interface{}
+	}{
+		{"ZERO_MUSIC_FILENAME_PATTERN", "(?P<title", func(cfg *Config) interface{} { return cfg.Music.FilenamePattern }},
+		{"ZERO_MUSIC_ID_LENGTH", "100", func(cfg *Config) interface{} { return cfg.Music.IDLength }},
+		{"ZERO_MUSIC_STREAM_BUFFER_SIZE", "1", func(cfg *Config) interface{} { return cfg.Server.StreamBufferSize }},
+		{"ZERO_MUSIC_STREAM_BUFFER_SIZE", "4294967296", func(cfg *Config) interface{} { return cfg.Server.StreamBufferSize }},
+		{"ZERO_MUSIC_TIME_FORMAT", "iso8601", func(cfg *Config) interface{} { return cfg.Server.TimeFormat }},
+		{"ZERO_MUSIC_DEFAULT_SORT", "duration", func(cfg *Config) interface{} { return cfg.Music.DefaultSort }},
+		{"ZERO_MUSIC_DEFAULT_ORDER", "random", func(cfg *Config) interface{} { return cfg.Music.DefaultOrder }},
+		{"ZERO_MUSIC_CHANGE_DETECTION", "ctime", func(cfg *Config) interface{} { return cfg.Music.ChangeDetection }},
+		{"ZERO_MUSIC_RANGE_OVER_LIMIT_BEHAVIOR", "clamp", func(cfg *Config) interface{} { return cfg.Server.RangeOverLimitBehavior }},
+		{"ZERO_MUSIC_HEALTH_CHECKS", "disk:unhealthy", func(cfg *Config) interface{} { return cfg.Server.HealthChecks }},
+		{"ZERO_MUSIC_HEALTH_CHECKS", "songs:fatal", func(cfg *Config) interface{} { return cfg.Server.HealthChecks }},
+		{"ZERO_MUSIC_FILENAME_ENCODING", "klingon", func(cfg *Config) interface{} { return cfg.Music.FilenameEncoding }},
+		{"ZERO_MUSIC_MUSIC_DIRECTORY", "$ZERO_MUSIC_TEST_UNSET/music", func(cfg *Config) interface{} { return cfg.Music.Directory }},
+		{"ZERO_MUSIC_X_ACCEL_REDIRECT_PREFIX", "protected-music", func(cfg *Config) interface{} { return cfg.Server.XAccelRedirectPrefix }},
+		{"ZERO_MUSIC_ADMIN_ADDR", "8081", func(cfg *Config) interface{} { return cfg.Server.AdminAddr }},
+		{"ZERO_MUSIC_ADMIN_ADDR", "127.0.0.1:", func(cfg *Config) interface{} { return cfg.Server.AdminAddr }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			t.Setenv(tt.env, tt.value)
+			cfg := GetDefaultConfig()
+			want := tt.field(cfg)
+
+			applyEnvOverrides(cfg)
+			if got := tt.field(cfg); !reflect.DeepEqual(got, want) {
+				t.Errorf("期望 %s=%q 被忽略并保留 %v, 得到 %v", tt.env, tt.value, want, got)
+			}
+		})
+	}
+}
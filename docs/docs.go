@@ -0,0 +1,1733 @@
+// Package docs Code generated by swaggo/swag. DO NOT EDIT
+package docs
+
+import "github.com/swaggo/swag"
+
+const docTemplate = `{
+    "schemes": {{ marshal .Schemes }},
+    "swagger": "2.0",
+    "info": {
+        "description": "{{escape .Description}}",
+        "title": "{{.Title}}",
+        "contact": {},
+        "version": "{{.Version}}"
+    },
+    "host": "{{.Host}}",
+    "basePath": "{{.BasePath}}",
+    "paths": {
+        "/admin/scan/info": {
+            "get": {
+                "description": "返回上次扫描时间、歌曲数量、缓存有效期以及缓存是否过期",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "获取扫描缓存状态",
+                "responses": {
+                    "200": {
+                        "description": "成功返回缓存状态",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/scan/progress": {
+            "get": {
+                "description": "以 text/event-stream 推送 progress 事件（已处理数、预计总数、百分比），扫描结束时推送 done 事件并关闭",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "订阅扫描进度",
+                "responses": {
+                    "200": {
+                        "description": "进度事件流",
+                        "schema": {
+                            "$ref": "#/definitions/services.ScanProgress"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/album/{name}/cover": {
+            "get": {
+                "description": "返回专辑的代表封面，目录封面文件优先于歌曲嵌入封面",
+                "produces": [
+                    "image/jpeg",
+                    "image/png"
+                ],
+                "tags": [
+                    "album"
+                ],
+                "summary": "获取专辑封面",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "专辑名称（URL 编码，不支持包含 / 的名称）",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "封面图片",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "专辑或封面未找到",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/album/{name}/download": {
+            "get": {
+                "description": "将专辑下的所有歌曲打包为 zip 下载，歌曲数或总大小超过上限时返回 413",
+                "produces": [
+                    "application/zip"
+                ],
+                "tags": [
+                    "album"
+                ],
+                "summary": "打包下载专辑",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "专辑名称（URL 编码，不支持包含 / 的名称）",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "专辑 zip 文件",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "专辑不存在",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    },
+                    "413": {
+                        "description": "专辑过大",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    },
+                    "429": {
+                        "description": "当前客户端的并发流数量已达上限",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    },
+                    "503": {
+                        "description": "并发流数量已达上限",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/artist/{name}": {
+            "get": {
+                "description": "返回拆分后的艺术家列表中包含指定艺术家的所有歌曲，歌曲按配置的默认排序返回",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "artist"
+                ],
+                "summary": "获取艺术家的歌曲",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "艺术家名称（URL 编码）",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "rfc3339",
+                            "unix_ms"
+                        ],
+                        "type": "string",
+                        "description": "时间字段格式：rfc3339 或 unix_ms，默认使用服务器配置",
+                        "name": "time_format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "成功返回歌曲列表",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "艺术家不存在",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/artists": {
+            "get": {
+                "description": "基于扫描缓存中拆分后的艺术家列表聚合歌曲数量，合作歌曲计入每位参与的艺术家，\n仅有空白、全角或大小写差异的名称会被合并",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "artist"
+                ],
+                "summary": "获取所有艺术家",
+                "responses": {
+                    "200": {
+                        "description": "成功返回艺术家列表",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/browse": {
+            "get": {
+                "description": "基于扫描结果逐层浏览音乐目录。子目录只包含其下有歌曲的目录，song_count 为其子树中的歌曲数；\n歌曲只包含直接位于该目录下的文件，按文件名排序",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlist"
+                ],
+                "summary": "按目录浏览歌曲",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "相对于音乐目录的路径，使用 / 分隔，为空时返回顶层",
+                        "name": "path",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "rfc3339",
+                            "unix_ms"
+                        ],
+                        "type": "string",
+                        "description": "时间字段格式：rfc3339 或 unix_ms，默认使用服务器配置",
+                        "name": "time_format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "成功返回目录内容",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.browseResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "路径越出音乐目录",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "目录未找到",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    },
+                    "503": {
+                        "description": "音乐目录暂时不可用且没有缓存",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/cover/{id}": {
+            "get": {
+                "description": "返回音频文件标签中嵌入的封面图片",
+                "produces": [
+                    "image/jpeg",
+                    "image/png"
+                ],
+                "tags": [
+                    "cover"
+                ],
+                "summary": "获取歌曲封面",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "歌曲ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "缩略图最长边（16-1000 像素），不指定则返回原图",
+                        "name": "size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "封面图片",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "歌曲或封面未找到",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/duplicates": {
+            "get": {
+                "description": "按内容指纹分组返回内容相同的歌曲，需要开启 compute_fingerprint",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlist"
+                ],
+                "summary": "获取重复歌曲",
+                "parameters": [
+                    {
+                        "enum": [
+                            "rfc3339",
+                            "unix_ms"
+                        ],
+                        "type": "string",
+                        "description": "时间字段格式：rfc3339 或 unix_ms，默认使用服务器配置",
+                        "name": "time_format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "成功返回重复歌曲分组",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/formats": {
+            "get": {
+                "description": "返回配置的 supported_formats 及其 MIME 类型；transcoding 表示服务端是否可以转码（ffmpeg 可用），\ntranscode_targets 为可以转码输出的 MIME 类型",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stream"
+                ],
+                "summary": "获取支持的音频格式",
+                "responses": {
+                    "200": {
+                        "description": "成功返回格式列表",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/genre/{name}": {
+            "get": {
+                "description": "返回指定流派下的所有歌曲，名称为 Unknown 时返回没有流派标签的歌曲；歌曲按配置的默认排序返回",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "genre"
+                ],
+                "summary": "获取流派下的歌曲",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "流派名称（URL 编码）",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "rfc3339",
+                            "unix_ms"
+                        ],
+                        "type": "string",
+                        "description": "时间字段格式：rfc3339 或 unix_ms，默认使用服务器配置",
+                        "name": "time_format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "成功返回歌曲列表",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "流派不存在",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/genres": {
+            "get": {
+                "description": "基于扫描缓存聚合所有流派及各自的歌曲数量，没有流派标签的歌曲归入 Unknown，\n仅有空白、全角或大小写差异的流派会被合并",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "genre"
+                ],
+                "summary": "获取所有流派",
+                "responses": {
+                    "200": {
+                        "description": "成功返回流派列表",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/progress/{id}": {
+            "get": {
+                "description": "返回指定设备上指定歌曲的播放位置，没有记录时返回 0",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "progress"
+                ],
+                "summary": "获取播放进度",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "歌曲ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "设备/用户标识",
+                        "name": "device",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "成功返回播放进度",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.progressResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "保存指定设备上指定歌曲的播放位置",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "progress"
+                ],
+                "summary": "保存播放进度",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "歌曲ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "播放位置与设备标识",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.progressRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "成功保存播放进度",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.progressResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/radio": {
+            "get": {
+                "description": "在一个长连接中连续播放随机顺序的同格式歌曲，客户端断开即停止",
+                "produces": [
+                    "audio/mpeg"
+                ],
+                "tags": [
+                    "stream"
+                ],
+                "summary": "随机电台",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "音频格式（如 mp3、ogg），默认 mp3",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "随机种子，相同的种子产生相同的播放顺序",
+                        "name": "seed",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "队列播放完后是否重新随机并继续播放",
+                        "name": "loop",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "连续的音频流",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "没有该格式的歌曲",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    },
+                    "503": {
+                        "description": "并发流数量已达上限",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/recommend": {
+            "get": {
+                "description": "根据播放记录与收藏推荐尚未播放的歌曲，没有历史数据时返回最近添加的歌曲",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlist"
+                ],
+                "summary": "获取推荐歌曲",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "返回数量（1-1000），默认 20",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "rfc3339",
+                            "unix_ms"
+                        ],
+                        "type": "string",
+                        "description": "时间字段格式：rfc3339 或 unix_ms，默认使用服务器配置",
+                        "name": "time_format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "推荐歌曲列表，fallback 表示没有基于历史的推荐",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/song/{id}": {
+            "get": {
+                "description": "根据歌曲ID返回歌曲详细信息",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlist"
+                ],
+                "summary": "获取指定歌曲信息",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "歌曲ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "是否附带同专辑的上一首/下一首歌曲 ID",
+                        "name": "related",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "是否附带完整的 stream_url 与 cover_url",
+                        "name": "include_urls",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "是否校验文件当前仍存在且大小与缓存一致，不一致时返回 stale: true",
+                        "name": "verify",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "是否返回音频内容的 SHA256 校验和（开销较大，结果按文件修改时间缓存）",
+                        "name": "checksum",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "rfc3339",
+                            "unix_ms"
+                        ],
+                        "type": "string",
+                        "description": "时间字段格式：rfc3339 或 unix_ms，默认使用服务器配置",
+                        "name": "time_format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "成功返回歌曲信息",
+                        "schema": {
+                            "$ref": "#/definitions/models.Song"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "歌曲未找到",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/song/{id}/pin": {
+            "post": {
+                "description": "置顶或取消置顶指定歌曲，置顶歌曲在 /api/songs?sort=pinned 中按权重排在前面",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlist"
+                ],
+                "summary": "设置歌曲置顶",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "歌曲ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "是否置顶与置顶权重",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.pinRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "成功更新置顶状态",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.pinResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "歌曲未找到",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/song/{id}/tags": {
+            "post": {
+                "description": "为指定歌曲添加自定义标签，标签会去除首尾空白并转换为小写，已存在的标签被忽略",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlist"
+                ],
+                "summary": "添加歌曲标签",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "歌曲ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "要添加的标签",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.addTagsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "成功返回歌曲的全部标签",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.songTagsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "歌曲未找到",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/song/{id}/tags/{tag}": {
+            "delete": {
+                "description": "移除指定歌曲的一个自定义标签",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlist"
+                ],
+                "summary": "移除歌曲标签",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "歌曲ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "标签",
+                        "name": "tag",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "成功返回歌曲的剩余标签",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.songTagsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/songs": {
+            "get": {
+                "description": "返回音乐目录中所有可用的歌曲列表，total、total_duration（秒）与 total_size（字节）基于过滤后的完整结果集而非当前页。\n响应头 X-Library-Version 是音乐库（扫描到的文件）的版本标识，不反映置顶与标签的变化",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlist"
+                ],
+                "summary": "获取所有歌曲",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "逗号分隔的字段列表，仅返回这些字段（如 id,title,artist）",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量，指定后按 (added_at, id) 排序并分页",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序字段：pinned（置顶歌曲在前）、title、artist、album、added_at，默认使用配置的 default_sort",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序方向：asc 或 desc，默认使用配置的 default_order",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "上一页响应中的 next_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "是否为每首歌曲附带完整的 stream_url 与 cover_url",
+                        "name": "include_urls",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "只返回在该时间及之后添加的歌曲（RFC3339 或 Unix 时间戳）",
+                        "name": "added_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "只返回在该时间及之前添加的歌曲（RFC3339 或 Unix 时间戳）",
+                        "name": "added_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "只返回带有该自定义标签的歌曲",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "为 true 时只返回有封面（嵌入封面或同目录封面文件）的歌曲，为 false 时只返回没有封面的歌曲",
+                        "name": "has_cover",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "上次响应的 X-Library-Version，音乐库没有变化时返回 304",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "rfc3339",
+                            "unix_ms"
+                        ],
+                        "type": "string",
+                        "description": "时间字段格式：rfc3339 或 unix_ms，默认使用服务器配置",
+                        "name": "time_format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "成功返回歌曲列表",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "304": {
+                        "description": "音乐库自 since 以来没有变化"
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    },
+                    "503": {
+                        "description": "音乐目录暂时不可用且没有缓存",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/songs/batch": {
+            "post": {
+                "description": "请求体为歌曲 ID 数组，返回以 ID 为键的歌曲详情；不存在的 ID 放入 not_found，格式无效的 ID 放入 invalid",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlist"
+                ],
+                "summary": "批量获取歌曲信息",
+                "parameters": [
+                    {
+                        "description": "歌曲 ID 列表",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    {
+                        "enum": [
+                            "rfc3339",
+                            "unix_ms"
+                        ],
+                        "type": "string",
+                        "description": "时间字段格式：rfc3339 或 unix_ms，默认使用服务器配置",
+                        "name": "time_format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "成功返回歌曲信息",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.batchSongsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    },
+                    "503": {
+                        "description": "音乐目录暂时不可用且没有缓存",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/stream/{id}": {
+            "get": {
+                "description": "通过 HTTP 流式传输指定的音频文件。源格式不被 Accept 接受且 ffmpeg 可用时，转码为 Accept 中首个支持的格式（audio/mpeg、audio/ogg、audio/flac、audio/wav）",
+                "produces": [
+                    "audio/mpeg"
+                ],
+                "tags": [
+                    "stream"
+                ],
+                "summary": "流式传输音频",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "歌曲ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "音频流",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "206": {
+                        "description": "音频流(部分内容)",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "禁止访问",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "文件未找到",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    },
+                    "406": {
+                        "description": "没有客户端可接受的音频格式",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    },
+                    "412": {
+                        "description": "If-Match 与当前 ETag 不符",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    },
+                    "416": {
+                        "description": "请求范围无法满足",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    },
+                    "429": {
+                        "description": "当前客户端的并发流数量已达上限",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    },
+                    "503": {
+                        "description": "并发流数量已达上限",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tags": {
+            "get": {
+                "description": "聚合当前扫描结果中歌曲的自定义标签及各自的歌曲数量，已不存在的歌曲不计入",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlist"
+                ],
+                "summary": "获取所有标签",
+                "responses": {
+                    "200": {
+                        "description": "成功返回标签列表",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    },
+                    "503": {
+                        "description": "音乐目录暂时不可用且没有缓存",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "按配置的检查项（音乐目录可访问、缓存非空、最近一次扫描成功）判定服务状态",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "健康检查",
+                "responses": {
+                    "200": {
+                        "description": "服务正常",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "检查项失败，状态为 degraded 或 unhealthy",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/health/detail": {
+            "get": {
+                "description": "返回磁盘空间、当前流连接数、缓存歌曲数、最近一次扫描时间与耗时",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "详细健康检查",
+                "responses": {
+                    "200": {
+                        "description": "服务正常",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "检查项失败",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "handlers.APIError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "details": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "description": "RequestID 是产生该错误的请求 ID，便于将客户端报错与服务端日志关联。",
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.DirectoryEntry": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "description": "Name 是子目录名。",
+                    "type": "string"
+                },
+                "path": {
+                    "description": "Path 是子目录相对于音乐目录的路径，使用 / 分隔，可直接作为下一次浏览的 path 参数。",
+                    "type": "string"
+                },
+                "song_count": {
+                    "description": "SongCount 是子目录及其所有下级目录中的歌曲数量。",
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.addTagsRequest": {
+            "type": "object",
+            "properties": {
+                "tags": {
+                    "description": "Tags 是要添加的标签，标签会去除首尾空白并转换为小写。",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "handlers.batchSongsResponse": {
+            "type": "object",
+            "properties": {
+                "invalid": {
+                    "description": "Invalid 是格式无效的歌曲 ID。",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "not_found": {
+                    "description": "NotFound 是格式有效但不存在的歌曲 ID。",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "songs": {
+                    "description": "Songs 是以 ID 为键的歌曲详情。",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/handlers.songJSON"
+                    }
+                }
+            }
+        },
+        "handlers.browseResponse": {
+            "type": "object",
+            "properties": {
+                "directories": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.DirectoryEntry"
+                    }
+                },
+                "path": {
+                    "type": "string"
+                },
+                "songs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.songJSON"
+                    }
+                }
+            }
+        },
+        "handlers.pinRequest": {
+            "type": "object",
+            "properties": {
+                "pinned": {
+                    "description": "Pinned 为 true 时置顶，为 false 时取消置顶。",
+                    "type": "boolean"
+                },
+                "weight": {
+                    "description": "Weight 是置顶权重，越大越靠前，未指定时为 1。",
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.pinResponse": {
+            "type": "object",
+            "properties": {
+                "pinned": {
+                    "type": "boolean"
+                },
+                "song_id": {
+                    "type": "string"
+                },
+                "weight": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.progressRequest": {
+            "type": "object",
+            "properties": {
+                "device": {
+                    "description": "Device 是设备或用户标识。",
+                    "type": "string"
+                },
+                "position_seconds": {
+                    "description": "PositionSeconds 是播放位置（秒）。",
+                    "type": "number"
+                }
+            }
+        },
+        "handlers.progressResponse": {
+            "type": "object",
+            "properties": {
+                "device": {
+                    "type": "string"
+                },
+                "position_seconds": {
+                    "type": "number"
+                },
+                "song_id": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.songJSON": {
+            "type": "object",
+            "properties": {
+                "acoustic_fingerprint": {
+                    "description": "AcousticFingerprint 是由 fpcalc 计算的声学指纹（见 ComputeAcousticFingerprint），仅在开启声学指纹计算时填充。\n同一首歌的不同编码得到相近的指纹，可用 AcousticSimilarity 比较；cue 虚拟歌曲不计算。",
+                    "type": "string"
+                },
+                "added_at": {
+                    "type": "string"
+                },
+                "album": {
+                    "description": "Album 是歌曲所属的专辑，默认为 \"Unknown\"。",
+                    "type": "string"
+                },
+                "artist": {
+                    "description": "Artist 是歌曲的艺术家，默认为 \"Unknown\"。保留标签中的原始字符串（如 \"A; B\"）。",
+                    "type": "string"
+                },
+                "artists": {
+                    "description": "Artists 是按分隔符拆分 Artist 得到的艺术家列表（如 [\"A\", \"B\"]），由扫描器填充。",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "bitrate": {
+                    "description": "Bitrate 是音频的平均比特率（kbps），由文件大小与时长估算，未知时为 0。",
+                    "type": "integer"
+                },
+                "comment": {
+                    "description": "Comment 是标签中的注释，未知时为空。",
+                    "type": "string"
+                },
+                "composer": {
+                    "description": "Composer 是歌曲的作曲者，未知时为空。",
+                    "type": "string"
+                },
+                "cover_url": {
+                    "description": "CoverURL 是歌曲封面的完整地址，仅在请求时填充。",
+                    "type": "string"
+                },
+                "disc_number": {
+                    "description": "DiscNumber 是歌曲所在的碟号，未知时为 0。",
+                    "type": "integer"
+                },
+                "duration": {
+                    "description": "Duration 是歌曲的时长（以秒为单位），默认为 0。",
+                    "type": "integer"
+                },
+                "end_ms": {
+                    "description": "EndMS 是 cue 虚拟歌曲在源文件中的结束时间（毫秒），为 0 表示到文件末尾。",
+                    "type": "integer"
+                },
+                "file_name": {
+                    "description": "FileName 是歌曲的文件名。",
+                    "type": "string"
+                },
+                "file_path": {
+                    "description": "FilePath 是歌曲文件的绝对路径。",
+                    "type": "string"
+                },
+                "file_size": {
+                    "description": "FileSize 是歌曲文件的大小（以字节为单位）。",
+                    "type": "integer"
+                },
+                "fingerprint": {
+                    "description": "Fingerprint 是歌曲的内容指纹（文件前 1MB 与大小的 SHA256），仅在开启指纹计算时填充。\n内容相同的文件具有相同的指纹，可用于重复检测。",
+                    "type": "string"
+                },
+                "format": {
+                    "description": "Format 是音频文件的格式/扩展名（如 .mp3, .flac）。",
+                    "type": "string"
+                },
+                "genre": {
+                    "description": "Genre 是歌曲的流派，从标签读取，未知时为空。",
+                    "type": "string"
+                },
+                "has_cover": {
+                    "description": "HasCover 表示歌曲是否有封面：标签中嵌入了封面，或同目录下有封面文件（由扫描器检测）。",
+                    "type": "boolean"
+                },
+                "id": {
+                    "description": "ID 是歌曲的唯一标识符，通过文件路径的 SHA256 哈希生成。",
+                    "type": "string"
+                },
+                "sample_rate": {
+                    "description": "SampleRate 是音频的采样率（Hz），目前仅对 Ogg/Opus 文件解析，未知时为 0。",
+                    "type": "integer"
+                },
+                "start_ms": {
+                    "description": "StartMS 是 cue 虚拟歌曲在源文件中的起始时间（毫秒），普通歌曲为 0。",
+                    "type": "integer"
+                },
+                "stream_url": {
+                    "description": "StreamURL 是可直接用于播放的完整音频流地址，仅在请求时填充。",
+                    "type": "string"
+                },
+                "title": {
+                    "description": "Title 是歌曲的标题，通常从文件名中提取。",
+                    "type": "string"
+                },
+                "track_number": {
+                    "description": "TrackNumber 是歌曲在专辑中的音轨号，未知时为 0。",
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.songTagsResponse": {
+            "type": "object",
+            "properties": {
+                "song_id": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.Song": {
+            "type": "object",
+            "properties": {
+                "acoustic_fingerprint": {
+                    "description": "AcousticFingerprint 是由 fpcalc 计算的声学指纹（见 ComputeAcousticFingerprint），仅在开启声学指纹计算时填充。\n同一首歌的不同编码得到相近的指纹，可用 AcousticSimilarity 比较；cue 虚拟歌曲不计算。",
+                    "type": "string"
+                },
+                "added_at": {
+                    "description": "AddedAt 是歌曲文件最后修改的时间。",
+                    "type": "string"
+                },
+                "album": {
+                    "description": "Album 是歌曲所属的专辑，默认为 \"Unknown\"。",
+                    "type": "string"
+                },
+                "artist": {
+                    "description": "Artist 是歌曲的艺术家，默认为 \"Unknown\"。保留标签中的原始字符串（如 \"A; B\"）。",
+                    "type": "string"
+                },
+                "artists": {
+                    "description": "Artists 是按分隔符拆分 Artist 得到的艺术家列表（如 [\"A\", \"B\"]），由扫描器填充。",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "bitrate": {
+                    "description": "Bitrate 是音频的平均比特率（kbps），由文件大小与时长估算，未知时为 0。",
+                    "type": "integer"
+                },
+                "comment": {
+                    "description": "Comment 是标签中的注释，未知时为空。",
+                    "type": "string"
+                },
+                "composer": {
+                    "description": "Composer 是歌曲的作曲者，未知时为空。",
+                    "type": "string"
+                },
+                "cover_url": {
+                    "description": "CoverURL 是歌曲封面的完整地址，仅在请求时填充。",
+                    "type": "string"
+                },
+                "disc_number": {
+                    "description": "DiscNumber 是歌曲所在的碟号，未知时为 0。",
+                    "type": "integer"
+                },
+                "duration": {
+                    "description": "Duration 是歌曲的时长（以秒为单位），默认为 0。",
+                    "type": "integer"
+                },
+                "end_ms": {
+                    "description": "EndMS 是 cue 虚拟歌曲在源文件中的结束时间（毫秒），为 0 表示到文件末尾。",
+                    "type": "integer"
+                },
+                "file_name": {
+                    "description": "FileName 是歌曲的文件名。",
+                    "type": "string"
+                },
+                "file_path": {
+                    "description": "FilePath 是歌曲文件的绝对路径。",
+                    "type": "string"
+                },
+                "file_size": {
+                    "description": "FileSize 是歌曲文件的大小（以字节为单位）。",
+                    "type": "integer"
+                },
+                "fingerprint": {
+                    "description": "Fingerprint 是歌曲的内容指纹（文件前 1MB 与大小的 SHA256），仅在开启指纹计算时填充。\n内容相同的文件具有相同的指纹，可用于重复检测。",
+                    "type": "string"
+                },
+                "format": {
+                    "description": "Format 是音频文件的格式/扩展名（如 .mp3, .flac）。",
+                    "type": "string"
+                },
+                "genre": {
+                    "description": "Genre 是歌曲的流派，从标签读取，未知时为空。",
+                    "type": "string"
+                },
+                "has_cover": {
+                    "description": "HasCover 表示歌曲是否有封面：标签中嵌入了封面，或同目录下有封面文件（由扫描器检测）。",
+                    "type": "boolean"
+                },
+                "id": {
+                    "description": "ID 是歌曲的唯一标识符，通过文件路径的 SHA256 哈希生成。",
+                    "type": "string"
+                },
+                "sample_rate": {
+                    "description": "SampleRate 是音频的采样率（Hz），目前仅对 Ogg/Opus 文件解析，未知时为 0。",
+                    "type": "integer"
+                },
+                "start_ms": {
+                    "description": "StartMS 是 cue 虚拟歌曲在源文件中的起始时间（毫秒），普通歌曲为 0。",
+                    "type": "integer"
+                },
+                "stream_url": {
+                    "description": "StreamURL 是可直接用于播放的完整音频流地址，仅在请求时填充。",
+                    "type": "string"
+                },
+                "title": {
+                    "description": "Title 是歌曲的标题，通常从文件名中提取。",
+                    "type": "string"
+                },
+                "track_number": {
+                    "description": "TrackNumber 是歌曲在专辑中的音轨号，未知时为 0。",
+                    "type": "integer"
+                }
+            }
+        },
+        "services.ScanProgress": {
+            "type": "object",
+            "properties": {
+                "percent": {
+                    "description": "Percent 是完成百分比（0-100），扫描进行中最多为 99，总数未知时为 0。",
+                    "type": "integer"
+                },
+                "processed": {
+                    "description": "Processed 是本次扫描已检查过的文件数量，未在扫描时为最近一次扫描的结果。",
+                    "type": "integer"
+                },
+                "scanning": {
+                    "description": "Scanning 表示当前是否正在扫描。",
+                    "type": "boolean"
+                },
+                "total": {
+                    "description": "Total 是预计需要检查的文件总数，扫描中发现的文件超出预计时随已处理数量增长。",
+                    "type": "integer"
+                }
+            }
+        }
+    }
+}`
+
+// SwaggerInfo holds exported Swagger Info so clients can modify it
+var SwaggerInfo = &swag.Spec{
+	Version:          "1.0",
+	Host:             "",
+	BasePath:         "/",
+	Schemes:          []string{},
+	Title:            "Zero Music API",
+	Description:      "Zero Music 音乐服务器的 HTTP API：歌曲列表、音频流、封面、播放进度等。",
+	InfoInstanceName: "swagger",
+	SwaggerTemplate:  docTemplate,
+	LeftDelim:        "{{",
+	RightDelim:       "}}",
+}
+
+func init() {
+	swag.Register(SwaggerInfo.InstanceName(), SwaggerInfo)
+}
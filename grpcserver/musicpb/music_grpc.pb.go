@@ -0,0 +1,214 @@
+// music.proto 定义了 zero music 的 gRPC 接口，能力与 REST API 一致。
+// 修改后在仓库根目录执行 `buf generate` 重新生成 grpcserver/musicpb 中的代码。
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: music.proto
+
+package musicpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	MusicService_ListSongs_FullMethodName   = "/zeromusic.v1.MusicService/ListSongs"
+	MusicService_GetSong_FullMethodName     = "/zeromusic.v1.MusicService/GetSong"
+	MusicService_StreamAudio_FullMethodName = "/zeromusic.v1.MusicService/StreamAudio"
+)
+
+// MusicServiceClient is the client API for MusicService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// MusicService 提供歌曲查询与音频流传输。
+type MusicServiceClient interface {
+	// ListSongs 返回音乐目录中的所有歌曲。
+	ListSongs(ctx context.Context, in *ListSongsRequest, opts ...grpc.CallOption) (*ListSongsResponse, error)
+	// GetSong 返回指定 ID 的歌曲。
+	GetSong(ctx context.Context, in *GetSongRequest, opts ...grpc.CallOption) (*Song, error)
+	// StreamAudio 分块返回音频文件的字节，可从指定偏移量开始。
+	StreamAudio(ctx context.Context, in *StreamAudioRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AudioChunk], error)
+}
+
+type musicServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMusicServiceClient(cc grpc.ClientConnInterface) MusicServiceClient {
+	return &musicServiceClient{cc}
+}
+
+func (c *musicServiceClient) ListSongs(ctx context.Context, in *ListSongsRequest, opts ...grpc.CallOption) (*ListSongsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSongsResponse)
+	err := c.cc.Invoke(ctx, MusicService_ListSongs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *musicServiceClient) GetSong(ctx context.Context, in *GetSongRequest, opts ...grpc.CallOption) (*Song, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Song)
+	err := c.cc.Invoke(ctx, MusicService_GetSong_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *musicServiceClient) StreamAudio(ctx context.Context, in *StreamAudioRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AudioChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &MusicService_ServiceDesc.Streams[0], MusicService_StreamAudio_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamAudioRequest, AudioChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MusicService_StreamAudioClient = grpc.ServerStreamingClient[AudioChunk]
+
+// MusicServiceServer is the server API for MusicService service.
+// All implementations must embed UnimplementedMusicServiceServer
+// for forward compatibility.
+//
+// MusicService 提供歌曲查询与音频流传输。
+type MusicServiceServer interface {
+	// ListSongs 返回音乐目录中的所有歌曲。
+	ListSongs(context.Context, *ListSongsRequest) (*ListSongsResponse, error)
+	// GetSong 返回指定 ID 的歌曲。
+	GetSong(context.Context, *GetSongRequest) (*Song, error)
+	// StreamAudio 分块返回音频文件的字节，可从指定偏移量开始。
+	StreamAudio(*StreamAudioRequest, grpc.ServerStreamingServer[AudioChunk]) error
+	mustEmbedUnimplementedMusicServiceServer()
+}
+
+// UnimplementedMusicServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMusicServiceServer struct{}
+
+func (UnimplementedMusicServiceServer) ListSongs(context.Context, *ListSongsRequest) (*ListSongsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSongs not implemented")
+}
+func (UnimplementedMusicServiceServer) GetSong(context.Context, *GetSongRequest) (*Song, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSong not implemented")
+}
+func (UnimplementedMusicServiceServer) StreamAudio(*StreamAudioRequest, grpc.ServerStreamingServer[AudioChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamAudio not implemented")
+}
+func (UnimplementedMusicServiceServer) mustEmbedUnimplementedMusicServiceServer() {}
+func (UnimplementedMusicServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeMusicServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MusicServiceServer will
+// result in compilation errors.
+type UnsafeMusicServiceServer interface {
+	mustEmbedUnimplementedMusicServiceServer()
+}
+
+func RegisterMusicServiceServer(s grpc.ServiceRegistrar, srv MusicServiceServer) {
+	// If the following call pancis, it indicates UnimplementedMusicServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&MusicService_ServiceDesc, srv)
+}
+
+func _MusicService_ListSongs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSongsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MusicServiceServer).ListSongs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MusicService_ListSongs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MusicServiceServer).ListSongs(ctx, req.(*ListSongsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MusicService_GetSong_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSongRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MusicServiceServer).GetSong(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MusicService_GetSong_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MusicServiceServer).GetSong(ctx, req.(*GetSongRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MusicService_StreamAudio_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamAudioRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MusicServiceServer).StreamAudio(m, &grpc.GenericServerStream[StreamAudioRequest, AudioChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MusicService_StreamAudioServer = grpc.ServerStreamingServer[AudioChunk]
+
+// MusicService_ServiceDesc is the grpc.ServiceDesc for MusicService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MusicService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "zeromusic.v1.MusicService",
+	HandlerType: (*MusicServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListSongs",
+			Handler:    _MusicService_ListSongs_Handler,
+		},
+		{
+			MethodName: "GetSong",
+			Handler:    _MusicService_GetSong_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamAudio",
+			Handler:       _MusicService_StreamAudio_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "music.proto",
+}
@@ -0,0 +1,216 @@
+// Package grpcserver 提供与 REST API 能力一致的 gRPC 服务。
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net"
+	"zero-music/grpcserver/musicpb"
+	"zero-music/handlers"
+	"zero-music/logger"
+	"zero-music/models"
+	"zero-music/services"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// defaultChunkSize 是音频流每个分块的默认字节数。
+	defaultChunkSize = 64 * 1024
+	// maxChunkSize 是客户端可请求的最大分块字节数。
+	maxChunkSize = 1024 * 1024
+)
+
+// Server 实现了 musicpb.MusicServiceServer，与 HTTP 处理器共用同一个 Scanner，
+// 音频流与 HTTP 共用 StreamHandler 的并发流上限、歌曲查找与路径安全检查。
+type Server struct {
+	musicpb.UnimplementedMusicServiceServer
+
+	scanner services.Scanner
+	streams *handlers.StreamHandler
+}
+
+// NewServer 创建一个新的 gRPC 服务实现。
+func NewServer(scanner services.Scanner, streams *handlers.StreamHandler) *Server {
+	return &Server{
+		scanner: scanner,
+		streams: streams,
+	}
+}
+
+// Register 创建一个 grpc.Server 并注册音乐服务。
+func Register(srv *Server, opts ...grpc.ServerOption) *grpc.Server {
+	grpcServer := grpc.NewServer(opts...)
+	musicpb.RegisterMusicServiceServer(grpcServer, srv)
+	return grpcServer
+}
+
+// toProtoSong 将歌曲转换为 protobuf 消息。
+func toProtoSong(song *models.Song) *musicpb.Song {
+	return &musicpb.Song{
+		Id:          song.ID,
+		Title:       song.Title,
+		Artist:      song.Artist,
+		Album:       song.Album,
+		Genre:       song.Genre,
+		TrackNumber: int32(song.TrackNumber),
+		Duration:    int32(song.Duration),
+		FileName:    song.FileName,
+		FileSize:    song.FileSize,
+		AddedAt:     timestamppb.New(song.AddedAt),
+		Format:      song.Format,
+	}
+}
+
+// ListSongs 返回音乐目录中的所有歌曲。
+func (s *Server) ListSongs(ctx context.Context, _ *musicpb.ListSongsRequest) (*musicpb.ListSongsResponse, error) {
+	songs, _, err := services.ScanOrCached(ctx, s.scanner)
+	if err != nil {
+		return nil, songError(err)
+	}
+
+	response := &musicpb.ListSongsResponse{
+		Total: int32(len(songs)),
+		Songs: make([]*musicpb.Song, 0, len(songs)),
+	}
+	for _, song := range songs {
+		response.Songs = append(response.Songs, toProtoSong(song))
+	}
+	return response, nil
+}
+
+// GetSong 返回指定 ID 的歌曲。
+func (s *Server) GetSong(ctx context.Context, req *musicpb.GetSongRequest) (*musicpb.Song, error) {
+	song, err := s.findSong(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	return toProtoSong(song), nil
+}
+
+// StreamAudio 从指定偏移量开始分块返回音频文件的字节，客户端取消时停止。
+func (s *Server) StreamAudio(req *musicpb.StreamAudioRequest, stream musicpb.MusicService_StreamAudioServer) error {
+	chunkSize := int(req.GetChunkSize())
+	if chunkSize == 0 {
+		chunkSize = defaultChunkSize
+	}
+	if chunkSize < 0 || chunkSize > maxChunkSize {
+		return status.Errorf(codes.InvalidArgument, "chunk_size 必须在 0-%d 范围内", maxChunkSize)
+	}
+	if req.GetOffset() < 0 {
+		return status.Error(codes.InvalidArgument, "offset 不能为负数")
+	}
+
+	if !models.IsValidID(req.GetId()) {
+		return status.Error(codes.InvalidArgument, "无效的歌曲 ID 格式")
+	}
+
+	// 与 HTTP 音频流共用并发流名额，名额在流结束时释放。
+	ctx := stream.Context()
+	clientIP := peerIP(ctx)
+	release, err := s.streams.AcquireStream(clientIP)
+	if err != nil {
+		logger.Warnf("gRPC 客户端 %s 的音频流被拒绝: %v", clientIP, err)
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	defer release()
+
+	src, err := s.streams.OpenSong(ctx, req.GetId())
+	if err != nil {
+		return songError(err)
+	}
+	defer src.Close()
+	content, size := src.Content, src.Size
+	if req.GetOffset() > size {
+		return status.Errorf(codes.OutOfRange, "offset 超出文件大小 %d", size)
+	}
+	if _, err := content.Seek(req.GetOffset(), io.SeekStart); err != nil {
+		return status.Errorf(codes.Internal, "定位音频文件失败: %v", err)
+	}
+
+	buf := make([]byte, chunkSize)
+	offset := req.GetOffset()
+	first := true
+	for {
+		if err := ctx.Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+
+		n, readErr := content.Read(buf)
+		if n > 0 || first {
+			chunk := &musicpb.AudioChunk{Offset: offset, Data: buf[:n]}
+			if first {
+				chunk.MimeType = models.MIMEType(src.Path)
+				chunk.FileSize = size
+				first = false
+			}
+			if err := stream.Send(chunk); err != nil {
+				return err
+			}
+			offset += int64(n)
+		}
+		if errors.Is(readErr, io.EOF) {
+			return nil
+		}
+		if readErr != nil {
+			return status.Errorf(codes.Internal, "读取音频文件失败: %v", readErr)
+		}
+	}
+}
+
+// findSong 验证 ID 并在扫描结果中查找歌曲，音乐目录暂时不可用时使用缓存。
+func (s *Server) findSong(ctx context.Context, id string) (*models.Song, error) {
+	if !models.IsValidID(id) {
+		return nil, status.Error(codes.InvalidArgument, "无效的歌曲 ID 格式")
+	}
+	songs, _, err := services.ScanOrCached(ctx, s.scanner)
+	if err != nil {
+		return nil, songError(err)
+	}
+	for _, song := range songs {
+		if song.ID == id {
+			return song, nil
+		}
+	}
+	return nil, status.Error(codes.NotFound, "歌曲未找到")
+}
+
+// songError 将扫描与 StreamHandler.OpenSong 返回的错误转换为 gRPC 状态。
+func songError(err error) error {
+	switch {
+	case errors.Is(err, handlers.ErrInvalidSongID):
+		return status.Error(codes.InvalidArgument, "无效的歌曲 ID 格式")
+	case errors.Is(err, handlers.ErrSongNotFound):
+		return status.Error(codes.NotFound, "歌曲未找到")
+	case errors.Is(err, handlers.ErrAccessDenied):
+		logger.Warnf("安全警告: %v", err)
+		return status.Error(codes.PermissionDenied, "拒绝访问")
+	case errors.Is(err, fs.ErrNotExist):
+		return status.Error(codes.NotFound, "音频文件未找到")
+	case errors.Is(err, services.ErrDirectoryUnavailable):
+		logger.Warnf("音乐目录暂时不可用且没有缓存: %v", err)
+		return status.Error(codes.Unavailable, "音乐目录暂时不可用，请稍后重试")
+	default:
+		logger.Errorf("获取音乐文件失败: %v", err)
+		return status.Errorf(codes.Internal, "获取音乐文件失败: %v", err)
+	}
+}
+
+// peerIP 返回 gRPC 客户端的 IP，用于按客户端限制并发流数量；地址不含端口时原样返回。
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	addr := p.Addr.String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
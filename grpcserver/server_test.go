@@ -0,0 +1,220 @@
+package grpcserver
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"zero-music/config"
+	"zero-music/grpcserver/musicpb"
+	"zero-music/handlers"
+	"zero-music/services"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// setupTestClient 启动一个基于内存连接的 gRPC 服务，返回客户端和测试音频内容。
+func setupTestClient(t *testing.T) (musicpb.MusicServiceClient, []byte) {
+	client, audio, _ := setupTestClientWithConfig(t, nil)
+	return client, audio
+}
+
+// setupTestClientWithConfig 与 setupTestClient 相同，但允许在创建服务前修改配置，
+// 并额外返回与 gRPC 服务共用并发流上限的 StreamHandler。
+func setupTestClientWithConfig(t *testing.T, modify func(cfg *config.Config)) (musicpb.MusicServiceClient, []byte, *handlers.StreamHandler) {
+	tmpDir := t.TempDir()
+	audio := make([]byte, 200*1024)
+	for i := range audio {
+		audio[i] = byte(i % 251)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "test.mp3"), audio, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{
+		Music: config.MusicConfig{
+			Directory:        tmpDir,
+			SupportedFormats: []string{".mp3"},
+			CacheTTLMinutes:  5,
+		},
+	}
+	if modify != nil {
+		modify(cfg)
+	}
+	scanner := services.NewMusicScanner(cfg.Music.Directory, cfg.Music.SupportedFormats, cfg.Music.CacheTTLMinutes)
+	streams := handlers.NewStreamHandler(scanner, cfg)
+
+	lis := bufconn.Listen(1024 * 1024)
+	srv := Register(NewServer(scanner, streams))
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("创建 gRPC 客户端失败: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return musicpb.NewMusicServiceClient(conn), audio, streams
+}
+
+// TestListSongsAndStreamAudio 测试通过 gRPC 列出歌曲并分块流式获取音频字节。
+func TestListSongsAndStreamAudio(t *testing.T) {
+	client, audio := setupTestClient(t)
+	ctx := context.Background()
+
+	list, err := client.ListSongs(ctx, &musicpb.ListSongsRequest{})
+	if err != nil {
+		t.Fatalf("ListSongs 失败: %v", err)
+	}
+	if list.GetTotal() != 1 || len(list.GetSongs()) != 1 {
+		t.Fatalf("期望 1 首歌曲, 得到 %d", list.GetTotal())
+	}
+	song := list.GetSongs()[0]
+	if song.GetFileName() != "test.mp3" || song.GetFileSize() != int64(len(audio)) {
+		t.Errorf("歌曲信息不正确: %+v", song)
+	}
+
+	got, err := client.GetSong(ctx, &musicpb.GetSongRequest{Id: song.GetId()})
+	if err != nil {
+		t.Fatalf("GetSong 失败: %v", err)
+	}
+	if got.GetId() != song.GetId() {
+		t.Errorf("期望歌曲 ID 为 %s, 得到 %s", song.GetId(), got.GetId())
+	}
+
+	tests := []struct {
+		name      string
+		offset    int64
+		chunkSize int32
+	}{
+		{"默认分块", 0, 0},
+		{"指定偏移与分块", 1000, 4096},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := client.StreamAudio(ctx, &musicpb.StreamAudioRequest{Id: song.GetId(), Offset: tt.offset, ChunkSize: tt.chunkSize})
+			if err != nil {
+				t.Fatalf("StreamAudio 失败: %v", err)
+			}
+
+			var data []byte
+			chunks := 0
+			for {
+				chunk, err := stream.Recv()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					t.Fatalf("接收分块失败: %v", err)
+				}
+				if chunks == 0 && (chunk.GetMimeType() != "audio/mpeg" || chunk.GetFileSize() != int64(len(audio))) {
+					t.Errorf("第一个分块的元数据不正确: %s, %d", chunk.GetMimeType(), chunk.GetFileSize())
+				}
+				if chunk.GetOffset() != tt.offset+int64(len(data)) {
+					t.Errorf("期望分块偏移量为 %d, 得到 %d", tt.offset+int64(len(data)), chunk.GetOffset())
+				}
+				data = append(data, chunk.GetData()...)
+				chunks++
+			}
+
+			if !bytes.Equal(data, audio[tt.offset:]) {
+				t.Errorf("流式获取的字节与文件内容不一致 (得到 %d 字节)", len(data))
+			}
+			if tt.chunkSize > 0 && chunks < len(data)/int(tt.chunkSize) {
+				t.Errorf("期望至少 %d 个分块, 得到 %d", len(data)/int(tt.chunkSize), chunks)
+			}
+		})
+	}
+}
+
+// TestGetSong_Errors 测试无效 ID 与不存在的歌曲返回对应的 gRPC 状态码。
+func TestGetSong_Errors(t *testing.T) {
+	client, _ := setupTestClient(t)
+
+	tests := []struct {
+		id   string
+		want codes.Code
+	}{
+		{"../etc/passwd", codes.InvalidArgument},
+		{"0123456789abcdef0123456789abcdef", codes.NotFound},
+	}
+	for _, tt := range tests {
+		_, err := client.GetSong(context.Background(), &musicpb.GetSongRequest{Id: tt.id})
+		if status.Code(err) != tt.want {
+			t.Errorf("对于 %s，期望状态码 %v, 得到 %v", tt.id, tt.want, status.Code(err))
+		}
+	}
+}
+
+// TestStreamAudio_SharedLimits 测试 gRPC 音频流与 HTTP 共用并发流总数与单 IP 上限，名额用尽时返回 ResourceExhausted。
+func TestStreamAudio_SharedLimits(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(cfg *config.Config)
+		clientIP string
+	}{
+		// 其他客户端占满了总名额。
+		{"并发流总数", func(cfg *config.Config) { cfg.Server.MaxConcurrentStreams = 1 }, "192.0.2.1"},
+		// 同一客户端（bufconn 连接的地址）已有一个 HTTP 流。
+		{"单 IP 上限", func(cfg *config.Config) { cfg.Server.MaxStreamsPerIP = 1 }, "bufconn"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, audio, streams := setupTestClientWithConfig(t, tt.modify)
+			ctx := context.Background()
+			list, err := client.ListSongs(ctx, &musicpb.ListSongsRequest{})
+			if err != nil || len(list.GetSongs()) != 1 {
+				t.Fatalf("ListSongs 失败: %v", err)
+			}
+			id := list.GetSongs()[0].GetId()
+
+			release, err := streams.AcquireStream(tt.clientIP)
+			if err != nil {
+				t.Fatalf("占用流名额失败: %v", err)
+			}
+			stream, err := client.StreamAudio(ctx, &musicpb.StreamAudioRequest{Id: id})
+			if err != nil {
+				t.Fatalf("StreamAudio 失败: %v", err)
+			}
+			if _, err := stream.Recv(); status.Code(err) != codes.ResourceExhausted {
+				t.Fatalf("期望状态码 %v, 得到 %v", codes.ResourceExhausted, err)
+			}
+
+			// 释放后 gRPC 流恢复正常。
+			release()
+			stream, err = client.StreamAudio(ctx, &musicpb.StreamAudioRequest{Id: id})
+			if err != nil {
+				t.Fatalf("StreamAudio 失败: %v", err)
+			}
+			var data []byte
+			for {
+				chunk, err := stream.Recv()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					t.Fatalf("接收分块失败: %v", err)
+				}
+				data = append(data, chunk.GetData()...)
+			}
+			if !bytes.Equal(data, audio) {
+				t.Errorf("期望 %d 字节, 得到 %d", len(audio), len(data))
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+	"zero-music/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler 负责处理运维相关的 API 请求。
+type AdminHandler struct {
+	scanner services.Scanner
+}
+
+// NewAdminHandler 创建一个新的 AdminHandler 实例。
+func NewAdminHandler(scanner services.Scanner) *AdminHandler {
+	return &AdminHandler{
+		scanner: scanner,
+	}
+}
+
+// GetScanInfo 返回扫描器缓存的状态，包括上次扫描时间和缓存是否过期。
+// @Summary 获取扫描缓存状态
+// @Description 返回上次扫描时间、歌曲数量、缓存有效期以及缓存是否过期
+// @Tags admin
+// @Produce json
+// @Success 200 {object} map[string]interface{} "成功返回缓存状态"
+// @Router /admin/scan/info [get]
+func (h *AdminHandler) GetScanInfo(c *gin.Context) {
+	stats := h.scanner.Stats()
+
+	var lastScan interface{}
+	if !stats.LastScan.IsZero() {
+		lastScan = stats.LastScan
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"last_scan":         lastScan,
+		"song_count":        stats.SongCount,
+		"cache_ttl_seconds": int64(stats.CacheTTL.Seconds()),
+		"stale":             stats.Stale,
+	})
+}
+
+// scanProgressInterval 是扫描进度事件的推送间隔，测试时可以缩短。
+var scanProgressInterval = 500 * time.Millisecond
+
+// StreamScanProgress 以 Server-Sent Events 推送扫描进度。
+// 订阅后立即推送一次 progress 事件，扫描进行时按 scanProgressInterval 周期推送，
+// 扫描结束（或订阅时没有扫描在进行）后推送 done 事件并关闭连接。客户端断开时停止推送。
+// @Summary 订阅扫描进度
+// @Description 以 text/event-stream 推送 progress 事件（已处理数、预计总数、百分比），扫描结束时推送 done 事件并关闭
+// @Tags admin
+// @Produce text/event-stream
+// @Success 200 {object} services.ScanProgress "进度事件流"
+// @Router /admin/scan/progress [get]
+func (h *AdminHandler) StreamScanProgress(c *gin.Context) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// 禁止反向代理缓冲事件流。
+	c.Header("X-Accel-Buffering", "no")
+
+	ticker := time.NewTicker(scanProgressInterval)
+	defer ticker.Stop()
+
+	for {
+		progress := h.scanner.Progress()
+		c.SSEvent("progress", progress)
+		if !progress.Scanning {
+			c.SSEvent("done", progress)
+			c.Writer.Flush()
+			return
+		}
+		c.Writer.Flush()
+
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"zero-music/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestGetScanInfo 测试扫描信息端点在扫描前后是否返回正确的缓存状态。
+func TestGetScanInfo(t *testing.T) {
+	router, _ := setupTestEnv(t)
+
+	getInfo := func() map[string]interface{} {
+		req, _ := http.NewRequest("GET", "/admin/scan/info", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("期望状态码 200, 得到 %d", w.Code)
+		}
+		var info map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &info); err != nil {
+			t.Fatalf("解析响应失败: %v", err)
+		}
+		return info
+	}
+
+	// 扫描前缓存为空且已过期。
+	info := getInfo()
+	if info["stale"] != true {
+		t.Errorf("期望扫描前 stale 为 true, 得到 %v", info["stale"])
+	}
+	if info["last_scan"] != nil {
+		t.Errorf("期望扫描前 last_scan 为空, 得到 %v", info["last_scan"])
+	}
+
+	// 触发一次扫描。
+	req, _ := http.NewRequest("GET", "/api/songs", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	info = getInfo()
+	if info["stale"] != false {
+		t.Errorf("期望扫描后 stale 为 false, 得到 %v", info["stale"])
+	}
+	if info["song_count"] != float64(2) {
+		t.Errorf("期望 song_count 为 2, 得到 %v", info["song_count"])
+	}
+	if info["cache_ttl_seconds"] != float64(300) {
+		t.Errorf("期望 cache_ttl_seconds 为 300, 得到 %v", info["cache_ttl_seconds"])
+	}
+}
+
+// progressScanner 是按顺序返回预设扫描进度的 Scanner，最后一个进度会被重复返回。
+type progressScanner struct {
+	services.Scanner
+	steps []services.ScanProgress
+	calls int
+}
+
+// Progress 实现 services.Scanner。
+func (s *progressScanner) Progress() services.ScanProgress {
+	progress := s.steps[min(s.calls, len(s.steps)-1)]
+	s.calls++
+	return progress
+}
+
+// TestStreamScanProgress 测试订阅扫描进度时先收到进度事件，扫描结束后收到 done 事件并关闭。
+func TestStreamScanProgress(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	original := scanProgressInterval
+	scanProgressInterval = time.Millisecond
+	defer func() { scanProgressInterval = original }()
+
+	scanner := &progressScanner{steps: []services.ScanProgress{
+		{Scanning: true, Processed: 1, Total: 4, Percent: 25},
+		{Scanning: true, Processed: 3, Total: 4, Percent: 75},
+		{Scanning: false, Processed: 4, Total: 4, Percent: 100},
+	}}
+	router := gin.New()
+	router.GET("/admin/scan/progress", NewAdminHandler(scanner).StreamScanProgress)
+
+	req := httptest.NewRequest("GET", "/admin/scan/progress", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("期望状态码 200, 得到 %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/event-stream") {
+		t.Errorf("期望 Content-Type 为 text/event-stream, 得到 %s", ct)
+	}
+
+	body := w.Body.String()
+	if got := strings.Count(body, "event:progress"); got != 3 {
+		t.Errorf("期望收到 3 个 progress 事件, 得到 %d: %s", got, body)
+	}
+	if !strings.Contains(body, `"processed":3`) {
+		t.Errorf("期望进度事件包含已处理数量, 得到 %s", body)
+	}
+	if strings.Count(body, "event:done") != 1 || !strings.HasSuffix(strings.TrimSpace(body), `"percent":100}`) {
+		t.Errorf("期望最后收到 done 事件, 得到 %s", body)
+	}
+}
+
+// TestStreamScanProgress_ClientDisconnect 测试客户端断开后停止推送。
+func TestStreamScanProgress_ClientDisconnect(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	scanner := &progressScanner{steps: []services.ScanProgress{{Scanning: true}}}
+	router := gin.New()
+	router.GET("/admin/scan/progress", NewAdminHandler(scanner).StreamScanProgress)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest("GET", "/admin/scan/progress", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		router.ServeHTTP(w, req)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("客户端断开后处理器未停止推送")
+	}
+	if strings.Contains(w.Body.String(), "event:done") {
+		t.Errorf("期望断开后不再推送 done 事件, 得到 %s", w.Body.String())
+	}
+}
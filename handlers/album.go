@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"zero-music/logger"
+	"zero-music/middleware"
+	"zero-music/models"
+	"zero-music/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maxAlbumDownloadFiles 是单次打包下载允许的最大歌曲数。
+	maxAlbumDownloadFiles = 500
+	// maxAlbumDownloadBytes 是单次打包下载允许的歌曲文件总大小上限（4GB）。
+	maxAlbumDownloadBytes = 4 << 30
+)
+
+// AlbumHandler 负责处理专辑相关的 API 请求。
+type AlbumHandler struct {
+	scanner services.Scanner
+	// streams 提供打包下载与音频流共用的并发流上限与慢速客户端检测配置。
+	streams *StreamHandler
+	// maxFiles 与 maxBytes 是单次打包下载的歌曲数与总大小上限。
+	maxFiles int
+	maxBytes int64
+
+	// coverSources 缓存每个专辑（按规范化键）选定的封面来源，媒体库版本变化后整体失效。
+	coverMu      sync.Mutex
+	coverVersion string
+	coverSources map[string]albumCoverSource
+}
+
+// albumCoverSource 是为专辑选定的封面来源：同目录封面文件，或某首歌曲的嵌入封面。
+type albumCoverSource struct {
+	folderCover string // 封面文件路径，为空时使用 songPath 的嵌入封面
+	songPath    string
+}
+
+// read 读取封面来源对应的图片。
+func (s albumCoverSource) read() (*models.Cover, error) {
+	if s.folderCover != "" {
+		return models.ReadCoverFile(s.folderCover)
+	}
+	return models.ReadEmbeddedCover(s.songPath)
+}
+
+// NewAlbumHandler 创建一个新的 AlbumHandler 实例。
+func NewAlbumHandler(scanner services.Scanner, streams *StreamHandler) *AlbumHandler {
+	return &AlbumHandler{
+		scanner:  scanner,
+		streams:  streams,
+		maxFiles: maxAlbumDownloadFiles,
+		maxBytes: maxAlbumDownloadBytes,
+
+		coverSources: make(map[string]albumCoverSource),
+	}
+}
+
+// albumSongs 返回名称按规范化键匹配的专辑歌曲，按音轨号排序。
+// 媒体库不可用或专辑不存在时已写出错误响应并返回 false。
+func (h *AlbumHandler) albumSongs(c *gin.Context, name string) ([]*models.Song, bool) {
+	songs, ok := scanSongs(c, h.scanner)
+	if !ok {
+		return nil, false
+	}
+
+	key := models.GroupKey(name)
+	matched := make([]*models.Song, 0)
+	for _, song := range songs {
+		if models.GroupKey(song.Album) == key {
+			matched = append(matched, song)
+		}
+	}
+	if len(matched) == 0 {
+		RespondError(c, http.StatusNotFound, NewNotFoundError("专辑"))
+		return nil, false
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].TrackNumber < matched[j].TrackNumber
+	})
+	return matched, true
+}
+
+// DownloadAlbum 将指定专辑的所有歌曲打包为 zip 流式返回，不在磁盘上生成临时文件。
+// 专辑名称按规范化键匹配；zip 内的文件名为 "艺术家 - 标题.ext"，重名时追加序号。
+// 音频本身已经压缩，因此 zip 条目只存储不压缩。
+// @Summary 打包下载专辑
+// @Description 将专辑下的所有歌曲打包为 zip 下载，歌曲数或总大小超过上限时返回 413
+// @Tags album
+// @Produce application/zip
+// @Param name path string true "专辑名称（URL 编码，不支持包含 / 的名称）"
+// @Success 200 {file} binary "专辑 zip 文件"
+// @Failure 404 {object} APIError "专辑不存在"
+// @Failure 413 {object} APIError "专辑过大"
+// @Failure 429 {object} APIError "当前客户端的并发流数量已达上限"
+// @Failure 500 {object} APIError "服务器错误"
+// @Failure 503 {object} APIError "并发流数量已达上限"
+// @Router /api/album/{name}/download [get]
+func (h *AlbumHandler) DownloadAlbum(c *gin.Context) {
+	requestID := middleware.GetRequestID(c)
+	name := c.Param("name")
+
+	matched, ok := h.albumSongs(c, name)
+	if !ok {
+		return
+	}
+	var totalSize int64
+	for _, song := range matched {
+		totalSize += song.FileSize
+	}
+	if len(matched) > h.maxFiles || totalSize > h.maxBytes {
+		logger.WithRequestID(requestID).Warnf("拒绝打包过大的专辑 %s: %d 首歌曲, %d 字节", name, len(matched), totalSize)
+		RespondError(c, http.StatusRequestEntityTooLarge, NewPayloadTooLargeError("专辑过大，无法打包下载"))
+		return
+	}
+
+	// 打包下载与音频流共用并发流总数与单 IP 上限，名额在下载结束时释放。
+	release, ok := h.streams.acquireHTTPStream(c, c.ClientIP())
+	if !ok {
+		return
+	}
+	defer release()
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", attachmentDisposition(matched[0].Album+".zip"))
+	c.Status(http.StatusOK)
+
+	// 响应头已经发出，之后的错误（通常是客户端中途断开）只能记录日志并停止写出。
+	// 与音频流相同，每次写出前延长写超时，长时间不读取数据的客户端会被断开；
+	// 隐藏 streamWriter 的 ReadFrom，使每次写出都经过 Write。
+	w := newStreamWriter(c, h.streams.maxRangeSize, h.streams.stallTimeout, h.streams.buffers, requestID)
+	defer w.clearDeadline()
+	zw := zip.NewWriter(struct{ io.Writer }{w})
+	used := make(map[string]int)
+	for _, song := range matched {
+		if err := c.Request.Context().Err(); err != nil {
+			logger.WithRequestID(requestID).Infof("客户端断开，停止打包专辑 %s: %v", name, err)
+			return
+		}
+		if err := writeAlbumEntry(zw, song, uniqueEntryName(displayFileName(song), used)); err != nil {
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				logger.WithRequestID(requestID).Warnf("客户端超过 %v 没有读取数据，停止打包专辑 %s (已写出 %d 字节)", h.streams.stallTimeout, name, w.written)
+				return
+			}
+			logger.WithRequestID(requestID).Warnf("打包专辑 %s 失败: %v", name, err)
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		logger.WithRequestID(requestID).Warnf("打包专辑 %s 失败: %v", name, err)
+	}
+}
+
+// GetCover 返回专辑的代表封面。
+// 同目录的封面文件（cover.jpg、folder.jpg、front.jpg 等）优先于歌曲的嵌入封面，
+// 专辑跨多个目录时按音轨顺序依次查找；选定的封面来源会被缓存，直到媒体库发生变化。
+// @Summary 获取专辑封面
+// @Description 返回专辑的代表封面，目录封面文件优先于歌曲嵌入封面
+// @Tags album
+// @Produce image/jpeg,image/png
+// @Param name path string true "专辑名称（URL 编码，不支持包含 / 的名称）"
+// @Success 200 {file} binary "封面图片"
+// @Failure 404 {object} APIError "专辑或封面未找到"
+// @Failure 500 {object} APIError "服务器错误"
+// @Router /api/album/{name}/cover [get]
+func (h *AlbumHandler) GetCover(c *gin.Context) {
+	requestID := middleware.GetRequestID(c)
+	name := c.Param("name")
+
+	matched, ok := h.albumSongs(c, name)
+	if !ok {
+		return
+	}
+
+	cover, err := h.albumCover(models.GroupKey(name), matched)
+	if err != nil {
+		if errors.Is(err, models.ErrNoCover) {
+			RespondError(c, http.StatusNotFound, NewNotFoundError("封面"))
+			return
+		}
+		logger.WithRequestID(requestID).Errorf("读取专辑 %s 的封面失败: %v", name, err)
+		RespondError(c, http.StatusInternalServerError, NewInternalError(err))
+		return
+	}
+	c.Data(http.StatusOK, cover.MIMEType, cover.Data)
+}
+
+// albumCover 读取专辑的封面，优先使用缓存的封面来源。
+// 缓存的来源读取失败（如封面文件已被删除）时丢弃缓存并重新选择。
+func (h *AlbumHandler) albumCover(key string, songs []*models.Song) (*models.Cover, error) {
+	if source, ok := h.cachedCoverSource(key); ok {
+		if cover, err := source.read(); err == nil {
+			return cover, nil
+		}
+		h.storeCoverSource(key, albumCoverSource{})
+	}
+
+	source, ok := selectAlbumCover(songs)
+	if !ok {
+		return nil, models.ErrNoCover
+	}
+	h.storeCoverSource(key, source)
+	return source.read()
+}
+
+// selectAlbumCover 按优先级为专辑选择封面来源：先查找各歌曲所在目录的封面文件，
+// 再查找歌曲的嵌入封面。都没有时返回 false。
+func selectAlbumCover(songs []*models.Song) (albumCoverSource, bool) {
+	checked := make(map[string]bool)
+	for _, song := range songs {
+		dir := filepath.Dir(song.FilePath)
+		if checked[dir] {
+			continue
+		}
+		checked[dir] = true
+		if path := models.FindFolderCover(dir); path != "" {
+			return albumCoverSource{folderCover: path}, true
+		}
+	}
+	for _, song := range songs {
+		if _, err := models.ReadEmbeddedCover(song.FilePath); err == nil {
+			return albumCoverSource{songPath: song.FilePath}, true
+		}
+	}
+	return albumCoverSource{}, false
+}
+
+// cachedCoverSource 返回专辑缓存的封面来源，媒体库版本变化时先清空缓存。
+func (h *AlbumHandler) cachedCoverSource(key string) (albumCoverSource, bool) {
+	h.coverMu.Lock()
+	defer h.coverMu.Unlock()
+	if version := h.scanner.LibraryVersion(); version != h.coverVersion {
+		h.coverVersion = version
+		h.coverSources = make(map[string]albumCoverSource)
+	}
+	source, ok := h.coverSources[key]
+	return source, ok
+}
+
+// storeCoverSource 缓存专辑的封面来源，来源为空值时删除缓存。
+func (h *AlbumHandler) storeCoverSource(key string, source albumCoverSource) {
+	h.coverMu.Lock()
+	defer h.coverMu.Unlock()
+	if source == (albumCoverSource{}) {
+		delete(h.coverSources, key)
+		return
+	}
+	h.coverSources[key] = source
+}
+
+// writeAlbumEntry 将歌曲的音频内容写入 zip 中名为 entryName 的条目。
+// cue 虚拟歌曲只写入对应的音轨片段。
+func writeAlbumEntry(zw *zip.Writer, song *models.Song, entryName string) error {
+	file, err := os.Open(song.FilePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader, _, err := models.OpenTrack(file, song)
+	if err != nil {
+		return err
+	}
+	w, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     entryName,
+		Method:   zip.Store,
+		Modified: song.AddedAt,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, reader); err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", entryName, err)
+	}
+	return nil
+}
+
+// uniqueEntryName 返回在 used 中未出现过的条目名，重名时在扩展名前追加 " (2)"、" (3)" 等序号。
+func uniqueEntryName(name string, used map[string]int) string {
+	used[name]++
+	if used[name] == 1 {
+		return name
+	}
+	ext := filepath.Ext(name)
+	unique := fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(name, ext), used[name], ext)
+	return uniqueEntryName(unique, used)
+}
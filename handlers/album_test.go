@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"zero-music/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestDownloadAlbum 测试专辑打包下载返回包含全部歌曲的 zip，以及专辑不存在、超过上限时的错误。
+func TestDownloadAlbum(t *testing.T) {
+	files := map[string]string{
+		"a.mp3": "fake mp3 data a",
+		"b.mp3": "fake mp3 data b",
+		"c.mp3": "fake mp3 data c",
+	}
+	cfg, scanner := newTestLibrary(t, files, nil)
+	handler := NewAlbumHandler(scanner, NewStreamHandler(scanner, cfg))
+	router := gin.New()
+	router.GET("/api/album/:name/download", handler.DownloadAlbum)
+
+	// 测试文件没有标签，全部归入 Unknown 专辑。
+	req, _ := http.NewRequest("GET", "/api/album/unknown/download", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("期望状态码 200, 得到 %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/zip" {
+		t.Errorf("期望 Content-Type 为 application/zip, 得到 %s", ct)
+	}
+	if cd := w.Header().Get("Content-Disposition"); cd != `attachment; filename="Unknown.zip"` {
+		t.Errorf("期望 Content-Disposition 为附件 Unknown.zip, 得到 %s", cd)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatalf("解析 zip 失败: %v", err)
+	}
+	if len(archive.File) != len(files) {
+		t.Fatalf("期望 zip 中有 %d 个文件, 得到 %d", len(files), len(archive.File))
+	}
+	names := make([]string, 0, len(archive.File))
+	for _, f := range archive.File {
+		names = append(names, f.Name)
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("打开 %s 失败: %v", f.Name, err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		if string(data) != files[f.Name] {
+			t.Errorf("期望 %s 的内容为 %q, 得到 %q", f.Name, files[f.Name], data)
+		}
+	}
+	sort.Strings(names)
+	if got := strings.Join(names, ","); got != "a.mp3,b.mp3,c.mp3" {
+		t.Errorf("期望 zip 中的文件为 a.mp3,b.mp3,c.mp3, 得到 %s", got)
+	}
+
+	testCases := []struct {
+		name         string
+		album        string
+		maxFiles     int
+		expectedCode int
+	}{
+		{"专辑不存在", "missing", maxAlbumDownloadFiles, http.StatusNotFound},
+		{"超过歌曲数上限", "Unknown", 2, http.StatusRequestEntityTooLarge},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler.maxFiles = tc.maxFiles
+			req, _ := http.NewRequest("GET", "/api/album/"+tc.album+"/download", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tc.expectedCode {
+				t.Errorf("期望状态码 %d, 得到 %d", tc.expectedCode, w.Code)
+			}
+		})
+	}
+}
+
+// TestDownloadAlbum_StreamLimits 测试专辑打包下载与音频流共用并发流总数与单 IP 上限。
+func TestDownloadAlbum_StreamLimits(t *testing.T) {
+	tests := []struct {
+		name         string
+		modify       func(cfg *config.Config)
+		clientIP     string
+		expectedCode int
+	}{
+		{"并发流总数", func(cfg *config.Config) { cfg.Server.MaxConcurrentStreams = 1 }, "198.51.100.1", http.StatusServiceUnavailable},
+		// httptest 请求的客户端地址为 192.0.2.1。
+		{"单 IP 上限", func(cfg *config.Config) { cfg.Server.MaxStreamsPerIP = 1 }, "192.0.2.1", http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, scanner := newTestLibrary(t, map[string]string{"a.mp3": "fake mp3 data a"}, tt.modify)
+			streams := NewStreamHandler(scanner, cfg)
+			router := gin.New()
+			router.GET("/api/album/:name/download", NewAlbumHandler(scanner, streams).DownloadAlbum)
+
+			// 其他音频流占满名额时拒绝打包下载。
+			release, err := streams.AcquireStream(tt.clientIP)
+			if err != nil {
+				t.Fatalf("占用流名额失败: %v", err)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/api/album/unknown/download", nil))
+			if w.Code != tt.expectedCode {
+				t.Fatalf("期望状态码 %d, 得到 %d", tt.expectedCode, w.Code)
+			}
+			if w.Header().Get("Retry-After") == "" {
+				t.Error("期望包含 Retry-After 响应头")
+			}
+
+			// 释放后恢复正常，下载结束时归还名额。
+			release()
+			w = httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/api/album/unknown/download", nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("期望状态码 200, 得到 %d", w.Code)
+			}
+			if active := streams.ActiveStreams(); active != 0 {
+				t.Errorf("期望下载结束后释放名额, 仍有 %d 个活动流", active)
+			}
+		})
+	}
+}
+
+// TestUniqueEntryName 测试重名的 zip 条目名在扩展名前追加序号。
+func TestUniqueEntryName(t *testing.T) {
+	used := make(map[string]int)
+	got := []string{
+		uniqueEntryName("a - b.mp3", used),
+		uniqueEntryName("a - b.mp3", used),
+		uniqueEntryName("a - b.mp3", used),
+		uniqueEntryName("noext", used),
+		uniqueEntryName("noext", used),
+	}
+	want := []string{"a - b.mp3", "a - b (2).mp3", "a - b (3).mp3", "noext", "noext (2)"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("第 %d 个条目名期望 %s, 得到 %s", i, want[i], got[i])
+		}
+	}
+}
+
+// TestGetAlbumCover 测试专辑封面优先选用目录封面文件，其次是歌曲的嵌入封面，都没有时返回 404。
+func TestGetAlbumCover(t *testing.T) {
+	embedded := []byte("\x89PNG\r\n\x1a\nembedded cover")
+	folder := "\xff\xd8\xff\xe0folder cover"
+	withCover := string(buildMP3WithCover("image/png", embedded))
+
+	testCases := []struct {
+		name         string
+		files        map[string]string
+		expectedCode int
+		expectedBody string
+		expectedType string
+	}{
+		{
+			name:         "目录封面优先于嵌入封面",
+			files:        map[string]string{"a.mp3": "fake mp3 data", "b.mp3": withCover, "folder.jpg": folder},
+			expectedCode: http.StatusOK,
+			expectedBody: folder,
+			expectedType: "image/jpeg",
+		},
+		{
+			name:         "没有目录封面时使用嵌入封面",
+			files:        map[string]string{"a.mp3": "fake mp3 data", "b.mp3": withCover},
+			expectedCode: http.StatusOK,
+			expectedBody: string(embedded),
+			expectedType: "image/png",
+		},
+		{
+			name:         "没有任何封面",
+			files:        map[string]string{"a.mp3": "fake mp3 data"},
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, scanner := newTestLibrary(t, tc.files, nil)
+			router := gin.New()
+			router.GET("/api/album/:name/cover", NewAlbumHandler(scanner, NewStreamHandler(scanner, cfg)).GetCover)
+
+			req, _ := http.NewRequest("GET", "/api/album/unknown/cover", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tc.expectedCode {
+				t.Fatalf("期望状态码 %d, 得到 %d: %s", tc.expectedCode, w.Code, w.Body.String())
+			}
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+			if w.Body.String() != tc.expectedBody {
+				t.Errorf("期望封面内容为 %q, 得到 %q", tc.expectedBody, w.Body.String())
+			}
+			if ct := w.Header().Get("Content-Type"); ct != tc.expectedType {
+				t.Errorf("期望 Content-Type 为 %s, 得到 %s", tc.expectedType, ct)
+			}
+		})
+	}
+}
+
+// TestGetAlbumCover_SourceRemoved 测试缓存的封面文件被删除后重新选择封面来源。
+func TestGetAlbumCover_SourceRemoved(t *testing.T) {
+	embedded := []byte("\x89PNG\r\n\x1a\nembedded cover")
+	cfg, scanner := newTestLibrary(t, map[string]string{
+		"a.mp3":     string(buildMP3WithCover("image/png", embedded)),
+		"cover.jpg": "\xff\xd8\xff\xe0folder cover",
+	}, nil)
+	router := gin.New()
+	router.GET("/api/album/:name/cover", NewAlbumHandler(scanner, NewStreamHandler(scanner, cfg)).GetCover)
+
+	get := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/api/album/unknown/cover", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	if w := get(); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "folder cover") {
+		t.Fatalf("期望返回目录封面, 得到 %d: %q", w.Code, w.Body.String())
+	}
+
+	if err := os.Remove(filepath.Join(cfg.Music.Directory, "cover.jpg")); err != nil {
+		t.Fatal(err)
+	}
+	if w := get(); w.Code != http.StatusOK || w.Body.String() != string(embedded) {
+		t.Errorf("期望重新选择嵌入封面, 得到 %d: %q", w.Code, w.Body.String())
+	}
+}
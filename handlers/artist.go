@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"zero-music/config"
+	"zero-music/logger"
+	"zero-music/models"
+	"zero-music/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ArtistSummary 是一位艺术家及其歌曲数量。
+type ArtistSummary struct {
+	Name      string `json:"name"`
+	SongCount int    `json:"song_count"`
+}
+
+// ArtistHandler 负责处理按艺术家浏览歌曲的 API 请求。
+// 分组基于拆分后的艺术家列表，合作歌曲会出现在每位参与艺术家名下。
+type ArtistHandler struct {
+	scanner services.Scanner
+	// order 是艺术家下歌曲列表的排序方式，由配置的默认排序决定。
+	order songOrder
+}
+
+// NewArtistHandler 创建一个新的 ArtistHandler 实例。
+// 艺术家下的歌曲按配置的默认排序返回；艺术家处理器不读取置顶信息，按 pinned 排序时等价于按添加时间排序。
+func NewArtistHandler(scanner services.Scanner, cfg *config.Config) *ArtistHandler {
+	order, err := parseSongOrder(cfg.Music.DefaultSort, cfg.Music.DefaultOrder, func() map[string]int { return nil })
+	if err != nil {
+		logger.Warnf("无效的默认排序配置，保持扫描顺序: %v", err)
+	}
+	return &ArtistHandler{
+		scanner: scanner,
+		order:   order,
+	}
+}
+
+// songArtists 返回歌曲的艺术家列表，没有拆分结果时使用原始的 Artist。
+func songArtists(song *models.Song) []string {
+	if len(song.Artists) > 0 {
+		return song.Artists
+	}
+	return []string{song.Artist}
+}
+
+// GetArtists 返回所有艺术家及各自的歌曲数量，按名称排序。
+// @Summary 获取所有艺术家
+// @Description 基于扫描缓存中拆分后的艺术家列表聚合歌曲数量，合作歌曲计入每位参与的艺术家，
+// @Description 仅有空白、全角或大小写差异的名称会被合并
+// @Tags artist
+// @Produce json
+// @Success 200 {object} map[string]interface{} "成功返回艺术家列表"
+// @Failure 500 {object} APIError "服务器错误"
+// @Router /api/artists [get]
+func (h *ArtistHandler) GetArtists(c *gin.Context) {
+	songs, ok := scanSongs(c, h.scanner)
+	if !ok {
+		return
+	}
+
+	// 按规范化键合并仅有空白、全角或大小写差异的名称，展示首次出现的写法。
+	index := make(map[string]int)
+	artists := make([]ArtistSummary, 0)
+	for _, song := range songs {
+		for _, name := range songArtists(song) {
+			key := models.GroupKey(name)
+			if i, ok := index[key]; ok {
+				artists[i].SongCount++
+				continue
+			}
+			index[key] = len(artists)
+			artists = append(artists, ArtistSummary{Name: name, SongCount: 1})
+		}
+	}
+	sort.Slice(artists, func(i, j int) bool {
+		return artists[i].Name < artists[j].Name
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":   len(artists),
+		"artists": artists,
+	})
+}
+
+// GetSongsByArtist 返回指定艺术家参与的所有歌曲，包括与其他艺术家的合作歌曲。
+// 名称需要进行 URL 编码，路由使用通配参数以支持包含 "/" 的名称；名称按规范化键匹配。
+// @Summary 获取艺术家的歌曲
+// @Description 返回拆分后的艺术家列表中包含指定艺术家的所有歌曲，歌曲按配置的默认排序返回
+// @Tags artist
+// @Produce json
+// @Param name path string true "艺术家名称（URL 编码）"
+// @Param time_format query string false "时间字段格式：rfc3339 或 unix_ms，默认使用服务器配置" Enums(rfc3339, unix_ms)
+// @Success 200 {object} map[string]interface{} "成功返回歌曲列表"
+// @Failure 404 {object} APIError "艺术家不存在"
+// @Failure 500 {object} APIError "服务器错误"
+// @Router /api/artist/{name} [get]
+func (h *ArtistHandler) GetSongsByArtist(c *gin.Context) {
+	name := strings.TrimPrefix(c.Param("name"), "/")
+
+	songs, ok := scanSongs(c, h.scanner)
+	if !ok {
+		return
+	}
+
+	key := models.GroupKey(name)
+	matched := make([]*models.Song, 0)
+	for _, song := range songs {
+		for _, artist := range songArtists(song) {
+			if models.GroupKey(artist) == key {
+				matched = append(matched, song)
+				break
+			}
+		}
+	}
+	if len(matched) == 0 {
+		RespondError(c, http.StatusNotFound, NewNotFoundError("艺术家"))
+		return
+	}
+	if h.order.sorted() {
+		matched = sortSongs(matched, h.order)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"artist": name,
+		"total":  len(matched),
+		"songs":  songsJSON(c, matched),
+	})
+}
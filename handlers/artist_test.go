@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// buildMP3WithArtist 构造一个带有 ID3v2.3 艺术家（TPE1）标签的 MP3 文件内容。
+func buildMP3WithArtist(artist string) string {
+	return string(buildMP3WithFrames(buildID3Frame("TPE1", append([]byte{0x00}, artist...))))
+}
+
+// setupArtistTestEnv 创建包含合作歌曲的艺术家测试环境。
+func setupArtistTestEnv(t *testing.T) *gin.Engine {
+	t.Helper()
+	cfg, scanner := newTestLibrary(t, map[string]string{
+		"solo.mp3":  buildMP3WithArtist("Artist A"),
+		"duet.mp3":  buildMP3WithArtist("Artist A; Artist B"),
+		"feat.mp3":  buildMP3WithArtist("Artist B feat. Artist C"),
+		"ft.mp3":    buildMP3WithArtist("artist c FT. Artist A"),
+		"plain.mp3": "fake mp3 data",
+	}, nil)
+	handler := NewArtistHandler(scanner, cfg)
+	router := gin.New()
+	router.GET("/api/artists", handler.GetArtists)
+	router.GET("/api/artist/*name", handler.GetSongsByArtist)
+	return router
+}
+
+// TestGetArtists 测试艺术家列表基于拆分后的艺术家聚合，合作歌曲计入每位艺术家。
+func TestGetArtists(t *testing.T) {
+	router := setupArtistTestEnv(t)
+
+	req, _ := http.NewRequest("GET", "/api/artists", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("期望状态码 200, 得到 %d", w.Code)
+	}
+
+	var resp struct {
+		Total   int             `json:"total"`
+		Artists []ArtistSummary `json:"artists"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	expected := []ArtistSummary{
+		{Name: "Artist A", SongCount: 3},
+		{Name: "Artist B", SongCount: 2},
+		{Name: "Artist C", SongCount: 2},
+		{Name: "Unknown", SongCount: 1},
+	}
+	if resp.Total != len(expected) {
+		t.Fatalf("期望 %d 位艺术家, 得到 %d: %+v", len(expected), resp.Total, resp.Artists)
+	}
+	for i, want := range expected {
+		if resp.Artists[i] != want {
+			t.Errorf("第 %d 位艺术家期望 %+v, 得到 %+v", i, want, resp.Artists[i])
+		}
+	}
+}
+
+// TestGetSongsByArtist 测试合作歌曲出现在每位参与艺术家名下，且保留原始的 artist 字段。
+func TestGetSongsByArtist(t *testing.T) {
+	router := setupArtistTestEnv(t)
+
+	testCases := []struct {
+		name          string
+		artist        string
+		expectedCode  int
+		expectedFiles []string
+	}{
+		{"独唱与合作歌曲", "Artist A", http.StatusOK, []string{"duet.mp3", "ft.mp3", "solo.mp3"}},
+		{"feat. 拆分", "Artist B", http.StatusOK, []string{"duet.mp3", "feat.mp3"}},
+		{"大小写不同的名称", "ARTIST C", http.StatusOK, []string{"feat.mp3", "ft.mp3"}},
+		{"艺术家不存在", "Artist D", http.StatusNotFound, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/artist/"+url.PathEscape(tc.artist), nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tc.expectedCode {
+				t.Fatalf("期望状态码 %d, 得到 %d", tc.expectedCode, w.Code)
+			}
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			var resp struct {
+				Songs []struct {
+					FileName string   `json:"file_name"`
+					Artist   string   `json:"artist"`
+					Artists  []string `json:"artists"`
+				} `json:"songs"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("解析响应失败: %v", err)
+			}
+			files := make(map[string]bool)
+			for _, song := range resp.Songs {
+				files[song.FileName] = true
+				if song.FileName == "duet.mp3" && (song.Artist != "Artist A; Artist B" || len(song.Artists) != 2) {
+					t.Errorf("期望合作歌曲保留原始 artist 并拆分为 2 位艺术家, 得到 %q, %v", song.Artist, song.Artists)
+				}
+			}
+			if len(files) != len(tc.expectedFiles) {
+				t.Fatalf("期望 %d 首歌曲, 得到 %d", len(tc.expectedFiles), len(files))
+			}
+			for _, name := range tc.expectedFiles {
+				if !files[name] {
+					t.Errorf("期望结果包含 %s", name)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"zero-music/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxBatchSize 是批量获取歌曲详情时单次请求允许的最大 ID 数量。
+const maxBatchSize = maxPageLimit
+
+// batchSongsResponse 是批量获取歌曲详情的响应体。
+type batchSongsResponse struct {
+	// Songs 是以 ID 为键的歌曲详情。
+	Songs map[string]songJSON `json:"songs"`
+	// NotFound 是格式有效但不存在的歌曲 ID。
+	NotFound []string `json:"not_found"`
+	// Invalid 是格式无效的歌曲 ID。
+	Invalid []string `json:"invalid"`
+}
+
+// GetSongsBatch 处理批量获取歌曲详情的请求。
+// @Summary 批量获取歌曲信息
+// @Description 请求体为歌曲 ID 数组，返回以 ID 为键的歌曲详情；不存在的 ID 放入 not_found，格式无效的 ID 放入 invalid
+// @Tags playlist
+// @Accept json
+// @Produce json
+// @Param body body []string true "歌曲 ID 列表"
+// @Param time_format query string false "时间字段格式：rfc3339 或 unix_ms，默认使用服务器配置" Enums(rfc3339, unix_ms)
+// @Success 200 {object} batchSongsResponse "成功返回歌曲信息"
+// @Failure 400 {object} APIError "请求参数错误"
+// @Failure 500 {object} APIError "服务器错误"
+// @Failure 503 {object} APIError "音乐目录暂时不可用且没有缓存"
+// @Router /api/songs/batch [post]
+func (h *PlaylistHandler) GetSongsBatch(c *gin.Context) {
+	var ids []string
+	if err := c.ShouldBindJSON(&ids); err != nil {
+		RespondError(c, http.StatusBadRequest, NewBadRequestError("请求体必须是歌曲 ID 数组"))
+		return
+	}
+	if len(ids) > maxBatchSize {
+		RespondError(c, http.StatusBadRequest, NewBadRequestError(fmt.Sprintf("单次最多查询 %d 个歌曲 ID", maxBatchSize)))
+		return
+	}
+
+	response := batchSongsResponse{
+		Songs:    make(map[string]songJSON),
+		NotFound: make([]string, 0),
+		Invalid:  make([]string, 0),
+	}
+
+	// 校验 ID 格式，防止路径遍历；重复的 ID 只查找一次。
+	valid := make([]string, 0, len(ids))
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if models.IsValidID(id) {
+			valid = append(valid, id)
+		} else {
+			response.Invalid = append(response.Invalid, id)
+		}
+	}
+
+	// 先执行扫描以确保缓存是最新的。
+	if _, ok := scanSongs(c, h.scanner); !ok {
+		return
+	}
+
+	// 使用索引批量查找歌曲。
+	format := getTimeFormat(c)
+	found := h.scanner.GetSongsByIDs(valid)
+	for _, id := range valid {
+		song, ok := found[id]
+		if !ok {
+			response.NotFound = append(response.NotFound, id)
+			continue
+		}
+		response.Songs[id] = newSongJSON(song, format)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
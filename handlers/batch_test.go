@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestGetSongsBatch 测试批量获取歌曲详情时存在、不存在与格式无效的 ID 被分别归类。
+func TestGetSongsBatch(t *testing.T) {
+	router, _ := setupTestEnv(t)
+
+	ids := make([]string, 0)
+	for _, song := range fetchSongsPage(t, router, "").Songs {
+		ids = append(ids, song.ID)
+	}
+	if len(ids) != 2 {
+		t.Fatalf("期望 2 首歌曲, 得到 %d", len(ids))
+	}
+	missing := strings.Repeat("0", 32)
+	invalid := "../../etc/passwd"
+
+	body, _ := json.Marshal([]string{ids[0], missing, invalid, ids[1], ids[0]})
+	req := httptest.NewRequest("POST", "/api/songs/batch", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("期望状态码 200, 得到 %d: %s", w.Code, w.Body.String())
+	}
+	var response batchSongsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+
+	if len(response.Songs) != 2 {
+		t.Errorf("期望返回 2 首歌曲, 得到 %d", len(response.Songs))
+	}
+	for _, id := range ids {
+		if song, ok := response.Songs[id]; !ok || song.ID != id {
+			t.Errorf("期望返回歌曲 %s, 得到 %v", id, song)
+		}
+	}
+	if len(response.NotFound) != 1 || response.NotFound[0] != missing {
+		t.Errorf("期望 not_found 为 [%s], 得到 %v", missing, response.NotFound)
+	}
+	if len(response.Invalid) != 1 || response.Invalid[0] != invalid {
+		t.Errorf("期望 invalid 为 [%s], 得到 %v", invalid, response.Invalid)
+	}
+}
+
+// TestGetSongsBatch_InvalidBody 测试请求体不是 ID 数组或 ID 数量超限时返回 400。
+func TestGetSongsBatch_InvalidBody(t *testing.T) {
+	router, _ := setupTestEnv(t)
+
+	tooMany, _ := json.Marshal(make([]string, maxBatchSize+1))
+	testCases := []struct {
+		name string
+		body string
+	}{
+		{"非数组", `{"ids": []}`},
+		{"非字符串元素", `[1, 2]`},
+		{"数量超限", string(tooMany)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/songs/batch", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("期望状态码 400, 得到 %d", w.Code)
+			}
+		})
+	}
+}
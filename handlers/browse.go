@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"net/http"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"zero-music/config"
+	"zero-music/logger"
+	"zero-music/middleware"
+	"zero-music/models"
+	"zero-music/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DirectoryEntry 是浏览结果中的一个子目录。
+type DirectoryEntry struct {
+	// Name 是子目录名。
+	Name string `json:"name"`
+	// Path 是子目录相对于音乐目录的路径，使用 / 分隔，可直接作为下一次浏览的 path 参数。
+	Path string `json:"path"`
+	// SongCount 是子目录及其所有下级目录中的歌曲数量。
+	SongCount int `json:"song_count"`
+}
+
+// browseResponse 是目录浏览的响应体。
+type browseResponse struct {
+	Path        string           `json:"path"`
+	Directories []DirectoryEntry `json:"directories"`
+	Songs       []songJSON       `json:"songs"`
+}
+
+// BrowseHandler 负责按目录树逐层浏览扫描结果的 API 请求。
+type BrowseHandler struct {
+	scanner     services.Scanner
+	musicDirAbs string // 音乐目录的绝对路径，歌曲路径基于它计算相对目录。
+}
+
+// NewBrowseHandler 创建一个新的 BrowseHandler 实例。
+func NewBrowseHandler(scanner services.Scanner, cfg *config.Config) *BrowseHandler {
+	musicDirAbs, err := filepath.Abs(cfg.Music.Directory)
+	if err != nil {
+		logger.Warnf("无法获取音乐目录的绝对路径: %v", err)
+		musicDirAbs = cfg.Music.Directory
+	}
+	return &BrowseHandler{
+		scanner:     scanner,
+		musicDirAbs: musicDirAbs,
+	}
+}
+
+// cleanBrowsePath 校验并规范化相对于音乐目录的浏览路径，根目录返回空字符串。
+// 绝对路径、包含 .. 路径段的路径以及包含反斜杠或空字节的路径均被拒绝，确保结果位于音乐目录内。
+func cleanBrowsePath(raw string) (string, bool) {
+	if strings.ContainsAny(raw, "\\\x00") || strings.HasPrefix(raw, "/") || filepath.IsAbs(raw) {
+		return "", false
+	}
+	for _, part := range strings.Split(raw, "/") {
+		if part == ".." {
+			return "", false
+		}
+	}
+	cleaned := path.Clean(raw)
+	if cleaned == "." {
+		return "", true
+	}
+	return cleaned, true
+}
+
+// Browse 返回指定目录下的子目录列表与该层的歌曲列表（不递归）。
+// @Summary 按目录浏览歌曲
+// @Description 基于扫描结果逐层浏览音乐目录。子目录只包含其下有歌曲的目录，song_count 为其子树中的歌曲数；
+// @Description 歌曲只包含直接位于该目录下的文件，按文件名排序
+// @Tags playlist
+// @Produce json
+// @Param path query string false "相对于音乐目录的路径，使用 / 分隔，为空时返回顶层"
+// @Param time_format query string false "时间字段格式：rfc3339 或 unix_ms，默认使用服务器配置" Enums(rfc3339, unix_ms)
+// @Success 200 {object} browseResponse "成功返回目录内容"
+// @Failure 403 {object} APIError "路径越出音乐目录"
+// @Failure 404 {object} APIError "目录未找到"
+// @Failure 500 {object} APIError "服务器错误"
+// @Failure 503 {object} APIError "音乐目录暂时不可用且没有缓存"
+// @Router /api/browse [get]
+func (h *BrowseHandler) Browse(c *gin.Context) {
+	requestID := middleware.GetRequestID(c)
+
+	dir, ok := cleanBrowsePath(c.Query("path"))
+	if !ok {
+		logger.WithRequestID(requestID).Warnf("安全警告: 拒绝浏览音乐目录之外的路径: %s", c.Query("path"))
+		RespondError(c, http.StatusForbidden, NewForbiddenError("拒绝访问"))
+		return
+	}
+
+	songs, ok := scanSongs(c, h.scanner)
+	if !ok {
+		return
+	}
+
+	response := browseResponse{
+		Path:        dir,
+		Directories: make([]DirectoryEntry, 0),
+	}
+	matched := make([]*models.Song, 0)
+	subdirs := make(map[string]int)
+	for _, song := range songs {
+		rel, err := filepath.Rel(h.musicDirAbs, filepath.Dir(song.FilePath))
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == "." {
+			rel = ""
+		}
+
+		switch {
+		case rel == dir:
+			matched = append(matched, song)
+		case dir == "":
+			subdirs[strings.SplitN(rel, "/", 2)[0]]++
+		case strings.HasPrefix(rel, dir+"/"):
+			subdirs[strings.SplitN(strings.TrimPrefix(rel, dir+"/"), "/", 2)[0]]++
+		}
+	}
+
+	// 根目录以外没有任何歌曲的目录视为不存在。
+	if dir != "" && len(matched) == 0 && len(subdirs) == 0 {
+		RespondError(c, http.StatusNotFound, NewNotFoundError("目录"))
+		return
+	}
+
+	for name, count := range subdirs {
+		response.Directories = append(response.Directories, DirectoryEntry{
+			Name:      name,
+			Path:      path.Join(dir, name),
+			SongCount: count,
+		})
+	}
+	sort.Slice(response.Directories, func(i, j int) bool {
+		return response.Directories[i].Name < response.Directories[j].Name
+	})
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].FileName < matched[j].FileName
+	})
+	response.Songs = songsJSON(c, matched)
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"zero-music/config"
+	"zero-music/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupBrowseRouter 创建包含多层目录的音乐库并注册浏览端点。
+func setupBrowseRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	tmpDir := t.TempDir()
+	for _, name := range []string{
+		"root.mp3",
+		"Artist A/single.mp3",
+		"Artist A/Album 1/01.mp3",
+		"Artist A/Album 1/02.mp3",
+		"Artist A/Album 2/01.mp3",
+		"Artist B/Album 3/Disc 1/01.mp3",
+		"Empty/cover.jpg",
+	} {
+		path := filepath.Join(tmpDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("fake mp3 "+name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &config.Config{Music: config.MusicConfig{Directory: tmpDir}}
+	scanner := services.NewMusicScanner(tmpDir, []string{".mp3"}, 5)
+	router := gin.New()
+	router.GET("/api/browse", NewBrowseHandler(scanner, cfg).Browse)
+	return router
+}
+
+// TestBrowse 测试逐层浏览时返回该层的子目录（含子树歌曲数）与直接位于该层的歌曲。
+func TestBrowse(t *testing.T) {
+	router := setupBrowseRouter(t)
+
+	tests := []struct {
+		path  string
+		dirs  map[string]int
+		songs []string
+	}{
+		{"", map[string]int{"Artist A": 4, "Artist B": 1}, []string{"root.mp3"}},
+		{"Artist A", map[string]int{"Artist A/Album 1": 2, "Artist A/Album 2": 1}, []string{"single.mp3"}},
+		{"Artist A/Album 1/", map[string]int{}, []string{"01.mp3", "02.mp3"}},
+		{"Artist B", map[string]int{"Artist B/Album 3": 1}, []string{}},
+		{"./Artist B/Album 3", map[string]int{"Artist B/Album 3/Disc 1": 1}, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/browse?path="+url.QueryEscape(tt.path), nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("期望状态码 200, 得到 %d: %s", w.Code, w.Body.String())
+			}
+
+			var response browseResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("解析响应失败: %v", err)
+			}
+			if len(response.Directories) != len(tt.dirs) {
+				t.Errorf("期望 %d 个子目录, 得到 %+v", len(tt.dirs), response.Directories)
+			}
+			for _, dir := range response.Directories {
+				if count, ok := tt.dirs[dir.Path]; !ok || count != dir.SongCount {
+					t.Errorf("子目录 %s: 期望歌曲数 %d, 得到 %d", dir.Path, count, dir.SongCount)
+				}
+			}
+			if len(response.Songs) != len(tt.songs) {
+				t.Fatalf("期望 %d 首歌曲, 得到 %d", len(tt.songs), len(response.Songs))
+			}
+			for i, song := range response.Songs {
+				if song.FileName != tt.songs[i] {
+					t.Errorf("期望第 %d 首歌曲为 %s, 得到 %s", i, tt.songs[i], song.FileName)
+				}
+			}
+		})
+	}
+}
+
+// TestBrowse_Rejected 测试越出音乐目录的路径返回 403，不存在或没有歌曲的目录返回 404。
+func TestBrowse_Rejected(t *testing.T) {
+	router := setupBrowseRouter(t)
+
+	tests := []struct {
+		path         string
+		expectedCode int
+	}{
+		{"..", http.StatusForbidden},
+		{"../etc", http.StatusForbidden},
+		{"Artist A/../../etc", http.StatusForbidden},
+		{"/etc", http.StatusForbidden},
+		{"Artist A\\..\\..", http.StatusForbidden},
+		{"Missing", http.StatusNotFound},
+		{"Empty", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/browse?path="+url.QueryEscape(tt.path), nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.expectedCode {
+				t.Errorf("期望状态码 %d, 得到 %d", tt.expectedCode, w.Code)
+			}
+		})
+	}
+}
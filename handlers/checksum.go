@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"os"
+	"sync"
+	"time"
+	"zero-music/models"
+)
+
+// checksumCache 缓存按需计算的歌曲校验和。源文件的修改时间或大小变化后缓存自动失效。
+type checksumCache struct {
+	mu      sync.Mutex
+	entries map[string]checksumEntry
+	compute func(song *models.Song) (string, error)
+}
+
+// checksumEntry 是一条缓存的校验和及计算时源文件的状态。
+type checksumEntry struct {
+	modTime  time.Time
+	size     int64
+	checksum string
+}
+
+// newChecksumCache 创建一个使用 compute 计算校验和的缓存。
+func newChecksumCache(compute func(song *models.Song) (string, error)) *checksumCache {
+	return &checksumCache{
+		entries: make(map[string]checksumEntry),
+		compute: compute,
+	}
+}
+
+// Get 返回歌曲的校验和。源文件自上次计算后未变化时直接返回缓存结果，否则重新计算。
+// 计算过程不持有锁，同一首歌的并发请求可能各自计算一次，结果相同。
+func (c *checksumCache) Get(song *models.Song) (string, error) {
+	info, err := os.Stat(song.FilePath)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	entry, ok := c.entries[song.ID]
+	c.mu.Unlock()
+	if ok && entry.modTime.Equal(info.ModTime()) && entry.size == info.Size() {
+		return entry.checksum, nil
+	}
+
+	checksum, err := c.compute(song)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	c.entries[song.ID] = checksumEntry{modTime: info.ModTime(), size: info.Size(), checksum: checksum}
+	c.mu.Unlock()
+	return checksum, nil
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+	"zero-music/logger"
+	"zero-music/middleware"
+	"zero-music/models"
+	"zero-music/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// coverCacheName 是原始封面在磁盘缓存中的条目名，缩略图在其后附加 "_<尺寸>"。
+	coverCacheName = "cover"
+	// minThumbnailSize 和 maxThumbnailSize 是缩略图最长边允许的范围（像素）。
+	minThumbnailSize = 16
+	maxThumbnailSize = 1000
+)
+
+// CoverHandler 负责处理歌曲封面相关的 API 请求。
+type CoverHandler struct {
+	scanner services.Scanner
+	cache   *services.DiskCache // 提取结果的磁盘缓存，为 nil 时不缓存。
+}
+
+// NewCoverHandler 创建一个新的 CoverHandler 实例。cache 可以为 nil。
+func NewCoverHandler(scanner services.Scanner, cache *services.DiskCache) *CoverHandler {
+	return &CoverHandler{
+		scanner: scanner,
+		cache:   cache,
+	}
+}
+
+// GetCover 处理获取歌曲封面的请求，返回音频文件中嵌入的封面图片。
+// @Summary 获取歌曲封面
+// @Description 返回音频文件标签中嵌入的封面图片
+// @Tags cover
+// @Produce image/jpeg,image/png
+// @Param id path string true "歌曲ID"
+// @Param size query int false "缩略图最长边（16-1000 像素），不指定则返回原图"
+// @Success 200 {file} binary "封面图片"
+// @Failure 400 {object} APIError "请求参数错误"
+// @Failure 404 {object} APIError "歌曲或封面未找到"
+// @Failure 500 {object} APIError "服务器错误"
+// @Router /api/cover/{id} [get]
+func (h *CoverHandler) GetCover(c *gin.Context) {
+	id := c.Param("id")
+	requestID := middleware.GetRequestID(c)
+
+	// 验证 ID 格式，防止路径遍历。
+	if !models.IsValidID(id) {
+		logger.WithRequestID(requestID).Warnf("无效的歌曲 ID 格式: %s", id)
+		RespondError(c, http.StatusBadRequest, NewBadRequestError("无效的歌曲 ID 格式"))
+		return
+	}
+
+	size, err := parseThumbnailSize(c.Query("size"))
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, NewBadRequestError(err.Error()))
+		return
+	}
+
+	if _, ok := scanSongs(c, h.scanner); !ok {
+		return
+	}
+
+	song := h.scanner.GetSongByID(id)
+	if song == nil {
+		RespondError(c, http.StatusNotFound, NewNotFoundError("歌曲"))
+		return
+	}
+
+	fileInfo, err := os.Stat(song.FilePath)
+	if err != nil {
+		logger.WithRequestID(requestID).Errorf("无法访问音频文件 %s: %v", song.FilePath, err)
+		RespondError(c, http.StatusNotFound, NewNotFoundError("歌曲文件"))
+		return
+	}
+
+	modTime := fileInfo.ModTime()
+
+	// 缩略图优先使用磁盘缓存，源文件更新后缓存自动失效。
+	cacheName := coverCacheName
+	if size > 0 {
+		cacheName = coverCacheName + "_" + strconv.Itoa(size)
+		if data, ok := h.cache.Get(song.ID, cacheName, modTime); ok {
+			c.Data(http.StatusOK, http.DetectContentType(data), data)
+			return
+		}
+	}
+
+	cover, err := h.loadCover(song, modTime, requestID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoCover) {
+			RespondError(c, http.StatusNotFound, NewNotFoundError("封面"))
+			return
+		}
+		logger.WithRequestID(requestID).Errorf("读取封面失败 %s: %v", song.FilePath, err)
+		RespondError(c, http.StatusInternalServerError, NewInternalError(err))
+		return
+	}
+
+	if size > 0 {
+		thumbnail, err := cover.Thumbnail(size)
+		if err != nil {
+			// 无法解码的图片格式直接返回原图。
+			logger.WithRequestID(requestID).Warnf("生成缩略图失败 %s: %v", song.FilePath, err)
+		} else {
+			cover = thumbnail
+			if err := h.cache.Put(song.ID, cacheName, modTime, cover.Data); err != nil {
+				logger.WithRequestID(requestID).Warnf("写入封面缓存失败: %v", err)
+			}
+		}
+	}
+
+	c.Data(http.StatusOK, cover.MIMEType, cover.Data)
+}
+
+// loadCover 返回歌曲的原始封面，优先读取磁盘缓存，未命中时从音频文件提取并写入缓存。
+func (h *CoverHandler) loadCover(song *models.Song, modTime time.Time, requestID string) (*models.Cover, error) {
+	if data, ok := h.cache.Get(song.ID, coverCacheName, modTime); ok {
+		return &models.Cover{MIMEType: http.DetectContentType(data), Data: data}, nil
+	}
+
+	cover, err := models.ReadCover(song.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.cache.Put(song.ID, coverCacheName, modTime, cover.Data); err != nil {
+		logger.WithRequestID(requestID).Warnf("写入封面缓存失败: %v", err)
+	}
+	return cover, nil
+}
+
+// parseThumbnailSize 解析缩略图尺寸参数，空字符串表示返回原图（返回 0）。
+func parseThumbnailSize(raw string) (int, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	size, err := strconv.Atoi(raw)
+	if err != nil || size < minThumbnailSize || size > maxThumbnailSize {
+		return 0, fmt.Errorf("size 必须是 %d-%d 之间的整数", minThumbnailSize, maxThumbnailSize)
+	}
+	return size, nil
+}
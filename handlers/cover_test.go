@@ -0,0 +1,434 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+	"zero-music/config"
+	"zero-music/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testCoverData 是测试用的封面图片数据（PNG 文件头）。
+var testCoverData = []byte("\x89PNG\r\n\x1a\nfake-cover")
+
+// buildID3Frame 构造一个 ID3v2.3 帧。
+func buildID3Frame(id string, body []byte) []byte {
+	var frame bytes.Buffer
+	frame.WriteString(id)
+	size := len(body)
+	frame.Write([]byte{byte(size >> 24), byte(size >> 16), byte(size >> 8), byte(size)})
+	frame.Write([]byte{0x00, 0x00})
+	frame.Write(body)
+	return frame.Bytes()
+}
+
+// buildMP3WithFrames 构造一个带有指定 ID3v2.3 帧的 MP3 文件内容。
+func buildMP3WithFrames(frames ...[]byte) []byte {
+	tag := bytes.Join(frames, nil)
+
+	var buf bytes.Buffer
+	buf.WriteString("ID3")
+	buf.Write([]byte{0x03, 0x00, 0x00})
+	tagSize := len(tag)
+	buf.Write([]byte{byte(tagSize >> 21 & 0x7f), byte(tagSize >> 14 & 0x7f), byte(tagSize >> 7 & 0x7f), byte(tagSize & 0x7f)})
+	buf.Write(tag)
+	buf.WriteString("fake mp3 audio data")
+	return buf.Bytes()
+}
+
+// buildMP3WithCover 构造一个带有 ID3v2.3 APIC 帧（嵌入封面）的 MP3 文件内容。
+func buildMP3WithCover(mimeType string, picture []byte) []byte {
+	var body bytes.Buffer
+	body.WriteByte(0x00) // 文本编码：ISO-8859-1
+	body.WriteString(mimeType)
+	body.WriteByte(0x00)
+	body.WriteByte(0x03) // 图片类型：封面
+	body.WriteByte(0x00) // 空描述
+	body.Write(picture)
+	return buildMP3WithFrames(buildID3Frame("APIC", body.Bytes()))
+}
+
+// setupURLTestEnv 创建一个注册了歌曲、流和封面路由的测试环境。
+func setupURLTestEnv(t *testing.T, publicBaseURL string, trustedProxies ...string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "cover.mp3"), buildMP3WithCover("image/png", testCoverData), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{
+		Server: config.ServerConfig{PublicBaseURL: publicBaseURL, TrustedProxies: trustedProxies},
+		Music: config.MusicConfig{
+			Directory:        tmpDir,
+			SupportedFormats: []string{".mp3"},
+			CacheTTLMinutes:  5,
+		},
+	}
+	scanner := services.NewMusicScanner(cfg.Music.Directory, cfg.Music.SupportedFormats, cfg.Music.CacheTTLMinutes)
+
+	router := gin.New()
+	router.GET("/api/songs", NewPlaylistHandler(scanner, nil, nil, cfg).GetAllSongs)
+	router.GET("/api/song/:id", NewPlaylistHandler(scanner, nil, nil, cfg).GetSongByID)
+	router.GET("/api/stream/:id", NewStreamHandler(scanner, cfg).StreamAudio)
+	router.GET("/api/cover/:id", NewCoverHandler(scanner, nil).GetCover)
+	return router
+}
+
+// TestGetAllSongs_IncludeURLs 测试 include_urls 参数能否生成可直接访问的完整 URL。
+func TestGetAllSongs_IncludeURLs(t *testing.T) {
+	tests := []struct {
+		name           string
+		publicBaseURL  string
+		trustedProxies []string
+		headers        map[string]string
+		wantPrefix     string
+	}{
+		{"请求 Host", "", nil, nil, "http://example.com"},
+		// httptest 请求的对端地址为 192.0.2.1。
+		{"受信任代理的转发头", "", []string{"192.0.2.0/24"}, map[string]string{"X-Forwarded-Proto": "https", "X-Forwarded-Host": "music.example.org, proxy.local"}, "https://music.example.org"},
+		{"不受信任的客户端伪造转发头", "", []string{"10.0.0.1"}, map[string]string{"X-Forwarded-Proto": "https", "X-Forwarded-Host": "evil.example.org"}, "http://example.com"},
+		{"配置的公开地址", "https://cdn.example.net/zero/", nil, map[string]string{"X-Forwarded-Host": "ignored.example.org"}, "https://cdn.example.net/zero"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := setupURLTestEnv(t, tt.publicBaseURL, tt.trustedProxies...)
+
+			req := httptest.NewRequest("GET", "/api/songs?include_urls=true", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("期望状态码 200, 得到 %d", w.Code)
+			}
+
+			var response struct {
+				Songs []struct {
+					ID        string `json:"id"`
+					StreamURL string `json:"stream_url"`
+					CoverURL  string `json:"cover_url"`
+				} `json:"songs"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("解析响应失败: %v", err)
+			}
+			if len(response.Songs) != 1 {
+				t.Fatalf("期望 1 首歌曲, 得到 %d", len(response.Songs))
+			}
+			song := response.Songs[0]
+			if want := tt.wantPrefix + "/api/stream/" + song.ID; song.StreamURL != want {
+				t.Errorf("期望 stream_url 为 %q, 得到 %q", want, song.StreamURL)
+			}
+			if want := tt.wantPrefix + "/api/cover/" + song.ID; song.CoverURL != want {
+				t.Errorf("期望 cover_url 为 %q, 得到 %q", want, song.CoverURL)
+			}
+		})
+	}
+}
+
+// TestIncludeURLs_Usable 测试生成的 URL 能否直接访问到音频和封面。
+func TestIncludeURLs_Usable(t *testing.T) {
+	router := setupURLTestEnv(t, "")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/songs", nil))
+	var list struct {
+		Songs []struct {
+			ID        string `json:"id"`
+			StreamURL string `json:"stream_url"`
+		} `json:"songs"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if len(list.Songs) != 1 {
+		t.Fatalf("期望 1 首歌曲, 得到 %d", len(list.Songs))
+	}
+	if list.Songs[0].StreamURL != "" {
+		t.Errorf("未指定 include_urls 时不应返回 stream_url, 得到 %q", list.Songs[0].StreamURL)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/song/"+list.Songs[0].ID+"?include_urls=true", nil))
+	var song struct {
+		StreamURL string `json:"stream_url"`
+		CoverURL  string `json:"cover_url"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &song); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", song.StreamURL, nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("访问 stream_url 期望状态码 200, 得到 %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", song.CoverURL, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("访问 cover_url 期望状态码 200, 得到 %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "image/png" {
+		t.Errorf("期望 Content-Type 为 image/png, 得到 %s", ct)
+	}
+	if !bytes.Equal(w.Body.Bytes(), testCoverData) {
+		t.Error("封面数据与嵌入的图片不一致")
+	}
+}
+
+// TestGetCover_NoCover 测试没有嵌入封面的歌曲返回 404。
+func TestGetCover_NoCover(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "plain.mp3"), []byte("fake mp3 data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	scanner := services.NewMusicScanner(tmpDir, []string{".mp3"}, 5)
+	songs, err := scanner.Scan(context.Background())
+	if err != nil || len(songs) != 1 {
+		t.Fatalf("扫描失败: %v", err)
+	}
+
+	router := gin.New()
+	router.GET("/api/cover/:id", NewCoverHandler(scanner, nil).GetCover)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/cover/"+songs[0].ID, nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("期望状态码 404, 得到 %d", w.Code)
+	}
+}
+
+// TestGetCover_DiskCache 测试封面首次提取后写入磁盘缓存、再次请求命中缓存、源文件更新后重新生成。
+func TestGetCover_DiskCache(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	musicDir := t.TempDir()
+	songPath := filepath.Join(musicDir, "cover.mp3")
+	if err := os.WriteFile(songPath, buildMP3WithCover("image/png", testCoverData), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cacheDir := t.TempDir()
+	cache, err := services.NewDiskCache(cacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scanner := services.NewMusicScanner(musicDir, []string{".mp3"}, 5)
+	songs, err := scanner.Scan(context.Background())
+	if err != nil || len(songs) != 1 {
+		t.Fatalf("扫描失败: %v", err)
+	}
+	id := songs[0].ID
+
+	router := gin.New()
+	router.GET("/api/cover/:id", NewCoverHandler(scanner, cache).GetCover)
+	fetch := func() []byte {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/api/cover/"+id, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("期望状态码 200, 得到 %d", w.Code)
+		}
+		return w.Body.Bytes()
+	}
+
+	// 首次请求未命中，从源文件提取并写入缓存。
+	if got := fetch(); !bytes.Equal(got, testCoverData) {
+		t.Fatal("首次请求返回的封面数据不正确")
+	}
+	entry := filepath.Join(cacheDir, id[:2], id+"_"+coverCacheName)
+	if _, err := os.Stat(entry); err != nil {
+		t.Fatalf("期望写入缓存文件: %v", err)
+	}
+
+	// 第二次请求命中缓存：篡改缓存内容（保持修改时间）后应返回缓存中的数据。
+	info, _ := os.Stat(entry)
+	cachedData := append([]byte(nil), testCoverData...)
+	cachedData = append(cachedData, []byte("-cached")...)
+	if err := os.WriteFile(entry, cachedData, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(entry, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	if got := fetch(); !bytes.Equal(got, cachedData) {
+		t.Error("期望第二次请求命中磁盘缓存")
+	}
+
+	// 源文件更新后重新提取。
+	newCover := []byte("\x89PNG\r\n\x1a\nnew-cover")
+	if err := os.WriteFile(songPath, buildMP3WithCover("image/png", newCover), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := info.ModTime().Add(time.Minute)
+	if err := os.Chtimes(songPath, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if got := fetch(); !bytes.Equal(got, newCover) {
+		t.Errorf("期望源文件更新后重新生成封面, 得到 %q", got)
+	}
+}
+
+// encodeTestPNG 生成一张指定尺寸的 PNG 图片。
+func encodeTestPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// TestGetCover_Thumbnail 测试不同 size 参数下缩略图的输出尺寸，以及缩略图写入磁盘缓存。
+func TestGetCover_Thumbnail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	musicDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(musicDir, "cover.mp3"), buildMP3WithCover("image/png", encodeTestPNG(t, 400, 200)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cacheDir := t.TempDir()
+	cache, err := services.NewDiskCache(cacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scanner := services.NewMusicScanner(musicDir, []string{".mp3"}, 5)
+	songs, err := scanner.Scan(context.Background())
+	if err != nil || len(songs) != 1 {
+		t.Fatalf("扫描失败: %v", err)
+	}
+	id := songs[0].ID
+
+	router := gin.New()
+	router.GET("/api/cover/:id", NewCoverHandler(scanner, cache).GetCover)
+
+	tests := []struct {
+		query      string
+		wantWidth  int
+		wantHeight int
+	}{
+		{"", 400, 200},
+		{"size=200", 200, 100},
+		{"size=16", 16, 8},
+		{"size=1000", 400, 200}, // 原图较小时不放大。
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/api/cover/"+id+"?"+tt.query, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("期望状态码 200, 得到 %d", w.Code)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "image/png" {
+				t.Errorf("期望 Content-Type 为 image/png, 得到 %s", ct)
+			}
+			cfg, err := png.DecodeConfig(bytes.NewReader(w.Body.Bytes()))
+			if err != nil {
+				t.Fatalf("解码响应图片失败: %v", err)
+			}
+			if cfg.Width != tt.wantWidth || cfg.Height != tt.wantHeight {
+				t.Errorf("期望尺寸 %dx%d, 得到 %dx%d", tt.wantWidth, tt.wantHeight, cfg.Width, cfg.Height)
+			}
+		})
+	}
+
+	if _, err := os.Stat(filepath.Join(cacheDir, id[:2], id+"_"+coverCacheName+"_200")); err != nil {
+		t.Errorf("期望缩略图写入磁盘缓存: %v", err)
+	}
+}
+
+// TestGetCover_InvalidSize 测试非法的 size 参数返回 400。
+func TestGetCover_InvalidSize(t *testing.T) {
+	router := setupURLTestEnv(t, "")
+	id := "0123456789abcdef0123456789abcdef"
+
+	for _, query := range []string{"size=abc", "size=15", "size=1001", "size=-1"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/api/cover/"+id+"?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("对于 %s，期望状态码 400, 得到 %d", query, w.Code)
+		}
+	}
+}
+
+// TestGetAllSongs_HasCover 测试按是否有封面过滤歌曲，同目录的封面文件同样视为有封面并可通过封面端点获取。
+func TestGetAllSongs_HasCover(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tmpDir := t.TempDir()
+	albumDir := filepath.Join(tmpDir, "Album")
+	if err := os.MkdirAll(albumDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{
+		filepath.Join(tmpDir, "embedded.mp3"): buildMP3WithCover("image/png", testCoverData),
+		filepath.Join(tmpDir, "plain.mp3"):    []byte("fake mp3 data"),
+		filepath.Join(albumDir, "track.mp3"):  []byte("fake mp3 data"),
+		filepath.Join(albumDir, "Cover.JPG"):  testCoverData,
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scanner := services.NewMusicScanner(tmpDir, []string{".mp3"}, 5)
+	router := gin.New()
+	router.GET("/api/songs", NewPlaylistHandler(scanner, nil, nil, &config.Config{}).GetAllSongs)
+	router.GET("/api/cover/:id", NewCoverHandler(scanner, nil).GetCover)
+
+	names := func(query string) string {
+		var result []string
+		for _, song := range fetchSongsPage(t, router, query).Songs {
+			result = append(result, song.FileName)
+		}
+		sort.Strings(result)
+		return strings.Join(result, ",")
+	}
+	if got := names("has_cover=true"); got != "embedded.mp3,track.mp3" {
+		t.Errorf("期望有封面的歌曲为 embedded.mp3,track.mp3, 得到 %s", got)
+	}
+	if got := names("has_cover=false"); got != "plain.mp3" {
+		t.Errorf("期望没有封面的歌曲为 plain.mp3, 得到 %s", got)
+	}
+	if got := names(""); got != "embedded.mp3,plain.mp3,track.mp3" {
+		t.Errorf("期望不过滤时返回全部歌曲, 得到 %s", got)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/songs?has_cover=maybe", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("期望无效的 has_cover 返回 400, 得到 %d", w.Code)
+	}
+
+	// 同目录封面文件可以通过封面端点获取。
+	for _, song := range fetchSongsPage(t, router, "has_cover=true").Songs {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/api/cover/"+song.ID, nil))
+		if w.Code != http.StatusOK || !bytes.Equal(w.Body.Bytes(), testCoverData) {
+			t.Errorf("%s: 期望返回封面图片, 得到状态码 %d", song.FileName, w.Code)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"zero-music/models"
+)
+
+// displayFileName 返回用于下载的展示文件名。
+// 标签中有艺术家信息时使用 "艺术家 - 标题.ext"，否则使用磁盘上的文件名。
+// 结果中的路径分隔符和控制字符会被替换，防止头部注入。
+func displayFileName(song *models.Song) string {
+	name := song.FileName
+	if song.Artist != "" && song.Artist != "Unknown" && song.Title != "" {
+		name = song.Artist + " - " + song.Title + filepath.Ext(song.FileName)
+	}
+	return sanitizeFileName(name)
+}
+
+// sanitizeFileName 将文件名中的路径分隔符、引号和控制字符替换为下划线。
+func sanitizeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == '"' || unicode.IsControl(r) {
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+// asciiFileName 将文件名中的非 ASCII 字符替换为下划线，用作 filename 参数的回退值。
+func asciiFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r > unicode.MaxASCII {
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+// isRFC5987AttrChar 判断字节是否可以在 RFC 5987 扩展参数值中直接出现。
+func isRFC5987AttrChar(b byte) bool {
+	switch {
+	case 'a' <= b && b <= 'z', 'A' <= b && b <= 'Z', '0' <= b && b <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$&+-.^_`|~", b) >= 0
+}
+
+// encodeRFC5987 按 RFC 5987 对 UTF-8 字符串进行百分号编码。
+func encodeRFC5987(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if isRFC5987AttrChar(s[i]) {
+			b.WriteByte(s[i])
+		} else {
+			fmt.Fprintf(&b, "%%%02X", s[i])
+		}
+	}
+	return b.String()
+}
+
+// contentDisposition 生成音频流的 Content-Disposition 头。
+// 展示文件名为纯 ASCII 时只使用 filename 参数；否则额外提供 RFC 5987 编码的 filename*，
+// 并以 ASCII 化的原文件名作为不支持 filename* 的客户端的回退值。
+func contentDisposition(song *models.Song) string {
+	return dispositionHeader("inline", displayFileName(song), asciiFileName(sanitizeFileName(song.FileName)))
+}
+
+// attachmentDisposition 生成以附件形式下载 name 的 Content-Disposition 头，
+// 非 ASCII 的文件名以 ASCII 化的 name 作为回退值。
+func attachmentDisposition(name string) string {
+	name = sanitizeFileName(name)
+	return dispositionHeader("attachment", name, asciiFileName(name))
+}
+
+// dispositionHeader 生成类型为 kind 的 Content-Disposition 头，
+// name 不是纯 ASCII 时使用 fallback 作为 filename 参数并附带 filename*。
+func dispositionHeader(kind, name, fallback string) string {
+	if asciiFileName(name) == name {
+		return fmt.Sprintf("%s; filename=\"%s\"", kind, name)
+	}
+	return fmt.Sprintf("%s; filename=\"%s\"; filename*=UTF-8''%s", kind, fallback, encodeRFC5987(name))
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf16"
+	"zero-music/config"
+	"zero-music/models"
+	"zero-music/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestContentDisposition 测试基于标签的展示文件名、RFC 5987 编码与特殊字符过滤。
+func TestContentDisposition(t *testing.T) {
+	tests := []struct {
+		name string
+		song models.Song
+		want string
+	}{
+		{
+			"没有标签时使用原文件名",
+			models.Song{FileName: "track01.mp3", Title: "track01", Artist: "Unknown"},
+			`inline; filename="track01.mp3"`,
+		},
+		{
+			"ASCII 标签",
+			models.Song{FileName: "track01.mp3", Title: "Hello", Artist: "Adele"},
+			`inline; filename="Adele - Hello.mp3"`,
+		},
+		{
+			"中文标签",
+			models.Song{FileName: "track01.flac", Title: "晴天", Artist: "周杰伦"},
+			`inline; filename="track01.flac"; filename*=UTF-8''%E5%91%A8%E6%9D%B0%E4%BC%A6%20-%20%E6%99%B4%E5%A4%A9.flac`,
+		},
+		{
+			"中文文件名回退",
+			models.Song{FileName: "晴天.mp3", Title: "晴天", Artist: "Unknown"},
+			`inline; filename="__.mp3"; filename*=UTF-8''%E6%99%B4%E5%A4%A9.mp3`,
+		},
+		{
+			"特殊字符",
+			models.Song{FileName: "a.mp3", Title: "x\"\r\nSet-Cookie: y", Artist: "AC/DC"},
+			`inline; filename="AC_DC - x___Set-Cookie: y.mp3"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := contentDisposition(&tt.song)
+			if got != tt.want {
+				t.Errorf("期望 %s, 得到 %s", tt.want, got)
+			}
+			if strings.ContainsAny(got, "\r\n") {
+				t.Errorf("头部中不应包含换行符: %q", got)
+			}
+		})
+	}
+}
+
+// utf16Text 构造一个 UTF-16（带 BOM）编码的 ID3v2.3 文本帧内容。
+func utf16Text(s string) []byte {
+	body := []byte{0x01, 0xFF, 0xFE}
+	for _, u := range utf16.Encode([]rune(s)) {
+		body = append(body, byte(u), byte(u>>8))
+	}
+	return append(body, 0x00, 0x00)
+}
+
+// TestStreamAudio_ContentDisposition 测试带中文标签的歌曲在流式传输时使用展示文件名。
+func TestStreamAudio_ContentDisposition(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tmpDir := t.TempDir()
+	data := buildMP3WithFrames(
+		buildID3Frame("TIT2", utf16Text("晴天")),
+		buildID3Frame("TPE1", utf16Text("周杰伦")),
+	)
+	if err := os.WriteFile(filepath.Join(tmpDir, "track01.mp3"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{
+		Server: config.ServerConfig{MaxRangeSize: config.DefaultMaxRangeSize},
+		Music: config.MusicConfig{
+			Directory:        tmpDir,
+			SupportedFormats: []string{".mp3"},
+			CacheTTLMinutes:  5,
+		},
+	}
+	scanner := services.NewMusicScanner(cfg.Music.Directory, cfg.Music.SupportedFormats, cfg.Music.CacheTTLMinutes)
+	router := gin.New()
+	router.GET("/api/songs", NewPlaylistHandler(scanner, nil, nil, cfg).GetAllSongs)
+	router.GET("/api/stream/:id", NewStreamHandler(scanner, cfg).StreamAudio)
+
+	page := fetchSongsPage(t, router, "")
+	if len(page.Songs) != 1 {
+		t.Fatalf("期望 1 首歌曲, 得到 %d", len(page.Songs))
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/stream/"+page.Songs[0].ID, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("期望状态码 200, 得到 %d", w.Code)
+	}
+	want := `inline; filename="track01.mp3"; filename*=UTF-8''%E5%91%A8%E6%9D%B0%E4%BC%A6%20-%20%E6%99%B4%E5%A4%A9.mp3`
+	if got := w.Header().Get("Content-Disposition"); got != want {
+		t.Errorf("期望 Content-Disposition 为 %s, 得到 %s", want, got)
+	}
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"zero-music/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DuplicateGroup 是一组内容指纹相同的歌曲。
+type DuplicateGroup struct {
+	Fingerprint string     `json:"fingerprint"`
+	Songs       []songJSON `json:"songs"`
+}
+
+// GetDuplicates 返回内容指纹相同的歌曲分组。
+// 需要开启 ComputeFingerprint，未计算指纹的歌曲不参与分组。
+// @Summary 获取重复歌曲
+// @Description 按内容指纹分组返回内容相同的歌曲，需要开启 compute_fingerprint
+// @Tags playlist
+// @Produce json
+// @Param time_format query string false "时间字段格式：rfc3339 或 unix_ms，默认使用服务器配置" Enums(rfc3339, unix_ms)
+// @Success 200 {object} map[string]interface{} "成功返回重复歌曲分组"
+// @Failure 500 {object} APIError "服务器错误"
+// @Router /api/duplicates [get]
+func (h *PlaylistHandler) GetDuplicates(c *gin.Context) {
+	songs, ok := scanSongs(c, h.scanner)
+	if !ok {
+		return
+	}
+
+	byFingerprint := make(map[string][]*models.Song)
+	for _, song := range songs {
+		if song.Fingerprint != "" {
+			byFingerprint[song.Fingerprint] = append(byFingerprint[song.Fingerprint], song)
+		}
+	}
+
+	groups := make([]DuplicateGroup, 0)
+	for fingerprint, group := range byFingerprint {
+		if len(group) < 2 {
+			continue
+		}
+		sort.Slice(group, func(i, j int) bool {
+			return group[i].FilePath < group[j].FilePath
+		})
+		groups = append(groups, DuplicateGroup{Fingerprint: fingerprint, Songs: songsJSON(c, group)})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Songs[0].FilePath < groups[j].Songs[0].FilePath
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":  len(groups),
+		"groups": groups,
+	})
+}
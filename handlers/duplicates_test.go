@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"zero-music/config"
+	"zero-music/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestGetDuplicates 测试内容相同的歌曲被分为一组，内容不同的歌曲不出现在结果中。
+func TestGetDuplicates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"a.mp3":       "duplicate content",
+		"backup.mp3":  "duplicate content",
+		"unique1.mp3": "unique content 1",
+		"unique2.mp3": "unique content 2",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scanner := services.NewMusicScanner(tmpDir, []string{".mp3"}, 5, services.WithFingerprint(nil))
+	handler := NewPlaylistHandler(scanner, nil, nil, &config.Config{})
+	router := gin.New()
+	router.GET("/api/duplicates", handler.GetDuplicates)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/duplicates", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("期望状态码 200, 得到 %d", w.Code)
+	}
+
+	var response struct {
+		Total  int              `json:"total"`
+		Groups []DuplicateGroup `json:"groups"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if response.Total != 1 || len(response.Groups) != 1 {
+		t.Fatalf("期望 1 组重复歌曲, 得到 %d", response.Total)
+	}
+	group := response.Groups[0]
+	if len(group.Songs) != 2 || group.Songs[0].FileName != "a.mp3" || group.Songs[1].FileName != "backup.mp3" {
+		t.Errorf("期望分组包含 a.mp3 与 backup.mp3, 得到 %+v", group.Songs)
+	}
+}
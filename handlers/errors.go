@@ -3,6 +3,11 @@ package handlers
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"zero-music/middleware"
+
+	"github.com/gin-gonic/gin"
 )
 
 // APIError 定义了 API 返回的标准化错误结构。
@@ -10,6 +15,63 @@ type APIError struct {
 	Code    string `json:"code"`
 	Message string `json:"message"`
 	Details string `json:"details,omitempty"`
+	// RequestID 是产生该错误的请求 ID，便于将客户端报错与服务端日志关联。
+	RequestID string `json:"request_id,omitempty"`
+
+	// resource 是 NOT_FOUND 错误中未找到的资源名称，用于按语言重新组织消息。
+	resource string
+}
+
+const (
+	// langZh 是默认的错误消息语言。
+	langZh = "zh"
+	// langEn 是英文错误消息语言。
+	langEn = "en"
+)
+
+// errorMessages 维护错误消息的多语言文本，键为默认的中文消息。
+// 没有对应翻译的消息（如包含动态内容的消息）回退为中文。
+var errorMessages = map[string]map[string]string{
+	"内部服务器错误":                                         {langEn: "Internal server error"},
+	"无效的歌曲 ID 格式":                                     {langEn: "Invalid song ID format"},
+	"无效的请求体":                                          {langEn: "Invalid request body"},
+	"无效的设备标识":                                         {langEn: "Invalid device identifier"},
+	"无效的播放位置":                                         {langEn: "Invalid playback position"},
+	"无效的游标":                                           {langEn: "Invalid cursor"},
+	"seed 必须是整数":                                      {langEn: "seed must be an integer"},
+	"sort 仅支持 pinned, title, artist, album, added_at": {langEn: "sort only supports pinned, title, artist, album, added_at"},
+	"order 仅支持 asc 或 desc":                            {langEn: "order only supports asc or desc"},
+	"置顶权重不能为负数":                                       {langEn: "Pin weight must not be negative"},
+	"置顶功能不可用":                                         {langEn: "Pinning is unavailable"},
+	"has_cover 必须是 true 或 false":                      {langEn: "has_cover must be true or false"},
+	"标签功能不可用":                                         {langEn: "Tagging is unavailable"},
+	"标签不能为空":                                          {langEn: "Tag must not be empty"},
+	"标签不能包含 / 或控制字符":                                  {langEn: "Tag must not contain / or control characters"},
+	"仅允许本地访问":                                         {langEn: "Local access only"},
+	"拒绝访问":                                            {langEn: "Access denied"},
+	"没有客户端可接受的音频格式":                                   {langEn: "No audio format acceptable to the client"},
+	"音乐目录暂时不可用，请稍后重试":                                 {langEn: "Music directory is temporarily unavailable, please retry later"},
+	"资源已变更":                                           {langEn: "Resource has changed"},
+	"请求范围无法满足":                                        {langEn: "Requested range not satisfiable"},
+	"专辑过大，无法打包下载":                                     {langEn: "Album is too large to download as an archive"},
+	"无法流式传输目录":                                        {langEn: "Cannot stream a directory"},
+	"time_format 必须为 rfc3339 或 unix_ms":               {langEn: "time_format must be rfc3339 or unix_ms"},
+	"并发流数量已达上限，请稍后重试":                                 {langEn: "Too many concurrent streams, please retry later"},
+	"当前客户端的并发流数量已达上限，请稍后重试":                           {langEn: "Too many concurrent streams from this client, please retry later"},
+}
+
+// resourceNames 维护 NOT_FOUND 错误中资源名称的多语言文本。
+var resourceNames = map[string]map[string]string{
+	"歌曲":   {langEn: "Song"},
+	"歌曲文件": {langEn: "Song file"},
+	"音频文件": {langEn: "Audio file"},
+	"封面":   {langEn: "Cover"},
+	"流派":   {langEn: "Genre"},
+	"专辑":   {langEn: "Album"},
+	"艺术家":  {langEn: "Artist"},
+	"目录":   {langEn: "Directory"},
+	"接口":   {langEn: "Endpoint"},
+	"页面":   {langEn: "Page"},
 }
 
 // Error 实现了标准错误接口。
@@ -17,11 +79,86 @@ func (e *APIError) Error() string {
 	return fmt.Sprintf("[%s] %s", e.Code, e.Message)
 }
 
+// RespondError 返回错误响应，并将当前请求 ID 写入 APIError。
+// 错误消息按 Accept-Language 选择中文或英文，默认为中文。
+// 默认以 JSON 形式返回；当客户端明确只接受 text/plain 时，返回形如 "[CODE] message" 的纯文本。
+// 所有 handler 都应通过该函数返回错误。
+func RespondError(c *gin.Context, status int, apiErr *APIError) {
+	apiErr.RequestID = middleware.GetRequestID(c)
+	apiErr.localize(preferredLanguage(c.GetHeader("Accept-Language")))
+	if acceptsOnlyPlainText(c.GetHeader("Accept")) {
+		c.String(status, "%s\n", apiErr.Error())
+		return
+	}
+	c.JSON(status, apiErr)
+}
+
+// localize 将错误消息替换为指定语言的文本，没有对应翻译时保持不变。
+func (e *APIError) localize(lang string) {
+	if lang == langZh {
+		return
+	}
+	if e.resource != "" {
+		if name, ok := resourceNames[e.resource][lang]; ok {
+			e.Message = name + " not found"
+		}
+		return
+	}
+	if message, ok := errorMessages[e.Message][lang]; ok {
+		e.Message = message
+	}
+}
+
+// preferredLanguage 根据 Accept-Language 头选择错误消息语言。
+// 按权重从高到低选择第一个支持的语言（zh 或 en），都不支持时返回中文。
+func preferredLanguage(acceptLanguage string) string {
+	lang := langZh
+	bestQ := 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(part, ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		primary := strings.SplitN(tag, "-", 2)[0]
+		if primary != langZh && primary != langEn {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > bestQ {
+			lang, bestQ = primary, q
+		}
+	}
+	return lang
+}
+
+// acceptsOnlyPlainText 判断 Accept 头是否只接受 text/plain 而不接受 JSON。
+func acceptsOnlyPlainText(accept string) bool {
+	plainText := false
+	for _, part := range strings.Split(accept, ",") {
+		mediaType := strings.ToLower(strings.TrimSpace(strings.SplitN(part, ";", 2)[0]))
+		switch mediaType {
+		case "text/plain":
+			plainText = true
+		case "application/json", "application/*", "*/*":
+			return false
+		}
+	}
+	return plainText
+}
+
 // NewNotFoundError 创建一个表示资源未找到的 APIError。
 func NewNotFoundError(resource string) *APIError {
 	return &APIError{
-		Code:    "NOT_FOUND",
-		Message: fmt.Sprintf("%s未找到", resource),
+		Code:     "NOT_FOUND",
+		Message:  fmt.Sprintf("%s未找到", resource),
+		resource: resource,
 	}
 }
 
@@ -32,12 +169,12 @@ func NewInternalError(err error) *APIError {
 		Code:    "INTERNAL_ERROR",
 		Message: "内部服务器错误",
 	}
-	
+
 	// 仅在非生产环境中暴露错误详情
 	if os.Getenv("ZERO_MUSIC_ENV") != "production" {
 		apiErr.Details = err.Error()
 	}
-	
+
 	return apiErr
 }
 
@@ -56,3 +193,51 @@ func NewForbiddenError(message string) *APIError {
 		Message: message,
 	}
 }
+
+// NewTooManyRequestsError 创建一个表示请求过多的 APIError。
+func NewTooManyRequestsError(message string) *APIError {
+	return &APIError{
+		Code:    "TOO_MANY_REQUESTS",
+		Message: message,
+	}
+}
+
+// NewPayloadTooLargeError 创建一个表示请求的内容超过大小上限的 APIError。
+func NewPayloadTooLargeError(message string) *APIError {
+	return &APIError{
+		Code:    "PAYLOAD_TOO_LARGE",
+		Message: message,
+	}
+}
+
+// NewNotAcceptableError 创建一个表示没有可接受的响应格式的 APIError。
+func NewNotAcceptableError(message string) *APIError {
+	return &APIError{
+		Code:    "NOT_ACCEPTABLE",
+		Message: message,
+	}
+}
+
+// NewPreconditionFailedError 创建一个表示前置条件不满足的 APIError。
+func NewPreconditionFailedError(message string) *APIError {
+	return &APIError{
+		Code:    "PRECONDITION_FAILED",
+		Message: message,
+	}
+}
+
+// NewRangeNotSatisfiableError 创建一个表示请求范围无法满足的 APIError。
+func NewRangeNotSatisfiableError(message string) *APIError {
+	return &APIError{
+		Code:    "RANGE_NOT_SATISFIABLE",
+		Message: message,
+	}
+}
+
+// NewServiceUnavailableError 创建一个表示服务暂时不可用的 APIError。
+func NewServiceUnavailableError(message string) *APIError {
+	return &APIError{
+		Code:    "SERVICE_UNAVAILABLE",
+		Message: message,
+	}
+}
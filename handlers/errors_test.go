@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestRespondError_ContentNegotiation 测试错误响应是否根据 Accept 头返回 JSON 或纯文本。
+func TestRespondError_ContentNegotiation(t *testing.T) {
+	router, _ := setupTestEnv(t)
+
+	testCases := []struct {
+		name      string
+		accept    string
+		plainText bool
+	}{
+		{"未指定 Accept", "", false},
+		{"application/json", "application/json", false},
+		{"任意类型", "*/*", false},
+		{"同时接受 JSON 与纯文本", "text/plain, application/json;q=0.9", false},
+		{"只接受纯文本", "text/plain", true},
+		{"只接受纯文本（带参数）", "text/plain; charset=utf-8", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/song/abc123", nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("期望状态码 400, 得到 %d", w.Code)
+			}
+
+			contentType := w.Header().Get("Content-Type")
+			if tc.plainText {
+				if !strings.HasPrefix(contentType, "text/plain") {
+					t.Errorf("期望 Content-Type 为 text/plain, 得到 %s", contentType)
+				}
+				if body := strings.TrimSpace(w.Body.String()); body != "[BAD_REQUEST] 无效的歌曲 ID 格式" {
+					t.Errorf("期望纯文本错误为 '[BAD_REQUEST] 无效的歌曲 ID 格式', 得到 %q", body)
+				}
+				return
+			}
+
+			if !strings.HasPrefix(contentType, "application/json") {
+				t.Errorf("期望 Content-Type 为 application/json, 得到 %s", contentType)
+			}
+			var apiErr APIError
+			if err := json.Unmarshal(w.Body.Bytes(), &apiErr); err != nil {
+				t.Fatalf("解析响应失败: %v", err)
+			}
+			if apiErr.Code != "BAD_REQUEST" {
+				t.Errorf("期望错误码为 BAD_REQUEST, 得到 %s", apiErr.Code)
+			}
+		})
+	}
+}
+
+// TestRespondError_AcceptLanguage 测试错误消息是否根据 Accept-Language 返回中文或英文。
+func TestRespondError_AcceptLanguage(t *testing.T) {
+	router, _ := setupTestEnv(t)
+
+	testCases := []struct {
+		name           string
+		path           string
+		acceptLanguage string
+		want           string
+	}{
+		{"默认中文", "/api/song/abc123", "", "无效的歌曲 ID 格式"},
+		{"英文", "/api/song/abc123", "en", "Invalid song ID format"},
+		{"带地区的英文", "/api/song/abc123", "en-US,en;q=0.9", "Invalid song ID format"},
+		{"中文权重更高", "/api/song/abc123", "en;q=0.5, zh-CN;q=0.9", "无效的歌曲 ID 格式"},
+		{"不支持的语言回退中文", "/api/song/abc123", "fr-FR", "无效的歌曲 ID 格式"},
+		{"资源未找到", "/api/song/0123456789abcdef0123456789abcdef", "en", "Song not found"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tc.path, nil)
+			if tc.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tc.acceptLanguage)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			var apiErr APIError
+			if err := json.Unmarshal(w.Body.Bytes(), &apiErr); err != nil {
+				t.Fatalf("解析响应失败: %v", err)
+			}
+			if apiErr.Message != tc.want {
+				t.Errorf("期望错误消息为 %q, 得到 %q", tc.want, apiErr.Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+	"zero-music/models"
+)
+
+// addedAtRange 是按 AddedAt 过滤歌曲的闭区间，零值表示对应一侧不限制。
+type addedAtRange struct {
+	after  time.Time
+	before time.Time
+}
+
+// parseTimeParam 解析 RFC3339 格式或 Unix 时间戳（秒）的时间参数，空字符串返回零值。
+func parseTimeParam(name, raw string) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	if sec, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return time.Unix(sec, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("%s 必须是 RFC3339 格式或 Unix 时间戳", name)
+}
+
+// parseAddedAtRange 从 added_after 和 added_before 参数解析时间区间。
+func parseAddedAtRange(afterRaw, beforeRaw string) (addedAtRange, error) {
+	var r addedAtRange
+	var err error
+	if r.after, err = parseTimeParam("added_after", afterRaw); err != nil {
+		return r, err
+	}
+	if r.before, err = parseTimeParam("added_before", beforeRaw); err != nil {
+		return r, err
+	}
+	return r, nil
+}
+
+// isZero 判断区间是否两侧都不限制。
+func (r addedAtRange) isZero() bool {
+	return r.after.IsZero() && r.before.IsZero()
+}
+
+// filter 返回 AddedAt 落在区间内（含边界）的歌曲。
+func (r addedAtRange) filter(songs []*models.Song) []*models.Song {
+	if r.isZero() {
+		return songs
+	}
+	result := make([]*models.Song, 0, len(songs))
+	for _, song := range songs {
+		if !r.after.IsZero() && song.AddedAt.Before(r.after) {
+			continue
+		}
+		if !r.before.IsZero() && song.AddedAt.After(r.before) {
+			continue
+		}
+		result = append(result, song)
+	}
+	return result
+}
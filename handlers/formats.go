@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FormatInfo 描述一种支持的音频格式及其转码能力。
+type FormatInfo struct {
+	Extension string `json:"extension"`
+	MIMEType  string `json:"mime_type"`
+	// TranscodeSource 表示该格式的歌曲能否被转码为其他格式，ffmpeg 可用时总是为 true。
+	TranscodeSource bool `json:"transcode_source"`
+	// TranscodeTarget 表示其他格式的歌曲能否被转码为该格式输出。
+	TranscodeTarget bool `json:"transcode_target"`
+}
+
+// GetFormats 返回配置中支持的音频格式及各自的 MIME 类型与转码能力，
+// 客户端可据此决定请求音频流时的 Accept 头。
+// @Summary 获取支持的音频格式
+// @Description 返回配置的 supported_formats 及其 MIME 类型；transcoding 表示服务端是否可以转码（ffmpeg 可用），
+// @Description transcode_targets 为可以转码输出的 MIME 类型
+// @Tags stream
+// @Produce json
+// @Success 200 {object} map[string]interface{} "成功返回格式列表"
+// @Router /api/formats [get]
+func (h *StreamHandler) GetFormats(c *gin.Context) {
+	canTranscode := h.ffmpegPath != ""
+
+	formats := make([]FormatInfo, 0, len(h.supportedFormats))
+	for _, ext := range h.supportedFormats {
+		ext = strings.ToLower(ext)
+		mimeType := getMimeType(ext)
+		_, target := transcodeFormats[mimeType]
+		formats = append(formats, FormatInfo{
+			Extension:       ext,
+			MIMEType:        mimeType,
+			TranscodeSource: canTranscode,
+			TranscodeTarget: canTranscode && target,
+		})
+	}
+
+	targets := make([]string, 0, len(transcodeFormats))
+	if canTranscode {
+		for mimeType := range transcodeFormats {
+			targets = append(targets, mimeType)
+		}
+		sort.Strings(targets)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"formats":           formats,
+		"transcoding":       canTranscode,
+		"transcode_targets": targets,
+	})
+}
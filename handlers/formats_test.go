@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"zero-music/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestGetFormats 测试返回的格式列表与配置一致，转码能力取决于 ffmpeg 是否可用。
+func TestGetFormats(t *testing.T) {
+	cfg, scanner := newTestLibrary(t, nil, func(cfg *config.Config) {
+		cfg.Music.SupportedFormats = []string{".mp3", ".FLAC", ".opus"}
+	})
+	handler := NewStreamHandler(scanner, cfg)
+	router := gin.New()
+	router.GET("/api/formats", handler.GetFormats)
+
+	testCases := []struct {
+		name        string
+		ffmpegPath  string
+		wantFormats []FormatInfo
+		wantTargets []string
+	}{
+		{
+			name:       "无法转码",
+			ffmpegPath: "",
+			wantFormats: []FormatInfo{
+				{Extension: ".mp3", MIMEType: "audio/mpeg"},
+				{Extension: ".flac", MIMEType: "audio/flac"},
+				{Extension: ".opus", MIMEType: "audio/opus"},
+			},
+			wantTargets: []string{},
+		},
+		{
+			name:       "可以转码",
+			ffmpegPath: "/usr/bin/ffmpeg",
+			wantFormats: []FormatInfo{
+				{Extension: ".mp3", MIMEType: "audio/mpeg", TranscodeSource: true, TranscodeTarget: true},
+				{Extension: ".flac", MIMEType: "audio/flac", TranscodeSource: true, TranscodeTarget: true},
+				{Extension: ".opus", MIMEType: "audio/opus", TranscodeSource: true},
+			},
+			wantTargets: []string{"audio/flac", "audio/mpeg", "audio/ogg", "audio/wav"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler.ffmpegPath = tc.ffmpegPath
+			req, _ := http.NewRequest("GET", "/api/formats", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("期望状态码 200, 得到 %d", w.Code)
+			}
+
+			var response struct {
+				Formats          []FormatInfo `json:"formats"`
+				Transcoding      bool         `json:"transcoding"`
+				TranscodeTargets []string     `json:"transcode_targets"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("解析响应失败: %v", err)
+			}
+			if !reflect.DeepEqual(response.Formats, tc.wantFormats) {
+				t.Errorf("期望格式列表 %+v, 得到 %+v", tc.wantFormats, response.Formats)
+			}
+			if response.Transcoding != (tc.ffmpegPath != "") {
+				t.Errorf("期望 transcoding 为 %v, 得到 %v", tc.ffmpegPath != "", response.Transcoding)
+			}
+			if !reflect.DeepEqual(response.TranscodeTargets, tc.wantTargets) {
+				t.Errorf("期望可转码目标 %v, 得到 %v", tc.wantTargets, response.TranscodeTargets)
+			}
+		})
+	}
+}
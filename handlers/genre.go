@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"zero-music/config"
+	"zero-music/logger"
+	"zero-music/models"
+	"zero-music/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unknownGenre 是没有流派标签的歌曲所归入的流派名称。
+const unknownGenre = "Unknown"
+
+// GenreSummary 是一个流派及其歌曲数量。
+type GenreSummary struct {
+	Name      string `json:"name"`
+	SongCount int    `json:"song_count"`
+}
+
+// GenreHandler 负责处理按流派浏览歌曲的 API 请求。
+type GenreHandler struct {
+	scanner services.Scanner
+	// order 是流派下歌曲列表的排序方式，由配置的默认排序决定。
+	order songOrder
+}
+
+// NewGenreHandler 创建一个新的 GenreHandler 实例。
+// 流派下的歌曲按配置的默认排序返回；流派处理器不读取置顶信息，按 pinned 排序时等价于按添加时间排序。
+func NewGenreHandler(scanner services.Scanner, cfg *config.Config) *GenreHandler {
+	order, err := parseSongOrder(cfg.Music.DefaultSort, cfg.Music.DefaultOrder, func() map[string]int { return nil })
+	if err != nil {
+		logger.Warnf("无效的默认排序配置，保持扫描顺序: %v", err)
+	}
+	return &GenreHandler{
+		scanner: scanner,
+		order:   order,
+	}
+}
+
+// songGenre 返回歌曲所属的流派名称，流派为空时归入 Unknown。
+func songGenre(song *models.Song) string {
+	if genre := strings.TrimSpace(song.Genre); genre != "" {
+		return genre
+	}
+	return unknownGenre
+}
+
+// GetGenres 返回所有流派及各自的歌曲数量，按名称排序。
+// @Summary 获取所有流派
+// @Description 基于扫描缓存聚合所有流派及各自的歌曲数量，没有流派标签的歌曲归入 Unknown，
+// @Description 仅有空白、全角或大小写差异的流派会被合并
+// @Tags genre
+// @Produce json
+// @Success 200 {object} map[string]interface{} "成功返回流派列表"
+// @Failure 500 {object} APIError "服务器错误"
+// @Router /api/genres [get]
+func (h *GenreHandler) GetGenres(c *gin.Context) {
+	songs, ok := scanSongs(c, h.scanner)
+	if !ok {
+		return
+	}
+
+	// 按规范化键合并仅有空白、全角或大小写差异的流派，展示首次出现的名称。
+	index := make(map[string]int)
+	genres := make([]GenreSummary, 0)
+	for _, song := range songs {
+		name := songGenre(song)
+		key := models.GroupKey(name)
+		if i, ok := index[key]; ok {
+			genres[i].SongCount++
+			continue
+		}
+		index[key] = len(genres)
+		genres = append(genres, GenreSummary{Name: name, SongCount: 1})
+	}
+	sort.Slice(genres, func(i, j int) bool {
+		return genres[i].Name < genres[j].Name
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":  len(genres),
+		"genres": genres,
+	})
+}
+
+// GetSongsByGenre 返回指定流派下的所有歌曲。
+// 流派名称需要进行 URL 编码，路由使用通配参数以支持包含 "/" 的名称（如 "Rock/Pop"）。
+// 名称按规范化键匹配，因此 "rock" 与 "Ｒｏｃｋ" 都能匹配 "Rock"。
+// @Summary 获取流派下的歌曲
+// @Description 返回指定流派下的所有歌曲，名称为 Unknown 时返回没有流派标签的歌曲；歌曲按配置的默认排序返回
+// @Tags genre
+// @Produce json
+// @Param name path string true "流派名称（URL 编码）"
+// @Param time_format query string false "时间字段格式：rfc3339 或 unix_ms，默认使用服务器配置" Enums(rfc3339, unix_ms)
+// @Success 200 {object} map[string]interface{} "成功返回歌曲列表"
+// @Failure 404 {object} APIError "流派不存在"
+// @Failure 500 {object} APIError "服务器错误"
+// @Router /api/genre/{name} [get]
+func (h *GenreHandler) GetSongsByGenre(c *gin.Context) {
+	name := strings.TrimPrefix(c.Param("name"), "/")
+
+	songs, ok := scanSongs(c, h.scanner)
+	if !ok {
+		return
+	}
+
+	key := models.GroupKey(name)
+	matched := make([]*models.Song, 0)
+	for _, song := range songs {
+		if models.GroupKey(songGenre(song)) == key {
+			matched = append(matched, song)
+		}
+	}
+	if len(matched) == 0 {
+		RespondError(c, http.StatusNotFound, NewNotFoundError("流派"))
+		return
+	}
+	if h.order.sorted() {
+		matched = sortSongs(matched, h.order)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"genre": name,
+		"total": len(matched),
+		"songs": songsJSON(c, matched),
+	})
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"zero-music/config"
+	"zero-music/models"
+	"zero-music/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// buildMP3WithGenre 构造一个带有 ID3v2.3 流派（TCON）标签的 MP3 文件内容。
+func buildMP3WithGenre(genre string) []byte {
+	return buildMP3WithFrames(buildID3Frame("TCON", append([]byte{0x00}, genre...)))
+}
+
+// setupGenreTestEnv 创建包含多个流派歌曲的测试环境。
+func setupGenreTestEnv(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	tmpDir := t.TempDir()
+
+	files := map[string][]byte{
+		"rock1.mp3":  buildMP3WithGenre("Rock"),
+		"rock2.mp3":  buildMP3WithGenre("Rock"),
+		"jazz.mp3":   buildMP3WithGenre("Jazz"),
+		"hiphop.mp3": buildMP3WithGenre("Hip Hop/Rap"),
+		"plain.mp3":  []byte("fake mp3 data"),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scanner := services.NewMusicScanner(tmpDir, []string{".mp3"}, 5)
+	handler := NewGenreHandler(scanner, &config.Config{})
+	router := gin.New()
+	router.GET("/api/genres", handler.GetGenres)
+	router.GET("/api/genre/*name", handler.GetSongsByGenre)
+	return router
+}
+
+// TestGetGenres 测试多个流派的聚合结果，没有流派标签的歌曲归入 Unknown。
+func TestGetGenres(t *testing.T) {
+	router := setupGenreTestEnv(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/genres", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("期望状态码 200, 得到 %d", w.Code)
+	}
+
+	var response struct {
+		Total  int            `json:"total"`
+		Genres []GenreSummary `json:"genres"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+
+	expected := []GenreSummary{
+		{Name: "Hip Hop/Rap", SongCount: 1},
+		{Name: "Jazz", SongCount: 1},
+		{Name: "Rock", SongCount: 2},
+		{Name: "Unknown", SongCount: 1},
+	}
+	if response.Total != len(expected) || len(response.Genres) != len(expected) {
+		t.Fatalf("期望 %d 个流派, 得到 %+v", len(expected), response.Genres)
+	}
+	for i, genre := range expected {
+		if response.Genres[i] != genre {
+			t.Errorf("期望第 %d 个流派为 %+v, 得到 %+v", i, genre, response.Genres[i])
+		}
+	}
+}
+
+// TestGetSongsByGenre 测试按流派获取歌曲，包括 URL 编码的名称和不存在的流派。
+func TestGetSongsByGenre(t *testing.T) {
+	router := setupGenreTestEnv(t)
+
+	tests := []struct {
+		name      string
+		wantCode  int
+		wantTotal int
+	}{
+		{"Rock", http.StatusOK, 2},
+		{"Hip Hop/Rap", http.StatusOK, 1},
+		{"Unknown", http.StatusOK, 1},
+		{"Metal", http.StatusNotFound, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/api/genre/"+url.PathEscape(tt.name), nil))
+			if w.Code != tt.wantCode {
+				t.Fatalf("期望状态码 %d, 得到 %d", tt.wantCode, w.Code)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Genre string `json:"genre"`
+				Total int    `json:"total"`
+				Songs []struct {
+					Genre string `json:"genre"`
+				} `json:"songs"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("解析响应失败: %v", err)
+			}
+			if response.Genre != tt.name || response.Total != tt.wantTotal || len(response.Songs) != tt.wantTotal {
+				t.Errorf("期望流派 %s 下有 %d 首歌曲, 得到 %+v", tt.name, tt.wantTotal, response)
+			}
+		})
+	}
+}
+
+// TestGetGenres_Normalized 测试仅有空白、全角或大小写差异的流派被合并。
+func TestGetGenres_Normalized(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tmpDir := t.TempDir()
+	files := map[string][]byte{
+		"a.mp3": buildMP3WithGenre("Rock"),
+		"b.mp3": buildMP3WithGenre("  Rock "),
+		"c.mp3": buildMP3WithFrames(buildID3Frame("TCON", utf16Text("Ｒｏｃｋ"))),
+		"d.mp3": buildMP3WithGenre("rock"),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	handler := NewGenreHandler(services.NewMusicScanner(tmpDir, []string{".mp3"}, 5), &config.Config{})
+	router := gin.New()
+	router.GET("/api/genres", handler.GetGenres)
+	router.GET("/api/genre/*name", handler.GetSongsByGenre)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/genres", nil))
+	var response struct {
+		Genres []GenreSummary `json:"genres"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if len(response.Genres) != 1 || response.Genres[0].SongCount != 4 {
+		t.Fatalf("期望合并为 1 个包含 4 首歌曲的流派, 得到 %+v", response.Genres)
+	}
+	if response.Genres[0].Name != "Rock" {
+		t.Errorf("期望展示名称为 Rock, 得到 %q", response.Genres[0].Name)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/genre/"+url.PathEscape("ＲＯＣＫ"), nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("期望全角名称也能匹配, 得到状态码 %d", w.Code)
+	}
+}
+
+// TestSongArtistNormalized 测试艺术家标签的首尾空白被去除，且带全角差异的同一艺术家得到相同的分组键。
+func TestSongArtistNormalized(t *testing.T) {
+	tmpDir := t.TempDir()
+	artists := map[string]string{
+		"a.mp3": "Artist Name",
+		"b.mp3": "  Artist Name ",
+		"c.mp3": "Ａｒｔｉｓｔ　Ｎａｍｅ",
+	}
+	for name, artist := range artists {
+		data := buildMP3WithFrames(buildID3Frame("TPE1", utf16Text(artist)))
+		if err := os.WriteFile(filepath.Join(tmpDir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	songs, err := services.NewMusicScanner(tmpDir, []string{".mp3"}, 5).Scan(context.Background())
+	if err != nil {
+		t.Fatalf("扫描失败: %v", err)
+	}
+	keys := make(map[string]bool)
+	for _, song := range songs {
+		if song.Artist != strings.TrimSpace(song.Artist) {
+			t.Errorf("期望艺术家首尾空白被去除, 得到 %q", song.Artist)
+		}
+		keys[models.GroupKey(song.Artist)] = true
+	}
+	if len(keys) != 1 {
+		t.Errorf("期望同一艺术家合并为 1 个分组键, 得到 %v", keys)
+	}
+}
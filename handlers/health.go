@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+	"sort"
+	"zero-music/logger"
+	"zero-music/middleware"
+	"zero-music/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// activeStreamCounter 提供当前正在处理的流请求数量，由 StreamHandler 实现。
+type activeStreamCounter interface {
+	ActiveStreams() int64
+}
+
+const (
+	// HealthCheckMusicDir 检查音乐目录是否可以访问。
+	HealthCheckMusicDir = "music_dir"
+	// HealthCheckSongs 检查缓存的歌曲数是否不为 0。
+	HealthCheckSongs = "songs"
+	// HealthCheckLastScan 检查最近一次扫描是否成功，从未扫描时视为通过。
+	HealthCheckLastScan = "last_scan"
+
+	// HealthDegraded 表示服务仍在运行但功能受限。
+	HealthDegraded = "degraded"
+	// HealthUnhealthy 表示服务无法正常工作。
+	HealthUnhealthy = "unhealthy"
+)
+
+// DefaultHealthChecks 是未配置时启用的检查项：只检查音乐目录，失败时为 degraded。
+var DefaultHealthChecks = map[string]string{HealthCheckMusicDir: HealthDegraded}
+
+// HealthHandler 负责处理健康检查请求。
+type HealthHandler struct {
+	scanner  services.Scanner
+	streams  activeStreamCounter
+	musicDir string
+	// checks 是启用的检查项及其失败时的状态。
+	checks map[string]string
+	// diskSpace 查询磁盘空间，默认为 services.GetDiskSpace，测试时可以替换。
+	diskSpace func(path string) (services.DiskSpace, error)
+}
+
+// NewHealthHandler 创建一个新的 HealthHandler 实例。checks 为 nil 时使用 DefaultHealthChecks。
+func NewHealthHandler(scanner services.Scanner, streams activeStreamCounter, musicDir string, checks map[string]string) *HealthHandler {
+	if checks == nil {
+		checks = DefaultHealthChecks
+	}
+	return &HealthHandler{
+		scanner:   scanner,
+		streams:   streams,
+		musicDir:  musicDir,
+		checks:    checks,
+		diskSpace: services.GetDiskSpace,
+	}
+}
+
+// healthResult 是一次健康判定的结果。
+type healthResult struct {
+	status             string
+	httpStatus         int
+	failedChecks       []string
+	musicDirAccessible bool
+	stats              services.CacheStats
+}
+
+// evaluate 执行所有启用的检查项。全部通过时状态为 ok；否则状态为失败项中最严重的一级，并返回 503。
+// 各项数据均从并发安全的来源读取，不会触发扫描。
+func (h *HealthHandler) evaluate() healthResult {
+	result := healthResult{
+		status:       "ok",
+		httpStatus:   http.StatusOK,
+		failedChecks: make([]string, 0),
+		stats:        h.scanner.Stats(),
+	}
+	_, err := os.Stat(h.musicDir)
+	result.musicDirAccessible = err == nil
+
+	passed := map[string]bool{
+		HealthCheckMusicDir: result.musicDirAccessible,
+		HealthCheckSongs:    result.stats.SongCount > 0,
+		HealthCheckLastScan: result.stats.LastScanError == "",
+	}
+	for name, severity := range h.checks {
+		if passed[name] {
+			continue
+		}
+		result.failedChecks = append(result.failedChecks, name)
+		result.httpStatus = http.StatusServiceUnavailable
+		if result.status != HealthUnhealthy {
+			result.status = severity
+		}
+	}
+	sort.Strings(result.failedChecks)
+	return result
+}
+
+// Health 返回服务的健康状态，供负载均衡与容器编排探测使用。
+// 启用的检查项任一失败时返回 503，并在 failed_checks 中列出失败的检查项。
+// @Summary 健康检查
+// @Description 按配置的检查项（音乐目录可访问、缓存非空、最近一次扫描成功）判定服务状态
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]interface{} "服务正常"
+// @Failure 503 {object} map[string]interface{} "检查项失败，状态为 degraded 或 unhealthy"
+// @Router /health [get]
+func (h *HealthHandler) Health(c *gin.Context) {
+	result := h.evaluate()
+	c.JSON(result.httpStatus, gin.H{
+		"status":               result.status,
+		"message":              "zero music服务器正在运行",
+		"failed_checks":        result.failedChecks,
+		"music_dir_accessible": result.musicDirAccessible,
+		"music_directory":      h.musicDir,
+	})
+}
+
+// Detail 返回详细的系统状态：音乐目录所在分区的空间、当前流连接数、缓存歌曲数以及最近一次扫描的时间与耗时。
+// 磁盘空间无法查询时 disk 为 null，不影响整体状态；整体状态与 /health 的判定规则一致。
+// @Summary 详细健康检查
+// @Description 返回磁盘空间、当前流连接数、缓存歌曲数、最近一次扫描时间与耗时
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]interface{} "服务正常"
+// @Failure 503 {object} map[string]interface{} "检查项失败"
+// @Router /health/detail [get]
+func (h *HealthHandler) Detail(c *gin.Context) {
+	result := h.evaluate()
+
+	var disk interface{}
+	if space, err := h.diskSpace(h.musicDir); err != nil {
+		logger.WithRequestID(middleware.GetRequestID(c)).Debugf("查询磁盘空间失败: %v", err)
+	} else {
+		disk = space
+	}
+
+	stats := result.stats
+	var lastScan interface{}
+	if !stats.LastScan.IsZero() {
+		lastScan = stats.LastScan
+	}
+
+	c.JSON(result.httpStatus, gin.H{
+		"status":                result.status,
+		"failed_checks":         result.failedChecks,
+		"music_dir_accessible":  result.musicDirAccessible,
+		"music_directory":       h.musicDir,
+		"disk":                  disk,
+		"active_streams":        h.streams.ActiveStreams(),
+		"song_count":            stats.SongCount,
+		"last_scan":             lastScan,
+		"last_scan_duration_ms": stats.LastScanDuration.Milliseconds(),
+		"last_scan_error":       stats.LastScanError,
+	})
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+	"zero-music/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeStreamCounter 是返回固定流数量的 activeStreamCounter。
+type fakeStreamCounter int64
+
+func (f fakeStreamCounter) ActiveStreams() int64 { return int64(f) }
+
+// TestHealthDetail 测试详细健康检查返回磁盘空间、流连接数、缓存歌曲数与最近一次扫描信息。
+func TestHealthDetail(t *testing.T) {
+	cfg, scanner := newTestLibrary(t, map[string]string{
+		"a.mp3": "fake mp3 data a",
+		"b.mp3": "fake mp3 data b",
+	}, nil)
+	handler := NewHealthHandler(scanner, fakeStreamCounter(3), cfg.Music.Directory, nil)
+	handler.diskSpace = func(path string) (services.DiskSpace, error) {
+		if path != cfg.Music.Directory {
+			t.Errorf("期望查询音乐目录的磁盘空间, 得到 %s", path)
+		}
+		return services.DiskSpace{Total: 1000, Free: 400, Available: 300}, nil
+	}
+	router := gin.New()
+	router.GET("/health/detail", handler.Detail)
+
+	get := func() map[string]interface{} {
+		req, _ := http.NewRequest("GET", "/health/detail", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("期望状态码 200, 得到 %d: %s", w.Code, w.Body.String())
+		}
+		var body map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("解析响应失败: %v", err)
+		}
+		return body
+	}
+
+	// 尚未扫描时没有扫描时间。
+	body := get()
+	if body["last_scan"] != nil || body["song_count"] != float64(0) {
+		t.Errorf("期望扫描前 last_scan 为 null 且 song_count 为 0, 得到 %v, %v", body["last_scan"], body["song_count"])
+	}
+
+	if _, err := scanner.Scan(context.Background()); err != nil {
+		t.Fatalf("扫描失败: %v", err)
+	}
+	body = get()
+	if body["status"] != "ok" || body["music_dir_accessible"] != true {
+		t.Errorf("期望状态为 ok, 得到 %v", body["status"])
+	}
+	if body["active_streams"] != float64(3) {
+		t.Errorf("期望 active_streams 为 3, 得到 %v", body["active_streams"])
+	}
+	if body["song_count"] != float64(2) {
+		t.Errorf("期望 song_count 为 2, 得到 %v", body["song_count"])
+	}
+	if body["last_scan"] == nil {
+		t.Error("期望扫描后返回 last_scan")
+	}
+	if _, ok := body["last_scan_duration_ms"].(float64); !ok {
+		t.Errorf("期望返回数值类型的 last_scan_duration_ms, 得到 %v", body["last_scan_duration_ms"])
+	}
+	disk, ok := body["disk"].(map[string]interface{})
+	if !ok || disk["total_bytes"] != float64(1000) || disk["free_bytes"] != float64(400) || disk["available_bytes"] != float64(300) {
+		t.Errorf("期望返回磁盘空间信息, 得到 %v", body["disk"])
+	}
+
+	// 磁盘空间查询失败时 disk 为 null，不影响整体状态。
+	handler.diskSpace = func(string) (services.DiskSpace, error) {
+		return services.DiskSpace{}, services.ErrDiskSpaceUnsupported
+	}
+	body = get()
+	if body["disk"] != nil || body["status"] != "ok" {
+		t.Errorf("期望 disk 为 null 且状态为 ok, 得到 %v, %v", body["disk"], body["status"])
+	}
+}
+
+// TestHealthDetail_Concurrent 测试详细健康检查可以与流请求、扫描并发执行。
+func TestHealthDetail_Concurrent(t *testing.T) {
+	router, handler, _, _ := setupStreamTestEnvWithConfig(t, nil)
+	health := NewHealthHandler(handler.scanner, handler, handler.musicDir, nil)
+	router.GET("/health/detail", health.Detail)
+	songID := getSongID(t, router)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest("GET", "/api/stream/"+songID, nil)
+			router.ServeHTTP(httptest.NewRecorder(), req)
+		}()
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest("GET", "/health/detail", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Errorf("期望状态码 200, 得到 %d", w.Code)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := handler.ActiveStreams(); n != 0 {
+		t.Errorf("期望所有流结束后 active_streams 为 0, 得到 %d", n)
+	}
+}
+
+// TestHealth_Checks 测试健康检查按配置的检查项判定状态，并列出失败的检查项。
+func TestHealth_Checks(t *testing.T) {
+	testCases := []struct {
+		name           string
+		files          map[string]string
+		checks         map[string]string
+		removeDir      bool
+		expectedCode   int
+		expectedStatus string
+		expectedFailed []string
+	}{
+		{"默认检查项在歌曲数为 0 时正常", nil, nil, false, http.StatusOK, "ok", []string{}},
+		{"歌曲数为 0 时 degraded", nil, map[string]string{HealthCheckMusicDir: HealthDegraded, HealthCheckSongs: HealthDegraded},
+			false, http.StatusServiceUnavailable, "degraded", []string{"songs"}},
+		{"有歌曲时正常", map[string]string{"a.mp3": "fake mp3 data a"}, map[string]string{HealthCheckSongs: HealthDegraded, HealthCheckLastScan: HealthDegraded},
+			false, http.StatusOK, "ok", []string{}},
+		{"扫描失败时取最严重的状态", nil, map[string]string{HealthCheckMusicDir: HealthUnhealthy, HealthCheckLastScan: HealthDegraded},
+			true, http.StatusServiceUnavailable, "unhealthy", []string{"last_scan", "music_dir"}},
+		{"不做任何检查", nil, map[string]string{}, true, http.StatusOK, "ok", []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, scanner := newTestLibrary(t, tc.files, nil)
+			if tc.removeDir {
+				if err := os.RemoveAll(cfg.Music.Directory); err != nil {
+					t.Fatal(err)
+				}
+			}
+			scanner.Scan(context.Background())
+
+			handler := NewHealthHandler(scanner, fakeStreamCounter(0), cfg.Music.Directory, tc.checks)
+			router := gin.New()
+			router.GET("/health", handler.Health)
+			router.GET("/health/detail", handler.Detail)
+
+			for _, path := range []string{"/health", "/health/detail"} {
+				req, _ := http.NewRequest("GET", path, nil)
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				if w.Code != tc.expectedCode {
+					t.Errorf("%s: 期望状态码 %d, 得到 %d", path, tc.expectedCode, w.Code)
+				}
+
+				var body struct {
+					Status       string   `json:"status"`
+					FailedChecks []string `json:"failed_checks"`
+				}
+				if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+					t.Fatalf("%s: 解析响应失败: %v", path, err)
+				}
+				if body.Status != tc.expectedStatus {
+					t.Errorf("%s: 期望状态为 %s, 得到 %s", path, tc.expectedStatus, body.Status)
+				}
+				if !reflect.DeepEqual(body.FailedChecks, tc.expectedFailed) {
+					t.Errorf("%s: 期望失败的检查项为 %v, 得到 %v", path, tc.expectedFailed, body.FailedChecks)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"zero-music/logger"
+	"zero-music/middleware"
+	"zero-music/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// writeSongsJSON 以流式方式返回包含 songs 数组的 JSON 对象。
+// fields 中的其他字段先写出，随后边遍历边逐首编码歌曲，
+// 避免为超大音乐库在内存中一次性构建整个响应体。encode 将单首歌曲转换为要输出的值。
+// 请求美化输出时绕过 PrettyJSON 中间件的整体缓冲，在流式编码时自行缩进。
+func writeSongsJSON(c *gin.Context, fields gin.H, songs []*models.Song, encode func(*models.Song) interface{}) {
+	indent := middleware.BypassPrettyJSON(c)
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	// 响应头已经发出，写出失败（通常是客户端断开）时只能记录日志。
+	if err := encodeSongsJSON(c.Writer, fields, songs, encode, indent); err != nil {
+		logger.WithRequestID(middleware.GetRequestID(c)).Warnf("写出歌曲列表失败: %v", err)
+	}
+}
+
+// encodeSongsJSON 将 fields 与 songs 数组编码为一个 JSON 对象写入 w，字段按名称排序。
+// 写入经过固定大小的缓冲区，内存占用与歌曲数量无关。indent 为 true 时输出与 PrettyJSON 中间件相同格式的缩进 JSON。
+func encodeSongsJSON(w io.Writer, fields map[string]interface{}, songs []*models.Song, encode func(*models.Song) interface{}, indent bool) error {
+	buf := bufio.NewWriter(w)
+	if indent {
+		if err := encodeSongsJSONIndent(buf, fields, songs, encode); err != nil {
+			return err
+		}
+		return buf.Flush()
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	buf.WriteByte('{')
+	for _, key := range keys {
+		name, _ := json.Marshal(key)
+		value, err := json.Marshal(fields[key])
+		if err != nil {
+			return err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+		buf.WriteByte(',')
+	}
+
+	buf.WriteString(`"songs":[`)
+	encoder := json.NewEncoder(buf)
+	for i, song := range songs {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := encoder.Encode(encode(song)); err != nil {
+			return err
+		}
+	}
+	buf.WriteString("]}")
+
+	return buf.Flush()
+}
+
+// encodeSongsJSONIndent 以 middleware.PrettyJSONIndent 缩进编码 fields 与 songs 数组，
+// 输出与对紧凑输出调用 json.Indent 后追加换行的结果相同。
+func encodeSongsJSONIndent(buf *bufio.Writer, fields map[string]interface{}, songs []*models.Song, encode func(*models.Song) interface{}) error {
+	const indent = middleware.PrettyJSONIndent
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	buf.WriteString("{\n")
+	for _, key := range keys {
+		name, _ := json.Marshal(key)
+		value, err := json.MarshalIndent(fields[key], indent, indent)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(indent)
+		buf.Write(name)
+		buf.WriteString(": ")
+		buf.Write(value)
+		buf.WriteString(",\n")
+	}
+
+	buf.WriteString(indent + `"songs": [`)
+	for i, song := range songs {
+		value, err := json.MarshalIndent(encode(song), indent+indent, indent)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString("\n" + indent + indent)
+		buf.Write(value)
+	}
+	if len(songs) > 0 {
+		buf.WriteString("\n" + indent)
+	}
+	buf.WriteString("]\n}\n")
+	return nil
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"zero-music/middleware"
+	"zero-music/models"
+)
+
+// recordingWriter 记录写入的全部数据与单次写入的最大字节数。
+type recordingWriter struct {
+	data     []byte
+	maxWrite int
+}
+
+// Write 实现 io.Writer。
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	w.maxWrite = max(w.maxWrite, len(p))
+	w.data = append(w.data, p...)
+	return len(p), nil
+}
+
+// TestEncodeSongsJSON 测试流式编码输出合法的 JSON，且对大列表也只按固定大小的块写出。
+func TestEncodeSongsJSON(t *testing.T) {
+	identity := func(song *models.Song) interface{} { return song }
+
+	tests := []struct {
+		name  string
+		count int
+	}{
+		{"空列表", 0},
+		{"单首歌曲", 1},
+		{"大列表", 20000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			songs := make([]*models.Song, tt.count)
+			for i := range songs {
+				songs[i] = &models.Song{ID: fmt.Sprintf("%032x", i), Title: fmt.Sprintf("<歌曲 %d>", i)}
+			}
+
+			w := &recordingWriter{}
+			err := encodeSongsJSON(w, map[string]interface{}{"total": tt.count, "next_cursor": ""}, songs, identity, false)
+			if err != nil {
+				t.Fatalf("编码失败: %v", err)
+			}
+
+			var response struct {
+				Total int           `json:"total"`
+				Songs []models.Song `json:"songs"`
+			}
+			if err := json.Unmarshal(w.data, &response); err != nil {
+				t.Fatalf("输出不是合法的 JSON: %v", err)
+			}
+			if response.Total != tt.count || len(response.Songs) != tt.count {
+				t.Errorf("期望 %d 首歌曲, 得到 total=%d, songs=%d", tt.count, response.Total, len(response.Songs))
+			}
+			if tt.count > 0 && response.Songs[tt.count-1].Title != songs[tt.count-1].Title {
+				t.Errorf("期望最后一首歌曲标题为 %q, 得到 %q", songs[tt.count-1].Title, response.Songs[tt.count-1].Title)
+			}
+			// 单次写入不超过缓冲区大小，说明响应没有在内存中整体构建。
+			if w.maxWrite > 4096 {
+				t.Errorf("期望单次写入不超过 4096 字节, 得到 %d", w.maxWrite)
+			}
+		})
+	}
+}
+
+// TestEncodeSongsJSON_Indent 测试缩进输出与对紧凑输出调用 json.Indent 的结果一致，且仍按固定大小的块写出。
+func TestEncodeSongsJSON_Indent(t *testing.T) {
+	identity := func(song *models.Song) interface{} { return song }
+	fields := map[string]interface{}{
+		"total":   0,
+		"filters": map[string]interface{}{"artist": "a", "tags": []string{"rock"}},
+		"empty":   []string{},
+	}
+
+	for _, count := range []int{0, 1, 3, 20000} {
+		t.Run(fmt.Sprint(count), func(t *testing.T) {
+			songs := make([]*models.Song, count)
+			for i := range songs {
+				songs[i] = &models.Song{ID: fmt.Sprintf("%032x", i), Title: fmt.Sprintf("<歌曲 %d>", i), Artists: []string{"a", "b"}}
+			}
+
+			compact := &recordingWriter{}
+			if err := encodeSongsJSON(compact, fields, songs, identity, false); err != nil {
+				t.Fatalf("编码失败: %v", err)
+			}
+			var expected bytes.Buffer
+			if err := json.Indent(&expected, compact.data, "", middleware.PrettyJSONIndent); err != nil {
+				t.Fatalf("紧凑输出不是合法的 JSON: %v", err)
+			}
+			expected.WriteByte('\n')
+
+			indented := &recordingWriter{}
+			if err := encodeSongsJSON(indented, fields, songs, identity, true); err != nil {
+				t.Fatalf("编码失败: %v", err)
+			}
+			if !bytes.Equal(indented.data, expected.Bytes()) {
+				t.Errorf("期望缩进输出与 json.Indent 一致:\n%s\n得到:\n%s", expected.Bytes()[:min(expected.Len(), 2000)], indented.data[:min(len(indented.data), 2000)])
+			}
+			if indented.maxWrite > 4096 {
+				t.Errorf("期望单次写入不超过 4096 字节, 得到 %d", indented.maxWrite)
+			}
+		})
+	}
+}
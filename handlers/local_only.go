@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"net"
+	"net/http"
+	"zero-music/logger"
+	"zero-music/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LocalOnly 是一个 Gin 中间件，只允许来自本机回环地址的请求，其余请求通过 RespondError 返回 403。
+// 客户端 IP 通过 ClientIP 解析，因此只有受信任的反向代理才能转发本地请求。
+func LocalOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ip := net.ParseIP(c.ClientIP())
+		if ip != nil && ip.IsLoopback() {
+			c.Next()
+			return
+		}
+
+		logger.WithRequestID(middleware.GetRequestID(c)).Warnf("拒绝非本地地址访问 %s: %s", c.Request.URL.Path, c.ClientIP())
+		RespondError(c, http.StatusForbidden, NewForbiddenError("仅允许本地访问"))
+		c.Abort()
+	}
+}
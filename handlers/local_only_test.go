@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"zero-music/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestLocalOnly 测试本地地址可以访问，非本地地址与其他错误一样通过 RespondError 返回 403：
+// 带有请求 ID，并按 Accept-Language 本地化错误消息。
+func TestLocalOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	if err := router.SetTrustedProxies(nil); err != nil {
+		t.Fatal(err)
+	}
+	router.Use(middleware.RequestID())
+	router.GET("/debug", LocalOnly(), func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		wantStatus int
+	}{
+		{"IPv4 回环地址", "127.0.0.1:40000", http.StatusOK},
+		{"IPv6 回环地址", "[::1]:40000", http.StatusOK},
+		{"非本地地址", "192.0.2.1:40000", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/debug", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("Accept-Language", "en")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("期望状态码 %d, 得到 %d", tt.wantStatus, w.Code)
+			}
+			if tt.wantStatus == http.StatusOK {
+				return
+			}
+
+			var apiErr APIError
+			if err := json.Unmarshal(w.Body.Bytes(), &apiErr); err != nil {
+				t.Fatalf("解析响应失败: %v", err)
+			}
+			if apiErr.Code != "FORBIDDEN" || apiErr.RequestID == "" {
+				t.Errorf("期望带有请求 ID 的 FORBIDDEN 错误, 得到 %+v", apiErr)
+			}
+			if apiErr.Message != "Local access only" {
+				t.Errorf("期望英文错误消息, 得到 %q", apiErr.Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+	"zero-music/models"
+)
+
+const (
+	// maxPageLimit 是单页允许返回的最大歌曲数量。
+	maxPageLimit = 1000
+)
+
+// songCursor 是游标分页中编码的排序键，指向上一页的最后一条记录。
+// 排序键 (Weight 降序, Value, AddedAt, ID) 是稳定且唯一的，翻页过程中库的变动不会导致重复或遗漏。
+// Weight 是置顶权重，只在按置顶排序时非零；Value 是按标题、艺术家或专辑排序时的规范化字段值。
+type songCursor struct {
+	Weight  int       `json:"w,omitempty"`
+	Value   string    `json:"v,omitempty"`
+	AddedAt time.Time `json:"a"`
+	ID      string    `json:"i"`
+}
+
+// 支持的排序字段。
+const (
+	sortPinned  = "pinned"
+	sortTitle   = "title"
+	sortArtist  = "artist"
+	sortAlbum   = "album"
+	sortAddedAt = "added_at"
+)
+
+// songOrder 描述了歌曲列表的排序方式。零值表示按 (AddedAt, ID) 升序。
+type songOrder struct {
+	// field 是排序字段，为空或 added_at 时按添加时间排序。
+	field string
+	// desc 表示按降序排列，置顶权重始终按降序排列在最前。
+	desc bool
+	// weights 是置顶权重，只在按 pinned 排序时非 nil。
+	weights map[string]int
+}
+
+// parseSongOrder 解析排序字段和排序方向，weights 只在按 pinned 排序时被调用。
+func parseSongOrder(field, order string, weights func() map[string]int) (songOrder, error) {
+	var o songOrder
+	switch field {
+	case "", sortAddedAt, sortTitle, sortArtist, sortAlbum:
+		o.field = field
+	case sortPinned:
+		o.field = field
+		o.weights = weights()
+	default:
+		return o, errors.New("sort 仅支持 pinned, title, artist, album, added_at")
+	}
+	switch order {
+	case "", "asc":
+	case "desc":
+		o.desc = true
+	default:
+		return o, errors.New("order 仅支持 asc 或 desc")
+	}
+	return o, nil
+}
+
+// sorted 判断是否需要对歌曲列表排序。零值保留扫描顺序，以兼容未指定排序时的行为。
+func (o songOrder) sorted() bool {
+	return o.field != "" || o.desc
+}
+
+// key 返回歌曲在该排序方式下的排序键。
+func (o songOrder) key(song *models.Song) songCursor {
+	key := songCursor{Weight: o.weights[song.ID], AddedAt: song.AddedAt, ID: song.ID}
+	switch o.field {
+	case sortTitle:
+		key.Value = models.GroupKey(song.Title)
+	case sortArtist:
+		key.Value = models.GroupKey(song.Artist)
+	case sortAlbum:
+		key.Value = models.GroupKey(song.Album)
+	}
+	return key
+}
+
+// less 按 (Weight 降序, Value, AddedAt, ID) 比较两个排序键，降序时除 Weight 外的部分整体反转。
+func (o songOrder) less(a, b songCursor) bool {
+	if a.Weight != b.Weight {
+		return a.Weight > b.Weight
+	}
+	if o.desc {
+		a, b = b, a
+	}
+	if a.Value != b.Value {
+		return a.Value < b.Value
+	}
+	if !a.AddedAt.Equal(b.AddedAt) {
+		return a.AddedAt.Before(b.AddedAt)
+	}
+	return a.ID < b.ID
+}
+
+// encodeCursor 将排序键编码为不透明的游标字符串。
+func encodeCursor(key songCursor) string {
+	data, _ := json.Marshal(key)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor 解析游标字符串。
+func decodeCursor(raw string) (*songCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, errors.New("无效的游标")
+	}
+	var cursor songCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" {
+		return nil, errors.New("无效的游标")
+	}
+	return &cursor, nil
+}
+
+// parseLimit 解析分页大小，空字符串表示不分页（返回 0）。
+func parseLimit(raw string) (int, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit < 1 || limit > maxPageLimit {
+		return 0, errors.New("limit 必须是 1-" + strconv.Itoa(maxPageLimit) + " 之间的整数")
+	}
+	return limit, nil
+}
+
+// sortSongs 返回按 order 排序的歌曲副本列表。
+func sortSongs(songs []*models.Song, order songOrder) []*models.Song {
+	sorted := make([]*models.Song, len(songs))
+	copy(sorted, songs)
+	sort.Slice(sorted, func(i, j int) bool {
+		return order.less(order.key(sorted[i]), order.key(sorted[j]))
+	})
+	return sorted
+}
+
+// paginateByCursor 按 order 排序后，返回游标之后的至多 limit 首歌曲，
+// 以及下一页的游标（没有更多数据时为空字符串）。cursor 为 nil 时从第一条开始。
+func paginateByCursor(songs []*models.Song, order songOrder, cursor *songCursor, limit int) ([]*models.Song, string) {
+	sorted := sortSongs(songs, order)
+
+	start := 0
+	if cursor != nil {
+		start = sort.Search(len(sorted), func(i int) bool {
+			return order.less(*cursor, order.key(sorted[i]))
+		})
+	}
+
+	end := start + limit
+	if end >= len(sorted) {
+		return sorted[start:], ""
+	}
+	page := sorted[start:end]
+	return page, encodeCursor(order.key(page[len(page)-1]))
+}
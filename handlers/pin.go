@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"net/http"
+	"zero-music/logger"
+	"zero-music/middleware"
+	"zero-music/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultPinWeight 是置顶时未指定权重所使用的默认权重。
+const defaultPinWeight = 1
+
+// pinRequest 是设置歌曲置顶的请求体。
+type pinRequest struct {
+	// Pinned 为 true 时置顶，为 false 时取消置顶。
+	Pinned *bool `json:"pinned"`
+	// Weight 是置顶权重，越大越靠前，未指定时为 1。
+	Weight int `json:"weight"`
+}
+
+// pinResponse 是歌曲置顶状态的响应体。
+type pinResponse struct {
+	SongID string `json:"song_id"`
+	Pinned bool   `json:"pinned"`
+	Weight int    `json:"weight"`
+}
+
+// pinWeights 返回当前所有置顶歌曲的权重，未配置置顶存储时返回空映射。
+func (h *PlaylistHandler) pinWeights() map[string]int {
+	if h.pins == nil {
+		return map[string]int{}
+	}
+	return h.pins.Weights()
+}
+
+// SetPin 处理设置或取消歌曲置顶的请求。
+// @Summary 设置歌曲置顶
+// @Description 置顶或取消置顶指定歌曲，置顶歌曲在 /api/songs?sort=pinned 中按权重排在前面
+// @Tags playlist
+// @Accept json
+// @Produce json
+// @Param id path string true "歌曲ID"
+// @Param body body pinRequest true "是否置顶与置顶权重"
+// @Success 200 {object} pinResponse "成功更新置顶状态"
+// @Failure 400 {object} APIError "请求参数错误"
+// @Failure 404 {object} APIError "歌曲未找到"
+// @Failure 500 {object} APIError "服务器错误"
+// @Router /api/song/{id}/pin [post]
+func (h *PlaylistHandler) SetPin(c *gin.Context) {
+	id := c.Param("id")
+	requestID := middleware.GetRequestID(c)
+
+	if !models.IsValidID(id) {
+		logger.WithRequestID(requestID).Warnf("无效的歌曲 ID 格式: %s", id)
+		RespondError(c, http.StatusBadRequest, NewBadRequestError("无效的歌曲 ID 格式"))
+		return
+	}
+
+	var req pinRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Pinned == nil {
+		RespondError(c, http.StatusBadRequest, NewBadRequestError("无效的请求体"))
+		return
+	}
+	if req.Weight < 0 {
+		RespondError(c, http.StatusBadRequest, NewBadRequestError("置顶权重不能为负数"))
+		return
+	}
+	if h.pins == nil {
+		RespondError(c, http.StatusServiceUnavailable, NewServiceUnavailableError("置顶功能不可用"))
+		return
+	}
+
+	if _, ok := scanSongs(c, h.scanner); !ok {
+		return
+	}
+	if h.scanner.GetSongByID(id) == nil {
+		RespondError(c, http.StatusNotFound, NewNotFoundError("歌曲"))
+		return
+	}
+
+	response := pinResponse{SongID: id}
+	var err error
+	if *req.Pinned {
+		response.Pinned = true
+		response.Weight = req.Weight
+		if response.Weight == 0 {
+			response.Weight = defaultPinWeight
+		}
+		err = h.pins.Pin(id, response.Weight)
+	} else {
+		err = h.pins.Unpin(id)
+	}
+	if err != nil {
+		logger.WithRequestID(requestID).Errorf("保存置顶标记失败: %v", err)
+		RespondError(c, http.StatusInternalServerError, NewInternalError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"zero-music/config"
+	"zero-music/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestSetPin_SortPinned 测试置顶歌曲在 sort=pinned 时按权重出现在列表头部，其余按添加时间排序。
+func TestSetPin_SortPinned(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tmpDir := t.TempDir()
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, name := range []string{"a.mp3", "b.mp3", "c.mp3", "d.mp3"} {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte("fake mp3 "+name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		modTime := base.Add(time.Duration(i+1) * time.Hour)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pinsPath := filepath.Join(t.TempDir(), "pins.json")
+	pins, err := services.NewFilePinStore(pinsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scanner := services.NewMusicScanner(tmpDir, []string{".mp3"}, 5)
+	handler := NewPlaylistHandler(scanner, pins, nil, &config.Config{})
+	router := gin.New()
+	router.GET("/api/songs", handler.GetAllSongs)
+	router.POST("/api/song/:id/pin", handler.SetPin)
+
+	ids := make(map[string]string)
+	for _, song := range fetchSongsPage(t, router, "").Songs {
+		ids[song.FileName] = song.ID
+	}
+
+	setPin := func(name, body string) {
+		req := httptest.NewRequest("POST", "/api/song/"+ids[name]+"/pin", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("设置 %s 置顶期望状态码 200, 得到 %d: %s", name, w.Code, w.Body.String())
+		}
+	}
+	names := func(page songsPage) string {
+		var result []string
+		for _, song := range page.Songs {
+			result = append(result, song.FileName)
+		}
+		return strings.Join(result, ",")
+	}
+
+	setPin("d.mp3", `{"pinned": true}`)
+	setPin("c.mp3", `{"pinned": true, "weight": 5}`)
+
+	if got := names(fetchSongsPage(t, router, "sort=pinned")); got != "c.mp3,d.mp3,a.mp3,b.mp3" {
+		t.Errorf("期望置顶歌曲在前, 得到 %s", got)
+	}
+
+	// 与游标分页组合。
+	page := fetchSongsPage(t, router, "sort=pinned&limit=3")
+	if got := names(page); got != "c.mp3,d.mp3,a.mp3" {
+		t.Errorf("期望第一页为 c.mp3,d.mp3,a.mp3, 得到 %s", got)
+	}
+	if got := names(fetchSongsPage(t, router, "sort=pinned&limit=3&cursor="+page.NextCursor)); got != "b.mp3" {
+		t.Errorf("期望第二页为 b.mp3, 得到 %s", got)
+	}
+
+	// 取消置顶后恢复按添加时间排序。
+	setPin("c.mp3", `{"pinned": false}`)
+	if got := names(fetchSongsPage(t, router, "sort=pinned")); got != "d.mp3,a.mp3,b.mp3,c.mp3" {
+		t.Errorf("期望取消置顶后 c.mp3 回到原位, 得到 %s", got)
+	}
+
+	// 置顶标记已持久化。
+	reloaded, err := services.NewFilePinStore(pinsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if weights := reloaded.Weights(); len(weights) != 1 || weights[ids["d.mp3"]] != 1 {
+		t.Errorf("期望持久化的置顶标记只有 d.mp3, 得到 %v", weights)
+	}
+}
+
+// TestSetPin_InvalidRequest 测试无效的置顶请求。
+func TestSetPin_InvalidRequest(t *testing.T) {
+	router, _ := setupTestEnv(t)
+	id := "0123456789abcdef0123456789abcdef"
+
+	tests := []struct {
+		name string
+		path string
+		body string
+		want int
+	}{
+		{"无效 ID", "/api/song/bad/pin", `{"pinned": true}`, http.StatusBadRequest},
+		{"缺少 pinned", "/api/song/" + id + "/pin", `{}`, http.StatusBadRequest},
+		{"负权重", "/api/song/" + id + "/pin", `{"pinned": true, "weight": -1}`, http.StatusBadRequest},
+		{"歌曲不存在", "/api/song/" + id + "/pin", `{"pinned": true}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("期望状态码 %d, 得到 %d", tt.want, w.Code)
+			}
+		})
+	}
+}
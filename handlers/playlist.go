@@ -1,8 +1,16 @@
 package handlers
 
 import (
+	"errors"
+	"net"
 	"net/http"
-	"regexp"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"zero-music/config"
 	"zero-music/logger"
 	"zero-music/middleware"
 	"zero-music/models"
@@ -11,56 +19,260 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-var (
-	// validIDPattern 验证歌曲 ID 是否为有效的 SHA256 哈希（32 字节十六进制，即 64 个字符）
-	// 注意：generateID 函数使用前 16 字节，因此是 32 个十六进制字符
-	validIDPattern = regexp.MustCompile(models.ValidIDPattern())
-)
-
 // PlaylistHandler 负责处理与播放列表相关的 API 请求。
 type PlaylistHandler struct {
-	scanner services.Scanner
+	scanner       services.Scanner
+	pins          services.PinStore // 歌曲置顶标记，为 nil 时不支持置顶。
+	tags          services.TagStore // 歌曲自定义标签，为 nil 时不支持标签。
+	publicBaseURL string            // 生成 stream_url/cover_url 时使用的公开访问地址，为空时根据请求推断。
+	// trustedProxies 是受信任的反向代理，推断地址时只采用来自它们的 X-Forwarded-Proto 与 X-Forwarded-Host。
+	trustedProxies []*net.IPNet
+	defaultSort    string         // 未指定 sort 时使用的排序字段，为空时保持扫描顺序。
+	defaultOrder   string         // 未指定 order 时使用的排序方向。
+	checksums      *checksumCache // checksum=true 时按需计算的内容校验和缓存。
 }
 
-// NewPlaylistHandler 创建一个新的 PlaylistHandler 实例。
-func NewPlaylistHandler(scanner services.Scanner) *PlaylistHandler {
+// NewPlaylistHandler 创建一个新的 PlaylistHandler 实例。pins 与 tags 可以为 nil。
+func NewPlaylistHandler(scanner services.Scanner, pins services.PinStore, tags services.TagStore, cfg *config.Config) *PlaylistHandler {
 	return &PlaylistHandler{
-		scanner: scanner,
+		scanner:        scanner,
+		pins:           pins,
+		tags:           tags,
+		publicBaseURL:  cfg.Server.PublicBaseURL,
+		trustedProxies: parseTrustedProxies(cfg.Server.TrustedProxies),
+		defaultSort:    cfg.Music.DefaultSort,
+		defaultOrder:   cfg.Music.DefaultOrder,
+		checksums:      newChecksumCache(models.ComputeTrackChecksum),
 	}
 }
 
 // GetAllSongs 处理获取所有歌曲列表的请求。
 // @Summary 获取所有歌曲
-// @Description 返回音乐目录中所有可用的歌曲列表
+// @Description 返回音乐目录中所有可用的歌曲列表，total、total_duration（秒）与 total_size（字节）基于过滤后的完整结果集而非当前页。
+// @Description 响应头 X-Library-Version 是音乐库（扫描到的文件）的版本标识，不反映置顶与标签的变化
 // @Tags playlist
 // @Produce json
+// @Param fields query string false "逗号分隔的字段列表，仅返回这些字段（如 id,title,artist）"
+// @Param limit query int false "每页数量，指定后按 (added_at, id) 排序并分页"
+// @Param sort query string false "排序字段：pinned（置顶歌曲在前）、title、artist、album、added_at，默认使用配置的 default_sort"
+// @Param order query string false "排序方向：asc 或 desc，默认使用配置的 default_order"
+// @Param cursor query string false "上一页响应中的 next_cursor"
+// @Param include_urls query bool false "是否为每首歌曲附带完整的 stream_url 与 cover_url"
+// @Param added_after query string false "只返回在该时间及之后添加的歌曲（RFC3339 或 Unix 时间戳）"
+// @Param added_before query string false "只返回在该时间及之前添加的歌曲（RFC3339 或 Unix 时间戳）"
+// @Param tag query string false "只返回带有该自定义标签的歌曲"
+// @Param has_cover query bool false "为 true 时只返回有封面（嵌入封面或同目录封面文件）的歌曲，为 false 时只返回没有封面的歌曲"
+// @Param since query string false "上次响应的 X-Library-Version，音乐库没有变化时返回 304"
 // @Success 200 {object} map[string]interface{} "成功返回歌曲列表"
+// @Param time_format query string false "时间字段格式：rfc3339 或 unix_ms，默认使用服务器配置" Enums(rfc3339, unix_ms)
+// @Success 304 "音乐库自 since 以来没有变化"
+// @Failure 400 {object} APIError "请求参数错误"
 // @Failure 500 {object} APIError "服务器错误"
+// @Failure 503 {object} APIError "音乐目录暂时不可用且没有缓存"
 // @Router /api/songs [get]
 func (h *PlaylistHandler) GetAllSongs(c *gin.Context) {
-	requestID := middleware.GetRequestID(c)
+	// 解析分页参数。
+	limit, err := parseLimit(c.Query("limit"))
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, NewBadRequestError(err.Error()))
+		return
+	}
+	var cursor *songCursor
+	if raw := c.Query("cursor"); raw != "" {
+		if cursor, err = decodeCursor(raw); err != nil {
+			RespondError(c, http.StatusBadRequest, NewBadRequestError(err.Error()))
+			return
+		}
+	}
 
-	// 扫描音乐文件。
-	songs, err := h.scanner.Scan(c.Request.Context())
+	// 解析排序参数，未指定时使用配置的默认排序；按置顶排序时使用置顶权重作为首要排序键。
+	order, err := parseSongOrder(
+		queryOrDefault(c, "sort", h.defaultSort),
+		queryOrDefault(c, "order", h.defaultOrder),
+		h.pinWeights,
+	)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, NewBadRequestError(err.Error()))
+		return
+	}
+
+	// 解析添加时间过滤参数。
+	addedRange, err := parseAddedAtRange(c.Query("added_after"), c.Query("added_before"))
 	if err != nil {
-		logger.WithRequestID(requestID).Errorf("扫描音乐文件失败: %v", err)
-		c.JSON(http.StatusInternalServerError, NewInternalError(err))
+		RespondError(c, http.StatusBadRequest, NewBadRequestError(err.Error()))
 		return
 	}
 
+	// 解析自定义标签过滤参数。
+	var tag string
+	if raw := c.Query("tag"); raw != "" {
+		if tag, err = services.NormalizeTag(raw); err != nil {
+			RespondError(c, http.StatusBadRequest, NewBadRequestError(err.Error()))
+			return
+		}
+	}
+
+	// 解析封面过滤参数。
+	hasCover, err := parseHasCover(c.Query("has_cover"))
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, NewBadRequestError(err.Error()))
+		return
+	}
+
+	// 扫描音乐文件。
+	songs, ok := scanSongs(c, h.scanner)
+	if !ok {
+		return
+	}
+
+	// 音乐库自客户端上次获取以来没有变化时返回 304，客户端继续使用已有的列表。
+	version := h.scanner.LibraryVersion()
+	c.Header("X-Library-Version", version)
+	if since := c.Query("since"); since != "" && since == version {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	songs = filterByCover(h.filterByTag(addedRange.filter(songs), tag), hasCover)
+
+	// 汇总基于过滤后、分页前的完整结果集。
+	totalDuration, totalSize := songTotals(songs)
+	response := gin.H{
+		"total":          len(songs),
+		"total_duration": totalDuration,
+		"total_size":     totalSize,
+	}
+
+	// 指定了 limit 或 cursor 时使用基于游标的分页。
+	if limit > 0 || cursor != nil {
+		if limit == 0 {
+			limit = maxPageLimit
+		}
+		var nextCursor string
+		songs, nextCursor = paginateByCursor(songs, order, cursor, limit)
+		response["next_cursor"] = nextCursor
+	} else if order.sorted() {
+		songs = sortSongs(songs, order)
+	}
+
+	// 按需附带可直接使用的完整 URL，并在指定了 fields 参数时只返回请求的字段。
+	// 两者都在写出时逐首处理，不为整个列表构建副本。
+	var baseURL string
+	includeURLs := c.Query("include_urls") == "true"
+	if includeURLs {
+		baseURL = requestBaseURL(c, h.publicBaseURL, h.trustedProxies)
+	}
+	fields := parseFields(c.Query("fields"))
+	timeFormat := getTimeFormat(c)
+
 	// 返回歌曲列表。
-	c.JSON(http.StatusOK, gin.H{
-		"total": len(songs),
-		"songs": songs,
+	writeSongsJSON(c, response, songs, func(song *models.Song) interface{} {
+		if includeURLs {
+			song = withURL(song, baseURL)
+		}
+		if len(fields) > 0 {
+			return projectSong(song, fields, timeFormat)
+		}
+		return newSongJSON(song, timeFormat)
 	})
 }
 
+// parseHasCover 解析 has_cover 参数，为空时返回 nil 表示不过滤。
+func parseHasCover(raw string) (*bool, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	hasCover, err := strconv.ParseBool(raw)
+	if err != nil {
+		return nil, errors.New("has_cover 必须是 true 或 false")
+	}
+	return &hasCover, nil
+}
+
+// filterByCover 按是否有封面过滤歌曲，hasCover 为 nil 时返回原列表。
+func filterByCover(songs []*models.Song, hasCover *bool) []*models.Song {
+	if hasCover == nil {
+		return songs
+	}
+	filtered := make([]*models.Song, 0, len(songs))
+	for _, song := range songs {
+		if song.HasCover == *hasCover {
+			filtered = append(filtered, song)
+		}
+	}
+	return filtered
+}
+
+// songTotals 返回歌曲列表的总时长（秒）与总大小（字节）。
+func songTotals(songs []*models.Song) (duration int, size int64) {
+	for _, song := range songs {
+		duration += song.Duration
+		size += song.FileSize
+	}
+	return duration, size
+}
+
+// queryOrDefault 返回查询参数的值，参数为空时返回默认值。
+func queryOrDefault(c *gin.Context, key, defaultValue string) string {
+	if value := c.Query(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// parseFields 解析逗号分隔的字段列表，去除空白和空项。
+func parseFields(raw string) []string {
+	if raw == "" {
+		return nil
+	}
+	fields := make([]string, 0)
+	for _, field := range strings.Split(raw, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// projectSong 将单首歌曲投影为仅包含指定字段的 map，时间字段按 timeFormat 输出。
+func projectSong(song *models.Song, fields []string, timeFormat string) map[string]interface{} {
+	projected := make(map[string]interface{}, len(fields))
+	v := reflect.ValueOf(song).Elem()
+	for _, field := range fields {
+		if index, ok := songFieldIndex[field]; ok {
+			projected[field] = v.Field(index).Interface()
+		}
+	}
+	if _, ok := projected["added_at"]; ok {
+		projected["added_at"] = formatTime(song.AddedAt, timeFormat)
+	}
+	return projected
+}
+
+// songFieldIndex 是 Song 的 JSON 字段名到结构体字段下标的映射。
+var songFieldIndex = func() map[string]int {
+	index := make(map[string]int)
+	t := reflect.TypeOf(models.Song{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			index[name] = i
+		}
+	}
+	return index
+}()
+
 // GetSongByID 处理根据 ID 获取特定歌曲信息的请求。
 // @Summary 获取指定歌曲信息
 // @Description 根据歌曲ID返回歌曲详细信息
 // @Tags playlist
 // @Produce json
 // @Param id path string true "歌曲ID"
+// @Param related query bool false "是否附带同专辑的上一首/下一首歌曲 ID"
+// @Param include_urls query bool false "是否附带完整的 stream_url 与 cover_url"
+// @Param verify query bool false "是否校验文件当前仍存在且大小与缓存一致，不一致时返回 stale: true"
+// @Param checksum query bool false "是否返回音频内容的 SHA256 校验和（开销较大，结果按文件修改时间缓存）"
+// @Param time_format query string false "时间字段格式：rfc3339 或 unix_ms，默认使用服务器配置" Enums(rfc3339, unix_ms)
 // @Success 200 {object} models.Song "成功返回歌曲信息"
 // @Failure 400 {object} APIError "请求参数错误"
 // @Failure 404 {object} APIError "歌曲未找到"
@@ -71,17 +283,15 @@ func (h *PlaylistHandler) GetSongByID(c *gin.Context) {
 	requestID := middleware.GetRequestID(c)
 
 	// 验证 ID 格式，确保是有效的 SHA256 哈希格式，防止路径遍历。
-	if !validIDPattern.MatchString(id) {
+	if !models.IsValidID(id) {
 		logger.WithRequestID(requestID).Warnf("无效的歌曲 ID 格式: %s", id)
-		c.JSON(http.StatusBadRequest, NewBadRequestError("无效的歌曲 ID 格式"))
+		RespondError(c, http.StatusBadRequest, NewBadRequestError("无效的歌曲 ID 格式"))
 		return
 	}
 
 	// 先执行扫描以确保缓存是最新的。
-	_, err := h.scanner.Scan(c.Request.Context())
-	if err != nil {
-		logger.WithRequestID(requestID).Errorf("扫描音乐文件失败: %v", err)
-		c.JSON(http.StatusInternalServerError, NewInternalError(err))
+	_, ok := scanSongs(c, h.scanner)
+	if !ok {
 		return
 	}
 
@@ -89,9 +299,118 @@ func (h *PlaylistHandler) GetSongByID(c *gin.Context) {
 	song := h.scanner.GetSongByID(id)
 	if song == nil {
 		logger.WithRequestID(requestID).Warnf("歌曲未找到: %s", id)
-		c.JSON(http.StatusNotFound, NewNotFoundError("歌曲"))
+		RespondError(c, http.StatusNotFound, NewNotFoundError("歌曲"))
 		return
 	}
 
-	c.JSON(http.StatusOK, song)
+	// 按需附带可直接使用的完整 URL。GetSongByID 返回的是副本，可以直接修改。
+	if c.Query("include_urls") == "true" {
+		setURLs(song, requestBaseURL(c, h.publicBaseURL, h.trustedProxies))
+	}
+
+	detail := songDetail{songJSON: newSongJSON(song, getTimeFormat(c))}
+
+	// 按需附带同专辑的上一首/下一首，基于缓存数据计算。
+	if c.Query("related") == "true" {
+		related := findAlbumNeighbors(h.scanner.GetSongs(), song)
+		detail.Related = &related
+	}
+
+	// 按需校验缓存的文件状态是否仍与磁盘一致。
+	if c.Query("verify") == "true" {
+		stale := isSongStale(song)
+		if stale {
+			logger.WithRequestID(requestID).Infof("歌曲文件已变化，缓存信息可能过期: %s", song.FilePath)
+		}
+		detail.Stale = &stale
+	}
+
+	// 按需计算内容校验和，读取整个文件，源文件未变化时使用缓存结果。
+	if c.Query("checksum") == "true" {
+		checksum, err := h.checksums.Get(song)
+		if err != nil {
+			logger.WithRequestID(requestID).Errorf("计算歌曲校验和失败: %s, 错误: %v", song.FilePath, err)
+			RespondError(c, http.StatusInternalServerError, NewInternalError(err))
+			return
+		}
+		detail.Checksum = checksum
+	}
+
+	c.JSON(http.StatusOK, detail)
+}
+
+// isSongStale 判断缓存的歌曲信息是否已与磁盘上的文件不一致：文件已不存在，或大小发生了变化。
+// cue 虚拟歌曲的 FileSize 是按时长估算的音轨大小，因此只检查源文件是否存在。
+func isSongStale(song *models.Song) bool {
+	info, err := os.Stat(song.FilePath)
+	if err != nil {
+		return true
+	}
+	return !song.IsCueTrack() && info.Size() != song.FileSize
+}
+
+// RelatedSongs 描述了一首歌在其专辑中的相邻歌曲。
+// 位于专辑开头或末尾时，对应的 ID 为空字符串。
+type RelatedSongs struct {
+	PrevID string `json:"prev_id"`
+	NextID string `json:"next_id"`
+}
+
+// songDetail 是歌曲详情响应，按请求参数附带关联歌曲与文件校验结果。
+type songDetail struct {
+	songJSON
+	Related *RelatedSongs `json:"related,omitempty"`
+	// Stale 仅在 verify=true 时返回，为 true 表示文件已被删除或大小已变化。
+	Stale *bool `json:"stale,omitempty"`
+	// Checksum 仅在 checksum=true 时返回，是音频内容（与未转码的 /api/stream 响应一致）的 SHA256 十六进制值。
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// findAlbumNeighbors 在歌曲列表中查找与指定歌曲同专辑的上一首和下一首。
+// 专辑内按音轨号排序，音轨号缺失时按文件名排序兜底。
+// 专辑未知时以所在目录作为分组依据。
+func findAlbumNeighbors(songs []*models.Song, target *models.Song) RelatedSongs {
+	album := make([]*models.Song, 0)
+	for _, song := range songs {
+		if sameAlbum(song, target) {
+			album = append(album, song)
+		}
+	}
+
+	sort.SliceStable(album, func(i, j int) bool {
+		a, b := album[i], album[j]
+		if a.TrackNumber != b.TrackNumber {
+			// 有音轨号的歌曲排在缺失音轨号的歌曲之前。
+			if a.TrackNumber == 0 || b.TrackNumber == 0 {
+				return b.TrackNumber == 0
+			}
+			return a.TrackNumber < b.TrackNumber
+		}
+		return a.FileName < b.FileName
+	})
+
+	var related RelatedSongs
+	for i, song := range album {
+		if song.ID != target.ID {
+			continue
+		}
+		if i > 0 {
+			related.PrevID = album[i-1].ID
+		}
+		if i < len(album)-1 {
+			related.NextID = album[i+1].ID
+		}
+		break
+	}
+	return related
+}
+
+// sameAlbum 判断两首歌曲是否属于同一专辑，专辑名与艺术家均按规范化键比较，
+// 避免不同艺术家的同名专辑（如 "Greatest Hits"）被合并为一张。
+func sameAlbum(a, b *models.Song) bool {
+	if a.Album == "Unknown" || b.Album == "Unknown" {
+		return a.Album == b.Album && filepath.Dir(a.FilePath) == filepath.Dir(b.FilePath)
+	}
+	return models.GroupKey(a.Album) == models.GroupKey(b.Album) &&
+		models.GroupKey(a.Artist) == models.GroupKey(b.Artist)
 }
@@ -1,13 +1,24 @@
 package handlers
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 	"zero-music/config"
+	"zero-music/middleware"
+	"zero-music/models"
 	"zero-music/services"
 
 	"github.com/gin-gonic/gin"
@@ -16,45 +27,35 @@ import (
 // setupTestEnv 初始化一个用于播放列表处理器测试的环境。
 // 它会创建一个临时的音乐目录和一些测试文件，并返回一个配置好的 Gin 引擎。
 func setupTestEnv(t *testing.T) (*gin.Engine, string) {
-	// 将 Gin 设置为测试模式。
-	gin.SetMode(gin.TestMode)
-
-	// 创建一个临时目录来存放测试音乐文件。
-	tmpDir := t.TempDir()
+	// 在临时音乐目录中创建假的 MP3 文件，并创建扫描器。
+	cfg, scanner := newTestLibrary(t, map[string]string{
+		"test1.mp3": "fake mp3 data 1",
+		"test2.mp3": "fake mp3 data 2",
+	}, nil)
 
-	// 创建假的 MP3 文件用于测试。
-	testFile1 := filepath.Join(tmpDir, "test1.mp3")
-	testFile2 := filepath.Join(tmpDir, "test2.mp3")
-	if err := os.WriteFile(testFile1, []byte("fake mp3 data 1"), 0644); err != nil {
+	pins, err := services.NewFilePinStore(filepath.Join(t.TempDir(), "pins.json"))
+	if err != nil {
 		t.Fatal(err)
 	}
-	if err := os.WriteFile(testFile2, []byte("fake mp3 data 2"), 0644); err != nil {
+	tags, err := services.NewFileTagStore(filepath.Join(t.TempDir(), "tags.json"))
+	if err != nil {
 		t.Fatal(err)
 	}
 
-	// 使用临时目录创建测试配置。
-	cfg := &config.Config{
-		Music: config.MusicConfig{
-			Directory:        tmpDir,
-			SupportedFormats: []string{".mp3"},
-			CacheTTLMinutes:  5,
-		},
-	}
-
-	// 创建扫描器和处理器。
-	scanner := services.NewMusicScanner(
-		cfg.Music.Directory,
-		cfg.Music.SupportedFormats,
-		cfg.Music.CacheTTLMinutes,
-	)
-
 	// 创建 Gin 路由器并注册处理器。
 	router := gin.New()
-	handler := NewPlaylistHandler(scanner)
+	router.Use(middleware.PrettyJSON(false))
+	handler := NewPlaylistHandler(scanner, pins, tags, cfg)
 	router.GET("/api/songs", handler.GetAllSongs)
 	router.GET("/api/song/:id", handler.GetSongByID)
+	router.POST("/api/songs/batch", handler.GetSongsBatch)
+	router.POST("/api/song/:id/pin", handler.SetPin)
+	router.POST("/api/song/:id/tags", handler.AddTags)
+	router.DELETE("/api/song/:id/tags/:tag", handler.RemoveTag)
+	router.GET("/api/tags", handler.GetTags)
+	router.GET("/admin/scan/info", NewAdminHandler(scanner).GetScanInfo)
 
-	return router, tmpDir
+	return router, cfg.Music.Directory
 }
 
 // TestGetAllSongs 测试 GetAllSongs 端点是否能成功返回所有歌曲。
@@ -168,3 +169,720 @@ func TestGetSongByID_InvalidFormat(t *testing.T) {
 		})
 	}
 }
+
+// TestGetAllSongs_Fields 测试 fields 参数是否只返回请求的字段。
+func TestGetAllSongs_Fields(t *testing.T) {
+	router, _ := setupTestEnv(t)
+
+	req, _ := http.NewRequest("GET", "/api/songs?fields=id,title,unknown", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("期望状态码 200, 得到 %d", w.Code)
+	}
+
+	var response struct {
+		Total int                      `json:"total"`
+		Songs []map[string]interface{} `json:"songs"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if len(response.Songs) != 2 {
+		t.Fatalf("期望有 2 首歌曲, 得到 %d", len(response.Songs))
+	}
+
+	for _, song := range response.Songs {
+		if len(song) != 2 {
+			t.Errorf("期望只包含 2 个字段, 得到 %v", song)
+		}
+		if _, ok := song["id"]; !ok {
+			t.Error("响应中缺少 'id' 字段")
+		}
+		if _, ok := song["title"]; !ok {
+			t.Error("响应中缺少 'title' 字段")
+		}
+		if _, ok := song["file_path"]; ok {
+			t.Error("响应中不应包含 'file_path' 字段")
+		}
+	}
+}
+
+// TestGetAllSongs_EmptyFields 测试空的 fields 参数是否返回全部字段。
+func TestGetAllSongs_EmptyFields(t *testing.T) {
+	router, _ := setupTestEnv(t)
+
+	req, _ := http.NewRequest("GET", "/api/songs?fields=", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response struct {
+		Songs []map[string]interface{} `json:"songs"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if len(response.Songs) == 0 {
+		t.Fatal("期望返回歌曲")
+	}
+	if _, ok := response.Songs[0]["file_path"]; !ok {
+		t.Error("期望返回全量字段，缺少 'file_path'")
+	}
+}
+
+// TestGetAllSongs_Since 测试响应带有 X-Library-Version，音乐库没有变化时按 since 返回 304。
+func TestGetAllSongs_Since(t *testing.T) {
+	router, _ := setupTestEnv(t)
+
+	req, _ := http.NewRequest("GET", "/api/songs", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	version := w.Header().Get("X-Library-Version")
+	if version == "" {
+		t.Fatal("期望响应包含 X-Library-Version")
+	}
+
+	testCases := []struct {
+		name         string
+		since        string
+		expectedCode int
+	}{
+		{"版本未变化", version, http.StatusNotModified},
+		{"版本已过期", "stale", http.StatusOK},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/songs?since="+tc.since, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tc.expectedCode {
+				t.Fatalf("期望状态码 %d, 得到 %d", tc.expectedCode, w.Code)
+			}
+			if got := w.Header().Get("X-Library-Version"); got != version {
+				t.Errorf("期望 X-Library-Version 为 %s, 得到 %s", version, got)
+			}
+			if tc.expectedCode == http.StatusNotModified && w.Body.Len() != 0 {
+				t.Errorf("期望 304 响应没有正文, 得到 %s", w.Body.String())
+			}
+		})
+	}
+}
+
+// TestFindAlbumNeighbors 测试专辑内上一首/下一首的顺序与边界。
+func TestFindAlbumNeighbors(t *testing.T) {
+	songs := []*models.Song{
+		{ID: "c", Album: "A", TrackNumber: 3, FileName: "c.mp3", FilePath: "/m/c.mp3"},
+		{ID: "a", Album: "A", TrackNumber: 1, FileName: "z.mp3", FilePath: "/m/z.mp3"},
+		{ID: "b", Album: "A", TrackNumber: 2, FileName: "y.mp3", FilePath: "/m/y.mp3"},
+		{ID: "x", Album: "B", TrackNumber: 1, FileName: "x.mp3", FilePath: "/m/x.mp3"},
+		// 音轨号缺失的歌曲排在最后，并按文件名排序。
+		{ID: "e", Album: "A", FileName: "e.mp3", FilePath: "/m/e.mp3"},
+		{ID: "d", Album: "A", FileName: "d.mp3", FilePath: "/m/d.mp3"},
+		// 不同艺术家的同名专辑各自独立。
+		{ID: "g1", Album: "Greatest Hits", Artist: "X", TrackNumber: 1, FileName: "g1.mp3", FilePath: "/m/x/g1.mp3"},
+		{ID: "g2", Album: "Greatest Hits", Artist: "Y", TrackNumber: 2, FileName: "g2.mp3", FilePath: "/m/y/g2.mp3"},
+		{ID: "g3", Album: "Greatest Hits", Artist: "X", TrackNumber: 3, FileName: "g3.mp3", FilePath: "/m/x/g3.mp3"},
+	}
+	byID := make(map[string]*models.Song)
+	for _, song := range songs {
+		byID[song.ID] = song
+	}
+
+	testCases := []struct {
+		id     string
+		prevID string
+		nextID string
+	}{
+		{"a", "", "b"},   // 第一首
+		{"b", "a", "c"},  // 中间
+		{"c", "b", "d"},  // 有音轨号的最后一首
+		{"d", "c", "e"},  // 音轨号缺失，按文件名排序
+		{"e", "d", ""},   // 最后一首
+		{"x", "", ""},    // 专辑只有一首
+		{"g1", "", "g3"}, // 同名专辑只与同一艺术家的歌曲相邻
+		{"g2", "", ""},   // 其他艺术家的同名专辑
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.id, func(t *testing.T) {
+			related := findAlbumNeighbors(songs, byID[tc.id])
+			if related.PrevID != tc.prevID || related.NextID != tc.nextID {
+				t.Errorf("期望上一首/下一首为 %q/%q, 得到 %q/%q", tc.prevID, tc.nextID, related.PrevID, related.NextID)
+			}
+		})
+	}
+}
+
+// TestGetSongByID_Related 测试 related 参数是否按文件名返回相邻歌曲。
+func TestGetSongByID_Related(t *testing.T) {
+	router, _ := setupTestEnv(t)
+
+	// 获取歌曲列表，测试文件没有标签，因此按文件名排序：test1.mp3、test2.mp3。
+	req, _ := http.NewRequest("GET", "/api/songs", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response struct {
+		Songs []models.Song `json:"songs"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	ids := make(map[string]string)
+	for _, song := range response.Songs {
+		ids[song.FileName] = song.ID
+	}
+
+	req, _ = http.NewRequest("GET", "/api/song/"+ids["test1.mp3"]+"?related=true", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("期望状态码 200, 得到 %d", w.Code)
+	}
+
+	var song struct {
+		ID      string       `json:"id"`
+		Related RelatedSongs `json:"related"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &song); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if song.ID != ids["test1.mp3"] {
+		t.Errorf("期望歌曲 ID 为 %s, 得到 %s", ids["test1.mp3"], song.ID)
+	}
+	if song.Related.PrevID != "" {
+		t.Errorf("期望第一首没有上一首, 得到 %s", song.Related.PrevID)
+	}
+	if song.Related.NextID != ids["test2.mp3"] {
+		t.Errorf("期望下一首为 %s, 得到 %s", ids["test2.mp3"], song.Related.NextID)
+	}
+}
+
+// TestGetAllSongs_Pretty 测试 pretty=true 时流式歌曲列表自行缩进输出，结果与 PrettyJSON 中间件缩进紧凑输出的格式一致。
+func TestGetAllSongs_Pretty(t *testing.T) {
+	router, _ := setupTestEnv(t)
+
+	get := func(path string) []byte {
+		t.Helper()
+		req, _ := http.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("期望状态码 200, 得到 %d", w.Code)
+		}
+		return w.Body.Bytes()
+	}
+
+	var expected bytes.Buffer
+	if err := json.Indent(&expected, get("/api/songs?sort=title"), "", middleware.PrettyJSONIndent); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	expected.WriteByte('\n')
+	if got := get("/api/songs?sort=title&pretty=true"); !bytes.Equal(got, expected.Bytes()) {
+		t.Errorf("期望缩进输出:\n%s\n得到:\n%s", expected.Bytes(), got)
+	}
+}
+
+// TestGetSongByID_Verify 测试 verify=true 时校验文件状态：文件未变化时 stale 为 false，
+// 大小变化或被删除后返回 stale: true；未开启 verify 时不返回 stale 字段。
+func TestGetSongByID_Verify(t *testing.T) {
+	router, musicDir := setupTestEnv(t)
+	songID := getSongID(t, router)
+
+	// getDetail 请求歌曲详情，返回 stale 字段（未返回时为 nil）。
+	getDetail := func(query string) *bool {
+		t.Helper()
+		req, _ := http.NewRequest("GET", "/api/song/"+songID+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("期望状态码 200, 得到 %d", w.Code)
+		}
+		var detail struct {
+			Stale *bool `json:"stale"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &detail); err != nil {
+			t.Fatalf("解析响应失败: %v", err)
+		}
+		return detail.Stale
+	}
+
+	if stale := getDetail(""); stale != nil {
+		t.Errorf("未开启 verify 时不应返回 stale, 得到 %v", *stale)
+	}
+	if stale := getDetail("?verify=true"); stale == nil || *stale {
+		t.Errorf("期望文件未变化时 stale 为 false, 得到 %v", stale)
+	}
+
+	// 找到该歌曲对应的文件并修改其大小。
+	var song models.Song
+	req, _ := http.NewRequest("GET", "/api/song/"+songID, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if err := json.Unmarshal(w.Body.Bytes(), &song); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	path := filepath.Join(musicDir, song.FileName)
+	if err := os.WriteFile(path, []byte("modified fake mp3 data with a different size"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if stale := getDetail("?verify=true"); stale == nil || !*stale {
+		t.Errorf("期望文件大小变化后 stale 为 true, 得到 %v", stale)
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if stale := getDetail("?verify=true"); stale == nil || !*stale {
+		t.Errorf("期望文件删除后 stale 为 true, 得到 %v", stale)
+	}
+}
+
+// TestGetSongByID_Checksum 测试 checksum=true 时返回与文件实际内容一致的 SHA256，
+// 文件未变化时第二次请求命中缓存，修改时间变化后重新计算；未开启时不返回 checksum。
+func TestGetSongByID_Checksum(t *testing.T) {
+	router, musicDir := setupTestEnv(t)
+	songID := getSongID(t, router)
+
+	// getDetail 请求歌曲详情，返回解析后的响应。
+	getDetail := func(query string) (models.Song, string) {
+		t.Helper()
+		req, _ := http.NewRequest("GET", "/api/song/"+songID+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("期望状态码 200, 得到 %d", w.Code)
+		}
+		var song models.Song
+		var detail struct {
+			Checksum string `json:"checksum"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &song); err != nil {
+			t.Fatalf("解析响应失败: %v", err)
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &detail); err != nil {
+			t.Fatalf("解析响应失败: %v", err)
+		}
+		return song, detail.Checksum
+	}
+	// fileHash 计算文件当前内容的 SHA256。
+	fileHash := func(path string) string {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum := sha256.Sum256(data)
+		return hex.EncodeToString(sum[:])
+	}
+
+	song, checksum := getDetail("")
+	if checksum != "" {
+		t.Errorf("未开启 checksum 时不应返回校验和, 得到 %q", checksum)
+	}
+	path := filepath.Join(musicDir, song.FileName)
+	original := fileHash(path)
+	if _, checksum = getDetail("?checksum=true"); checksum != original {
+		t.Fatalf("期望校验和 %s, 得到 %s", original, checksum)
+	}
+
+	// 写入大小相同的新内容并恢复修改时间：缓存仍然有效，返回的是缓存的旧校验和。
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)-1] ^= 0xff
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	if _, checksum = getDetail("?checksum=true"); checksum != original {
+		t.Errorf("期望第二次请求命中缓存返回 %s, 得到 %s", original, checksum)
+	}
+
+	// 修改时间变化后缓存失效，重新计算得到新内容的校验和。
+	modTime := info.ModTime().Add(time.Minute)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	updated := fileHash(path)
+	if updated == original {
+		t.Fatal("修改后的文件内容应产生不同的校验和")
+	}
+	if _, checksum = getDetail("?checksum=true"); checksum != updated {
+		t.Errorf("期望修改时间变化后重新计算为 %s, 得到 %s", updated, checksum)
+	}
+}
+
+// songsPage 是 /api/songs 分页响应的结构。
+type songsPage struct {
+	Total      int           `json:"total"`
+	Songs      []models.Song `json:"songs"`
+	NextCursor string        `json:"next_cursor"`
+}
+
+// fetchSongsPage 是一个辅助函数，请求一页歌曲列表。
+func fetchSongsPage(t *testing.T, router *gin.Engine, query string) songsPage {
+	req, _ := http.NewRequest("GET", "/api/songs?"+query, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("期望状态码 200, 得到 %d: %s", w.Code, w.Body.String())
+	}
+
+	var page songsPage
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	return page
+}
+
+// TestGetAllSongs_CursorPagination 测试基于游标的分页在翻页过程中新增歌曲时不会重复或遗漏。
+func TestGetAllSongs_CursorPagination(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tmpDir := t.TempDir()
+
+	// 创建修改时间递增的歌曲文件。
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	writeSong := func(name string, modTime time.Time) {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte("fake mp3 "+name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeSong("a.mp3", base.Add(1*time.Hour))
+	writeSong("b.mp3", base.Add(2*time.Hour))
+	writeSong("c.mp3", base.Add(3*time.Hour))
+	writeSong("d.mp3", base.Add(4*time.Hour))
+
+	scanner := services.NewMusicScanner(tmpDir, []string{".mp3"}, 5)
+	router := gin.New()
+	router.GET("/api/songs", NewPlaylistHandler(scanner, nil, nil, &config.Config{}).GetAllSongs)
+
+	// 第一页。
+	page := fetchSongsPage(t, router, "limit=2")
+	if len(page.Songs) != 2 || page.Songs[0].FileName != "a.mp3" || page.Songs[1].FileName != "b.mp3" {
+		t.Fatalf("期望第一页为 a.mp3、b.mp3, 得到 %+v", page.Songs)
+	}
+	if page.NextCursor == "" {
+		t.Fatal("期望返回 next_cursor")
+	}
+	seen := map[string]bool{"a.mp3": true, "b.mp3": true}
+
+	// 翻页过程中新增一首排在已翻页范围内的歌曲和一首排在末尾的歌曲。
+	writeSong("early.mp3", base.Add(90*time.Minute))
+	writeSong("late.mp3", base.Add(5*time.Hour))
+	if _, err := scanner.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 继续翻页直到结束。
+	cursor := page.NextCursor
+	var rest []string
+	for cursor != "" {
+		page = fetchSongsPage(t, router, "limit=2&cursor="+cursor)
+		for _, song := range page.Songs {
+			if seen[song.FileName] {
+				t.Errorf("歌曲 %s 被重复返回", song.FileName)
+			}
+			seen[song.FileName] = true
+			rest = append(rest, song.FileName)
+		}
+		cursor = page.NextCursor
+	}
+
+	expected := []string{"c.mp3", "d.mp3", "late.mp3"}
+	if strings.Join(rest, ",") != strings.Join(expected, ",") {
+		t.Errorf("期望后续页为 %v, 得到 %v", expected, rest)
+	}
+}
+
+// TestGetAllSongs_InvalidPagination 测试无效的分页参数是否返回 400。
+func TestGetAllSongs_InvalidPagination(t *testing.T) {
+	router, _ := setupTestEnv(t)
+
+	for _, query := range []string{"limit=0", "limit=abc", "limit=100000", "cursor=not-a-cursor", "sort=rating", "order=up"} {
+		req, _ := http.NewRequest("GET", "/api/songs?"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("对于 %s，期望状态码 400, 得到 %d", query, w.Code)
+		}
+	}
+}
+
+// TestGetAllSongs_DefaultSort 测试配置默认排序后，未指定 sort/order 的请求按默认排序返回，显式参数优先。
+func TestGetAllSongs_DefaultSort(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tmpDir := t.TempDir()
+
+	// 添加时间与标题顺序相反，便于区分两种排序。
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, name := range []string{"c.mp3", "a.mp3", "b.mp3"} {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte("fake mp3 "+name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		modTime := base.Add(time.Duration(i) * time.Hour)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &config.Config{}
+	cfg.Music.DefaultSort = "title"
+	cfg.Music.DefaultOrder = "desc"
+	scanner := services.NewMusicScanner(tmpDir, []string{".mp3"}, 5)
+	router := gin.New()
+	router.GET("/api/songs", NewPlaylistHandler(scanner, nil, nil, cfg).GetAllSongs)
+
+	names := func(page songsPage) string {
+		var result []string
+		for _, song := range page.Songs {
+			result = append(result, song.FileName)
+		}
+		return strings.Join(result, ",")
+	}
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"", "c.mp3,b.mp3,a.mp3"},
+		{"limit=2", "c.mp3,b.mp3"},
+		{"order=asc", "a.mp3,b.mp3,c.mp3"},
+		{"sort=added_at&order=asc", "c.mp3,a.mp3,b.mp3"},
+	}
+	for _, tt := range tests {
+		if got := names(fetchSongsPage(t, router, tt.query)); got != tt.expected {
+			t.Errorf("对于 %q，期望顺序 %s, 得到 %s", tt.query, tt.expected, got)
+		}
+	}
+}
+
+// TestGetAllSongs_AddedAtFilter 测试按添加时间区间过滤歌曲（含边界），并能与分页组合使用。
+func TestGetAllSongs_AddedAtFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tmpDir := t.TempDir()
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, name := range []string{"a.mp3", "b.mp3", "c.mp3", "d.mp3"} {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte("fake mp3 "+name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		modTime := base.Add(time.Duration(i+1) * time.Hour)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scanner := services.NewMusicScanner(tmpDir, []string{".mp3"}, 5)
+	router := gin.New()
+	router.GET("/api/songs", NewPlaylistHandler(scanner, nil, nil, &config.Config{}).GetAllSongs)
+
+	bHour := base.Add(2 * time.Hour)
+	cHour := base.Add(3 * time.Hour)
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"含边界的区间", "added_after=" + bHour.Format(time.RFC3339) + "&added_before=" + cHour.Format(time.RFC3339), []string{"b.mp3", "c.mp3"}},
+		{"Unix 时间戳", "added_after=" + strconv.FormatInt(cHour.Unix(), 10), []string{"c.mp3", "d.mp3"}},
+		{"只有上界", "added_before=" + bHour.Format(time.RFC3339), []string{"a.mp3", "b.mp3"}},
+		{"与分页组合", "added_after=" + bHour.Format(time.RFC3339) + "&limit=2", []string{"b.mp3", "c.mp3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := fetchSongsPage(t, router, tt.query)
+			var names []string
+			for _, song := range page.Songs {
+				names = append(names, song.FileName)
+			}
+			sort.Strings(names)
+			if strings.Join(names, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("期望歌曲为 %v, 得到 %v", tt.want, names)
+			}
+		})
+	}
+
+	// 分页的下一页仍然只包含区间内的歌曲。
+	page := fetchSongsPage(t, router, "added_after="+bHour.Format(time.RFC3339)+"&limit=2")
+	if page.Total != 3 {
+		t.Errorf("期望过滤后总数为 3, 得到 %d", page.Total)
+	}
+	page = fetchSongsPage(t, router, "added_after="+bHour.Format(time.RFC3339)+"&limit=2&cursor="+page.NextCursor)
+	if len(page.Songs) != 1 || page.Songs[0].FileName != "d.mp3" || page.NextCursor != "" {
+		t.Errorf("期望第二页只有 d.mp3, 得到 %+v", page.Songs)
+	}
+}
+
+// TestGetAllSongs_InvalidAddedAt 测试非法的时间格式是否返回 400。
+func TestGetAllSongs_InvalidAddedAt(t *testing.T) {
+	router, _ := setupTestEnv(t)
+
+	for _, query := range []string{"added_after=yesterday", "added_before=2024-01-01"} {
+		req, _ := http.NewRequest("GET", "/api/songs?"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("对于 %s，期望状态码 400, 得到 %d", query, w.Code)
+		}
+	}
+}
+
+// buildWAV 生成一个指定时长的静音 WAV 文件内容（8kHz、16 位、单声道）。
+func buildWAV(seconds int) []byte {
+	const sampleRate, blockAlign = 8000, 2
+	dataLen := seconds * sampleRate * blockAlign
+	buf := make([]byte, 44+dataLen)
+	copy(buf[0:], "RIFF")
+	binary.LittleEndian.PutUint32(buf[4:], uint32(36+dataLen))
+	copy(buf[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(buf[16:], 16)
+	binary.LittleEndian.PutUint16(buf[20:], 1)
+	binary.LittleEndian.PutUint16(buf[22:], 1)
+	binary.LittleEndian.PutUint32(buf[24:], sampleRate)
+	binary.LittleEndian.PutUint32(buf[28:], sampleRate*blockAlign)
+	binary.LittleEndian.PutUint16(buf[32:], blockAlign)
+	binary.LittleEndian.PutUint16(buf[34:], 16)
+	copy(buf[36:], "data")
+	binary.LittleEndian.PutUint32(buf[40:], uint32(dataLen))
+	return buf
+}
+
+// TestGetAllSongs_Totals 测试 total_duration 与 total_size 汇总整个结果集，分页时不随当前页变化。
+func TestGetAllSongs_Totals(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tmpDir := t.TempDir()
+
+	// 10 秒的整轨 WAV 按 cue 拆分为 4 秒与 6 秒两首，另有一首没有时长信息的 MP3。
+	if err := os.WriteFile(filepath.Join(tmpDir, "album.wav"), buildWAV(10), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cue := "FILE \"album.wav\" WAVE\n  TRACK 01 AUDIO\n    INDEX 01 00:00:00\n  TRACK 02 AUDIO\n    INDEX 01 00:04:00\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, "album.cue"), []byte(cue), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "a.mp3"), []byte("fake mp3 data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	scanner := services.NewMusicScanner(tmpDir, []string{".wav", ".mp3"}, 5)
+	router := gin.New()
+	router.GET("/api/songs", NewPlaylistHandler(scanner, nil, nil, &config.Config{}).GetAllSongs)
+
+	type totalsPage struct {
+		Total         int           `json:"total"`
+		TotalDuration int           `json:"total_duration"`
+		TotalSize     int64         `json:"total_size"`
+		Songs         []models.Song `json:"songs"`
+	}
+	fetch := func(query string) totalsPage {
+		req, _ := http.NewRequest("GET", "/api/songs?"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("期望状态码 200, 得到 %d", w.Code)
+		}
+		var page totalsPage
+		if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+			t.Fatalf("解析响应失败: %v", err)
+		}
+		return page
+	}
+
+	all := fetch("")
+	if all.Total != 3 {
+		t.Fatalf("期望 3 首歌曲, 得到 %d", all.Total)
+	}
+	var expectedSize int64
+	for _, song := range all.Songs {
+		expectedSize += song.FileSize
+	}
+	if all.TotalDuration != 10 {
+		t.Errorf("期望 total_duration 为 10, 得到 %d", all.TotalDuration)
+	}
+	if all.TotalSize != expectedSize {
+		t.Errorf("期望 total_size 为 %d, 得到 %d", expectedSize, all.TotalSize)
+	}
+
+	page := fetch("limit=1")
+	if len(page.Songs) != 1 {
+		t.Fatalf("期望当前页 1 首歌曲, 得到 %d", len(page.Songs))
+	}
+	if page.TotalDuration != all.TotalDuration || page.TotalSize != all.TotalSize {
+		t.Errorf("期望分页时汇总与完整结果集一致 (%d 秒, %d 字节), 得到 (%d 秒, %d 字节)",
+			all.TotalDuration, all.TotalSize, page.TotalDuration, page.TotalSize)
+	}
+}
+
+// TestGetAllSongs_DirectoryUnavailable 测试音乐目录消失后继续从缓存提供歌曲列表并返回 X-Stale-Data 头，
+// 没有缓存时返回 503。
+func TestGetAllSongs_DirectoryUnavailable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	musicDir := filepath.Join(t.TempDir(), "music")
+	if err := os.Mkdir(musicDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.mp3", "b.mp3"} {
+		if err := os.WriteFile(filepath.Join(musicDir, name), []byte("fake mp3 "+name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scanner := services.NewMusicScanner(musicDir, []string{".mp3"}, 5)
+	router := gin.New()
+	router.GET("/api/songs", NewPlaylistHandler(scanner, nil, nil, &config.Config{}).GetAllSongs)
+
+	req, _ := http.NewRequest("GET", "/api/songs", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Header().Get("X-Stale-Data") != "" {
+		t.Fatalf("期望目录可用时返回 200 且没有 X-Stale-Data 头, 得到 %d, %q", w.Code, w.Header().Get("X-Stale-Data"))
+	}
+
+	// 模拟外接硬盘被拔出。
+	if err := os.RemoveAll(musicDir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := scanner.Refresh(context.Background()); err == nil {
+		t.Fatal("期望刷新失败")
+	}
+
+	page := fetchSongsPage(t, router, "")
+	if page.Total != 2 {
+		t.Errorf("期望从缓存返回 2 首歌曲, 得到 %d", page.Total)
+	}
+	req, _ = http.NewRequest("GET", "/api/songs", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if got := w.Header().Get("X-Stale-Data"); got != "true" {
+		t.Errorf("期望 X-Stale-Data 为 true, 得到 %q", got)
+	}
+
+	// 没有缓存时返回 503。
+	emptyRouter := gin.New()
+	emptyScanner := services.NewMusicScanner(musicDir, []string{".mp3"}, 5)
+	emptyRouter.GET("/api/songs", NewPlaylistHandler(emptyScanner, nil, nil, &config.Config{}).GetAllSongs)
+	w = httptest.NewRecorder()
+	emptyRouter.ServeHTTP(w, httptest.NewRequest("GET", "/api/songs", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("期望没有缓存时返回 503, 得到 %d", w.Code)
+	}
+}
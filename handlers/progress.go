@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+	"time"
+	"zero-music/logger"
+	"zero-music/middleware"
+	"zero-music/models"
+	"zero-music/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	// validDevicePattern 验证设备/用户标识：1-64 个字母、数字、下划线或连字符。
+	validDevicePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+)
+
+// ProgressHandler 负责处理播放进度相关的 API 请求。
+type ProgressHandler struct {
+	store services.ProgressStore
+}
+
+// NewProgressHandler 创建一个新的 ProgressHandler 实例。
+func NewProgressHandler(store services.ProgressStore) *ProgressHandler {
+	return &ProgressHandler{
+		store: store,
+	}
+}
+
+// progressRequest 是保存播放进度的请求体。
+type progressRequest struct {
+	// PositionSeconds 是播放位置（秒）。
+	PositionSeconds *float64 `json:"position_seconds"`
+	// Device 是设备或用户标识。
+	Device string `json:"device"`
+}
+
+// progressResponse 是播放进度的响应体。
+type progressResponse struct {
+	SongID          string     `json:"song_id"`
+	Device          string     `json:"device"`
+	PositionSeconds float64    `json:"position_seconds"`
+	UpdatedAt       *time.Time `json:"updated_at,omitempty"`
+}
+
+// GetProgress 处理读取播放进度的请求。
+// @Summary 获取播放进度
+// @Description 返回指定设备上指定歌曲的播放位置，没有记录时返回 0
+// @Tags progress
+// @Produce json
+// @Param id path string true "歌曲ID"
+// @Param device query string true "设备/用户标识"
+// @Success 200 {object} progressResponse "成功返回播放进度"
+// @Failure 400 {object} APIError "请求参数错误"
+// @Router /api/progress/{id} [get]
+func (h *ProgressHandler) GetProgress(c *gin.Context) {
+	id := c.Param("id")
+	device := c.Query("device")
+	requestID := middleware.GetRequestID(c)
+
+	if !models.IsValidID(id) {
+		logger.WithRequestID(requestID).Warnf("无效的歌曲 ID 格式: %s", id)
+		RespondError(c, http.StatusBadRequest, NewBadRequestError("无效的歌曲 ID 格式"))
+		return
+	}
+	if !validDevicePattern.MatchString(device) {
+		RespondError(c, http.StatusBadRequest, NewBadRequestError("无效的设备标识"))
+		return
+	}
+
+	response := progressResponse{SongID: id, Device: device}
+	if progress, ok := h.store.Get(id, device); ok {
+		response.PositionSeconds = progress.PositionSeconds
+		response.UpdatedAt = &progress.UpdatedAt
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// SaveProgress 处理保存播放进度的请求。
+// @Summary 保存播放进度
+// @Description 保存指定设备上指定歌曲的播放位置
+// @Tags progress
+// @Accept json
+// @Produce json
+// @Param id path string true "歌曲ID"
+// @Param body body progressRequest true "播放位置与设备标识"
+// @Success 200 {object} progressResponse "成功保存播放进度"
+// @Failure 400 {object} APIError "请求参数错误"
+// @Failure 500 {object} APIError "服务器错误"
+// @Router /api/progress/{id} [put]
+func (h *ProgressHandler) SaveProgress(c *gin.Context) {
+	id := c.Param("id")
+	requestID := middleware.GetRequestID(c)
+
+	if !models.IsValidID(id) {
+		logger.WithRequestID(requestID).Warnf("无效的歌曲 ID 格式: %s", id)
+		RespondError(c, http.StatusBadRequest, NewBadRequestError("无效的歌曲 ID 格式"))
+		return
+	}
+
+	var req progressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, http.StatusBadRequest, NewBadRequestError("无效的请求体"))
+		return
+	}
+	if !validDevicePattern.MatchString(req.Device) {
+		RespondError(c, http.StatusBadRequest, NewBadRequestError("无效的设备标识"))
+		return
+	}
+	if req.PositionSeconds == nil || *req.PositionSeconds < 0 {
+		RespondError(c, http.StatusBadRequest, NewBadRequestError("无效的播放位置"))
+		return
+	}
+
+	progress, err := h.store.Set(id, req.Device, *req.PositionSeconds)
+	if err != nil {
+		logger.WithRequestID(requestID).Errorf("保存播放进度失败: %v", err)
+		RespondError(c, http.StatusInternalServerError, NewInternalError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, progressResponse{
+		SongID:          id,
+		Device:          req.Device,
+		PositionSeconds: progress.PositionSeconds,
+		UpdatedAt:       &progress.UpdatedAt,
+	})
+}
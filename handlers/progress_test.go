@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"zero-music/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testProgressSongID = "0123456789abcdef0123456789abcdef"
+
+// setupProgressTestEnv 初始化一个用于播放进度处理器测试的环境。
+func setupProgressTestEnv(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	store, err := services.NewFileProgressStore(filepath.Join(t.TempDir(), "progress.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	handler := NewProgressHandler(store)
+	router.GET("/api/progress/:id", handler.GetProgress)
+	router.PUT("/api/progress/:id", handler.SaveProgress)
+	return router
+}
+
+// getProgress 是一个辅助函数，读取指定设备上的播放位置。
+func getProgress(t *testing.T, router *gin.Engine, device string) float64 {
+	req, _ := http.NewRequest("GET", "/api/progress/"+testProgressSongID+"?device="+device, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("期望状态码 200, 得到 %d", w.Code)
+	}
+
+	var response progressResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	return response.PositionSeconds
+}
+
+// TestProgress_SaveAndGet 测试写入后读取一致，且不同设备之间互相隔离。
+func TestProgress_SaveAndGet(t *testing.T) {
+	router := setupProgressTestEnv(t)
+
+	// 没有记录时返回 0。
+	if pos := getProgress(t, router, "phone"); pos != 0 {
+		t.Errorf("期望没有记录时位置为 0, 得到 %v", pos)
+	}
+
+	req, _ := http.NewRequest("PUT", "/api/progress/"+testProgressSongID, strings.NewReader(`{"position_seconds": 83.5, "device": "phone"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("期望状态码 200, 得到 %d", w.Code)
+	}
+
+	if pos := getProgress(t, router, "phone"); pos != 83.5 {
+		t.Errorf("期望 phone 的位置为 83.5, 得到 %v", pos)
+	}
+	if pos := getProgress(t, router, "laptop"); pos != 0 {
+		t.Errorf("期望 laptop 的位置为 0, 得到 %v", pos)
+	}
+}
+
+// TestProgress_InvalidInput 测试无效的设备标识、播放位置和歌曲 ID 是否返回 400。
+func TestProgress_InvalidInput(t *testing.T) {
+	router := setupProgressTestEnv(t)
+
+	testCases := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+	}{
+		{"读取时缺少设备标识", "GET", "/api/progress/" + testProgressSongID, ""},
+		{"读取时设备标识非法", "GET", "/api/progress/" + testProgressSongID + "?device=a/b", ""},
+		{"无效的歌曲 ID", "PUT", "/api/progress/abc", `{"position_seconds": 1, "device": "phone"}`},
+		{"缺少播放位置", "PUT", "/api/progress/" + testProgressSongID, `{"device": "phone"}`},
+		{"负数播放位置", "PUT", "/api/progress/" + testProgressSongID, `{"position_seconds": -1, "device": "phone"}`},
+		{"设备标识过长", "PUT", "/api/progress/" + testProgressSongID, `{"position_seconds": 1, "device": "` + strings.Repeat("a", 65) + `"}`},
+		{"无效的 JSON", "PUT", "/api/progress/" + testProgressSongID, `not json`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("期望状态码 400, 得到 %d", w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"zero-music/logger"
+	"zero-music/middleware"
+	"zero-music/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultRadioFormat 是未指定 format 参数时电台使用的音频格式。
+const defaultRadioFormat = ".mp3"
+
+// Radio 处理随机电台请求：在一个长连接中按随机队列连续输出多首歌曲。
+// 目前只支持同一格式的歌曲直接拼接字节，不做转码。
+// @Summary 随机电台
+// @Description 在一个长连接中连续播放随机顺序的同格式歌曲，客户端断开即停止
+// @Tags stream
+// @Produce audio/mpeg
+// @Param format query string false "音频格式（如 mp3、ogg），默认 mp3"
+// @Param seed query int false "随机种子，相同的种子产生相同的播放顺序"
+// @Param loop query bool false "队列播放完后是否重新随机并继续播放"
+// @Success 200 {file} binary "连续的音频流"
+// @Failure 400 {object} APIError "请求参数错误"
+// @Failure 404 {object} APIError "没有该格式的歌曲"
+// @Failure 500 {object} APIError "服务器错误"
+// @Failure 503 {object} APIError "并发流数量已达上限"
+// @Router /api/radio [get]
+func (h *StreamHandler) Radio(c *gin.Context) {
+	requestID := middleware.GetRequestID(c)
+
+	// 电台与普通音频流共用并发流名额。
+	release, ok := h.acquireHTTPStream(c, c.ClientIP())
+	if !ok {
+		return
+	}
+	defer release()
+
+	format := defaultRadioFormat
+	if raw := strings.ToLower(c.Query("format")); raw != "" {
+		format = "." + strings.TrimPrefix(raw, ".")
+	}
+
+	seed := time.Now().UnixNano()
+	if raw := c.Query("seed"); raw != "" {
+		var err error
+		if seed, err = strconv.ParseInt(raw, 10, 64); err != nil {
+			RespondError(c, http.StatusBadRequest, NewBadRequestError("seed 必须是整数"))
+			return
+		}
+	}
+	loop := c.Query("loop") == "true"
+
+	songs, ok := scanSongs(c, h.scanner)
+	if !ok {
+		return
+	}
+
+	// 只选取同一格式且位于音乐目录内的歌曲，异格式拼接需要转码，暂不支持。
+	queue := make([]*models.Song, 0, len(songs))
+	for _, song := range songs {
+		if song.Format != format {
+			continue
+		}
+		if cleanPath, err := filepath.Abs(song.FilePath); err != nil || !strings.HasPrefix(cleanPath, h.musicDirAbs) {
+			continue
+		}
+		queue = append(queue, song)
+	}
+	if len(queue) == 0 {
+		RespondError(c, http.StatusNotFound, NewNotFoundError(strings.TrimPrefix(format, ".")+" 格式的歌曲"))
+		return
+	}
+
+	logger.WithRequestID(requestID).WithFields(map[string]interface{}{
+		"format": format,
+		"seed":   seed,
+		"queue":  len(queue),
+		"loop":   loop,
+	}).Info("电台流请求")
+
+	// 连续流没有固定长度，不可 seek，也不应被缓存；返回种子便于客户端复现播放顺序。
+	c.Header("Content-Type", getMimeType("radio"+format))
+	c.Header("Cache-Control", "no-store")
+	c.Header("Accept-Ranges", "none")
+	c.Header("X-Radio-Seed", strconv.FormatInt(seed, 10))
+	c.Status(http.StatusOK)
+
+	// 与音频流相同，每次写出前延长写超时，长时间不读取数据的客户端会被断开。
+	w := newStreamWriter(c, h.maxRangeSize, h.stallTimeout, h.buffers, requestID)
+	defer w.clearDeadline()
+
+	rng := rand.New(rand.NewSource(seed))
+	ctx := c.Request.Context()
+	for {
+		rng.Shuffle(len(queue), func(i, j int) { queue[i], queue[j] = queue[j], queue[i] })
+		played := 0
+		for _, song := range queue {
+			if ctx.Err() != nil {
+				return
+			}
+			ok, err := h.writeRadioSong(c, w, song)
+			if err != nil {
+				if errors.Is(err, os.ErrDeadlineExceeded) {
+					logger.WithRequestID(requestID).Warnf("客户端超过 %v 没有读取数据，电台流已断开 (已写出 %d 字节)", h.stallTimeout, w.written)
+					return
+				}
+				// 写入失败通常意味着客户端已断开。
+				logger.WithRequestID(requestID).Debugf("电台流结束: %v", err)
+				return
+			}
+			if ok {
+				played++
+			}
+		}
+		// 整轮都没有可播放的歌曲时停止，避免空转。
+		if !loop || played == 0 {
+			return
+		}
+	}
+}
+
+// writeRadioSong 将一首歌曲的完整内容经 w 写入电台流并立即刷新，返回是否播放了该歌曲。
+// 文件无法打开时跳过该歌曲（返回 false, nil），只有写入响应失败时才返回错误。
+func (h *StreamHandler) writeRadioSong(c *gin.Context, w *streamWriter, song *models.Song) (bool, error) {
+	file, err := os.Open(song.FilePath)
+	if err != nil {
+		logger.WithRequestID(middleware.GetRequestID(c)).Warnf("电台跳过无法打开的歌曲 %s: %v", song.FilePath, err)
+		return false, nil
+	}
+	defer file.Close()
+
+	content, _, err := models.OpenTrack(file, song)
+	if err != nil {
+		logger.WithRequestID(middleware.GetRequestID(c)).Warnf("电台跳过无法读取的歌曲 %s: %v", song.FilePath, err)
+		return false, nil
+	}
+	// 隐藏 *os.File 的 WriteTo，使拷贝使用池中的缓冲区而不是 io.Copy 默认的 32KB 缓冲区；
+	// 同时隐藏 streamWriter 的 ReadFrom，每次写出都经过 Write 检查请求是否已取消并延长写超时。
+	if _, err := h.buffers.copy(struct{ io.Writer }{w}, struct{ io.Reader }{content}); err != nil {
+		return true, err
+	}
+	c.Writer.Flush()
+	return true, nil
+}
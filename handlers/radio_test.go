@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"zero-music/config"
+	"zero-music/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupRadioTestEnv 创建包含若干同格式小文件的电台测试环境，返回路由器和各歌曲内容。
+func setupRadioTestEnv(t *testing.T) (*gin.Engine, map[string][]byte) {
+	gin.SetMode(gin.TestMode)
+	tmpDir := t.TempDir()
+
+	contents := map[string][]byte{
+		"a.mp3": bytes.Repeat([]byte("A"), 1000),
+		"b.mp3": bytes.Repeat([]byte("B"), 1500),
+		"c.mp3": bytes.Repeat([]byte("C"), 700),
+	}
+	for name, data := range contents {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 不同格式的歌曲不应出现在 mp3 电台中。
+	if err := os.WriteFile(filepath.Join(tmpDir, "d.ogg"), bytes.Repeat([]byte("D"), 500), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{
+		Music: config.MusicConfig{
+			Directory:        tmpDir,
+			SupportedFormats: []string{".mp3", ".ogg"},
+			CacheTTLMinutes:  5,
+		},
+	}
+	scanner := services.NewMusicScanner(cfg.Music.Directory, cfg.Music.SupportedFormats, cfg.Music.CacheTTLMinutes)
+
+	router := gin.New()
+	router.GET("/api/radio", NewStreamHandler(scanner, cfg).Radio)
+	return router, contents
+}
+
+// TestRadio_ContinuousOutput 测试电台按队列连续输出所有同格式歌曲，且相同种子得到相同顺序。
+func TestRadio_ContinuousOutput(t *testing.T) {
+	router, contents := setupRadioTestEnv(t)
+
+	fetch := func() []byte {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/api/radio?seed=42", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("期望状态码 200, 得到 %d", w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "audio/mpeg" {
+			t.Errorf("期望 Content-Type 为 audio/mpeg, 得到 %s", ct)
+		}
+		if seed := w.Header().Get("X-Radio-Seed"); seed != "42" {
+			t.Errorf("期望 X-Radio-Seed 为 42, 得到 %s", seed)
+		}
+		if ar := w.Header().Get("Accept-Ranges"); ar != "none" {
+			t.Errorf("期望 Accept-Ranges 为 none, 得到 %s", ar)
+		}
+		return w.Body.Bytes()
+	}
+
+	body := fetch()
+	total := 0
+	for _, data := range contents {
+		total += len(data)
+	}
+	if len(body) != total {
+		t.Fatalf("期望输出 %d 字节, 得到 %d", total, len(body))
+	}
+
+	// 每首歌曲都完整且连续地出现一次。
+	var order []string
+	for len(body) > 0 {
+		name := strings.ToLower(string(body[0])) + ".mp3"
+		data, ok := contents[name]
+		if !ok {
+			t.Fatalf("输出中出现了不属于队列的内容 %q", body[0])
+		}
+		if !bytes.HasPrefix(body, data) {
+			t.Fatalf("歌曲 %s 的内容不完整", name)
+		}
+		order = append(order, name)
+		body = body[len(data):]
+	}
+	if len(order) != len(contents) {
+		t.Errorf("期望播放 %d 首歌曲, 得到 %v", len(contents), order)
+	}
+
+	if !bytes.Equal(fetch(), fetch()) {
+		t.Error("期望相同种子产生相同的播放顺序")
+	}
+}
+
+// TestRadio_LoopAcrossSongs 测试循环模式下读取超过一轮队列的数据时跨歌曲连续输出，客户端断开后停止。
+func TestRadio_LoopAcrossSongs(t *testing.T) {
+	router, contents := setupRadioTestEnv(t)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/radio?loop=true&seed=7")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+
+	// 读取两轮多一点的数据。
+	total := 0
+	for _, data := range contents {
+		total += len(data)
+	}
+	buf := make([]byte, total*2+100)
+	if _, err := io.ReadFull(resp.Body, buf); err != nil {
+		t.Fatalf("读取电台流失败: %v", err)
+	}
+	resp.Body.Close()
+
+	// 统计各歌曲出现的字节数：完整的两轮中每首歌都出现两次。
+	counts := map[byte]int{}
+	for _, b := range buf[:total*2] {
+		counts[b]++
+	}
+	for name, data := range contents {
+		key := strings.ToUpper(name[:1])[0]
+		if counts[key] < len(data) {
+			t.Errorf("期望歌曲 %s 在两轮中至少完整出现一次, 得到 %d 字节", name, counts[key])
+		}
+	}
+	if counts['D'] != 0 {
+		t.Error("mp3 电台中不应出现 ogg 歌曲")
+	}
+}
+
+// TestRadio_NoSongs 测试没有指定格式的歌曲时返回 404，非法种子返回 400。
+func TestRadio_NoSongs(t *testing.T) {
+	router, _ := setupRadioTestEnv(t)
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"format=flac", http.StatusNotFound},
+		{"seed=abc", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/api/radio?"+tt.query, nil))
+		if w.Code != tt.want {
+			t.Errorf("对于 %s，期望状态码 %d, 得到 %d", tt.query, tt.want, w.Code)
+		}
+	}
+}
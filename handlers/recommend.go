@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"time"
+	"zero-music/models"
+	"zero-music/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// defaultRecommendLimit 是未指定 limit 参数时返回的推荐歌曲数量。
+	defaultRecommendLimit = 20
+	// favoriteArtistWeight 是每首收藏（置顶）歌曲为其艺术家贡献的权重，高于一次播放记录。
+	favoriteArtistWeight = 2
+)
+
+// unknownGroupKey 是缺省元数据 "Unknown" 的规范化键，不参与推荐打分。
+var unknownGroupKey = models.GroupKey("Unknown")
+
+// RecommendHandler 负责根据播放记录与收藏生成推荐歌曲。
+type RecommendHandler struct {
+	scanner  services.Scanner
+	progress services.ProgressStore // 播放记录，为 nil 时视为没有历史。
+	pins     services.PinStore      // 收藏（置顶）标记，为 nil 时视为没有收藏。
+}
+
+// NewRecommendHandler 创建一个新的 RecommendHandler 实例。progress 与 pins 可以为 nil。
+func NewRecommendHandler(scanner services.Scanner, progress services.ProgressStore, pins services.PinStore) *RecommendHandler {
+	return &RecommendHandler{
+		scanner:  scanner,
+		progress: progress,
+		pins:     pins,
+	}
+}
+
+// Recommend 返回推荐歌曲列表。
+// 推荐在内存中基于歌曲缓存、播放记录与收藏计算：常播艺术家与专辑中尚未播放的歌曲、
+// 收藏艺术家的其他歌曲得分更高；得分相同或没有历史数据时按最近添加排序。
+// @Summary 获取推荐歌曲
+// @Description 根据播放记录与收藏推荐尚未播放的歌曲，没有历史数据时返回最近添加的歌曲
+// @Tags playlist
+// @Produce json
+// @Param limit query int false "返回数量（1-1000），默认 20"
+// @Param time_format query string false "时间字段格式：rfc3339 或 unix_ms，默认使用服务器配置" Enums(rfc3339, unix_ms)
+// @Success 200 {object} map[string]interface{} "推荐歌曲列表，fallback 表示没有基于历史的推荐"
+// @Failure 400 {object} APIError "请求参数错误"
+// @Failure 500 {object} APIError "服务器错误"
+// @Router /api/recommend [get]
+func (h *RecommendHandler) Recommend(c *gin.Context) {
+	limit, err := parseLimit(c.Query("limit"))
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, NewBadRequestError(err.Error()))
+		return
+	}
+	if limit == 0 {
+		limit = defaultRecommendLimit
+	}
+
+	songs, ok := scanSongs(c, h.scanner)
+	if !ok {
+		return
+	}
+
+	var played map[string]time.Time
+	if h.progress != nil {
+		played = h.progress.Played()
+	}
+	var pinned map[string]int
+	if h.pins != nil {
+		pinned = h.pins.Weights()
+	}
+
+	recommended, fallback := recommendSongs(songs, played, pinned, limit)
+	c.JSON(http.StatusOK, gin.H{
+		"total":    len(recommended),
+		"fallback": fallback,
+		"songs":    songsJSON(c, recommended),
+	})
+}
+
+// recommendSongs 从未播放且未收藏的歌曲中选出至多 limit 首推荐歌曲。
+// 每首播放过的歌曲为其艺术家和专辑各加 1 分，每首收藏的歌曲为其艺术家加 favoriteArtistWeight 分，
+// 候选歌曲的得分为所属艺术家与专辑的分数之和。得分为正的歌曲不足 limit 首时用最近添加的歌曲补足；
+// 没有任何得分为正的歌曲时 fallback 为 true。
+func recommendSongs(songs []*models.Song, played map[string]time.Time, pinned map[string]int, limit int) ([]*models.Song, bool) {
+	artistScores := make(map[string]int)
+	albumScores := make(map[string]int)
+	for _, song := range songs {
+		artist, album := models.GroupKey(song.Artist), models.GroupKey(song.Album)
+		if _, ok := played[song.ID]; ok {
+			artistScores[artist]++
+			albumScores[album]++
+		}
+		if _, ok := pinned[song.ID]; ok {
+			artistScores[artist] += favoriteArtistWeight
+		}
+	}
+	delete(artistScores, unknownGroupKey)
+	delete(albumScores, unknownGroupKey)
+
+	type candidate struct {
+		song  *models.Song
+		score int
+	}
+	candidates := make([]candidate, 0, len(songs))
+	for _, song := range songs {
+		if _, ok := played[song.ID]; ok {
+			continue
+		}
+		if _, ok := pinned[song.ID]; ok {
+			continue
+		}
+		score := artistScores[models.GroupKey(song.Artist)] + albumScores[models.GroupKey(song.Album)]
+		candidates = append(candidates, candidate{song: song, score: score})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		if !a.song.AddedAt.Equal(b.song.AddedAt) {
+			return a.song.AddedAt.After(b.song.AddedAt)
+		}
+		return a.song.ID < b.song.ID
+	})
+
+	result := make([]*models.Song, 0, min(limit, len(candidates)))
+	for _, c := range candidates[:min(limit, len(candidates))] {
+		result = append(result, c.song)
+	}
+	fallback := len(candidates) == 0 || candidates[0].score == 0
+	return result, fallback
+}
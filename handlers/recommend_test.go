@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"zero-music/models"
+	"zero-music/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// buildMP3WithArtistAlbum 构造一个带有 ID3v2.3 艺术家（TPE1）与专辑（TALB）标签的 MP3 文件内容。
+func buildMP3WithArtistAlbum(artist, album string) string {
+	return string(buildMP3WithFrames(
+		buildID3Frame("TPE1", append([]byte{0x00}, artist...)),
+		buildID3Frame("TALB", append([]byte{0x00}, album...)),
+	))
+}
+
+// recommendResponse 是 /api/recommend 的响应结构。
+type recommendResponse struct {
+	Total    int            `json:"total"`
+	Fallback bool           `json:"fallback"`
+	Songs    []*models.Song `json:"songs"`
+}
+
+// setupRecommendTestEnv 创建推荐测试环境，返回路由、文件名到歌曲 ID 的映射以及播放记录与收藏存储。
+func setupRecommendTestEnv(t *testing.T) (*gin.Engine, map[string]string, *services.FileProgressStore, *services.FilePinStore) {
+	t.Helper()
+	_, scanner := newTestLibrary(t, map[string]string{
+		"a1.mp3": buildMP3WithArtistAlbum("Artist A", "Album X"),
+		"a2.mp3": buildMP3WithArtistAlbum("Artist A", "Album Y"),
+		"b1.mp3": buildMP3WithArtistAlbum("Artist B", "Album Z"),
+		"b2.mp3": buildMP3WithArtistAlbum("Artist B", "Album W"),
+		"c1.mp3": buildMP3WithArtistAlbum("Artist C", "Album V"),
+	}, nil)
+	songs, err := scanner.Scan(context.Background())
+	if err != nil {
+		t.Fatalf("扫描失败: %v", err)
+	}
+	ids := make(map[string]string, len(songs))
+	for _, song := range songs {
+		ids[filepath.Base(song.FilePath)] = song.ID
+	}
+
+	dataDir := t.TempDir()
+	progress, err := services.NewFileProgressStore(filepath.Join(dataDir, "progress.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pins, err := services.NewFilePinStore(filepath.Join(dataDir, "pins.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	router.GET("/api/recommend", NewRecommendHandler(scanner, progress, pins).Recommend)
+	return router, ids, progress, pins
+}
+
+// getRecommend 请求推荐列表并解析响应。
+func getRecommend(t *testing.T, router *gin.Engine, query string) recommendResponse {
+	t.Helper()
+	req, _ := http.NewRequest("GET", "/api/recommend"+query, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("期望状态码 200, 得到 %d: %s", w.Code, w.Body.String())
+	}
+	var resp recommendResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	return resp
+}
+
+// TestRecommend 测试有播放记录与收藏时推荐同艺术家的其他歌曲，且不包含已播放与已收藏的歌曲。
+func TestRecommend(t *testing.T) {
+	router, ids, progress, pins := setupRecommendTestEnv(t)
+	if _, err := progress.Set(ids["a1.mp3"], "phone", 30); err != nil {
+		t.Fatal(err)
+	}
+	if err := pins.Pin(ids["b1.mp3"], 1); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := getRecommend(t, router, "")
+	if resp.Fallback {
+		t.Error("期望有历史数据时 fallback 为 false")
+	}
+	// 收藏艺术家 B 的权重 (2) 高于播放过一次的艺术家 A (1)，无关的艺术家 C 排在最后。
+	expected := []string{ids["b2.mp3"], ids["a2.mp3"], ids["c1.mp3"]}
+	if resp.Total != len(expected) {
+		t.Fatalf("期望推荐 %d 首歌曲, 得到 %d", len(expected), resp.Total)
+	}
+	for i, id := range expected {
+		if resp.Songs[i].ID != id {
+			t.Errorf("第 %d 首期望 %s, 得到 %s (%s)", i, id, resp.Songs[i].ID, resp.Songs[i].Artist)
+		}
+	}
+
+	resp = getRecommend(t, router, "?limit=1")
+	if resp.Total != 1 || resp.Songs[0].ID != ids["b2.mp3"] {
+		t.Errorf("期望 limit=1 时只返回得分最高的歌曲, 得到 %+v", resp.Songs)
+	}
+}
+
+// TestRecommend_Fallback 测试没有历史数据时回退到最近添加的歌曲，以及无效的 limit 参数。
+func TestRecommend_Fallback(t *testing.T) {
+	router, _, _, _ := setupRecommendTestEnv(t)
+
+	resp := getRecommend(t, router, "?limit=3")
+	if !resp.Fallback {
+		t.Error("期望没有历史数据时 fallback 为 true")
+	}
+	if resp.Total != 3 {
+		t.Errorf("期望返回 3 首歌曲, 得到 %d", resp.Total)
+	}
+	for i := 1; i < len(resp.Songs); i++ {
+		if resp.Songs[i].AddedAt.After(resp.Songs[i-1].AddedAt) {
+			t.Errorf("期望按最近添加排序, 第 %d 首晚于第 %d 首", i, i-1)
+		}
+	}
+
+	req, _ := http.NewRequest("GET", "/api/recommend?limit=abc", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("期望无效的 limit 返回 400, 得到 %d", w.Code)
+	}
+}
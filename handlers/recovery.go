@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"zero-music/logger"
+	"zero-music/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Recovery 是一个 Gin 中间件，用于捕获 handler 中的 panic。
+// 它会连同请求 ID 记录 panic 信息和调用栈，并与其他错误一样通过 RespondError 返回 500：
+// 带有请求 ID，按 Accept-Language 本地化，并支持纯文本格式。
+// 必须注册在 RequestID 中间件之后。
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.WithRequestID(middleware.GetRequestID(c)).WithFields(map[string]interface{}{
+					"method": c.Request.Method,
+					"path":   c.Request.URL.Path,
+					"panic":  r,
+					"stack":  string(debug.Stack()),
+				}).Error("请求处理时发生 panic")
+
+				// 如果响应已经开始写出，则无法再返回错误体。
+				if c.Writer.Written() {
+					c.Abort()
+					return
+				}
+				RespondError(c, http.StatusInternalServerError, NewInternalError(fmt.Errorf("panic: %v", r)))
+				c.Abort()
+			}
+		}()
+
+		c.Next()
+	}
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"zero-music/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestRecovery 测试 panic 与其他错误一样通过 RespondError 返回 500：
+// 带有请求 ID，按 Accept-Language 本地化，并遵循纯文本 Accept。
+func TestRecovery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.RequestID())
+	router.Use(Recovery())
+	router.GET("/panic", func(c *gin.Context) {
+		panic("测试 panic")
+	})
+
+	t.Run("JSON 本地化", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/panic", nil)
+		req.Header.Set("Accept-Language", "en")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusInternalServerError {
+			t.Fatalf("期望状态码 500, 得到 %d", w.Code)
+		}
+
+		var apiErr APIError
+		if err := json.Unmarshal(w.Body.Bytes(), &apiErr); err != nil {
+			t.Fatalf("解析响应失败: %v", err)
+		}
+		if apiErr.Code != "INTERNAL_ERROR" || apiErr.RequestID == "" {
+			t.Errorf("期望带有请求 ID 的 INTERNAL_ERROR 错误, 得到 %+v", apiErr)
+		}
+		if apiErr.Message != "Internal server error" {
+			t.Errorf("期望英文错误消息, 得到 %q", apiErr.Message)
+		}
+	})
+
+	t.Run("纯文本", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/panic", nil)
+		req.Header.Set("Accept", "text/plain")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusInternalServerError {
+			t.Fatalf("期望状态码 500, 得到 %d", w.Code)
+		}
+		if body := strings.TrimSpace(w.Body.String()); body != "[INTERNAL_ERROR] 内部服务器错误" {
+			t.Errorf("期望纯文本错误为 '[INTERNAL_ERROR] 内部服务器错误', 得到 %q", body)
+		}
+	})
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"zero-music/logger"
+	"zero-music/middleware"
+	"zero-music/models"
+	"zero-music/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// scanSongs 扫描音乐目录并返回歌曲列表，失败时写出错误响应并返回 false。
+// 音乐目录暂时不可用（如外接硬盘被拔出）时，继续使用上次成功扫描的缓存并设置 X-Stale-Data: true，
+// 没有缓存时返回 503；其他错误返回 500。
+func scanSongs(c *gin.Context, scanner services.Scanner) ([]*models.Song, bool) {
+	requestID := middleware.GetRequestID(c)
+	songs, stale, err := services.ScanOrCached(c.Request.Context(), scanner)
+	if err == nil {
+		if stale {
+			logger.WithRequestID(requestID).Warnf("音乐目录暂时不可用，使用缓存的 %d 首歌曲", len(songs))
+			c.Header("X-Stale-Data", "true")
+		}
+		return songs, true
+	}
+
+	if errors.Is(err, services.ErrDirectoryUnavailable) {
+		logger.WithRequestID(requestID).Warnf("音乐目录暂时不可用且没有缓存: %v", err)
+		RespondError(c, http.StatusServiceUnavailable, NewServiceUnavailableError("音乐目录暂时不可用，请稍后重试"))
+		return nil, false
+	}
+
+	logger.WithRequestID(requestID).Errorf("扫描音乐文件失败: %v", err)
+	RespondError(c, http.StatusInternalServerError, NewInternalError(err))
+	return nil, false
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"zero-music/config"
+	"zero-music/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestLibrary 在临时目录中写入给定的音乐文件（文件名 -> 内容），并返回指向该目录的配置与扫描器。
+// modify 不为 nil 时在创建扫描器前调用，用于调整配置。
+// 播放列表与音频流测试共用此函数构造扫描器，避免各自的 setup 随构造器变更而漂移。
+func newTestLibrary(t *testing.T, files map[string]string, modify func(cfg *config.Config)) (*config.Config, *services.MusicScanner) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	tmpDir := t.TempDir()
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &config.Config{
+		Music: config.MusicConfig{
+			Directory:        tmpDir,
+			SupportedFormats: []string{".mp3"},
+			CacheTTLMinutes:  5,
+		},
+	}
+	if modify != nil {
+		modify(cfg)
+	}
+
+	scanner := services.NewMusicScanner(
+		cfg.Music.Directory,
+		cfg.Music.SupportedFormats,
+		cfg.Music.CacheTTLMinutes,
+	)
+	return cfg, scanner
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// spaIndex 是单页应用的入口文件，未匹配到静态资源的前端路由都回退到它。
+const spaIndex = "index.html"
+
+// StaticHandler 负责提供内置网页播放器的静态资源。
+type StaticHandler struct {
+	files fs.FS
+}
+
+// NewStaticHandler 创建一个新的 StaticHandler 实例。files 的根目录下应包含 index.html。
+func NewStaticHandler(files fs.FS) *StaticHandler {
+	return &StaticHandler{
+		files: files,
+	}
+}
+
+// Serve 提供静态文件，找不到对应文件时回退到 index.html 以支持前端路由。
+// 它注册为 NoRoute 处理器，因此 /api/ 与 /admin/ 下未匹配的路径仍返回 JSON 格式的 404。
+func (h *StaticHandler) Serve(c *gin.Context) {
+	urlPath := c.Request.URL.Path
+	isAPI := strings.HasPrefix(urlPath, "/api/") || strings.HasPrefix(urlPath, "/admin/")
+	if isAPI || (c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead) {
+		RespondError(c, http.StatusNotFound, NewNotFoundError("接口"))
+		return
+	}
+
+	name := strings.TrimPrefix(path.Clean(urlPath), "/")
+	if name == "" {
+		name = spaIndex
+	}
+	if info, err := fs.Stat(h.files, name); err != nil || info.IsDir() {
+		name = spaIndex
+	}
+
+	// http.ServeFileFS 会把以 /index.html 结尾的请求重定向到目录，回退时直接以文件内容响应。
+	if name == spaIndex && !strings.HasSuffix(urlPath, "/"+spaIndex) {
+		data, err := fs.ReadFile(h.files, spaIndex)
+		if err != nil {
+			RespondError(c, http.StatusNotFound, NewNotFoundError("页面"))
+			return
+		}
+		c.Header("Cache-Control", "no-cache")
+		c.Data(http.StatusOK, "text/html; charset=utf-8", data)
+		return
+	}
+	http.ServeFileFS(c.Writer, c.Request, h.files, name)
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestStaticHandler_Serve 测试静态资源的 Content-Type、前端路由回退以及 API 路径的 404。
+func TestStaticHandler_Serve(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	files := fstest.MapFS{
+		"index.html":      {Data: []byte("<!DOCTYPE html><title>Zero Music</title>")},
+		"app.js":          {Data: []byte("console.log('zero music');")},
+		"app.css":         {Data: []byte("body { margin: 0; }")},
+		"assets/logo.svg": {Data: []byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>")},
+	}
+	router := gin.New()
+	router.NoRoute(NewStaticHandler(files).Serve)
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		wantStatus  int
+		wantType    string
+		wantContain string
+	}{
+		{"根路径返回 index.html", "GET", "/", http.StatusOK, "text/html", "Zero Music"},
+		{"JavaScript", "GET", "/app.js", http.StatusOK, "text/javascript", "zero music"},
+		{"CSS", "GET", "/app.css", http.StatusOK, "text/css", "margin"},
+		{"子目录资源", "GET", "/assets/logo.svg", http.StatusOK, "image/svg+xml", "svg"},
+		{"前端路由回退", "GET", "/album/123", http.StatusOK, "text/html", "Zero Music"},
+		{"未知 API 返回 JSON 404", "GET", "/api/unknown", http.StatusNotFound, "application/json", "NOT_FOUND"},
+		{"非 GET 请求返回 404", "POST", "/app.js", http.StatusNotFound, "application/json", "NOT_FOUND"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("期望状态码 %d, 得到 %d", tt.wantStatus, w.Code)
+			}
+			if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, tt.wantType) {
+				t.Errorf("期望 Content-Type 为 %s, 得到 %s", tt.wantType, contentType)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantContain) {
+				t.Errorf("期望响应包含 %q, 得到 %s", tt.wantContain, w.Body.String())
+			}
+		})
+	}
+}
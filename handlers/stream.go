@@ -1,15 +1,18 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"io"
-	"mime"
 	"net/http"
+	"net/url"
 	"os"
+	"os/exec"
 	"path/filepath"
-	"regexp"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 	"zero-music/config"
 	"zero-music/logger"
 	"zero-music/middleware"
@@ -19,43 +22,143 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-var (
-	// validIDPatternStream 验证歌曲 ID 是否为有效的 SHA256 哈希（32 字节十六进制）
-	validIDPatternStream = regexp.MustCompile(models.ValidIDPattern())
-)
-
 // getMimeType 根据文件扩展名返回对应的 MIME 类型。
 func getMimeType(filename string) string {
-	ext := strings.ToLower(filepath.Ext(filename))
-	mimeType := mime.TypeByExtension(ext)
-	if mimeType == "" {
-		// 为常见音频格式提供备选 MIME 类型
-		switch ext {
-		case ".mp3":
-			return "audio/mpeg"
-		case ".flac":
-			return "audio/flac"
-		case ".wav":
-			return "audio/wav"
-		case ".m4a":
-			return "audio/mp4"
-		case ".ogg":
-			return "audio/ogg"
-		default:
-			return "application/octet-stream"
+	return models.MIMEType(filename)
+}
+
+// fileETag 根据文件大小和修改时间生成强 ETag。
+// 文件内容变化时这两者通常也会变化，足以用于断点续传的一致性判断。
+func fileETag(info os.FileInfo) string {
+	return fmt.Sprintf("\"%x-%x\"", info.Size(), info.ModTime().UnixNano())
+}
+
+// songETag 生成歌曲音频内容的 ETag。cue 虚拟歌曲共享同一源文件，因此额外加入起始时间加以区分。
+func songETag(info os.FileInfo, song *models.Song) string {
+	if !song.IsCueTrack() {
+		return fileETag(info)
+	}
+	return fmt.Sprintf("\"%x-%x-%x\"", info.Size(), info.ModTime().UnixNano(), song.StartMS)
+}
+
+// weakSongETag 生成歌曲音频内容的弱 ETag，用于会改写响应内容的代理之后。
+func weakSongETag(info os.FileInfo, song *models.Song) string {
+	return "W/" + songETag(info, song)
+}
+
+// etagMatches 按 If-Match 的强比较规则判断 ETag 列表 ifMatch 是否匹配 etag，"*" 匹配任意资源。
+// 弱 ETag（W/ 前缀）在强比较中永不匹配；etag 为空（无法计算 ETag）时只有 "*" 能匹配。
+func etagMatches(ifMatch, etag string) bool {
+	strong := etag != "" && !strings.HasPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || (strong && candidate == etag) {
+			return true
 		}
 	}
-	return mimeType
+	return false
+}
+
+// normalizeRangeHeader 校验并规范化请求的 Range 头。
+// 按 RFC 9110，服务端应忽略无法识别的范围单位，因此单位不是 bytes 的 Range 头会被移除，
+// 从而返回完整的 200 响应；单位两侧和范围前的多余空格会被去除。
+// 没有 "=" 分隔符的 Range 头保持原样，由 http.ServeContent 返回 416。
+func normalizeRangeHeader(r *http.Request) {
+	rangeHeader := strings.TrimSpace(r.Header.Get("Range"))
+	if rangeHeader == "" {
+		return
+	}
+	unit, spec, ok := strings.Cut(rangeHeader, "=")
+	if !ok {
+		return
+	}
+	if !strings.EqualFold(strings.TrimSpace(unit), "bytes") {
+		r.Header.Del("Range")
+		return
+	}
+	r.Header.Set("Range", "bytes="+strings.TrimSpace(spec))
+}
+
+// truncateRangeHeader 将超过 maxRangeSize 的单段 Range 请求截断为从起点开始的 maxRangeSize 字节。
+// 开放区间（如 "bytes=0-"）表示"从起点读到结尾"，客户端可以根据返回的 Content-Range 继续续传，
+// 因此总是截断；显式给出长度的区间（闭区间与后缀范围）只在 explicit 为 true 时截断，否则保持原样由范围校验拒绝。
+// 多段范围与无法满足的范围保持原样，分别由范围校验与 http.ServeContent 处理。
+func truncateRangeHeader(r *http.Request, size, maxRangeSize int64, explicit bool) {
+	spec, ok := strings.CutPrefix(r.Header.Get("Range"), "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return
+	}
+	startStr, endStr, ok := strings.Cut(spec, "-")
+	if !ok {
+		return
+	}
+	startStr, endStr = strings.TrimSpace(startStr), strings.TrimSpace(endStr)
+	if openEnded := startStr != "" && endStr == ""; !openEnded && !explicit {
+		return
+	}
+
+	var start, end int64
+	if startStr == "" {
+		// 后缀范围 "-N" 表示最后 N 个字节。
+		n, err := strconv.ParseInt(endStr, 10, 64)
+		if err != nil || n <= 0 {
+			return
+		}
+		start, end = max(size-n, 0), size-1
+	} else {
+		var err error
+		if start, err = strconv.ParseInt(startStr, 10, 64); err != nil || start < 0 || start >= size {
+			return
+		}
+		end = size - 1
+		if endStr != "" {
+			e, err := strconv.ParseInt(endStr, 10, 64)
+			if err != nil || e < start {
+				return
+			}
+			end = min(e, size-1)
+		}
+	}
+
+	if end-start+1 > maxRangeSize {
+		r.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, start+maxRangeSize-1))
+	}
 }
 
 // StreamHandler 负责处理音频流相关的 API 请求。
 type StreamHandler struct {
-	scanner      services.Scanner
-	musicDir     string
-	musicDirAbs  string // 预先计算的音乐目录绝对路径，用于安全检查。
-	maxRangeSize int64  // 单次 Range 请求允许的最大字节数。
+	scanner  services.Scanner
+	musicDir string
+	// supportedFormats 是配置中支持的音频文件扩展名。
+	supportedFormats []string
+	musicDirAbs      string // 预先计算的音乐目录绝对路径，用于安全检查。
+	maxRangeSize     int64  // 单次 Range 请求允许的最大字节数。
+	// truncateRanges 为 true 时将超过 maxRangeSize 的 Range 请求截断到上限，否则拒绝。
+	truncateRanges bool
+	// streamSlots 是限制并发流数量的信号量，为 nil 时表示不限制。
+	streamSlots chan struct{}
+	// ipStreams 限制单个客户端 IP 的并发流数量，为 nil 时表示不限制。
+	ipStreams *ipStreamLimiter
+	// activeStreams 是当前正在处理的流请求数量，不受是否配置并发上限影响。
+	activeStreams atomic.Int64
+	// stallTimeout 是写出没有进展时断开慢速客户端的超时时间，0 表示不检测。
+	stallTimeout time.Duration
+	// buffers 是音频流传输时复用的读取缓冲区。
+	buffers *streamBufferPool
+	// cacheControl 是成功响应的 Cache-Control 头，为空时不设置。
+	cacheControl string
+	// ffmpegPath 是用于转码的 ffmpeg 可执行文件路径，为空时表示转码不可用。
+	ffmpegPath string
+	// etag 计算歌曲音频内容的 ETag，默认为 songETag，配置 WeakETag 时为 weakSongETag。返回空字符串表示无法计算 ETag
+	// （如远程存储后端），此时不设置 ETag 头，条件请求退回到 Last-Modified 比较。
+	etag func(info os.FileInfo, song *models.Song) string
+	// xAccelPrefix 是 nginx internal location 的路径前缀（不含末尾斜杠），非空时由 nginx 发送文件。
+	xAccelPrefix string
 }
 
+// streamRetryAfterSeconds 是并发流达到上限时建议客户端等待的秒数。
+const streamRetryAfterSeconds = 5
+
 // NewStreamHandler 创建一个新的 StreamHandler 实例。
 func NewStreamHandler(scanner services.Scanner, cfg *config.Config) *StreamHandler {
 	musicDirAbs, err := filepath.Abs(cfg.Music.Directory)
@@ -63,18 +166,107 @@ func NewStreamHandler(scanner services.Scanner, cfg *config.Config) *StreamHandl
 		logger.Warnf("获取音乐目录的绝对路径失败: %v", err)
 		musicDirAbs = cfg.Music.Directory
 	}
-	return &StreamHandler{
-		scanner:      scanner,
-		musicDir:     cfg.Music.Directory,
-		musicDirAbs:  musicDirAbs,
-		maxRangeSize: cfg.Server.MaxRangeSize,
+	h := &StreamHandler{
+		scanner:          scanner,
+		musicDir:         cfg.Music.Directory,
+		supportedFormats: cfg.Music.SupportedFormats,
+		musicDirAbs:      musicDirAbs,
+		maxRangeSize:     cfg.Server.MaxRangeSize,
+		cacheControl:     cfg.Server.StreamCacheControl,
+		stallTimeout:     time.Duration(cfg.Server.StreamStallTimeoutSeconds) * time.Second,
+		buffers:          newStreamBufferPool(cfg.Server.StreamBufferSize),
+		truncateRanges:   cfg.Server.RangeOverLimitBehavior == "truncate",
+		ipStreams:        newIPStreamLimiter(cfg.Server.MaxStreamsPerIP),
+		etag:             songETag,
+		xAccelPrefix:     strings.TrimRight(cfg.Server.XAccelRedirectPrefix, "/"),
+	}
+	if cfg.Server.WeakETag {
+		h.etag = weakSongETag
+	}
+	if cfg.Server.MaxConcurrentStreams > 0 {
+		h.streamSlots = make(chan struct{}, cfg.Server.MaxConcurrentStreams)
+	}
+	if ffmpegPath, err := exec.LookPath("ffmpeg"); err == nil {
+		h.ffmpegPath = ffmpegPath
+	} else {
+		logger.Debugf("未找到 ffmpeg，不可用的格式将返回 406: %v", err)
+	}
+	return h
+}
+
+// acquireStream 尝试占用一个并发流名额，成功时返回 true。
+// 未配置并发上限时总是成功。
+func (h *StreamHandler) acquireStream() bool {
+	if h.streamSlots == nil {
+		return true
+	}
+	select {
+	case h.streamSlots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// releaseStream 释放一个由 acquireStream 占用的并发流名额。
+func (h *StreamHandler) releaseStream() {
+	if h.streamSlots != nil {
+		<-h.streamSlots
+	}
+}
+
+// ActiveStreams 返回当前正在处理的流请求数量，可以并发调用。
+func (h *StreamHandler) ActiveStreams() int64 {
+	return h.activeStreams.Load()
+}
+
+// ErrTooManyClientStreams 表示单个客户端 IP 的并发流数量已达上限。
+var ErrTooManyClientStreams = errors.New("当前客户端的并发流数量已达上限")
+
+// ErrTooManyStreams 表示并发流总数已达上限。
+var ErrTooManyStreams = errors.New("并发流数量已达上限")
+
+// AcquireStream 为 clientIP 占用单 IP 名额与全局并发流名额，并计入活动流数量，成功时返回释放函数。
+// HTTP 音频流、电台与 gRPC 音频流共用同一组上限，任何入口都无法绕过。
+// 超过单 IP 上限时返回 ErrTooManyClientStreams，超过总上限时返回 ErrTooManyStreams。
+func (h *StreamHandler) AcquireStream(clientIP string) (func(), error) {
+	if !h.ipStreams.acquire(clientIP) {
+		return nil, ErrTooManyClientStreams
+	}
+	if !h.acquireStream() {
+		h.ipStreams.release(clientIP)
+		return nil, ErrTooManyStreams
+	}
+	h.activeStreams.Add(1)
+	return func() {
+		h.activeStreams.Add(-1)
+		h.releaseStream()
+		h.ipStreams.release(clientIP)
+	}, nil
+}
+
+// acquireHTTPStream 通过 AcquireStream 为客户端占用流名额，超过单 IP 上限时返回 429，超过总上限时返回 503，
+// 失败时返回 false。成功时调用方必须在请求结束后调用返回的释放函数。
+func (h *StreamHandler) acquireHTTPStream(c *gin.Context, clientIP string) (func(), bool) {
+	release, err := h.AcquireStream(clientIP)
+	if err == nil {
+		return release, true
+	}
+	c.Header("Retry-After", strconv.Itoa(streamRetryAfterSeconds))
+	if errors.Is(err, ErrTooManyClientStreams) {
+		logger.WithRequestID(middleware.GetRequestID(c)).Warnf("客户端 %s 的并发流数量已达上限 (%d)", clientIP, h.ipStreams.max)
+		RespondError(c, http.StatusTooManyRequests, NewTooManyRequestsError("当前客户端的并发流数量已达上限，请稍后重试"))
+		return nil, false
 	}
+	logger.WithRequestID(middleware.GetRequestID(c)).Warnf("并发流数量已达上限 (%d)", cap(h.streamSlots))
+	RespondError(c, http.StatusServiceUnavailable, NewServiceUnavailableError("并发流数量已达上限，请稍后重试"))
+	return nil, false
 }
 
 // StreamAudio 处理流式传输音频文件的请求。
 // 它支持完整的音频文件传输和基于 Range 请求的部分内容传输。
 // @Summary 流式传输音频
-// @Description 通过 HTTP 流式传输指定的音频文件
+// @Description 通过 HTTP 流式传输指定的音频文件。源格式不被 Accept 接受且 ffmpeg 可用时，转码为 Accept 中首个支持的格式（audio/mpeg、audio/ogg、audio/flac、audio/wav）
 // @Tags stream
 // @Produce audio/mpeg
 // @Param id path string true "歌曲ID"
@@ -83,187 +275,194 @@ func NewStreamHandler(scanner services.Scanner, cfg *config.Config) *StreamHandl
 // @Failure 400 {object} APIError "请求参数错误"
 // @Failure 403 {object} APIError "禁止访问"
 // @Failure 404 {object} APIError "文件未找到"
+// @Failure 406 {object} APIError "没有客户端可接受的音频格式"
+// @Failure 412 {object} APIError "If-Match 与当前 ETag 不符"
+// @Failure 416 {object} APIError "请求范围无法满足"
+// @Failure 429 {object} APIError "当前客户端的并发流数量已达上限"
 // @Failure 500 {object} APIError "服务器错误"
+// @Failure 503 {object} APIError "并发流数量已达上限"
 // @Router /api/stream/{id} [get]
 func (h *StreamHandler) StreamAudio(c *gin.Context) {
 	id := c.Param("id")
 	requestID := middleware.GetRequestID(c)
 
-	// 验证 ID 格式，确保是有效的 SHA256 哈希格式，防止路径遍历攻击。
-	if !validIDPatternStream.MatchString(id) {
-		logger.WithRequestID(requestID).Warnf("无效的歌曲 ID 格式: %s", id)
-		c.JSON(http.StatusBadRequest, NewBadRequestError("无效的歌曲 ID 格式"))
+	// 先拒绝格式无效的 ID，避免无效请求占用流名额或被计为 503/429。
+	if !models.IsValidID(id) {
+		respondOpenSongError(c, id, ErrInvalidSongID)
 		return
 	}
 
-	// 扫描音乐文件以验证歌曲是否存在。
-	songs, err := h.scanner.Scan(c.Request.Context())
-	if err != nil {
-		logger.WithRequestID(requestID).Errorf("扫描音乐文件失败: %v", err)
-		c.JSON(http.StatusInternalServerError, NewInternalError(err))
+	// 限制同时进行的流数量，名额在请求结束（包括客户端断开和 panic）时通过 defer 释放。
+	clientIP := c.ClientIP()
+	release, ok := h.acquireHTTPStream(c, clientIP)
+	if !ok {
 		return
 	}
+	defer release()
 
-	// 查找歌曲并获取其文件路径。
-	var songPath string
-	found := false
-	for _, song := range songs {
-		if song.ID == id {
-			songPath = song.FilePath
-			found = true
-			break
-		}
-	}
-
-	if !found {
-		logger.WithRequestID(requestID).Warnf("歌曲未找到: %s", id)
-		c.JSON(http.StatusNotFound, NewNotFoundError("歌曲"))
+	// 查找并打开歌曲，ID 格式与文件路径的安全检查与 gRPC 共用。
+	src, err := h.OpenSong(c.Request.Context(), id)
+	if err != nil {
+		respondOpenSongError(c, id, err)
 		return
 	}
+	defer src.Close()
+	if src.Stale {
+		logger.WithRequestID(requestID).Warn("音乐目录暂时不可用，使用缓存的歌曲列表")
+		c.Header("X-Stale-Data", "true")
+	}
+	song, cleanPath, fileInfo, content, fileSize := src.Song, src.Path, src.Info, src.Content, src.Size
 
-	// 验证文件路径的安全性。
-	cleanPath, err := filepath.Abs(songPath)
-	if err != nil {
-		logger.WithRequestID(requestID).Errorf("获取文件绝对路径失败 %s: %v", songPath, err)
-		c.JSON(http.StatusInternalServerError, NewInternalError(err))
+	// 记录访问日志。
+	logger.WithRequestID(requestID).WithFields(map[string]interface{}{
+		"song_id":   id,
+		"file_path": cleanPath,
+		"file_size": fileSize,
+	}).Info("音频流请求")
+
+	// If-Match 与当前 ETag 不符说明资源已变更，拒绝续传以免拼接出错乱的内容。
+	// 没有 ETag 时只有 "*" 能匹配，If-Unmodified-Since 由 ServeContent 按修改时间检查。
+	etag := h.etag(fileInfo, song)
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" && !etagMatches(ifMatch, etag) {
+		RespondError(c, http.StatusPreconditionFailed, NewPreconditionFailedError("资源已变更"))
 		return
 	}
 
-	// 确保请求的路径位于配置的音乐目录内。
-	if !strings.HasPrefix(cleanPath, h.musicDirAbs) {
-		logger.WithRequestID(requestID).Warnf("安全警告: 拒绝访问 - 路径 %s 不在音乐目录 %s 内", cleanPath, h.musicDirAbs)
-		c.JSON(http.StatusForbidden, NewForbiddenError("拒绝访问"))
+	// 根据 Accept 头协商输出格式：源格式被接受时直通，否则在 ffmpeg 可用时转码。
+	c.Header("Vary", "Accept")
+	target, ok := negotiateAudioFormat(c.GetHeader("Accept"), getMimeType(cleanPath), h.ffmpegPath != "")
+	if !ok {
+		RespondError(c, http.StatusNotAcceptable, NewNotAcceptableError("没有客户端可接受的音频格式"))
 		return
 	}
-
-	// 检查文件是否存在。
-	fileInfo, err := os.Stat(cleanPath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			c.JSON(http.StatusNotFound, NewNotFoundError("音频文件"))
-		} else {
-			logger.WithRequestID(requestID).Errorf("无法获取文件信息 %s: %v", cleanPath, err)
-			c.JSON(http.StatusInternalServerError, NewInternalError(err))
-		}
+	if target != "" {
+		h.streamTranscoded(c, content, target, requestID, clientIP, id)
 		return
 	}
 
-	// 确保请求的不是一个目录。
-	if fileInfo.IsDir() {
-		logger.WithRequestID(requestID).Warnf("安全警告: 尝试流式传输目录: %s", cleanPath)
-		c.JSON(http.StatusForbidden, NewForbiddenError("无法流式传输目录"))
+	// 配置了 X-Accel-Redirect 前缀时只返回响应头，由 nginx 的 internal location 发送文件并处理 Range。
+	// cue 虚拟歌曲只对应文件中的一个片段，nginx 无法截取，仍由本进程输出。
+	if h.xAccelPrefix != "" && !song.IsCueTrack() {
+		h.accelRedirect(c, song, cleanPath, etag)
+		auditOffloadedStream(c, requestID, clientIP, id, fileSize)
 		return
 	}
 
-	// 打开音频文件。
-	file, err := os.Open(cleanPath)
-	if err != nil {
-		logger.WithRequestID(requestID).Errorf("打开音频文件失败 %s: %v", cleanPath, err)
-		c.JSON(http.StatusInternalServerError, NewInternalError(err))
+	// 0 字节文件没有任何可满足的范围，而 http.ServeContent 会忽略 Range 或返回空的 206，
+	// 因此显式返回 416；完整请求仍由 ServeContent 返回 200 与 Content-Length: 0。
+	normalizeRangeHeader(c.Request)
+	if fileSize == 0 && c.Request.Header.Get("Range") != "" {
+		c.Header("Content-Range", "bytes */0")
+		RespondError(c, http.StatusRequestedRangeNotSatisfiable, NewRangeNotSatisfiableError("请求范围无法满足"))
 		return
 	}
-	defer file.Close()
-
-	fileSize := fileInfo.Size()
-
-	// 记录访问日志。
-	logger.WithRequestID(requestID).WithFields(map[string]interface{}{
-		"song_id":   id,
-		"file_path": cleanPath,
-		"file_size": fileSize,
-	}).Info("音频流请求")
+	if h.maxRangeSize > 0 {
+		truncateRangeHeader(c.Request, fileSize, h.maxRangeSize, h.truncateRanges)
+	}
 
-	// 处理 Range 请求以支持断点续传。
-	rangeHeader := c.GetHeader("Range")
-	if rangeHeader != "" {
-		h.serveRange(c, file, fileSize, rangeHeader, filepath.Base(cleanPath), requestID)
-		return
+	// 设置自定义响应头，其余的 Range、多段范围、条件请求等交由 http.ServeContent 处理。
+	filename := filepath.Base(cleanPath)
+	c.Header("Content-Type", getMimeType(cleanPath))
+	// 下载时使用基于标签的展示文件名，非 ASCII 字符按 RFC 5987 编码。
+	c.Header("Content-Disposition", contentDisposition(song))
+	// ETag 与 Last-Modified（由 ServeContent 根据修改时间设置）共同用于 If-Range 等条件请求：
+	// 资源未变化时按 Range 返回 206，否则返回完整的 200 响应。
+	// 两者都存在时 If-None-Match 优先于 If-Modified-Since；没有 ETag 时按秒级精度比较修改时间。
+	if etag != "" {
+		c.Header("ETag", etag)
+	}
+	// 允许 CDN 等缓存音频内容；ServeContent 与范围校验在返回错误时会移除该头。
+	if h.cacheControl != "" {
+		c.Header("Cache-Control", h.cacheControl)
 	}
 
-	// 为完整文件传输设置响应头。
-	mimeType := getMimeType(cleanPath)
-	c.Header("Content-Type", mimeType)
-	c.Header("Content-Length", fmt.Sprintf("%d", fileSize))
-	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=\"%s\"", filepath.Base(cleanPath)))
-	c.Header("Accept-Ranges", "bytes")
+	w := newStreamWriter(c, h.maxRangeSize, h.stallTimeout, h.buffers, requestID)
+	http.ServeContent(w, c.Request, filename, fileInfo.ModTime(), content)
+	w.finish()
 
-	// 流式传输整个文件。
-	c.Status(http.StatusOK)
-	written, err := io.Copy(c.Writer, file)
-	if err != nil {
-		logger.WithRequestID(requestID).Errorf("流式传输音频时出错 (已写入 %d/%d 字节): %v", written, fileSize, err)
-	}
+	auditStream(c, requestID, clientIP, id, w.written)
 }
 
-// serveRange 处理 HTTP Range 请求，用于支持音频的断点续传。
-func (h *StreamHandler) serveRange(c *gin.Context, file *os.File, fileSize int64, rangeHeader string, filename string, requestID string) {
-	ranges := strings.TrimPrefix(rangeHeader, "bytes=")
-	parts := strings.Split(ranges, "-")
-
-	if len(parts) != 2 {
-		c.JSON(http.StatusBadRequest, NewBadRequestError("无效的 Range 请求头格式"))
+// accelRedirect 以 X-Accel-Redirect 头将文件的发送委托给 nginx，响应体为空。
+// 重定向路径是 xAccelPrefix 加上文件相对于音乐目录的路径，各段均经过 URL 编码。
+// Content-Type、Content-Disposition 与 Cache-Control 等头会被 nginx 保留在最终响应中。
+func (h *StreamHandler) accelRedirect(c *gin.Context, song *models.Song, cleanPath, etag string) {
+	rel, err := filepath.Rel(h.musicDirAbs, cleanPath)
+	if err != nil {
+		logger.WithRequestID(middleware.GetRequestID(c)).Errorf("计算相对路径失败 %s: %v", cleanPath, err)
+		RespondError(c, http.StatusInternalServerError, NewInternalError(err))
 		return
 	}
-
-	start := int64(0)
-	end := fileSize - 1
-
-	// 解析范围的起始位置。
-	if parts[0] != "" {
-		var err error
-		start, err = strconv.ParseInt(parts[0], 10, 64)
-		if err != nil || start < 0 {
-			c.JSON(http.StatusBadRequest, NewBadRequestError("无效的 Range 起始值"))
-			return
-		}
+	segments := strings.Split(filepath.ToSlash(rel), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
 	}
 
-	// 解析范围的结束位置。
-	if parts[1] != "" {
-		var err error
-		end, err = strconv.ParseInt(parts[1], 10, 64)
-		if err != nil || end < 0 {
-			c.JSON(http.StatusBadRequest, NewBadRequestError("无效的 Range 结束值"))
-			return
-		}
+	c.Header("X-Accel-Redirect", h.xAccelPrefix+"/"+strings.Join(segments, "/"))
+	c.Header("Content-Type", getMimeType(cleanPath))
+	c.Header("Content-Disposition", contentDisposition(song))
+	if etag != "" {
+		c.Header("ETag", etag)
 	}
-
-	// 验证请求范围的有效性。
-	if start < 0 || end >= fileSize || start > end {
-		c.Header("Content-Range", fmt.Sprintf("bytes */%d", fileSize))
-		c.Status(http.StatusRequestedRangeNotSatisfiable)
-		return
+	if h.cacheControl != "" {
+		c.Header("Cache-Control", h.cacheControl)
 	}
+	c.Status(http.StatusOK)
+}
 
-	contentLength := end - start + 1
-
-	// 限制单次请求的数据大小。
-	if contentLength > h.maxRangeSize {
-		logger.WithRequestID(requestID).Warnf("Range 请求过大: %d 字节 (最大 %d)", contentLength, h.maxRangeSize)
-		c.JSON(http.StatusBadRequest, NewBadRequestError(fmt.Sprintf("请求范围过大 (最大 %d 字节)", h.maxRangeSize)))
-		return
+// streamTranscoded 将音频内容转码为 target 格式后以 200 响应输出，不支持 Range 请求。
+// 转码输出无法 seek，因此以 "Accept-Ranges: none" 告知客户端不要发送 Range 请求；
+// 已发送的 Range 按 RFC 9110 被忽略，返回 200 与完整内容，客户端可以据状态码识别。
+func (h *StreamHandler) streamTranscoded(c *gin.Context, content io.Reader, target, requestID, clientIP, id string) {
+	if rangeHeader := c.Request.Header.Get("Range"); rangeHeader != "" {
+		logger.WithRequestID(requestID).Debugf("转码流不支持 Range，忽略 %s 并返回完整内容", rangeHeader)
+	}
+	c.Header("Content-Type", target)
+	c.Header("Accept-Ranges", "none")
+	c.Status(http.StatusOK)
+	w := newStreamWriter(c, h.maxRangeSize, h.stallTimeout, h.buffers, requestID)
+	defer w.clearDeadline()
+	// 隐藏 streamWriter 的 ReadFrom，使响应头只在 ffmpeg 实际输出数据时写出，
+	// 转码立即失败时仍可返回错误响应。
+	if err := transcode(c.Request.Context(), h.ffmpegPath, struct{ io.Writer }{w}, content, target); err != nil {
+		logger.WithRequestID(requestID).Errorf("转码音频失败 (已写入 %d 字节): %v", w.written, err)
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Type")
+			RespondError(c, http.StatusInternalServerError, NewInternalError(err))
+			return
+		}
 	}
+	auditStream(c, requestID, clientIP, id, w.written)
+}
 
-	// 设置部分内容响应的头部。
-	mimeType := getMimeType(filename)
-	c.Header("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, fileSize))
-	c.Header("Content-Length", fmt.Sprintf("%d", contentLength))
-	c.Header("Content-Type", mimeType)
-	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=\"%s\"", filename))
-	c.Header("Accept-Ranges", "bytes")
-	c.Status(http.StatusPartialContent)
+// auditStream 在实际传输了音频内容时写入审计记录，bytes 为实际写出的字节数。
+func auditStream(c *gin.Context, requestID, clientIP, id string, written int64) {
+	writeStreamAudit(c, map[string]interface{}{
+		"request_id": requestID,
+		"client_ip":  clientIP,
+		"song_id":    id,
+		"bytes":      written,
+	})
+}
 
-	// 将文件指针移动到请求的起始位置。
-	_, err := file.Seek(start, 0)
-	if err != nil {
-		logger.WithRequestID(requestID).Errorf("定位文件到 %d 位置失败: %v", start, err)
-		c.JSON(http.StatusInternalServerError, NewInternalError(err))
-		return
-	}
+// auditOffloadedStream 为通过 X-Accel-Redirect 交给 nginx 发送的音频流写入审计记录。
+// 实际发送的字节数只有 nginx 知道，因此不记录 bytes，而是以 offloaded 标记，
+// 并记录文件大小（完整请求时 nginx 发送的字节数），Range 请求的区间见 range 字段。
+func auditOffloadedStream(c *gin.Context, requestID, clientIP, id string, fileSize int64) {
+	writeStreamAudit(c, map[string]interface{}{
+		"request_id": requestID,
+		"client_ip":  clientIP,
+		"song_id":    id,
+		"offloaded":  true,
+		"file_size":  fileSize,
+	})
+}
 
-	// 传输指定范围的数据。
-	written, err := io.CopyN(c.Writer, file, contentLength)
-	if err != nil && err != io.EOF {
-		logger.WithRequestID(requestID).Errorf("流式传输范围时出错 (已写入 %d/%d 字节): %v", written, contentLength, err)
+// writeStreamAudit 在响应为 200 或 206 时补充 range 与 status 字段并写入审计记录。
+func writeStreamAudit(c *gin.Context, fields map[string]interface{}) {
+	if status := c.Writer.Status(); status == http.StatusOK || status == http.StatusPartialContent {
+		fields["range"] = c.Request.Header.Get("Range")
+		fields["status"] = status
+		logger.Audit(fields)
 	}
 }
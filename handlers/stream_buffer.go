@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"io"
+	"sync"
+)
+
+// streamBufferPool 复用流式传输的拷贝缓冲区，避免每次传输都分配新的缓冲区，减少大文件高吞吐场景下的 GC 压力。
+type streamBufferPool struct {
+	size int
+	// buffered 为 true 时拷贝强制经过池中的缓冲区，不交给 io.ReaderFrom/io.WriterTo（即不使用 sendfile）。
+	buffered bool
+	pool     sync.Pool
+}
+
+// newStreamBufferPool 创建缓冲池。size 大于 0 时强制所有拷贝经过 size 字节的缓冲区；
+// 不大于 0 时缓冲区大小为 streamPeekSize，只在底层连接无法直接读取文件时使用，优先使用 sendfile。
+func newStreamBufferPool(size int) *streamBufferPool {
+	p := &streamBufferPool{size: size, buffered: size > 0}
+	if !p.buffered {
+		p.size = streamPeekSize
+	}
+	p.pool.New = func() interface{} {
+		buf := make([]byte, p.size)
+		return &buf
+	}
+	return p
+}
+
+// copy 使用池中的缓冲区将 src 拷贝到 dst。未强制缓冲时与 io.CopyBuffer 相同，dst 实现 io.ReaderFrom
+// 或 src 实现 io.WriterTo 时交给它们处理（如 sendfile），不使用缓冲区；强制缓冲时隐藏这两个接口，
+// 以放弃 sendfile 为代价使用配置的缓冲区大小。p 为 nil 时退化为 io.Copy。
+func (p *streamBufferPool) copy(dst io.Writer, src io.Reader) (int64, error) {
+	if p == nil {
+		return io.Copy(dst, src)
+	}
+	buf := p.get()
+	defer p.put(buf)
+	if p.buffered {
+		return io.CopyBuffer(struct{ io.Writer }{dst}, struct{ io.Reader }{src}, *buf)
+	}
+	return io.CopyBuffer(dst, src, *buf)
+}
+
+// get 从池中取出一个缓冲区，用完后需通过 put 归还。p 为 nil 时分配一个 streamPeekSize 字节的缓冲区。
+func (p *streamBufferPool) get() *[]byte {
+	if p == nil {
+		buf := make([]byte, streamPeekSize)
+		return &buf
+	}
+	return p.pool.Get().(*[]byte)
+}
+
+// put 将 get 取出的缓冲区归还到池中。
+func (p *streamBufferPool) put(buf *[]byte) {
+	if p != nil {
+		p.pool.Put(buf)
+	}
+}
+
+// zeroCopy 返回拷贝时是否允许交给底层连接的 io.ReaderFrom（即 sendfile）。
+func (p *streamBufferPool) zeroCopy() bool {
+	return p == nil || !p.buffered
+}
+
+// chunkSize 返回分块拷贝时每块的字节数：不小于 minSize，且至少能填满一个缓冲区。
+func (p *streamBufferPool) chunkSize(minSize int64) int64 {
+	if p == nil {
+		return minSize
+	}
+	return max(minSize, int64(p.size))
+}
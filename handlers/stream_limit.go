@@ -0,0 +1,51 @@
+package handlers
+
+import "sync"
+
+// ipStreamLimiter 按客户端 IP 统计正在进行的音频流数量，并限制单个 IP 的并发上限。
+// 计数归零的条目会在释放时立即删除，因此映射中只保留仍有活动流的 IP。
+// 零值不可用，请使用 newIPStreamLimiter；nil 表示不限制。
+type ipStreamLimiter struct {
+	mu     sync.Mutex
+	counts map[string]int
+	max    int
+}
+
+// newIPStreamLimiter 创建单 IP 并发上限为 max 的限制器，max 不大于 0 时返回 nil。
+func newIPStreamLimiter(max int) *ipStreamLimiter {
+	if max <= 0 {
+		return nil
+	}
+	return &ipStreamLimiter{
+		counts: make(map[string]int),
+		max:    max,
+	}
+}
+
+// acquire 尝试为 ip 占用一个流名额，成功时返回 true。
+func (l *ipStreamLimiter) acquire(ip string) bool {
+	if l == nil {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.counts[ip] >= l.max {
+		return false
+	}
+	l.counts[ip]++
+	return true
+}
+
+// release 释放 ip 的一个流名额，计数归零时删除该条目。
+func (l *ipStreamLimiter) release(ip string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.counts[ip] <= 1 {
+		delete(l.counts, ip)
+		return
+	}
+	l.counts[ip]--
+}
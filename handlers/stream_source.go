@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"zero-music/logger"
+	"zero-music/middleware"
+	"zero-music/models"
+	"zero-music/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrInvalidSongID 表示歌曲 ID 不是有效的十六进制哈希格式。
+var ErrInvalidSongID = errors.New("无效的歌曲 ID 格式")
+
+// ErrSongNotFound 表示音乐库中没有该 ID 的歌曲。
+var ErrSongNotFound = errors.New("歌曲未找到")
+
+// ErrAccessDenied 表示歌曲文件不在音乐目录内或不是普通文件。
+var ErrAccessDenied = errors.New("拒绝访问")
+
+// errIsDirectory 表示歌曲路径指向一个目录，是 ErrAccessDenied 的一种。
+var errIsDirectory = fmt.Errorf("%w: 无法流式传输目录", ErrAccessDenied)
+
+// StreamSource 是 OpenSong 打开的歌曲音频内容，使用完毕后须调用 Close。
+type StreamSource struct {
+	Song *models.Song
+	// Path 是音频文件的绝对路径，cue 虚拟歌曲为其源文件。
+	Path string
+	// Info 是音频文件的文件信息。
+	Info os.FileInfo
+	// Content 是歌曲的音频内容：完整文件时为 *os.File 本身以便使用 sendfile，cue 虚拟歌曲只包含对应的片段。
+	Content io.ReadSeeker
+	// Size 是 Content 的字节数。
+	Size int64
+	// Stale 为 true 表示音乐目录暂时不可用，歌曲来自上次成功扫描的缓存。
+	Stale bool
+
+	file *os.File
+}
+
+// Close 关闭音频文件。
+func (s *StreamSource) Close() error {
+	return s.file.Close()
+}
+
+// OpenSong 查找并打开歌曲 id 的音频内容，HTTP 与 gRPC 的音频流共用同一套查找、缓存回退与路径安全检查。
+// 音乐目录暂时不可用时使用上次成功扫描的缓存（Stale 为 true）。失败时返回的错误可用 errors.Is 区分：
+// ErrInvalidSongID、ErrSongNotFound、ErrAccessDenied、fs.ErrNotExist（音频文件已不存在）、
+// services.ErrDirectoryUnavailable（目录不可用且没有缓存），其余为内部错误。
+func (h *StreamHandler) OpenSong(ctx context.Context, id string) (*StreamSource, error) {
+	// 验证 ID 格式，确保是有效的 SHA256 哈希格式，防止路径遍历攻击。
+	if !models.IsValidID(id) {
+		return nil, ErrInvalidSongID
+	}
+
+	songs, stale, err := services.ScanOrCached(ctx, h.scanner)
+	if err != nil {
+		return nil, err
+	}
+	var song *models.Song
+	for _, s := range songs {
+		if s.ID == id {
+			song = s
+			break
+		}
+	}
+	if song == nil {
+		return nil, ErrSongNotFound
+	}
+
+	// 确保请求的路径位于配置的音乐目录内。
+	cleanPath, err := filepath.Abs(song.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("获取文件绝对路径失败 %s: %w", song.FilePath, err)
+	}
+	if !strings.HasPrefix(cleanPath, h.musicDirAbs) {
+		return nil, fmt.Errorf("%w: 路径 %s 不在音乐目录 %s 内", ErrAccessDenied, cleanPath, h.musicDirAbs)
+	}
+
+	info, err := os.Stat(cleanPath)
+	if err != nil {
+		return nil, fmt.Errorf("无法获取文件信息 %s: %w", cleanPath, err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%w: %s", errIsDirectory, cleanPath)
+	}
+
+	file, err := os.Open(cleanPath)
+	if err != nil {
+		return nil, fmt.Errorf("打开音频文件失败 %s: %w", cleanPath, err)
+	}
+	// cue 虚拟歌曲只输出源文件中对应的时间片段。
+	content, size, err := models.OpenTrack(file, song)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("读取音轨片段失败 %s: %w", cleanPath, err)
+	}
+
+	return &StreamSource{
+		Song:    song,
+		Path:    cleanPath,
+		Info:    info,
+		Content: content,
+		Size:    size,
+		Stale:   stale,
+		file:    file,
+	}, nil
+}
+
+// respondOpenSongError 将 OpenSong 返回的错误记录日志并转换为对应的错误响应。
+func respondOpenSongError(c *gin.Context, id string, err error) {
+	log := logger.WithRequestID(middleware.GetRequestID(c))
+	switch {
+	case errors.Is(err, ErrInvalidSongID):
+		log.Warnf("无效的歌曲 ID 格式: %s", id)
+		RespondError(c, http.StatusBadRequest, NewBadRequestError("无效的歌曲 ID 格式"))
+	case errors.Is(err, ErrSongNotFound):
+		log.Warnf("歌曲未找到: %s", id)
+		RespondError(c, http.StatusNotFound, NewNotFoundError("歌曲"))
+	case errors.Is(err, errIsDirectory):
+		log.Warnf("安全警告: 尝试流式传输目录: %v", err)
+		RespondError(c, http.StatusForbidden, NewForbiddenError("无法流式传输目录"))
+	case errors.Is(err, ErrAccessDenied):
+		log.Warnf("安全警告: %v", err)
+		RespondError(c, http.StatusForbidden, NewForbiddenError("拒绝访问"))
+	case errors.Is(err, fs.ErrNotExist):
+		RespondError(c, http.StatusNotFound, NewNotFoundError("音频文件"))
+	case errors.Is(err, services.ErrDirectoryUnavailable):
+		log.Warnf("音乐目录暂时不可用且没有缓存: %v", err)
+		RespondError(c, http.StatusServiceUnavailable, NewServiceUnavailableError("音乐目录暂时不可用，请稍后重试"))
+	default:
+		log.Errorf("获取音频文件失败: %v", err)
+		RespondError(c, http.StatusInternalServerError, NewInternalError(err))
+	}
+}
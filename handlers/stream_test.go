@@ -1,13 +1,26 @@
 package handlers
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 	"zero-music/config"
+	"zero-music/logger"
+	"zero-music/middleware"
+	"zero-music/models"
 	"zero-music/services"
 
 	"github.com/gin-gonic/gin"
@@ -16,44 +29,38 @@ import (
 // setupStreamTestEnv 初始化一个用于音频流处理器测试的环境。
 // 它会创建一个临时的 MP3 文件并设置好 Gin 路由器。
 func setupStreamTestEnv(t *testing.T) (*gin.Engine, string, string) {
-	gin.SetMode(gin.TestMode)
-
-	tmpDir := t.TempDir()
-	testFile := filepath.Join(tmpDir, "test.mp3")
-	testData := []byte("fake mp3 data for streaming test")
-	if err := os.WriteFile(testFile, testData, 0644); err != nil {
-		t.Fatal(err)
-	}
+	router, _, tmpDir, testFile := setupStreamTestEnvWithConfig(t, nil)
+	return router, tmpDir, testFile
+}
 
-	cfg := &config.Config{
-		Server: config.ServerConfig{
+// setupStreamTestEnvWithConfig 与 setupStreamTestEnv 相同，但允许在创建处理器前修改配置，
+// 并额外返回创建的 StreamHandler。
+func setupStreamTestEnvWithConfig(t *testing.T, modify func(cfg *config.Config)) (*gin.Engine, *StreamHandler, string, string) {
+	cfg, scanner := newTestLibrary(t, map[string]string{
+		"test.mp3": "fake mp3 data for streaming test",
+	}, func(cfg *config.Config) {
+		cfg.Server = config.ServerConfig{
 			Host:         "0.0.0.0",
 			Port:         8080,
 			MaxRangeSize: 100 * 1024 * 1024, // 100MB
-		},
-		Music: config.MusicConfig{
-			Directory:        tmpDir,
-			SupportedFormats: []string{".mp3"},
-			CacheTTLMinutes:  5,
-		},
-	}
-
-	// 创建扫描器。
-	scanner := services.NewMusicScanner(
-		cfg.Music.Directory,
-		cfg.Music.SupportedFormats,
-		cfg.Music.CacheTTLMinutes,
-	)
+		}
+		if modify != nil {
+			modify(cfg)
+		}
+	})
 
 	router := gin.New()
+	// 与生产环境一致，音频流也经过 PrettyJSON 中间件。
+	router.Use(middleware.PrettyJSON(false))
 	handler := NewStreamHandler(scanner, cfg)
 
 	// 为了获取歌曲 ID，我们需要一个播放列表端点。
-	playlistHandler := NewPlaylistHandler(scanner)
+	playlistHandler := NewPlaylistHandler(scanner, nil, nil, cfg)
 	router.GET("/api/songs", playlistHandler.GetAllSongs)
 	router.GET("/api/stream/:id", handler.StreamAudio)
 
-	return router, tmpDir, testFile
+	tmpDir := cfg.Music.Directory
+	return router, handler, tmpDir, filepath.Join(tmpDir, "test.mp3")
 }
 
 // getSongID 是一个辅助函数，用于从 /api/songs 端点获取第一首歌曲的 ID。
@@ -159,6 +166,49 @@ func TestStreamAudio_RangeRequest(t *testing.T) {
 	}
 }
 
+// TestStreamAudio_RangeUnit 测试非 bytes 单位的 Range 被忽略并返回完整文件，多余空格被容忍。
+func TestStreamAudio_RangeUnit(t *testing.T) {
+	router, _, _ := setupStreamTestEnv(t)
+	songID := getSongID(t, router)
+
+	// 获取完整文件的大小作为对比。
+	req, _ := http.NewRequest("GET", "/api/stream/"+songID, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	fullSize := w.Body.Len()
+
+	testCases := []struct {
+		name     string
+		range_   string
+		wantCode int
+		wantSize int
+	}{
+		{"未知单位", "items=0-9", http.StatusOK, fullSize},
+		{"未知单位（带空格）", " items = 0-9 ", http.StatusOK, fullSize},
+		{"bytes 前后多余空格", "  bytes = 0-9  ", http.StatusPartialContent, 10},
+		{"bytes 大写", "BYTES=0-9", http.StatusPartialContent, 10},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/stream/"+songID, nil)
+			req.Header.Set("Range", tc.range_)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.wantCode {
+				t.Fatalf("期望状态码 %d, 得到 %d", tc.wantCode, w.Code)
+			}
+			if w.Body.Len() != tc.wantSize {
+				t.Errorf("期望响应体大小为 %d 字节, 得到 %d", tc.wantSize, w.Body.Len())
+			}
+			if tc.wantCode == http.StatusOK && w.Header().Get("Content-Range") != "" {
+				t.Errorf("忽略 Range 时不应返回 Content-Range, 得到 %s", w.Header().Get("Content-Range"))
+			}
+		})
+	}
+}
+
 // TestStreamAudio_InvalidRange 测试当提供无效的 Range 请求头时，是否返回错误。
 func TestStreamAudio_InvalidRange(t *testing.T) {
 	router, _, _ := setupStreamTestEnv(t)
@@ -186,3 +236,1032 @@ func TestStreamAudio_InvalidRange(t *testing.T) {
 		})
 	}
 }
+
+// setupStreamBenchServer 启动一个真实的 HTTP 服务器用于基准测试，
+// 只有经过真实连接时底层 ResponseWriter 才支持 sendfile。
+func setupStreamBenchServer(b *testing.B) (*httptest.Server, string) {
+	gin.SetMode(gin.TestMode)
+
+	tmpDir := b.TempDir()
+	testFile := filepath.Join(tmpDir, "bench.mp3")
+	if err := os.WriteFile(testFile, make([]byte, 8*1024*1024), 0644); err != nil {
+		b.Fatal(err)
+	}
+
+	cfg := &config.Config{
+		Server: config.ServerConfig{MaxRangeSize: 100 * 1024 * 1024},
+		Music: config.MusicConfig{
+			Directory:        tmpDir,
+			SupportedFormats: []string{".mp3"},
+			CacheTTLMinutes:  5,
+		},
+	}
+	scanner := services.NewMusicScanner(cfg.Music.Directory, cfg.Music.SupportedFormats, cfg.Music.CacheTTLMinutes)
+	songs, err := scanner.Scan(context.Background())
+	if err != nil || len(songs) == 0 {
+		b.Fatalf("扫描失败: %v", err)
+	}
+
+	router := gin.New()
+	handler := NewStreamHandler(scanner, cfg)
+	router.GET("/api/stream/:id", handler.StreamAudio)
+	// 作为对照组，直接通过 gin 的 ResponseWriter 进行缓冲拷贝。
+	router.GET("/baseline", func(c *gin.Context) {
+		file, err := os.Open(testFile)
+		if err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		defer file.Close()
+		c.Status(http.StatusOK)
+		io.Copy(c.Writer, file)
+	})
+
+	srv := httptest.NewServer(router)
+	b.Cleanup(srv.Close)
+	return srv, songs[0].ID
+}
+
+// runStreamBench 重复请求指定 URL 并丢弃响应体。
+func runStreamBench(b *testing.B, url string, rangeHeader string) {
+	b.ReportAllocs()
+	b.SetBytes(8 * 1024 * 1024)
+	for i := 0; i < b.N; i++ {
+		req, _ := http.NewRequest("GET", url, nil)
+		if rangeHeader != "" {
+			req.Header.Set("Range", rangeHeader)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			b.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+}
+
+// BenchmarkStreamAudio_Full 测试完整文件传输的性能（sendfile 路径）。
+func BenchmarkStreamAudio_Full(b *testing.B) {
+	srv, songID := setupStreamBenchServer(b)
+	runStreamBench(b, srv.URL+"/api/stream/"+songID, "")
+}
+
+// BenchmarkStreamAudio_Range 测试 Range 请求传输的性能（sendfile 路径）。
+func BenchmarkStreamAudio_Range(b *testing.B) {
+	srv, songID := setupStreamBenchServer(b)
+	runStreamBench(b, srv.URL+"/api/stream/"+songID, "bytes=0-8388607")
+}
+
+// BenchmarkStreamAudio_BufferedCopy 测试经由 gin ResponseWriter 缓冲拷贝的性能，作为对照。
+func BenchmarkStreamAudio_BufferedCopy(b *testing.B) {
+	srv, _ := setupStreamBenchServer(b)
+	runStreamBench(b, srv.URL+"/baseline", "")
+}
+
+// TestGetMimeType 测试各音频扩展名是否返回正确的 MIME 类型。
+func TestGetMimeType(t *testing.T) {
+	testCases := []struct {
+		filename string
+		expected string
+	}{
+		{"song.mp3", "audio/mpeg"},
+		{"song.flac", "audio/flac"},
+		{"song.wav", "audio/wav"},
+		{"song.m4a", "audio/mp4"},
+		{"book.m4b", "audio/mp4"},
+		{"song.ogg", "audio/ogg"},
+		{"song.opus", "audio/opus"},
+		{"song.aac", "audio/aac"},
+		{"song.wma", "audio/x-ms-wma"},
+		{"song.aiff", "audio/aiff"},
+		{"song.aif", "audio/aiff"},
+		{"SONG.OPUS", "audio/opus"},
+		{"song.unknownext", "application/octet-stream"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.filename, func(t *testing.T) {
+			if got := getMimeType(tc.filename); got != tc.expected {
+				t.Errorf("期望 %s 的 MIME 类型为 %s, 得到 %s", tc.filename, tc.expected, got)
+			}
+		})
+	}
+}
+
+// TestStreamAudio_MaxConcurrentStreams 测试并发流达到上限时请求被拒绝，释放后恢复。
+func TestStreamAudio_MaxConcurrentStreams(t *testing.T) {
+	router, handler, _, _ := setupStreamTestEnvWithConfig(t, func(cfg *config.Config) {
+		cfg.Server.MaxConcurrentStreams = 1
+	})
+	songID := getSongID(t, router)
+
+	// 占满信号量，新请求应被拒绝。
+	if !handler.acquireStream() {
+		t.Fatal("期望能够占用并发流名额")
+	}
+
+	req, _ := http.NewRequest("GET", "/api/stream/"+songID, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("期望状态码 503, 得到 %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("期望包含 Retry-After 响应头")
+	}
+
+	// 无效 ID 在占用名额之前被拒绝，名额已满时仍返回 400。
+	req, _ = http.NewRequest("GET", "/api/stream/abc123", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("期望状态码 400, 得到 %d", w.Code)
+	}
+
+	// 释放后请求应恢复正常。
+	handler.releaseStream()
+
+	req, _ = http.NewRequest("GET", "/api/stream/"+songID, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("期望状态码 200, 得到 %d", w.Code)
+	}
+
+	// 请求结束后名额应已归还。
+	if len(handler.streamSlots) != 0 {
+		t.Errorf("期望所有名额已释放, 仍占用 %d", len(handler.streamSlots))
+	}
+}
+
+// TestStreamAudio_MaxStreamsPerIP 测试同一 IP 超过并发上限时被拒绝，其他 IP 不受影响。
+func TestStreamAudio_MaxStreamsPerIP(t *testing.T) {
+	router, handler, _, _ := setupStreamTestEnvWithConfig(t, func(cfg *config.Config) {
+		cfg.Server.MaxStreamsPerIP = 1
+	})
+	songID := getSongID(t, router)
+
+	// 模拟 192.0.2.1 已有一个进行中的流。
+	if !handler.ipStreams.acquire("192.0.2.1") {
+		t.Fatal("期望能够占用单 IP 流名额")
+	}
+
+	tests := []struct {
+		name       string
+		id         string
+		remoteAddr string
+		wantStatus int
+	}{
+		{"同一 IP 超限被拒", songID, "192.0.2.1:1234", http.StatusTooManyRequests},
+		{"同一 IP 无效 ID 返回 400", "abc123", "192.0.2.1:1234", http.StatusBadRequest},
+		{"不同 IP 不受影响", songID, "192.0.2.2:1234", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/stream/"+tt.id, nil)
+			req.RemoteAddr = tt.remoteAddr
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("期望状态码 %d, 得到 %d", tt.wantStatus, w.Code)
+			}
+			if tt.wantStatus == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+				t.Error("期望包含 Retry-After 响应头")
+			}
+		})
+	}
+
+	// 释放后同一 IP 恢复正常，且请求结束后计数条目被清理。
+	handler.ipStreams.release("192.0.2.1")
+	req := httptest.NewRequest("GET", "/api/stream/"+songID, nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("期望状态码 200, 得到 %d", w.Code)
+	}
+	if n := len(handler.ipStreams.counts); n != 0 {
+		t.Errorf("期望所有 IP 计数已清理, 仍有 %d 项", n)
+	}
+}
+
+// TestStreamAudio_SuffixRange 测试后缀范围请求（bytes=-N）是否返回文件末尾的 N 个字节。
+func TestStreamAudio_SuffixRange(t *testing.T) {
+	router, _, testFile := setupStreamTestEnv(t)
+	songID := getSongID(t, router)
+
+	data, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ := http.NewRequest("GET", "/api/stream/"+songID, nil)
+	req.Header.Set("Range", "bytes=-5")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusPartialContent {
+		t.Fatalf("期望状态码 206, 得到 %d", w.Code)
+	}
+	if got, want := w.Body.String(), string(data[len(data)-5:]); got != want {
+		t.Errorf("期望响应体为 %q, 得到 %q", want, got)
+	}
+	expectedRange := fmt.Sprintf("bytes %d-%d/%d", len(data)-5, len(data)-1, len(data))
+	if got := w.Header().Get("Content-Range"); got != expectedRange {
+		t.Errorf("期望 Content-Range 为 %s, 得到 %s", expectedRange, got)
+	}
+}
+
+// TestStreamAudio_MultiRange 测试多段范围请求是否返回 multipart/byteranges 响应。
+func TestStreamAudio_MultiRange(t *testing.T) {
+	router, _, testFile := setupStreamTestEnv(t)
+	songID := getSongID(t, router)
+
+	data, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ := http.NewRequest("GET", "/api/stream/"+songID, nil)
+	req.Header.Set("Range", "bytes=0-3, 5-8")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusPartialContent {
+		t.Fatalf("期望状态码 206, 得到 %d", w.Code)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	if err != nil || mediaType != "multipart/byteranges" {
+		t.Fatalf("期望 Content-Type 为 multipart/byteranges, 得到 %s", w.Header().Get("Content-Type"))
+	}
+
+	reader := multipart.NewReader(w.Body, params["boundary"])
+	expected := []string{string(data[0:4]), string(data[5:9])}
+	for i, want := range expected {
+		part, err := reader.NextPart()
+		if err != nil {
+			t.Fatalf("读取第 %d 段失败: %v", i+1, err)
+		}
+		got, _ := io.ReadAll(part)
+		if string(got) != want {
+			t.Errorf("期望第 %d 段为 %q, 得到 %q", i+1, want, got)
+		}
+	}
+	if _, err := reader.NextPart(); err != io.EOF {
+		t.Errorf("期望只有 %d 段", len(expected))
+	}
+}
+
+// TestStreamAudio_RangeTooLarge 测试超过 maxRangeSize 的 Range 请求是否被拒绝。
+func TestStreamAudio_RangeTooLarge(t *testing.T) {
+	router, _, _, _ := setupStreamTestEnvWithConfig(t, func(cfg *config.Config) {
+		cfg.Server.MaxRangeSize = 8
+	})
+	songID := getSongID(t, router)
+
+	testCases := []struct {
+		name         string
+		rangeHeader  string
+		expectedCode int
+	}{
+		{"单段范围未超限", "bytes=0-7", http.StatusPartialContent},
+		{"单段范围超限", "bytes=0-8", http.StatusBadRequest},
+		{"后缀范围超限", "bytes=-9", http.StatusBadRequest},
+		{"多段范围总和超限", "bytes=0-3,5-9", http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/stream/"+songID, nil)
+			req.Header.Set("Range", tc.rangeHeader)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedCode {
+				t.Errorf("期望状态码 %d, 得到 %d", tc.expectedCode, w.Code)
+			}
+			if tc.expectedCode == http.StatusBadRequest {
+				if w.Header().Get("Content-Range") != "" {
+					t.Error("被拒绝的响应不应包含 Content-Range 头")
+				}
+				var apiErr APIError
+				if err := json.Unmarshal(w.Body.Bytes(), &apiErr); err != nil || apiErr.Code != "BAD_REQUEST" {
+					t.Errorf("期望返回 BAD_REQUEST 错误, 得到 %s", w.Body.String())
+				}
+			}
+		})
+	}
+}
+
+// TestStreamAudio_IfRange 测试 If-Range 条件范围请求：匹配时返回 206，不匹配时返回完整的 200。
+func TestStreamAudio_IfRange(t *testing.T) {
+	router, _, testFile := setupStreamTestEnv(t)
+	songID := getSongID(t, router)
+
+	info, err := os.Stat(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 首先获取当前的 ETag 与 Last-Modified。
+	req, _ := http.NewRequest("GET", "/api/stream/"+songID, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	etag := w.Header().Get("ETag")
+	lastModified := w.Header().Get("Last-Modified")
+	if etag == "" || lastModified == "" {
+		t.Fatal("期望响应包含 ETag 和 Last-Modified 头")
+	}
+
+	testCases := []struct {
+		name         string
+		ifRange      string
+		expectedCode int
+		expectedLen  int
+	}{
+		{"ETag 匹配", etag, http.StatusPartialContent, 10},
+		{"Last-Modified 匹配", lastModified, http.StatusPartialContent, 10},
+		{"ETag 不匹配", `"stale-etag"`, http.StatusOK, int(info.Size())},
+		{"Last-Modified 不匹配", "Mon, 02 Jan 2006 15:04:05 GMT", http.StatusOK, int(info.Size())},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/stream/"+songID, nil)
+			req.Header.Set("Range", "bytes=0-9")
+			req.Header.Set("If-Range", tc.ifRange)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedCode {
+				t.Errorf("期望状态码 %d, 得到 %d", tc.expectedCode, w.Code)
+			}
+			if w.Body.Len() != tc.expectedLen {
+				t.Errorf("期望响应体大小为 %d 字节, 得到 %d", tc.expectedLen, w.Body.Len())
+			}
+		})
+	}
+}
+
+// TestStreamAudio_CacheControl 测试配置 Cache-Control 后成功响应包含该头，而错误响应不包含。
+func TestStreamAudio_CacheControl(t *testing.T) {
+	const cacheControl = "public, max-age=86400"
+	router, _, _, _ := setupStreamTestEnvWithConfig(t, func(cfg *config.Config) {
+		cfg.Server.StreamCacheControl = cacheControl
+		cfg.Server.MaxRangeSize = 16
+	})
+	songID := getSongID(t, router)
+
+	testCases := []struct {
+		name         string
+		id           string
+		rangeHeader  string
+		expectedCode int
+		expectHeader bool
+	}{
+		{"完整响应", songID, "", http.StatusOK, true},
+		{"部分内容响应", songID, "bytes=0-9", http.StatusPartialContent, true},
+		{"无效的歌曲 ID", "abc", "", http.StatusBadRequest, false},
+		{"范围过大", songID, "bytes=0-20", http.StatusBadRequest, false},
+		{"范围无法满足", songID, "bytes=1000-2000", http.StatusRequestedRangeNotSatisfiable, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/stream/"+tc.id, nil)
+			if tc.rangeHeader != "" {
+				req.Header.Set("Range", tc.rangeHeader)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedCode {
+				t.Fatalf("期望状态码 %d, 得到 %d", tc.expectedCode, w.Code)
+			}
+			got := w.Header().Get("Cache-Control")
+			if tc.expectHeader && got != cacheControl {
+				t.Errorf("期望 Cache-Control 为 %q, 得到 %q", cacheControl, got)
+			}
+			if !tc.expectHeader && got != "" {
+				t.Errorf("期望错误响应不包含 Cache-Control, 得到 %q", got)
+			}
+		})
+	}
+}
+
+// TestStreamAudio_AuditLog 测试成功传输后审计记录被写入独立的审计日志文件。
+func TestStreamAudio_AuditLog(t *testing.T) {
+	router, _, _ := setupStreamTestEnv(t)
+	songID := getSongID(t, router)
+
+	auditPath := filepath.Join(t.TempDir(), "audit.log")
+	auditFile, err := logger.InitAudit(auditPath, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		auditFile.Close()
+		logger.InitAudit("", 0, 0)
+	}()
+
+	req := httptest.NewRequest("GET", "/api/stream/"+songID, nil)
+	req.Header.Set("Range", "bytes=0-3")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusPartialContent {
+		t.Fatalf("期望状态码 206, 得到 %d", w.Code)
+	}
+
+	data, err := os.ReadFile(auditPath)
+	if err != nil {
+		t.Fatalf("读取审计日志失败: %v", err)
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal(bytes.TrimSpace(data), &entry); err != nil {
+		t.Fatalf("期望审计日志包含一条 JSON 记录, 得到 %s", data)
+	}
+	expected := map[string]interface{}{
+		"client_ip": "192.0.2.1",
+		"song_id":   songID,
+		"bytes":     float64(4),
+		"range":     "bytes=0-3",
+		"status":    float64(http.StatusPartialContent),
+	}
+	for key, want := range expected {
+		if entry[key] != want {
+			t.Errorf("期望审计字段 %s 为 %v, 得到 %v", key, want, entry[key])
+		}
+	}
+	if _, ok := entry["request_id"]; !ok {
+		t.Error("期望审计记录包含 request_id 字段")
+	}
+}
+
+// TestStreamAudio_EmptyFile 测试 0 字节文件：完整请求返回 200 与 Content-Length: 0，
+// 任何 Range 请求都返回 416 与 Content-Range: bytes */0。
+func TestStreamAudio_EmptyFile(t *testing.T) {
+	router, _, testFile := setupStreamTestEnv(t)
+	if err := os.WriteFile(testFile, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	songID := getSongID(t, router)
+
+	testCases := []struct {
+		name          string
+		rangeHeader   string
+		expectedCode  int
+		expectedRange string
+	}{
+		{"完整请求", "", http.StatusOK, ""},
+		{"起始范围", "bytes=0-", http.StatusRequestedRangeNotSatisfiable, "bytes */0"},
+		{"闭区间范围", "bytes=0-0", http.StatusRequestedRangeNotSatisfiable, "bytes */0"},
+		{"后缀范围", "bytes=-10", http.StatusRequestedRangeNotSatisfiable, "bytes */0"},
+		{"多段范围", "bytes=0-0,-1", http.StatusRequestedRangeNotSatisfiable, "bytes */0"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/stream/"+songID, nil)
+			if tc.rangeHeader != "" {
+				req.Header.Set("Range", tc.rangeHeader)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedCode {
+				t.Fatalf("期望状态码 %d, 得到 %d", tc.expectedCode, w.Code)
+			}
+			if got := w.Header().Get("Content-Range"); got != tc.expectedRange {
+				t.Errorf("期望 Content-Range 为 %q, 得到 %q", tc.expectedRange, got)
+			}
+			if tc.expectedCode == http.StatusOK {
+				if got := w.Header().Get("Content-Length"); got != "0" {
+					t.Errorf("期望 Content-Length 为 0, 得到 %q", got)
+				}
+				if w.Body.Len() != 0 {
+					t.Errorf("期望响应体为空, 得到 %d 字节", w.Body.Len())
+				}
+			}
+		})
+	}
+}
+
+// TestStreamAudio_AcceptNegotiation 测试按 Accept 头选择直通、转码或返回 406。
+// 转码器使用原样输出输入内容的脚本代替 ffmpeg，以便在没有 ffmpeg 的环境中验证分派逻辑。
+func TestStreamAudio_AcceptNegotiation(t *testing.T) {
+	router, handler, _, _ := setupStreamTestEnvWithConfig(t, nil)
+	songID := getSongID(t, router)
+
+	fakeFFmpeg := filepath.Join(t.TempDir(), "ffmpeg")
+	if err := os.WriteFile(fakeFFmpeg, []byte("#!/bin/sh\ncat\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name         string
+		accept       string
+		ffmpegPath   string
+		expectedCode int
+		expectedType string
+	}{
+		{"缺省 Accept", "", "", http.StatusOK, "audio/mpeg"},
+		{"任意类型", "*/*", "", http.StatusOK, "audio/mpeg"},
+		{"音频通配", "audio/*", "", http.StatusOK, "audio/mpeg"},
+		{"接受源格式", "audio/ogg, audio/mpeg;q=0.5", fakeFFmpeg, http.StatusOK, "audio/mpeg"},
+		{"转码不可用", "audio/ogg", "", http.StatusNotAcceptable, ""},
+		{"不支持的格式", "audio/aac", fakeFFmpeg, http.StatusNotAcceptable, ""},
+		{"转码为首个支持的格式", "audio/aac, audio/flac;q=0.8, audio/ogg", fakeFFmpeg, http.StatusOK, "audio/ogg"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler.ffmpegPath = tc.ffmpegPath
+			req, _ := http.NewRequest("GET", "/api/stream/"+songID, nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedCode {
+				t.Fatalf("期望状态码 %d, 得到 %d: %s", tc.expectedCode, w.Code, w.Body.String())
+			}
+			if tc.expectedType != "" && w.Header().Get("Content-Type") != tc.expectedType {
+				t.Errorf("期望 Content-Type 为 %s, 得到 %s", tc.expectedType, w.Header().Get("Content-Type"))
+			}
+			if tc.expectedCode == http.StatusOK && w.Body.String() != "fake mp3 data for streaming test" {
+				t.Errorf("响应内容不正确: %q", w.Body.String())
+			}
+		})
+	}
+}
+
+// TestStreamAudio_AcceptRanges 测试直通文件响应 "Accept-Ranges: bytes"，
+// 转码流响应 "Accept-Ranges: none" 并忽略 Range 返回 200 完整内容。
+func TestStreamAudio_AcceptRanges(t *testing.T) {
+	router, handler, _, _ := setupStreamTestEnvWithConfig(t, nil)
+	songID := getSongID(t, router)
+
+	fakeFFmpeg := filepath.Join(t.TempDir(), "ffmpeg")
+	if err := os.WriteFile(fakeFFmpeg, []byte("#!/bin/sh\ncat\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	handler.ffmpegPath = fakeFFmpeg
+
+	testCases := []struct {
+		name                string
+		accept              string
+		rangeHeader         string
+		expectedCode        int
+		expectedAcceptRange string
+	}{
+		{"直通文件", "audio/mpeg", "", http.StatusOK, "bytes"},
+		{"直通文件的 Range 请求", "audio/mpeg", "bytes=0-3", http.StatusPartialContent, "bytes"},
+		{"转码流", "audio/ogg", "", http.StatusOK, "none"},
+		{"转码流忽略 Range", "audio/ogg", "bytes=5-", http.StatusOK, "none"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/stream/"+songID, nil)
+			req.Header.Set("Accept", tc.accept)
+			if tc.rangeHeader != "" {
+				req.Header.Set("Range", tc.rangeHeader)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedCode {
+				t.Fatalf("期望状态码 %d, 得到 %d", tc.expectedCode, w.Code)
+			}
+			if got := w.Header().Get("Accept-Ranges"); got != tc.expectedAcceptRange {
+				t.Errorf("期望 Accept-Ranges 为 %q, 得到 %q", tc.expectedAcceptRange, got)
+			}
+			if tc.expectedAcceptRange == "none" {
+				if w.Header().Get("Content-Range") != "" {
+					t.Errorf("期望转码流没有 Content-Range, 得到 %q", w.Header().Get("Content-Range"))
+				}
+				if w.Body.String() != "fake mp3 data for streaming test" {
+					t.Errorf("期望转码流返回完整内容, 得到 %q", w.Body.String())
+				}
+			}
+		})
+	}
+}
+
+// TestStreamAudio_TranscodeFLAC 测试源为 FLAC、Accept 仅接受 MP3 时使用 ffmpeg 转码（需要 ffmpeg）。
+func TestStreamAudio_TranscodeFLAC(t *testing.T) {
+	ffmpegPath, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		t.Skip("未找到 ffmpeg，跳过转码测试")
+	}
+	router, _, tmpDir, testFile := setupStreamTestEnvWithConfig(t, func(cfg *config.Config) {
+		cfg.Music.SupportedFormats = []string{".flac"}
+	})
+	os.Remove(testFile)
+
+	flacFile := filepath.Join(tmpDir, "sine.flac")
+	cmd := exec.Command(ffmpegPath, "-hide_banner", "-loglevel", "error", "-f", "lavfi", "-i", "sine=duration=1", flacFile)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("生成 FLAC 测试文件失败: %v: %s", err, out)
+	}
+	songID := getSongID(t, router)
+
+	req, _ := http.NewRequest("GET", "/api/stream/"+songID, nil)
+	req.Header.Set("Accept", "audio/mpeg")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("期望状态码 200, 得到 %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Type"); got != "audio/mpeg" {
+		t.Errorf("期望 Content-Type 为 audio/mpeg, 得到 %s", got)
+	}
+	body := w.Body.Bytes()
+	isMP3 := bytes.HasPrefix(body, []byte("ID3")) || (len(body) > 1 && body[0] == 0xFF && body[1]&0xE0 == 0xE0)
+	if !isMP3 {
+		t.Errorf("期望响应为 MP3 数据, 得到前缀 %x", body[:min(len(body), 4)])
+	}
+}
+
+// TestStreamAudio_TruncateRange 测试 truncate 模式下超过上限的 Range 被截断后返回 206。
+func TestStreamAudio_TruncateRange(t *testing.T) {
+	router, _, _, _ := setupStreamTestEnvWithConfig(t, func(cfg *config.Config) {
+		cfg.Server.MaxRangeSize = 16
+		cfg.Server.RangeOverLimitBehavior = "truncate"
+	})
+	songID := getSongID(t, router)
+
+	// 测试文件内容为 32 字节。
+	testCases := []struct {
+		name          string
+		rangeHeader   string
+		expectedCode  int
+		expectedRange string
+	}{
+		{"闭区间超限", "bytes=0-20", http.StatusPartialContent, "bytes 0-15/32"},
+		{"开放区间超限", "bytes=4-", http.StatusPartialContent, "bytes 4-19/32"},
+		{"后缀范围超限", "bytes=-30", http.StatusPartialContent, "bytes 2-17/32"},
+		{"未超限", "bytes=0-9", http.StatusPartialContent, "bytes 0-9/32"},
+		{"多段范围超限", "bytes=0-10,12-30", http.StatusBadRequest, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/stream/"+songID, nil)
+			req.Header.Set("Range", tc.rangeHeader)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedCode {
+				t.Fatalf("期望状态码 %d, 得到 %d", tc.expectedCode, w.Code)
+			}
+			if got := w.Header().Get("Content-Range"); got != tc.expectedRange {
+				t.Errorf("期望 Content-Range 为 %q, 得到 %q", tc.expectedRange, got)
+			}
+		})
+	}
+}
+
+// TestStreamAudio_OpenEndedRange 测试 reject 模式下超过上限的开放区间被截断为首段 206，
+// 显式给出长度的区间仍然被拒绝。
+func TestStreamAudio_OpenEndedRange(t *testing.T) {
+	router, _, _, _ := setupStreamTestEnvWithConfig(t, func(cfg *config.Config) {
+		cfg.Server.MaxRangeSize = 16
+	})
+	songID := getSongID(t, router)
+
+	// 测试文件内容为 32 字节。
+	testCases := []struct {
+		name          string
+		rangeHeader   string
+		expectedCode  int
+		expectedRange string
+		expectedBody  string
+	}{
+		{"从头开始的开放区间", "bytes=0-", http.StatusPartialContent, "bytes 0-15/32", "fake mp3 data fo"},
+		{"续传的开放区间", "bytes=16-", http.StatusPartialContent, "bytes 16-31/32", "r streaming test"},
+		{"闭区间超限", "bytes=0-20", http.StatusBadRequest, "", ""},
+		{"后缀范围超限", "bytes=-30", http.StatusBadRequest, "", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/stream/"+songID, nil)
+			req.Header.Set("Range", tc.rangeHeader)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedCode {
+				t.Fatalf("期望状态码 %d, 得到 %d", tc.expectedCode, w.Code)
+			}
+			if got := w.Header().Get("Content-Range"); got != tc.expectedRange {
+				t.Errorf("期望 Content-Range 为 %q, 得到 %q", tc.expectedRange, got)
+			}
+			if tc.expectedBody != "" && w.Body.String() != tc.expectedBody {
+				t.Errorf("期望响应内容为 %q, 得到 %q", tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+// TestStreamAudio_IfMatch 测试 If-Match 与当前 ETag 不符时返回 412，相符时正常续传。
+func TestStreamAudio_IfMatch(t *testing.T) {
+	router, _, _ := setupStreamTestEnv(t)
+	songID := getSongID(t, router)
+
+	// 先获取当前 ETag。
+	req, _ := http.NewRequest("GET", "/api/stream/"+songID, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("期望响应包含 ETag")
+	}
+
+	testCases := []struct {
+		name         string
+		ifMatch      string
+		expectedCode int
+	}{
+		{"ETag 相符", etag, http.StatusPartialContent},
+		{"列表中包含当前 ETag", `"other", ` + etag, http.StatusPartialContent},
+		{"通配符", "*", http.StatusPartialContent},
+		{"ETag 不符", `"stale-etag"`, http.StatusPreconditionFailed},
+		{"弱 ETag 不满足强比较", "W/" + etag, http.StatusPreconditionFailed},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/stream/"+songID, nil)
+			req.Header.Set("Range", "bytes=0-9")
+			req.Header.Set("If-Match", tc.ifMatch)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedCode {
+				t.Fatalf("期望状态码 %d, 得到 %d", tc.expectedCode, w.Code)
+			}
+			if tc.expectedCode == http.StatusPreconditionFailed && !strings.Contains(w.Body.String(), "PRECONDITION_FAILED") {
+				t.Errorf("期望返回 PRECONDITION_FAILED 错误, 得到 %s", w.Body.String())
+			}
+		})
+	}
+}
+
+// TestStreamAudio_WeakETag 测试开启 WeakETag 后返回弱 ETag：If-None-Match 按弱比较命中 304，
+// If-Match 与 If-Range 按强比较不会命中。
+func TestStreamAudio_WeakETag(t *testing.T) {
+	router, _, _, testFile := setupStreamTestEnvWithConfig(t, func(cfg *config.Config) {
+		cfg.Server.WeakETag = true
+	})
+	songID := getSongID(t, router)
+	info, err := os.Stat(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ := http.NewRequest("GET", "/api/stream/"+songID, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	etag := w.Header().Get("ETag")
+	if expected := "W/" + fileETag(info); etag != expected {
+		t.Fatalf("期望返回弱 ETag %s, 得到 %s", expected, etag)
+	}
+	strongETag := strings.TrimPrefix(etag, "W/")
+
+	testCases := []struct {
+		name         string
+		header       string
+		value        string
+		rangeHeader  string
+		expectedCode int
+	}{
+		{"If-None-Match 弱 ETag 命中", "If-None-Match", etag, "", http.StatusNotModified},
+		{"If-None-Match 弱比较忽略 W/ 前缀", "If-None-Match", strongETag, "", http.StatusNotModified},
+		{"If-None-Match 列表中包含", "If-None-Match", `"other", ` + etag, "", http.StatusNotModified},
+		{"If-None-Match 不符", "If-None-Match", `W/"stale-etag"`, "", http.StatusOK},
+		{"If-Match 弱 ETag 不满足强比较", "If-Match", etag, "bytes=0-9", http.StatusPreconditionFailed},
+		{"If-Match 通配符", "If-Match", "*", "bytes=0-9", http.StatusPartialContent},
+		{"If-Range 弱 ETag 返回完整内容", "If-Range", etag, "bytes=0-9", http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/stream/"+songID, nil)
+			req.Header.Set(tc.header, tc.value)
+			if tc.rangeHeader != "" {
+				req.Header.Set("Range", tc.rangeHeader)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedCode {
+				t.Fatalf("期望状态码 %d, 得到 %d", tc.expectedCode, w.Code)
+			}
+			if tc.expectedCode == http.StatusNotModified && w.Body.Len() != 0 {
+				t.Errorf("期望 304 响应体为空, 得到 %d 字节", w.Body.Len())
+			}
+		})
+	}
+}
+
+// TestStreamAudio_ConcurrentRanges 测试并发的多个 Range 请求同一首歌曲时各自返回正确的区间内容。
+func TestStreamAudio_ConcurrentRanges(t *testing.T) {
+	router, _, testFile := setupStreamTestEnv(t)
+	songID := getSongID(t, router)
+	data, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const requests = 32
+	var wg sync.WaitGroup
+	errs := make(chan error, requests)
+	for i := 0; i < requests; i++ {
+		start := i % len(data)
+		end := min(start+4, len(data)-1)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest("GET", "/api/stream/"+songID, nil)
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusPartialContent {
+				errs <- fmt.Errorf("bytes=%d-%d: 期望状态码 206, 得到 %d", start, end, w.Code)
+				return
+			}
+			if got, expected := w.Body.String(), string(data[start:end+1]); got != expected {
+				errs <- fmt.Errorf("bytes=%d-%d: 期望响应体 %q, 得到 %q", start, end, expected, got)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+// TestStreamAudio_LastModifiedFallback 测试无法提供 ETag 时按 If-Modified-Since 返回 304，
+// 以及两者同时存在时 ETag 优先。
+func TestStreamAudio_LastModifiedFallback(t *testing.T) {
+	router, handler, _, _ := setupStreamTestEnvWithConfig(t, nil)
+	songID := getSongID(t, router)
+	defaultETag := handler.etag
+	handler.etag = func(os.FileInfo, *models.Song) string { return "" }
+
+	req, _ := http.NewRequest("GET", "/api/stream/"+songID, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("期望状态码 200, 得到 %d", w.Code)
+	}
+	if etag := w.Header().Get("ETag"); etag != "" {
+		t.Errorf("期望不返回 ETag, 得到 %s", etag)
+	}
+	lastModified := w.Header().Get("Last-Modified")
+	if lastModified == "" {
+		t.Fatal("期望响应包含 Last-Modified")
+	}
+	modTime, err := http.ParseTime(lastModified)
+	if err != nil {
+		t.Fatalf("解析 Last-Modified 失败: %v", err)
+	}
+
+	testCases := []struct {
+		name            string
+		etag            func(os.FileInfo, *models.Song) string
+		ifNoneMatch     string
+		ifModifiedSince string
+		expectedCode    int
+	}{
+		{"未修改", handler.etag, "", lastModified, http.StatusNotModified},
+		{"已修改", handler.etag, "", modTime.Add(-time.Hour).UTC().Format(http.TimeFormat), http.StatusOK},
+		{"ETag 不符时忽略 If-Modified-Since", defaultETag, `"stale-etag"`, lastModified, http.StatusOK},
+		{"ETag 通配符", defaultETag, "*", "", http.StatusNotModified},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler.etag = tc.etag
+			req, _ := http.NewRequest("GET", "/api/stream/"+songID, nil)
+			if tc.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tc.ifNoneMatch)
+			}
+			if tc.ifModifiedSince != "" {
+				req.Header.Set("If-Modified-Since", tc.ifModifiedSince)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedCode {
+				t.Fatalf("期望状态码 %d, 得到 %d", tc.expectedCode, w.Code)
+			}
+		})
+	}
+}
+
+// TestStreamAudio_XAccelRedirect 测试配置 X-Accel-Redirect 前缀后只返回重定向头而不写响应体，
+// Range 原样交给 nginx 处理；路径中的特殊字符经过 URL 编码。
+func TestStreamAudio_XAccelRedirect(t *testing.T) {
+	router, _, tmpDir, _ := setupStreamTestEnvWithConfig(t, func(cfg *config.Config) {
+		cfg.Server.XAccelRedirectPrefix = "/protected-music/"
+	})
+	if err := os.MkdirAll(filepath.Join(tmpDir, "My Album"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filepath.Join(tmpDir, "test.mp3"), filepath.Join(tmpDir, "My Album", "歌 #1.mp3")); err != nil {
+		t.Fatal(err)
+	}
+	songID := getSongID(t, router)
+
+	for _, rangeHeader := range []string{"", "bytes=0-3"} {
+		req, _ := http.NewRequest("GET", "/api/stream/"+songID, nil)
+		if rangeHeader != "" {
+			req.Header.Set("Range", rangeHeader)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("期望状态码 200, 得到 %d", w.Code)
+		}
+		expected := "/protected-music/My%20Album/%E6%AD%8C%20%231.mp3"
+		if got := w.Header().Get("X-Accel-Redirect"); got != expected {
+			t.Errorf("期望 X-Accel-Redirect 为 %q, 得到 %q", expected, got)
+		}
+		if w.Body.Len() != 0 {
+			t.Errorf("期望响应体为空, 得到 %d 字节", w.Body.Len())
+		}
+		if got := w.Header().Get("Content-Type"); got != "audio/mpeg" {
+			t.Errorf("期望 Content-Type 为 audio/mpeg, 得到 %q", got)
+		}
+		if w.Header().Get("Content-Range") != "" {
+			t.Errorf("Range 应由 nginx 处理, 得到 Content-Range %q", w.Header().Get("Content-Range"))
+		}
+	}
+}
+
+// TestStreamAudio_XAccelRedirectAudit 测试交给 nginx 发送的流在审计记录中标记为 offloaded 并记录文件大小，
+// 不记录 bytes，避免与传输失败的 0 字节记录混淆。
+func TestStreamAudio_XAccelRedirectAudit(t *testing.T) {
+	router, _, _, testFile := setupStreamTestEnvWithConfig(t, func(cfg *config.Config) {
+		cfg.Server.XAccelRedirectPrefix = "/protected-music"
+	})
+	songID := getSongID(t, router)
+	info, err := os.Stat(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auditPath := filepath.Join(t.TempDir(), "audit.log")
+	auditFile, err := logger.InitAudit(auditPath, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		auditFile.Close()
+		logger.InitAudit("", 0, 0)
+	}()
+
+	req := httptest.NewRequest("GET", "/api/stream/"+songID, nil)
+	req.Header.Set("Range", "bytes=0-3")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("期望状态码 200, 得到 %d", w.Code)
+	}
+
+	data, err := os.ReadFile(auditPath)
+	if err != nil {
+		t.Fatalf("读取审计日志失败: %v", err)
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal(bytes.TrimSpace(data), &entry); err != nil {
+		t.Fatalf("期望审计日志包含一条 JSON 记录, 得到 %s", data)
+	}
+	expected := map[string]interface{}{
+		"song_id":   songID,
+		"offloaded": true,
+		"file_size": float64(info.Size()),
+		"range":     "bytes=0-3",
+		"status":    float64(http.StatusOK),
+	}
+	for key, want := range expected {
+		if entry[key] != want {
+			t.Errorf("期望审计字段 %s 为 %v, 得到 %v", key, want, entry[key])
+		}
+	}
+	if bytesWritten, ok := entry["bytes"]; ok {
+		t.Errorf("期望交给 nginx 的流不记录 bytes, 得到 %v", bytesWritten)
+	}
+}
@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+	"zero-music/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamPeekSize 是写出响应头前最多预先读取的字节数，预读使用传输缓冲池中的缓冲区。
+const streamPeekSize = 32 * 1024
+
+// stallCheckChunkSize 是检测慢速客户端时每次延长写超时后写出的最小字节数，缓冲区更大时以缓冲区大小为准。
+// 客户端在一个超时周期内至少需要读取这么多数据才不会被断开。
+const stallCheckChunkSize = 64 * 1024
+
+// errRangeTooLarge 表示 Range 请求的总大小超过了 maxRangeSize，响应已被改写为错误。
+var errRangeTooLarge = errors.New("请求范围过大")
+
+// streamWriter 包裹 gin 的 ResponseWriter，供 http.ServeContent 使用。
+// 它在写出 206 响应头之前检查 Content-Length，超过 maxRangeSize 时中断传输并返回 400 错误；
+// 同时在底层连接支持 io.ReaderFrom 时将数据直接交给它，以便使用 sendfile 零拷贝传输。
+// 请求的 context 被取消（客户端断开）或超时后，下一次写出前即停止读取文件。
+type streamWriter struct {
+	gin.ResponseWriter
+	c            *gin.Context
+	ctx          context.Context
+	maxRangeSize int64
+	requestID    string
+	// stallTimeout 是写出没有进展的最长时间，0 表示不检测慢速客户端。
+	stallTimeout time.Duration
+	// controller 用于设置底层连接的写超时。
+	controller *http.ResponseController
+	// buffers 提供拷贝响应体时使用的缓冲区并决定是否允许 sendfile，为 nil 时使用 io.Copy 的默认缓冲区。
+	buffers *streamBufferPool
+
+	rejected bool  // 是否因范围过大而拒绝了本次请求
+	written  int64 // 已写出的响应体字节数
+	err      error // 写出响应体时遇到的第一个错误
+}
+
+// newStreamWriter 创建一个新的 streamWriter。
+// stallTimeout 大于 0 时，每次写出前将连接的写超时延长 stallTimeout，
+// 客户端长时间不读取数据（如 slowloris 式的慢速消费者）时写出超时失败，连接随之断开。
+func newStreamWriter(c *gin.Context, maxRangeSize int64, stallTimeout time.Duration, buffers *streamBufferPool, requestID string) *streamWriter {
+	return &streamWriter{
+		ResponseWriter: c.Writer,
+		c:              c,
+		ctx:            c.Request.Context(),
+		maxRangeSize:   maxRangeSize,
+		requestID:      requestID,
+		stallTimeout:   stallTimeout,
+		controller:     http.NewResponseController(c.Writer),
+		buffers:        buffers,
+	}
+}
+
+// extendDeadline 将连接的写超时延长 stallTimeout。底层连接不支持写超时时不再尝试。
+func (w *streamWriter) extendDeadline() {
+	if w.stallTimeout <= 0 {
+		return
+	}
+	if err := w.controller.SetWriteDeadline(time.Now().Add(w.stallTimeout)); err != nil {
+		logger.WithRequestID(w.requestID).Debugf("无法设置写超时，不检测慢速客户端: %v", err)
+		w.stallTimeout = 0
+	}
+}
+
+// clearDeadline 清除写超时，避免影响同一 Keep-Alive 连接上的后续请求。
+func (w *streamWriter) clearDeadline() {
+	if w.stallTimeout > 0 {
+		_ = w.controller.SetWriteDeadline(time.Time{})
+	}
+}
+
+// WriteHeader 在写出 206 响应头前校验范围大小。
+func (w *streamWriter) WriteHeader(code int) {
+	if code == http.StatusPartialContent {
+		contentLength, err := strconv.ParseInt(w.Header().Get("Content-Length"), 10, 64)
+		if err == nil && contentLength > w.maxRangeSize {
+			w.reject(contentLength)
+			return
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// clearContentHeaders 清除已设置的内容与缓存相关响应头，以便改为返回错误响应。
+func (w *streamWriter) clearContentHeaders() {
+	header := w.Header()
+	for _, key := range []string{"Content-Range", "Content-Length", "Content-Type", "Content-Disposition", "Cache-Control", "ETag", "Last-Modified"} {
+		header.Del(key)
+	}
+}
+
+// reject 清除已设置的内容与缓存相关响应头，并返回范围过大的错误响应。
+func (w *streamWriter) reject(contentLength int64) {
+	w.rejected = true
+	w.clearContentHeaders()
+	logger.WithRequestID(w.requestID).Warnf("Range 请求过大: %d 字节 (最大 %d)", contentLength, w.maxRangeSize)
+	RespondError(w.c, http.StatusBadRequest, NewBadRequestError(fmt.Sprintf("请求范围过大 (最大 %d 字节)", w.maxRangeSize)))
+}
+
+// Write 写出响应体。请求被拒绝后返回 errRangeTooLarge 以终止 ServeContent 的拷贝。
+func (w *streamWriter) Write(p []byte) (int, error) {
+	if w.rejected {
+		return 0, errRangeTooLarge
+	}
+	if err := w.ctx.Err(); err != nil {
+		w.record(0, err)
+		return 0, err
+	}
+	w.extendDeadline()
+	n, err := w.ResponseWriter.Write(p)
+	w.record(int64(n), err)
+	return n, err
+}
+
+// ReadFrom 实现 io.ReaderFrom。
+// 当底层 ResponseWriter 实现了 io.ReaderFrom 时（如 net/http 的连接），
+// 直接交给其 ReadFrom 处理，以便在支持的平台上使用 sendfile 零拷贝传输；
+// 否则退化为普通的缓冲拷贝。
+func (w *streamWriter) ReadFrom(r io.Reader) (int64, error) {
+	if w.rejected {
+		return 0, errRangeTooLarge
+	}
+	if err := w.ctx.Err(); err != nil {
+		w.record(0, err)
+		return 0, err
+	}
+
+	// 先读取第一块数据再写出响应头：读取立即失败（如磁盘错误）时响应头尚未写出，仍可改为返回 500。
+	// 缓冲区从池中取出，写出预读的数据后立即归还，避免每个请求都在堆上分配。
+	bufp := w.buffers.get()
+	buf := (*bufp)[:min(len(*bufp), streamPeekSize)]
+	peeked, err := io.ReadAtLeast(r, buf, 1)
+	if err != nil && err != io.EOF {
+		w.buffers.put(bufp)
+		w.record(0, err)
+		return 0, err
+	}
+
+	// 写出状态码与响应头，避免绕过 gin 的 ResponseWriter 后丢失它们。
+	w.ResponseWriter.WriteHeaderNow()
+	w.extendDeadline()
+	if peeked > 0 {
+		n, err := w.ResponseWriter.Write(buf[:peeked])
+		w.record(int64(n), err)
+		if err != nil {
+			w.buffers.put(bufp)
+			return int64(n), err
+		}
+	}
+	w.buffers.put(bufp)
+
+	// 底层连接支持 io.ReaderFrom 时直接交给它，r 保持 ServeContent 传入的原样以便使用 sendfile，
+	// 客户端断开后 sendfile 的写出随之失败；否则逐次读取前检查请求是否已被取消，不再继续读盘。
+	dst, zeroCopy := w.rawWriter()
+	if !zeroCopy {
+		r = &contextReader{ctx: w.ctx, r: r}
+	}
+	n, err := w.copyWithDeadline(dst, r)
+	w.record(n, err)
+	return int64(peeked) + n, err
+}
+
+// rawWriter 返回拷贝响应体的目标：沿 Unwrap 链（gin 与中间件的包装）找到实现了 io.ReaderFrom 的底层 ResponseWriter，
+// 找到且未配置强制缓冲时返回它本身与 true，否则返回 gin 的 ResponseWriter 与 false。
+func (w *streamWriter) rawWriter() (io.Writer, bool) {
+	if !w.buffers.zeroCopy() {
+		return w.ResponseWriter, false
+	}
+	var rw http.ResponseWriter = w.ResponseWriter
+	for {
+		uw, ok := rw.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			break
+		}
+		if rw = uw.Unwrap(); rw == nil {
+			break
+		}
+		if _, ok := rw.(io.ReaderFrom); ok {
+			return rw, true
+		}
+	}
+	return w.ResponseWriter, false
+}
+
+// copyWithDeadline 将 src 拷贝到 dst。不检测慢速客户端时一次性交给 dst；
+// 检测慢速客户端时分块拷贝，每块写出前检查请求是否已被取消并延长写超时，
+// 因此只要客户端持续读取，总传输时间不受限制。
+// 分块时复用同一个 LimitedReader 并在每块前重新设置 N，而不是每块再包一层：
+// http.ServeContent 传入的本身就是 *io.LimitedReader，直接复用它，底层连接仍能识别出其中的文件并使用 sendfile。
+func (w *streamWriter) copyWithDeadline(dst io.Writer, src io.Reader) (int64, error) {
+	if w.stallTimeout <= 0 {
+		return w.buffers.copy(dst, src)
+	}
+
+	lr, ok := src.(*io.LimitedReader)
+	if !ok {
+		lr = &io.LimitedReader{R: src, N: math.MaxInt64}
+	}
+	remaining := lr.N
+	defer func() { lr.N = remaining }()
+
+	chunk := w.buffers.chunkSize(stallCheckChunkSize)
+	var total int64
+	for remaining > 0 {
+		if err := w.ctx.Err(); err != nil {
+			return total, err
+		}
+		w.extendDeadline()
+		want := min(chunk, remaining)
+		lr.N = want
+		n, err := w.buffers.copy(dst, lr)
+		total += n
+		remaining -= n
+		if err != nil {
+			return total, err
+		}
+		if n < want {
+			break
+		}
+	}
+	return total, nil
+}
+
+// contextReader 在每次读取前检查 context，context 被取消后返回其错误。
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+// Read 实现 io.Reader。
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+// finish 在 http.ServeContent 返回后检查传输是否完整。
+// 响应头尚未写出时发生的错误改为返回 500；已开始写出响应体后无法再修改状态码，
+// 此时记录 Content-Length 与实际写出字节数之间的缺口，便于排查被截断的响应。
+func (w *streamWriter) finish() {
+	w.clearDeadline()
+	if w.rejected {
+		return
+	}
+	// 客户端已断开或请求超时，连接不再可用，只记录日志。
+	if ctxErr := w.ctx.Err(); ctxErr != nil && errors.Is(w.err, ctxErr) {
+		logger.WithRequestID(w.requestID).Infof("请求已取消，停止传输 (已写出 %d 字节): %v", w.written, ctxErr)
+		return
+	}
+	if errors.Is(w.err, os.ErrDeadlineExceeded) {
+		logger.WithRequestID(w.requestID).Warnf("客户端超过 %v 没有读取数据，已断开 (已写出 %d 字节)", w.stallTimeout, w.written)
+	}
+	if w.err != nil && !w.Written() {
+		logger.WithRequestID(w.requestID).Errorf("读取音频内容失败，尚未写出响应: %v", w.err)
+		w.clearContentHeaders()
+		RespondError(w.c, http.StatusInternalServerError, NewInternalError(w.err))
+		return
+	}
+
+	status := w.Status()
+	if status != http.StatusOK && status != http.StatusPartialContent {
+		return
+	}
+	expected, err := strconv.ParseInt(w.Header().Get("Content-Length"), 10, 64)
+	if err != nil || w.written >= expected {
+		return
+	}
+	logger.WithRequestID(w.requestID).WithFields(map[string]interface{}{
+		"status":         status,
+		"content_length": expected,
+		"written_bytes":  w.written,
+		"missing_bytes":  expected - w.written,
+		"error":          fmt.Sprint(w.err),
+	}).Error("音频流传输未完成，响应体短于 Content-Length")
+}
+
+// record 累计已写出的字节数并记录第一个错误。
+func (w *streamWriter) record(n int64, err error) {
+	w.written += n
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+}
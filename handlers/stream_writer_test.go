@@ -0,0 +1,516 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"zero-music/config"
+	"zero-music/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// failingReader 是在读取到 failAt 字节处返回错误的 ReadSeeker，用于模拟传输中途的磁盘错误。
+type failingReader struct {
+	data   []byte
+	failAt int64
+	offset int64
+}
+
+// Read 实现 io.Reader。
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.offset >= r.failAt {
+		return 0, errors.New("模拟的磁盘读取错误")
+	}
+	n := copy(p, r.data[r.offset:r.failAt])
+	r.offset += int64(n)
+	return n, nil
+}
+
+// Seek 实现 io.Seeker。
+func (r *failingReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += int64(len(r.data))
+	}
+	r.offset = offset
+	return offset, nil
+}
+
+// TestStreamWriter_ReadError 测试读取失败时的响应与日志：
+// 响应头写出前失败返回 500，写出部分响应体后失败则记录与 Content-Length 之间的字节缺口。
+func TestStreamWriter_ReadError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	data := []byte("fake mp3 data for streaming test")
+
+	testCases := []struct {
+		name         string
+		failAt       int64
+		expectedCode int
+		expectedBody int
+		expectedLog  string
+	}{
+		{"响应头写出前失败", 0, http.StatusInternalServerError, -1, "读取音频内容失败"},
+		{"传输中途失败", 10, http.StatusOK, 10, `"missing_bytes":22`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var logs strings.Builder
+			l := logger.GetLogger()
+			original := l.Out
+			l.SetOutput(&logs)
+			defer l.SetOutput(original)
+
+			rec := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rec)
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/stream/test", nil)
+			c.Header("Content-Type", "audio/mpeg")
+			c.Header("ETag", `"test"`)
+
+			w := newStreamWriter(c, int64(len(data)), 0, nil, "")
+			http.ServeContent(w, c.Request, "test.mp3", time.Time{}, &failingReader{data: data, failAt: tc.failAt})
+			w.finish()
+			c.Writer.WriteHeaderNow()
+
+			if rec.Code != tc.expectedCode {
+				t.Fatalf("期望状态码 %d, 得到 %d", tc.expectedCode, rec.Code)
+			}
+			if tc.expectedCode == http.StatusInternalServerError {
+				if !strings.Contains(rec.Body.String(), "INTERNAL_ERROR") {
+					t.Errorf("期望返回 INTERNAL_ERROR 错误, 得到 %s", rec.Body.String())
+				}
+				if got := rec.Header().Get("ETag"); got != "" {
+					t.Errorf("期望错误响应不包含 ETag, 得到 %q", got)
+				}
+			}
+			if tc.expectedBody >= 0 && rec.Body.Len() != tc.expectedBody {
+				t.Errorf("期望响应体为 %d 字节, 得到 %d", tc.expectedBody, rec.Body.Len())
+			}
+			if !strings.Contains(logs.String(), tc.expectedLog) {
+				t.Errorf("期望日志包含 %s, 得到 %s", tc.expectedLog, logs.String())
+			}
+		})
+	}
+}
+
+// stallingResponseWriter 是支持写超时的 ResponseWriter，用于模拟读取缓慢的客户端。
+// 写出 stallAfter 字节后（为负数时从不）每次写出都阻塞到写超时到期并返回 os.ErrDeadlineExceeded；
+// 否则每次写出前等待 delay，模拟网速较慢但仍在持续读取的客户端。
+type stallingResponseWriter struct {
+	header     http.Header
+	code       int
+	body       bytes.Buffer
+	stallAfter int
+	delay      time.Duration
+	deadline   time.Time
+	timedOut   bool // 是否因写超时到期而返回了 os.ErrDeadlineExceeded
+}
+
+// Header 实现 http.ResponseWriter。
+func (w *stallingResponseWriter) Header() http.Header {
+	return w.header
+}
+
+// WriteHeader 实现 http.ResponseWriter。
+func (w *stallingResponseWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+// Write 实现 http.ResponseWriter。
+func (w *stallingResponseWriter) Write(p []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	if w.stallAfter >= 0 && w.body.Len() >= w.stallAfter {
+		if w.deadline.IsZero() {
+			return 0, errors.New("客户端停止读取但没有设置写超时")
+		}
+		time.Sleep(time.Until(w.deadline))
+		w.timedOut = true
+		return 0, os.ErrDeadlineExceeded
+	}
+	time.Sleep(w.delay)
+	if !w.deadline.IsZero() && time.Now().After(w.deadline) {
+		return 0, os.ErrDeadlineExceeded
+	}
+	return w.body.Write(p)
+}
+
+// SetWriteDeadline 供 http.ResponseController 设置写超时。
+func (w *stallingResponseWriter) SetWriteDeadline(deadline time.Time) error {
+	w.deadline = deadline
+	return nil
+}
+
+// TestStreamAudio_StallTimeout 测试长时间不读取数据的客户端被断开，
+// 而持续读取的慢速客户端即使总耗时超过超时时间也能完整接收。
+func TestStreamAudio_StallTimeout(t *testing.T) {
+	router, handler, _, testFile := setupStreamTestEnvWithConfig(t, nil)
+	data := bytes.Repeat([]byte("0123456789abcdef"), 64*1024) // 1MB，大于默认缓冲区
+	if err := os.WriteFile(testFile, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	songID := getSongID(t, router)
+	handler.stallTimeout = 100 * time.Millisecond
+
+	testCases := []struct {
+		name       string
+		stallAfter int
+		delay      time.Duration
+		wantFull   bool
+	}{
+		{"客户端停止读取", stallCheckChunkSize, 0, false},
+		{"慢速但持续读取", -1, 20 * time.Millisecond, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := &stallingResponseWriter{header: make(http.Header), stallAfter: tc.stallAfter, delay: tc.delay}
+			req := httptest.NewRequest(http.MethodGet, "/api/stream/"+songID, nil)
+
+			start := time.Now()
+			router.ServeHTTP(w, req)
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Fatalf("期望请求尽快结束, 实际耗时 %v", elapsed)
+			}
+
+			if w.code != http.StatusOK {
+				t.Fatalf("期望状态码 200, 得到 %d", w.code)
+			}
+			if full := bytes.Equal(w.body.Bytes(), data); full != tc.wantFull {
+				t.Errorf("期望完整接收为 %v, 实际接收 %d/%d 字节", tc.wantFull, w.body.Len(), len(data))
+			}
+			if !w.deadline.IsZero() {
+				t.Errorf("期望传输结束后清除写超时, 得到 %v", w.deadline)
+			}
+		})
+	}
+}
+
+// TestLongResponses_StallTimeout 测试电台与专辑打包下载与音频流使用相同的慢速客户端检测：
+// 客户端停止读取后在写超时到期时断开，传输结束后清除写超时。
+func TestLongResponses_StallTimeout(t *testing.T) {
+	data := strings.Repeat("0123456789abcdef", 16*1024) // 256KB
+	cfg, scanner := newTestLibrary(t, map[string]string{"a.mp3": data, "b.mp3": data}, func(cfg *config.Config) {
+		cfg.Server.StreamStallTimeoutSeconds = 1
+	})
+	streams := NewStreamHandler(scanner, cfg)
+	streams.stallTimeout = 100 * time.Millisecond
+	router := gin.New()
+	router.GET("/api/radio", streams.Radio)
+	router.GET("/api/album/:name/download", NewAlbumHandler(scanner, streams).DownloadAlbum)
+
+	for _, path := range []string{"/api/radio?loop=true", "/api/album/unknown/download"} {
+		t.Run(path, func(t *testing.T) {
+			w := &stallingResponseWriter{header: make(http.Header), stallAfter: stallCheckChunkSize}
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+
+			start := time.Now()
+			router.ServeHTTP(w, req)
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Fatalf("期望请求尽快结束, 实际耗时 %v", elapsed)
+			}
+			if w.code != http.StatusOK {
+				t.Fatalf("期望状态码 200, 得到 %d", w.code)
+			}
+			if !w.timedOut {
+				t.Error("期望客户端停止读取后因写超时到期而断开")
+			}
+			if w.body.Len() >= 2*len(data) {
+				t.Errorf("期望客户端停止读取后断开, 实际接收 %d 字节", w.body.Len())
+			}
+			if !w.deadline.IsZero() {
+				t.Errorf("期望传输结束后清除写超时, 得到 %v", w.deadline)
+			}
+		})
+	}
+}
+
+// disconnectingResponseWriter 在第一次写出后取消请求的 context，模拟客户端断开。
+// 之后的写出仍然成功（如同数据只写进了内核缓冲区），因此只有感知 context 才能及时停止传输。
+type disconnectingResponseWriter struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+	cancel context.CancelFunc
+}
+
+// Header 实现 http.ResponseWriter。
+func (w *disconnectingResponseWriter) Header() http.Header {
+	return w.header
+}
+
+// WriteHeader 实现 http.ResponseWriter。
+func (w *disconnectingResponseWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+// Write 实现 http.ResponseWriter。
+func (w *disconnectingResponseWriter) Write(p []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	w.cancel()
+	return w.body.Write(p)
+}
+
+// TestStreamAudio_ClientDisconnect 测试客户端断开（请求 context 被取消）后传输及时停止，不再读完整个文件。
+func TestStreamAudio_ClientDisconnect(t *testing.T) {
+	router, handler, _, testFile := setupStreamTestEnvWithConfig(t, nil)
+	data := bytes.Repeat([]byte("0123456789abcdef"), 64*1024) // 1MB
+	if err := os.WriteFile(testFile, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	songID := getSongID(t, router)
+
+	testCases := []struct {
+		name         string
+		stallTimeout time.Duration
+	}{
+		{"不检测慢速客户端", 0},
+		{"检测慢速客户端", time.Minute},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler.stallTimeout = tc.stallTimeout
+			var logs strings.Builder
+			l := logger.GetLogger()
+			original := l.Out
+			l.SetOutput(&logs)
+			defer l.SetOutput(original)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			w := &disconnectingResponseWriter{header: make(http.Header), cancel: cancel}
+			req := httptest.NewRequest(http.MethodGet, "/api/stream/"+songID, nil).WithContext(ctx)
+			router.ServeHTTP(w, req)
+
+			if w.code != http.StatusOK {
+				t.Fatalf("期望状态码 200, 得到 %d", w.code)
+			}
+			// 断开前最多写出预读的第一块数据。
+			if w.body.Len() > streamPeekSize {
+				t.Errorf("期望客户端断开后停止传输, 实际写出 %d/%d 字节", w.body.Len(), len(data))
+			}
+			if !strings.Contains(logs.String(), "请求已取消，停止传输") {
+				t.Errorf("期望记录传输被取消的日志, 得到 %s", logs.String())
+			}
+		})
+	}
+}
+
+// TestStreamAudio_BufferSize 测试不同的传输缓冲区大小下完整请求与 Range 请求返回的内容不变。
+func TestStreamAudio_BufferSize(t *testing.T) {
+	data := make([]byte, 700*1024)
+	for i := range data {
+		data[i] = byte(i * 7 % 251)
+	}
+
+	for _, size := range []int{config.MinStreamBufferSize, 0, 1024 * 1024} {
+		t.Run(strconv.Itoa(size), func(t *testing.T) {
+			router, _, _, testFile := setupStreamTestEnvWithConfig(t, func(cfg *config.Config) {
+				cfg.Server.StreamBufferSize = size
+			})
+			if err := os.WriteFile(testFile, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			songID := getSongID(t, router)
+
+			testCases := []struct {
+				rangeHeader  string
+				expectedCode int
+				expected     []byte
+			}{
+				{"", http.StatusOK, data},
+				{"bytes=1000-500000", http.StatusPartialContent, data[1000:500001]},
+				{"bytes=-300000", http.StatusPartialContent, data[len(data)-300000:]},
+			}
+			for _, tc := range testCases {
+				req := httptest.NewRequest(http.MethodGet, "/api/stream/"+songID, nil)
+				if tc.rangeHeader != "" {
+					req.Header.Set("Range", tc.rangeHeader)
+				}
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+
+				if w.Code != tc.expectedCode {
+					t.Fatalf("%q: 期望状态码 %d, 得到 %d", tc.rangeHeader, tc.expectedCode, w.Code)
+				}
+				if !bytes.Equal(w.Body.Bytes(), tc.expected) {
+					t.Errorf("%q: 期望响应体为 %d 字节的原始内容, 得到 %d 字节且内容不一致", tc.rangeHeader, len(tc.expected), w.Body.Len())
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkStreamWriter_BufferSize 通过真实的 TCP 连接对比不同传输缓冲区配置下的吞吐：
+// 0 表示交给连接的 ReadFrom（Linux 上为 sendfile），其余为强制经过对应大小缓冲区的用户态拷贝。
+func BenchmarkStreamWriter_BufferSize(b *testing.B) {
+	gin.SetMode(gin.TestMode)
+	const fileSize = 16 * 1024 * 1024
+	path := filepath.Join(b.TempDir(), "large.mp3")
+	if err := os.WriteFile(path, bytes.Repeat([]byte{0x55}, fileSize), 0644); err != nil {
+		b.Fatal(err)
+	}
+
+	for _, size := range []int{0, 32 * 1024, 256 * 1024, 1024 * 1024} {
+		b.Run(strconv.Itoa(size/1024)+"KB", func(b *testing.B) {
+			buffers := newStreamBufferPool(size)
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				file, err := os.Open(path)
+				if err != nil {
+					http.Error(rw, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				defer file.Close()
+				c, _ := gin.CreateTestContext(rw)
+				c.Request = r
+				w := newStreamWriter(c, fileSize, 0, buffers, "")
+				http.ServeContent(w, r, "large.mp3", time.Time{}, file)
+				w.finish()
+			}))
+			defer server.Close()
+
+			b.SetBytes(fileSize)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				resp, err := server.Client().Get(server.URL)
+				if err != nil {
+					b.Fatal(err)
+				}
+				n, err := io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+				if err != nil || n != fileSize {
+					b.Fatalf("期望读取 %d 字节, 得到 %d: %v", fileSize, n, err)
+				}
+			}
+		})
+	}
+}
+
+// readerFromRecorder 是实现了 io.ReaderFrom 与写超时的 ResponseWriter，模拟 net/http 的连接，
+// 记录每次 ReadFrom 收到的读取器类型（LimitedReader 时同时记录其内层类型）。
+type readerFromRecorder struct {
+	header    http.Header
+	code      int
+	body      bytes.Buffer
+	readers   []string
+	deadlines int
+}
+
+// Header 实现 http.ResponseWriter。
+func (w *readerFromRecorder) Header() http.Header {
+	return w.header
+}
+
+// WriteHeader 实现 http.ResponseWriter。
+func (w *readerFromRecorder) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+// Write 实现 http.ResponseWriter。
+func (w *readerFromRecorder) Write(p []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(p)
+}
+
+// ReadFrom 实现 io.ReaderFrom。
+func (w *readerFromRecorder) ReadFrom(r io.Reader) (int64, error) {
+	name := fmt.Sprintf("%T", r)
+	if lr, ok := r.(*io.LimitedReader); ok {
+		name += fmt.Sprintf("{%T}", lr.R)
+	}
+	w.readers = append(w.readers, name)
+	return io.Copy(&w.body, struct{ io.Reader }{r})
+}
+
+// SetWriteDeadline 供 http.ResponseController 设置写超时。
+func (w *readerFromRecorder) SetWriteDeadline(time.Time) error {
+	w.deadlines++
+	return nil
+}
+
+// TestStreamWriter_ZeroCopyReader 测试交给底层连接 ReadFrom 的读取器是只包一层 LimitedReader 的 *os.File，
+// 与 net.TCPConn 使用 sendfile 的条件一致；检测慢速客户端时分块传输也不叠加包装。
+// 配置了 StreamBufferSize 时强制经过缓冲区拷贝，不再交给底层连接。
+// 开启 pretty 输出时 PrettyJSON 中间件包装了 ResponseWriter，sendfile 与写超时仍须生效。
+func TestStreamWriter_ZeroCopyReader(t *testing.T) {
+	router, handler, _, testFile := setupStreamTestEnvWithConfig(t, nil)
+	data := bytes.Repeat([]byte("0123456789abcdef"), 64*1024) // 1MB
+	if err := os.WriteFile(testFile, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	songID := getSongID(t, router)
+
+	testCases := []struct {
+		name         string
+		stallTimeout time.Duration
+		bufferSize   int
+		rangeHeader  string
+		query        string
+		expected     []byte
+	}{
+		{"完整请求", 0, 0, "", "", data},
+		{"Range 请求", 0, 0, "bytes=100000-", "", data[100000:]},
+		{"检测慢速客户端", time.Minute, 0, "", "", data},
+		{"检测慢速客户端的 Range 请求", time.Minute, 0, "bytes=100000-", "", data[100000:]},
+		{"强制缓冲拷贝", 0, 64 * 1024, "", "", data},
+		{"检测慢速客户端时强制缓冲拷贝", time.Minute, 64 * 1024, "bytes=100000-", "", data[100000:]},
+		{"美化输出", 0, 0, "", "?pretty=true", data},
+		{"美化输出时检测慢速客户端", time.Minute, 0, "bytes=100000-", "?pretty=true", data[100000:]},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler.stallTimeout = tc.stallTimeout
+			handler.buffers = newStreamBufferPool(tc.bufferSize)
+			w := &readerFromRecorder{header: make(http.Header)}
+			req := httptest.NewRequest(http.MethodGet, "/api/stream/"+songID+tc.query, nil)
+			if tc.rangeHeader != "" {
+				req.Header.Set("Range", tc.rangeHeader)
+			}
+			router.ServeHTTP(w, req)
+
+			if !bytes.Equal(w.body.Bytes(), tc.expected) {
+				t.Fatalf("期望响应体为 %d 字节, 得到 %d", len(tc.expected), w.body.Len())
+			}
+			if tc.bufferSize > 0 {
+				if len(w.readers) != 0 {
+					t.Errorf("期望强制缓冲时不调用底层连接的 ReadFrom, 得到 %v", w.readers)
+				}
+				return
+			}
+			if len(w.readers) == 0 {
+				t.Fatal("期望响应体通过底层连接的 ReadFrom 写出")
+			}
+			if tc.stallTimeout > 0 && len(w.readers) < 2 {
+				t.Errorf("期望检测慢速客户端时分块写出, 只调用了 %d 次 ReadFrom", len(w.readers))
+			}
+			if tc.stallTimeout > 0 && w.deadlines == 0 {
+				t.Error("期望检测慢速客户端时设置底层连接的写超时")
+			}
+			for _, name := range w.readers {
+				if name != "*io.LimitedReader{*os.File}" {
+					t.Errorf("期望读取器为包裹 *os.File 的单层 *io.LimitedReader, 得到 %s", name)
+				}
+			}
+		})
+	}
+}
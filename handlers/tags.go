@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"zero-music/logger"
+	"zero-music/middleware"
+	"zero-music/models"
+	"zero-music/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// addTagsRequest 是为歌曲添加自定义标签的请求体。
+type addTagsRequest struct {
+	// Tags 是要添加的标签，标签会去除首尾空白并转换为小写。
+	Tags []string `json:"tags"`
+}
+
+// songTagsResponse 是歌曲自定义标签的响应体。
+type songTagsResponse struct {
+	SongID string   `json:"song_id"`
+	Tags   []string `json:"tags"`
+}
+
+// TagSummary 是一个自定义标签及其歌曲数量。
+type TagSummary struct {
+	Name      string `json:"name"`
+	SongCount int    `json:"song_count"`
+}
+
+// songTags 返回所有歌曲的自定义标签，未配置标签存储时返回空映射。
+func (h *PlaylistHandler) songTags() map[string][]string {
+	if h.tags == nil {
+		return map[string][]string{}
+	}
+	return h.tags.SongTags()
+}
+
+// filterByTag 返回带有指定自定义标签的歌曲，tag 为空时返回原列表。
+func (h *PlaylistHandler) filterByTag(songs []*models.Song, tag string) []*models.Song {
+	if tag == "" {
+		return songs
+	}
+	songTags := h.songTags()
+	filtered := make([]*models.Song, 0)
+	for _, song := range songs {
+		for _, t := range songTags[song.ID] {
+			if t == tag {
+				filtered = append(filtered, song)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// AddTags 处理为歌曲添加自定义标签的请求。
+// @Summary 添加歌曲标签
+// @Description 为指定歌曲添加自定义标签，标签会去除首尾空白并转换为小写，已存在的标签被忽略
+// @Tags playlist
+// @Accept json
+// @Produce json
+// @Param id path string true "歌曲ID"
+// @Param body body addTagsRequest true "要添加的标签"
+// @Success 200 {object} songTagsResponse "成功返回歌曲的全部标签"
+// @Failure 400 {object} APIError "请求参数错误"
+// @Failure 404 {object} APIError "歌曲未找到"
+// @Failure 500 {object} APIError "服务器错误"
+// @Router /api/song/{id}/tags [post]
+func (h *PlaylistHandler) AddTags(c *gin.Context) {
+	id := c.Param("id")
+	requestID := middleware.GetRequestID(c)
+
+	if !models.IsValidID(id) {
+		logger.WithRequestID(requestID).Warnf("无效的歌曲 ID 格式: %s", id)
+		RespondError(c, http.StatusBadRequest, NewBadRequestError("无效的歌曲 ID 格式"))
+		return
+	}
+
+	var req addTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.Tags) == 0 {
+		RespondError(c, http.StatusBadRequest, NewBadRequestError("无效的请求体"))
+		return
+	}
+	tags := make([]string, 0, len(req.Tags))
+	for _, raw := range req.Tags {
+		tag, err := services.NormalizeTag(raw)
+		if err != nil {
+			RespondError(c, http.StatusBadRequest, NewBadRequestError(err.Error()))
+			return
+		}
+		tags = append(tags, tag)
+	}
+	if h.tags == nil {
+		RespondError(c, http.StatusServiceUnavailable, NewServiceUnavailableError("标签功能不可用"))
+		return
+	}
+
+	if _, ok := scanSongs(c, h.scanner); !ok {
+		return
+	}
+	if h.scanner.GetSongByID(id) == nil {
+		RespondError(c, http.StatusNotFound, NewNotFoundError("歌曲"))
+		return
+	}
+
+	if err := h.tags.AddTags(id, tags...); err != nil {
+		logger.WithRequestID(requestID).Errorf("保存歌曲标签失败: %v", err)
+		RespondError(c, http.StatusInternalServerError, NewInternalError(err))
+		return
+	}
+	c.JSON(http.StatusOK, songTagsResponse{SongID: id, Tags: h.tagsOf(id)})
+}
+
+// RemoveTag 处理移除歌曲自定义标签的请求，标签不存在时同样返回成功。
+// @Summary 移除歌曲标签
+// @Description 移除指定歌曲的一个自定义标签
+// @Tags playlist
+// @Produce json
+// @Param id path string true "歌曲ID"
+// @Param tag path string true "标签"
+// @Success 200 {object} songTagsResponse "成功返回歌曲的剩余标签"
+// @Failure 400 {object} APIError "请求参数错误"
+// @Failure 500 {object} APIError "服务器错误"
+// @Router /api/song/{id}/tags/{tag} [delete]
+func (h *PlaylistHandler) RemoveTag(c *gin.Context) {
+	id := c.Param("id")
+	requestID := middleware.GetRequestID(c)
+
+	if !models.IsValidID(id) {
+		logger.WithRequestID(requestID).Warnf("无效的歌曲 ID 格式: %s", id)
+		RespondError(c, http.StatusBadRequest, NewBadRequestError("无效的歌曲 ID 格式"))
+		return
+	}
+	tag, err := services.NormalizeTag(c.Param("tag"))
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, NewBadRequestError(err.Error()))
+		return
+	}
+	if h.tags == nil {
+		RespondError(c, http.StatusServiceUnavailable, NewServiceUnavailableError("标签功能不可用"))
+		return
+	}
+
+	if err := h.tags.RemoveTag(id, tag); err != nil {
+		logger.WithRequestID(requestID).Errorf("保存歌曲标签失败: %v", err)
+		RespondError(c, http.StatusInternalServerError, NewInternalError(err))
+		return
+	}
+	c.JSON(http.StatusOK, songTagsResponse{SongID: id, Tags: h.tagsOf(id)})
+}
+
+// tagsOf 返回单首歌曲的标签，没有标签时返回空列表。
+func (h *PlaylistHandler) tagsOf(id string) []string {
+	if tags := h.songTags()[id]; tags != nil {
+		return tags
+	}
+	return []string{}
+}
+
+// GetTags 返回所有自定义标签及各自的歌曲数量，按名称排序。
+// @Summary 获取所有标签
+// @Description 聚合当前扫描结果中歌曲的自定义标签及各自的歌曲数量，已不存在的歌曲不计入
+// @Tags playlist
+// @Produce json
+// @Success 200 {object} map[string]interface{} "成功返回标签列表"
+// @Failure 500 {object} APIError "服务器错误"
+// @Failure 503 {object} APIError "音乐目录暂时不可用且没有缓存"
+// @Router /api/tags [get]
+func (h *PlaylistHandler) GetTags(c *gin.Context) {
+	songs, ok := scanSongs(c, h.scanner)
+	if !ok {
+		return
+	}
+
+	songTags := h.songTags()
+	counts := make(map[string]int)
+	for _, song := range songs {
+		for _, tag := range songTags[song.ID] {
+			counts[tag]++
+		}
+	}
+
+	tags := make([]TagSummary, 0, len(counts))
+	for name, count := range counts {
+		tags = append(tags, TagSummary{Name: name, SongCount: count})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].Name < tags[j].Name
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"total": len(tags),
+		"tags":  tags,
+	})
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestSongTags 测试打标签后按标签过滤能查到歌曲，删除标签后查不到，以及标签计数。
+func TestSongTags(t *testing.T) {
+	router, _ := setupTestEnv(t)
+
+	ids := make(map[string]string)
+	for _, song := range fetchSongsPage(t, router, "").Songs {
+		ids[song.FileName] = song.ID
+	}
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	names := func(query string) string {
+		var result []string
+		for _, song := range fetchSongsPage(t, router, query).Songs {
+			result = append(result, song.FileName)
+		}
+		return strings.Join(result, ",")
+	}
+
+	w := do("POST", "/api/song/"+ids["test1.mp3"]+"/tags", `{"tags": [" Workout ", "focus"]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("期望状态码 200, 得到 %d: %s", w.Code, w.Body.String())
+	}
+	var response songTagsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if strings.Join(response.Tags, ",") != "focus,workout" {
+		t.Errorf("期望标签为 focus,workout, 得到 %v", response.Tags)
+	}
+	if w := do("POST", "/api/song/"+ids["test2.mp3"]+"/tags", `{"tags": ["workout"]}`); w.Code != http.StatusOK {
+		t.Fatalf("期望状态码 200, 得到 %d: %s", w.Code, w.Body.String())
+	}
+
+	if got := names("tag=workout"); got != "test1.mp3,test2.mp3" {
+		t.Errorf("期望 workout 标签下有 test1.mp3,test2.mp3, 得到 %s", got)
+	}
+	if got := names("tag=FOCUS"); got != "test1.mp3" {
+		t.Errorf("期望 focus 标签下有 test1.mp3, 得到 %s", got)
+	}
+
+	// 标签计数。
+	req := httptest.NewRequest("GET", "/api/tags", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var tags struct {
+		Tags []TagSummary `json:"tags"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &tags); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if len(tags.Tags) != 2 || tags.Tags[0] != (TagSummary{"focus", 1}) || tags.Tags[1] != (TagSummary{"workout", 2}) {
+		t.Errorf("期望标签计数为 focus:1, workout:2, 得到 %+v", tags.Tags)
+	}
+
+	// 删除标签后查不到。
+	if w := do("DELETE", "/api/song/"+ids["test1.mp3"]+"/tags/workout", ""); w.Code != http.StatusOK {
+		t.Fatalf("期望状态码 200, 得到 %d: %s", w.Code, w.Body.String())
+	}
+	if got := names("tag=workout"); got != "test2.mp3" {
+		t.Errorf("期望删除后 workout 标签下只有 test2.mp3, 得到 %s", got)
+	}
+	if got := names("tag=focus"); got != "test1.mp3" {
+		t.Errorf("期望 focus 标签不受影响, 得到 %s", got)
+	}
+}
+
+// TestSongTags_Invalid 测试无效的标签请求。
+func TestSongTags_Invalid(t *testing.T) {
+	router, _ := setupTestEnv(t)
+	id := fetchSongsPage(t, router, "").Songs[0].ID
+	missing := strings.Repeat("0", 32)
+
+	testCases := []struct {
+		name         string
+		method       string
+		path         string
+		body         string
+		expectedCode int
+	}{
+		{"空标签列表", "POST", "/api/song/" + id + "/tags", `{"tags": []}`, http.StatusBadRequest},
+		{"空白标签", "POST", "/api/song/" + id + "/tags", `{"tags": [" "]}`, http.StatusBadRequest},
+		{"标签过长", "POST", "/api/song/" + id + "/tags", `{"tags": ["` + strings.Repeat("a", 33) + `"]}`, http.StatusBadRequest},
+		{"无效的歌曲 ID", "POST", "/api/song/invalid/tags", `{"tags": ["focus"]}`, http.StatusBadRequest},
+		{"歌曲不存在", "POST", "/api/song/" + missing + "/tags", `{"tags": ["focus"]}`, http.StatusNotFound},
+		{"删除不存在的标签", "DELETE", "/api/song/" + id + "/tags/focus", "", http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tc.expectedCode {
+				t.Errorf("期望状态码 %d, 得到 %d: %s", tc.expectedCode, w.Code, w.Body.String())
+			}
+		})
+	}
+}
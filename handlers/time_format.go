@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+	"zero-music/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// timeFormatRFC3339 以 RFC3339 字符串输出时间字段（默认格式）。
+	timeFormatRFC3339 = "rfc3339"
+	// timeFormatUnixMS 以 Unix 毫秒时间戳输出时间字段。
+	timeFormatUnixMS = "unix_ms"
+	// timeFormatKey 在 Gin Context 中存储时间格式的键名
+	timeFormatKey = "time_format"
+)
+
+// TimeFormat 是一个 Gin 中间件，解析 time_format 查询参数并存入上下文，
+// 参数为空时使用 defaultFormat（为空则为 rfc3339），取值无效时通过 RespondError 返回 400。
+func TimeFormat(defaultFormat string) gin.HandlerFunc {
+	if defaultFormat == "" {
+		defaultFormat = timeFormatRFC3339
+	}
+	return func(c *gin.Context) {
+		format := c.Query("time_format")
+		switch format {
+		case "":
+			format = defaultFormat
+		case timeFormatRFC3339, timeFormatUnixMS:
+		default:
+			RespondError(c, http.StatusBadRequest, NewBadRequestError("time_format 必须为 rfc3339 或 unix_ms"))
+			c.Abort()
+			return
+		}
+		c.Set(timeFormatKey, format)
+		c.Next()
+	}
+}
+
+// getTimeFormat 从 Gin Context 中获取时间格式，未经过 TimeFormat 中间件时返回 rfc3339。
+func getTimeFormat(c *gin.Context) string {
+	if format, ok := c.Get(timeFormatKey); ok {
+		if s, ok := format.(string); ok {
+			return s
+		}
+	}
+	return timeFormatRFC3339
+}
+
+// songJSON 是按请求的时间格式输出的歌曲。
+// AddedAt 位于更浅的层级，会覆盖 Song 自身的 added_at 字段。
+type songJSON struct {
+	*models.Song
+	AddedAt interface{} `json:"added_at" swaggertype:"string"`
+}
+
+// formatTime 按时间格式转换时间值：unix_ms 返回 Unix 毫秒时间戳，其余返回原值（序列化为 RFC3339）。
+func formatTime(t time.Time, format string) interface{} {
+	if format == timeFormatUnixMS {
+		return t.UnixMilli()
+	}
+	return t
+}
+
+// newSongJSON 返回按时间格式输出的歌曲。
+func newSongJSON(song *models.Song, format string) songJSON {
+	return songJSON{Song: song, AddedAt: formatTime(song.AddedAt, format)}
+}
+
+// songsJSON 按请求的时间格式转换歌曲列表。
+func songsJSON(c *gin.Context, songs []*models.Song) []songJSON {
+	format := getTimeFormat(c)
+	result := make([]songJSON, len(songs))
+	for i, song := range songs {
+		result[i] = newSongJSON(song, format)
+	}
+	return result
+}
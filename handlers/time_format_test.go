@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+	"zero-music/middleware"
+	"zero-music/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupTimeFormatTestEnv 创建注册了 TimeFormat 中间件的测试路由，defaultFormat 是服务器配置的默认格式。
+func setupTimeFormatTestEnv(t *testing.T, defaultFormat string) (*gin.Engine, services.Scanner) {
+	cfg, scanner := newTestLibrary(t, map[string]string{
+		"test1.mp3": "fake mp3 data 1",
+	}, nil)
+	pins, err := services.NewFilePinStore(filepath.Join(t.TempDir(), "pins.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags, err := services.NewFileTagStore(filepath.Join(t.TempDir(), "tags.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	router.Use(middleware.RequestID())
+	api := router.Group("/api", TimeFormat(defaultFormat))
+	handler := NewPlaylistHandler(scanner, pins, tags, cfg)
+	api.GET("/songs", handler.GetAllSongs)
+	api.GET("/song/:id", handler.GetSongByID)
+	return router, scanner
+}
+
+// TestTimeFormat 测试歌曲的 added_at 按 time_format 参数或服务器默认格式输出。
+func TestTimeFormat(t *testing.T) {
+	tests := []struct {
+		name          string
+		defaultFormat string
+		query         string
+		wantUnixMS    bool
+	}{
+		{"默认 RFC3339", "", "", false},
+		{"参数指定 unix_ms", "", "?time_format=unix_ms", true},
+		{"参数指定 rfc3339", "", "?time_format=rfc3339", false},
+		{"配置默认 unix_ms", "unix_ms", "", true},
+		{"参数覆盖配置默认值", "unix_ms", "?time_format=rfc3339", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, scanner := setupTimeFormatTestEnv(t, tt.defaultFormat)
+			songs, err := scanner.Scan(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			song := songs[0]
+			separator := "?"
+			if tt.query != "" {
+				separator = "&"
+			}
+
+			paths := []string{
+				"/api/songs" + tt.query,
+				"/api/songs" + tt.query + separator + "fields=id,added_at",
+				"/api/song/" + song.ID + tt.query,
+				"/api/song/" + song.ID + tt.query + separator + "related=true",
+			}
+			for _, path := range paths {
+				req, _ := http.NewRequest("GET", path, nil)
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				if w.Code != http.StatusOK {
+					t.Fatalf("%s: 期望状态码 200, 得到 %d: %s", path, w.Code, w.Body.String())
+				}
+
+				var response map[string]interface{}
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					t.Fatalf("%s: 解析响应失败: %v", path, err)
+				}
+				if list, ok := response["songs"].([]interface{}); ok {
+					response = list[0].(map[string]interface{})
+				}
+
+				addedAt := response["added_at"]
+				if tt.wantUnixMS {
+					ms, ok := addedAt.(float64)
+					if !ok || int64(ms) != song.AddedAt.UnixMilli() {
+						t.Errorf("%s: 期望 added_at 为 %d, 得到 %v", path, song.AddedAt.UnixMilli(), addedAt)
+					}
+					continue
+				}
+				s, ok := addedAt.(string)
+				if !ok {
+					t.Fatalf("%s: 期望 added_at 为 RFC3339 字符串, 得到 %v", path, addedAt)
+				}
+				parsed, err := time.Parse(time.RFC3339Nano, s)
+				if err != nil || !parsed.Equal(song.AddedAt) {
+					t.Errorf("%s: 期望 added_at 为 %v, 得到 %s", path, song.AddedAt, s)
+				}
+			}
+		})
+	}
+}
+
+// TestTimeFormat_Invalid 测试无效的 time_format 参数与其他错误一样通过 RespondError 返回 400：
+// 带有请求 ID，并按 Accept-Language 本地化错误消息。
+func TestTimeFormat_Invalid(t *testing.T) {
+	router, _ := setupTimeFormatTestEnv(t, "")
+
+	req, _ := http.NewRequest("GET", "/api/songs?time_format=unix", nil)
+	req.Header.Set("Accept-Language", "en")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("期望状态码 400, 得到 %d", w.Code)
+	}
+	var apiErr APIError
+	if err := json.Unmarshal(w.Body.Bytes(), &apiErr); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if apiErr.Code != "BAD_REQUEST" || apiErr.RequestID == "" {
+		t.Errorf("期望带有请求 ID 的 BAD_REQUEST 错误, 得到 %+v", apiErr)
+	}
+	if apiErr.Message != "time_format must be rfc3339 or unix_ms" {
+		t.Errorf("期望英文错误消息, 得到 %q", apiErr.Message)
+	}
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// transcodeFormats 是支持转码输出的 MIME 类型及对应的 ffmpeg 输出参数。
+var transcodeFormats = map[string][]string{
+	"audio/mpeg": {"-f", "mp3", "-codec:a", "libmp3lame", "-q:a", "2"},
+	"audio/ogg":  {"-f", "ogg", "-codec:a", "libvorbis", "-q:a", "5"},
+	"audio/flac": {"-f", "flac"},
+	"audio/wav":  {"-f", "wav"},
+}
+
+// acceptedMediaTypes 解析 Accept 头，返回 q 值大于 0 的媒体范围，按 q 值从高到低排列，q 值相同时保持原顺序。
+func acceptedMediaTypes(accept string) []string {
+	type mediaRange struct {
+		mediaType string
+		q         float64
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		fields := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(fields[0]))
+		if mediaType == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, mediaRange{mediaType, q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+
+	mediaTypes := make([]string, len(ranges))
+	for i, r := range ranges {
+		mediaTypes[i] = r.mediaType
+	}
+	return mediaTypes
+}
+
+// mediaTypeMatches 判断媒体范围（如 audio/*）是否匹配具体的 MIME 类型。
+func mediaTypeMatches(mediaRange, mimeType string) bool {
+	if mediaRange == "*/*" || mediaRange == mimeType {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(mediaRange, "/*")
+	return ok && strings.HasPrefix(mimeType, prefix+"/")
+}
+
+// negotiateAudioFormat 根据 Accept 头决定输出格式。
+// Accept 缺省或接受源格式时返回空字符串，表示直通原格式；否则在可以转码时返回 Accept 中首个支持转码的格式。
+// 源格式与可转码格式都不被接受时 ok 为 false。
+func negotiateAudioFormat(accept, source string, canTranscode bool) (target string, ok bool) {
+	if strings.TrimSpace(accept) == "" {
+		return "", true
+	}
+	mediaTypes := acceptedMediaTypes(accept)
+	for _, mediaType := range mediaTypes {
+		if mediaTypeMatches(mediaType, source) {
+			return "", true
+		}
+	}
+	if canTranscode {
+		for _, mediaType := range mediaTypes {
+			if _, supported := transcodeFormats[mediaType]; supported {
+				return mediaType, true
+			}
+		}
+	}
+	return "", false
+}
+
+// transcode 使用 ffmpeg 将 src 转码为 target 格式并写入 dst。
+// 转码输出的总长度事先未知，因此不支持 Range 请求。
+func transcode(ctx context.Context, ffmpegPath string, dst io.Writer, src io.Reader, target string) error {
+	args := []string{"-hide_banner", "-loglevel", "error", "-i", "pipe:0", "-vn", "-map_metadata", "-1"}
+	args = append(args, transcodeFormats[target]...)
+	args = append(args, "pipe:1")
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, ffmpegPath, args...)
+	cmd.Stdin = src
+	cmd.Stdout = dst
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("转码为 %s 失败: %v: %s", target, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
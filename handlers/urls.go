@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net"
+	"strings"
+	"zero-music/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestBaseURL 返回用于生成完整 URL 的基础地址（不含末尾斜杠）。
+// 配置了 publicBaseURL 时直接使用它；否则根据请求推断 scheme 与 host。
+// 只有直接连接的对端是 trustedProxies 中的反向代理时才采用 X-Forwarded-Proto 与 X-Forwarded-Host，
+// 否则任何客户端都能借这两个头把自己指定的主机注入生成的 URL。
+func requestBaseURL(c *gin.Context, publicBaseURL string, trustedProxies []*net.IPNet) string {
+	if publicBaseURL != "" {
+		return strings.TrimRight(publicBaseURL, "/")
+	}
+
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	host := c.Request.Host
+	if !isTrustedProxy(c.RemoteIP(), trustedProxies) {
+		return scheme + "://" + host
+	}
+
+	if proto := firstHeaderValue(c.GetHeader("X-Forwarded-Proto")); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+
+	if forwardedHost := firstHeaderValue(c.GetHeader("X-Forwarded-Host")); forwardedHost != "" {
+		host = forwardedHost
+	}
+
+	return scheme + "://" + host
+}
+
+// parseTrustedProxies 将配置中的受信任代理（IP 或 CIDR）解析为网段，单个 IP 视为只包含它自己的网段。
+// 配置验证时已确认各项有效，无法解析的项被忽略。
+func parseTrustedProxies(proxies []string) []*net.IPNet {
+	var nets []*net.IPNet
+	for _, proxy := range proxies {
+		if ip := net.ParseIP(proxy); ip != nil {
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		if _, ipNet, err := net.ParseCIDR(proxy); err == nil {
+			nets = append(nets, ipNet)
+		}
+	}
+	return nets
+}
+
+// isTrustedProxy 判断 remoteIP 是否属于受信任的反向代理。
+func isTrustedProxy(remoteIP string, trustedProxies []*net.IPNet) bool {
+	ip := net.ParseIP(remoteIP)
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range trustedProxies {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// firstHeaderValue 返回逗号分隔的头部值中的第一项（经过多级代理时会有多个值）。
+func firstHeaderValue(value string) string {
+	return strings.TrimSpace(strings.SplitN(value, ",", 2)[0])
+}
+
+// setURLs 为歌曲填充完整的 stream_url 与 cover_url。
+func setURLs(song *models.Song, baseURL string) {
+	song.StreamURL = baseURL + "/api/stream/" + song.ID
+	song.CoverURL = baseURL + "/api/cover/" + song.ID
+}
+
+// withURL 返回填充了完整 URL 的歌曲副本，不修改扫描器缓存中的数据。
+func withURL(song *models.Song, baseURL string) *models.Song {
+	copied := *song
+	setURLs(&copied, baseURL)
+	return &copied
+}
@@ -1,15 +1,18 @@
 package integration_test
 
 import (
+	"bytes"
 	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"zero-music/config"
 	"zero-music/handlers"
+	"zero-music/logger"
 	"zero-music/middleware"
 	"zero-music/services"
 
@@ -55,6 +58,7 @@ func setupTestServer(t *testing.T) (*gin.Engine, string) {
 	// 创建路由器
 	router := gin.New()
 	router.Use(middleware.RequestID())
+	router.Use(handlers.Recovery())
 
 	// 初始化扫描器和处理器
 	scanner := services.NewMusicScanner(
@@ -63,7 +67,7 @@ func setupTestServer(t *testing.T) (*gin.Engine, string) {
 		cfg.Music.CacheTTLMinutes,
 	)
 
-	playlistHandler := handlers.NewPlaylistHandler(scanner)
+	playlistHandler := handlers.NewPlaylistHandler(scanner, nil, nil, cfg)
 	streamHandler := handlers.NewStreamHandler(scanner, cfg)
 
 	// 设置路由
@@ -74,6 +78,10 @@ func setupTestServer(t *testing.T) (*gin.Engine, string) {
 		})
 	})
 
+	router.GET("/panic", func(c *gin.Context) {
+		panic("测试 panic")
+	})
+
 	api := router.Group("/api")
 	{
 		api.GET("/songs", playlistHandler.GetAllSongs)
@@ -219,6 +227,39 @@ func TestRequestIDMiddleware(t *testing.T) {
 	})
 }
 
+// TestRequestLogProto 测试访问日志中记录了请求的 HTTP 协议版本。
+func TestRequestLogProto(t *testing.T) {
+	router, _ := setupTestServer(t)
+
+	var buf bytes.Buffer
+	log := logger.GetLogger()
+	original := log.Out
+	log.SetOutput(&buf)
+	defer log.SetOutput(original)
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	req.Proto, req.ProtoMajor, req.ProtoMinor = "HTTP/2.0", 2, 0
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	found := false
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			continue
+		}
+		if entry["msg"] == "请求完成" {
+			found = true
+			if entry["proto"] != "HTTP/2.0" {
+				t.Errorf("期望日志 proto 字段为 HTTP/2.0, 得到 %v", entry["proto"])
+			}
+		}
+	}
+	if !found {
+		t.Errorf("未找到请求完成日志: %s", buf.String())
+	}
+}
+
 // TestConcurrentRequests 测试并发请求
 func TestConcurrentRequests(t *testing.T) {
 	router, _ := setupTestServer(t)
@@ -293,3 +334,59 @@ func TestStreamAudioContent(t *testing.T) {
 		t.Error("响应体为空")
 	}
 }
+
+// TestErrorResponseRequestID 测试 4xx/5xx 错误响应中是否包含与 X-Request-ID 一致的 request_id
+func TestErrorResponseRequestID(t *testing.T) {
+	router, _ := setupTestServer(t)
+
+	assertRequestID := func(t *testing.T, w *httptest.ResponseRecorder, expectCode int) {
+		t.Helper()
+		if w.Code != expectCode {
+			t.Fatalf("期望状态码 %d，实际得到 %d", expectCode, w.Code)
+		}
+		var response map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("解析响应失败: %v", err)
+		}
+		headerID := w.Header().Get("X-Request-ID")
+		if headerID == "" {
+			t.Fatal("响应缺少 X-Request-ID 头")
+		}
+		if response["request_id"] != headerID {
+			t.Errorf("期望 request_id 为 %s，实际得到 %v", headerID, response["request_id"])
+		}
+	}
+
+	t.Run("400 错误", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/song/abc123", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assertRequestID(t, w, http.StatusBadRequest)
+	})
+
+	t.Run("404 错误", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/stream/00000000000000000000000000000000", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assertRequestID(t, w, http.StatusNotFound)
+	})
+
+	t.Run("panic 导致的 500 错误", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/panic", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assertRequestID(t, w, http.StatusInternalServerError)
+	})
+
+	t.Run("503 错误", func(t *testing.T) {
+		// 使用新的服务器实例并删除音乐目录，目录不可用且没有缓存时扫描失败。
+		router, musicDir := setupTestServer(t)
+		if err := os.RemoveAll(musicDir); err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodGet, "/api/songs", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assertRequestID(t, w, http.StatusServiceUnavailable)
+	})
+}
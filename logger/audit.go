@@ -0,0 +1,138 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// auditLog 是独立于主日志的审计日志实例，为 nil 时审计记录写入主日志。
+var (
+	auditMu  sync.RWMutex
+	auditLog *logrus.Logger
+)
+
+// InitAudit 初始化写入独立文件的审计日志。
+// 文件超过 maxSizeMB 后轮转为 path.1、path.2……，最多保留 maxBackups 个旧文件；maxSizeMB 为 0 表示不轮转。
+// path 为空时不创建独立的审计日志，审计记录回退到主日志。返回的 Closer 用于在退出时关闭文件。
+func InitAudit(path string, maxSizeMB, maxBackups int) (io.Closer, error) {
+	auditMu.Lock()
+	defer auditMu.Unlock()
+
+	if path == "" {
+		auditLog = nil
+		return nil, nil
+	}
+
+	file, err := openRotatingFile(path, int64(maxSizeMB)<<20, maxBackups)
+	if err != nil {
+		auditLog = nil
+		return nil, fmt.Errorf("无法打开审计日志文件 %s: %v", path, err)
+	}
+
+	l := logrus.New()
+	l.SetFormatter(&logrus.JSONFormatter{
+		TimestampFormat: "2006-01-02 15:04:05",
+	})
+	l.SetOutput(file)
+	l.SetLevel(logrus.InfoLevel)
+	auditLog = l
+	return file, nil
+}
+
+// Audit 写入一条结构化审计记录。未初始化独立审计日志时写入主日志。
+func Audit(fields map[string]interface{}) {
+	auditMu.RLock()
+	l := auditLog
+	auditMu.RUnlock()
+	if l == nil {
+		l = GetLogger()
+	}
+	l.WithFields(fields).WithField("type", "audit").Info("流式传输审计")
+}
+
+// rotatingFile 是按大小轮转的日志文件写入器。
+type rotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// openRotatingFile 以追加方式打开日志文件。
+func openRotatingFile(path string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	f := &rotatingFile{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// open 打开当前日志文件并记录其大小。
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// Write 写入日志，写入后超过大小上限时先轮转。
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// rotate 将当前文件重命名为 path.1，已有的旧文件依次后移，超出 maxBackups 的被删除。
+func (f *rotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	if f.maxBackups <= 0 {
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return f.open()
+	}
+
+	os.Remove(fmt.Sprintf("%s.%d", f.path, f.maxBackups))
+	for i := f.maxBackups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", f.path, i), fmt.Sprintf("%s.%d", f.path, i+1))
+	}
+	if err := os.Rename(f.path, f.path+".1"); err != nil {
+		return err
+	}
+	return f.open()
+}
+
+// Close 关闭日志文件。
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Close()
+}
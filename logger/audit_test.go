@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestRotatingFile 测试日志文件超过大小上限后轮转，且只保留指定数量的旧文件。
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	f, err := openRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	for _, line := range []string{"aaaaaaaa\n", "bbbbbbbb\n", "cccccccc\n", "dddddddd\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := map[string]string{
+		path:        "dddddddd\n",
+		path + ".1": "cccccccc\n",
+		path + ".2": "bbbbbbbb\n",
+	}
+	for name, want := range expected {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("读取 %s 失败: %v", name, err)
+		}
+		if string(data) != want {
+			t.Errorf("期望 %s 的内容为 %q, 得到 %q", name, want, data)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Error("期望超出 MaxBackups 的旧文件被删除")
+	}
+}
+
+// TestAudit_FallbackToMainLogger 测试未配置审计日志文件时审计记录写入主日志。
+func TestAudit_FallbackToMainLogger(t *testing.T) {
+	if _, err := InitAudit("", 0, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf strings.Builder
+	l := GetLogger()
+	original := l.Out
+	l.SetOutput(&buf)
+	defer l.SetOutput(original)
+
+	Audit(map[string]interface{}{"song_id": "abc"})
+	if !strings.Contains(buf.String(), `"song_id":"abc"`) || !strings.Contains(buf.String(), `"type":"audit"`) {
+		t.Errorf("期望审计记录写入主日志, 得到 %s", buf.String())
+	}
+}
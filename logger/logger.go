@@ -34,7 +34,7 @@ func Init(logFilePath string) (*os.File, error) {
 	// 同时输出到文件和标准输出
 	multiWriter := io.MultiWriter(os.Stdout, logFile)
 	log.SetOutput(multiWriter)
-	
+
 	// 从环境变量读取日志级别，如果未设置则使用默认级别
 	logLevel := os.Getenv("LOG_LEVEL")
 	if logLevel == "" {
@@ -67,6 +67,21 @@ func WithRequestID(requestID string) *logrus.Entry {
 	return GetLogger().WithField("request_id", requestID)
 }
 
+// WithFields 创建带有结构化字段的日志条目
+func WithFields(fields map[string]interface{}) *logrus.Entry {
+	return GetLogger().WithFields(fields)
+}
+
+// Debug 记录调试级别日志
+func Debug(args ...interface{}) {
+	GetLogger().Debug(args...)
+}
+
+// Debugf 格式化记录调试级别日志
+func Debugf(format string, args ...interface{}) {
+	GetLogger().Debugf(format, args...)
+}
+
 // Info 记录信息级别日志
 func Info(args ...interface{}) {
 	GetLogger().Info(args...)
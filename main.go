@@ -1,66 +1,144 @@
+// 修改处理器上的 swaggo 注解后运行 go generate 更新 docs 中的 OpenAPI 规格。
+//go:generate swag init -g main.go -o docs --outputTypes go,json
+
 package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/fs"
+	"net"
 	"net/http"
+	"net/http/pprof"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"time"
 	"zero-music/config"
+	_ "zero-music/docs"
+	"zero-music/grpcserver"
 	"zero-music/handlers"
 	"zero-music/logger"
 	"zero-music/middleware"
+	"zero-music/models"
 	"zero-music/services"
+	"zero-music/web"
 
 	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
 	"go.uber.org/fx"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
 )
 
 // Params 定义命令行参数
 type Params struct {
 	ConfigPath string
 	LogFile    string
+	ScanOnly   bool
 }
 
 // parseFlags 解析命令行参数
 func parseFlags() *Params {
-	configPath := flag.String("config", "config.json", "指定配置文件的路径。")
+	configPath := flag.String("config", "config.json", "指定配置文件的路径，为 - 时从标准输入读取；设置 ZERO_MUSIC_CONFIG_JSON 环境变量时忽略。")
 	logFile := flag.String("log", "app.log", "指定日志文件的路径。")
+	scanOnly := flag.Bool("scan-only", false, "只扫描一次音乐目录并以 JSON 输出到标准输出后退出，不启动服务器；扫描失败时退出码非零。")
 	flag.Parse()
 
 	return &Params{
 		ConfigPath: *configPath,
 		LogFile:    *logFile,
+		ScanOnly:   *scanOnly,
 	}
 }
 
-// ProvideParams 提供命令行参数
-func ProvideParams() *Params {
-	return parseFlags()
-}
-
-// ProvideConfig 提供配置实例
+// ProvideConfig 提供配置实例。只有配置文件不存在时才使用默认配置；
+// 配置无法解析（如拼错的字段名）或验证失败时返回错误终止启动，而不是静默丢弃整个配置文件。
 func ProvideConfig(params *Params) (*config.Config, error) {
 	cfg, err := config.Load(params.ConfigPath)
-	if err != nil {
-		logger.Warnf("加载配置文件失败，将使用默认配置: %v", err)
+	if errors.Is(err, fs.ErrNotExist) {
+		logger.Warnf("配置文件不存在，将使用默认配置: %v", err)
 		return config.GetDefaultConfig(), nil
 	}
+	if err != nil {
+		return nil, fmt.Errorf("加载配置失败: %w", err)
+	}
 	return cfg, nil
 }
 
-// ProvideScanner 提供音乐扫描器实例
-func ProvideScanner(cfg *config.Config) services.Scanner {
+// ProvideScanner 提供音乐扫描器实例，内容指纹使用磁盘缓存持久化
+func ProvideScanner(cfg *config.Config, cache *services.DiskCache) (services.Scanner, error) {
+	// 歌曲 ID 长度必须在首次扫描前设置，长度无效时终止启动，而不是继续使用默认长度。
+	if cfg.Music.IDLength != 0 && cfg.Music.IDLength != models.DefaultSongIDLength {
+		if err := models.SetSongIDLength(cfg.Music.IDLength); err != nil {
+			return nil, err
+		}
+		logger.Warnf("歌曲 ID 长度已配置为 %d 字节（默认 %d 字节），以其他长度生成的歌曲 ID（置顶、播放进度、客户端收藏等）将失效",
+			cfg.Music.IDLength, models.DefaultSongIDLength)
+	}
+	opts := []services.ScannerOption{
+		services.WithIncludeHidden(cfg.Music.IncludeHidden),
+		services.WithMinFileSize(cfg.Music.MinFileSize),
+		services.WithScanTimeout(time.Duration(cfg.Music.ScanTimeoutSeconds) * time.Second),
+		services.WithTagTimeout(time.Duration(cfg.Music.TagTimeoutSeconds) * time.Second),
+		services.WithInferFromPath(cfg.Music.InferFromPath),
+	}
+	if cfg.Music.ScanMode != "" {
+		opts = append(opts, services.WithScanMode(cfg.Music.ScanMode))
+	}
+	if cfg.Music.ChangeDetection != "" {
+		opts = append(opts, services.WithChangeDetection(cfg.Music.ChangeDetection))
+	}
+	if cfg.Music.IgnoreMarkers != nil {
+		opts = append(opts, services.WithIgnoreMarkers(cfg.Music.IgnoreMarkers))
+	}
+	if cfg.Music.ArtistSeparators != nil {
+		opts = append(opts, services.WithArtistSeparators(cfg.Music.ArtistSeparators))
+	}
+	if cfg.Music.ComputeFingerprint {
+		opts = append(opts, services.WithFingerprint(cache))
+	}
+	if cfg.Music.ComputeAcousticFingerprint {
+		if fpcalcPath, err := exec.LookPath("fpcalc"); err == nil {
+			opts = append(opts, services.WithAcousticFingerprint(fpcalcPath, cache))
+		} else {
+			logger.Warnf("未找到 fpcalc，不计算声学指纹: %v", err)
+		}
+	}
+	if cfg.Music.FilenamePattern != "" {
+		pattern, err := regexp.Compile(cfg.Music.FilenamePattern)
+		if err != nil {
+			return nil, fmt.Errorf("FilenamePattern 不是有效的正则表达式: %w", err)
+		}
+		opts = append(opts, services.WithFilenamePattern(pattern))
+	}
+	// 配置验证时已确认编码受支持。
+	if enc, _ := models.LookupFilenameEncoding(cfg.Music.FilenameEncoding); enc != nil {
+		opts = append(opts, services.WithFilenameEncoding(enc))
+	}
 	return services.NewMusicScanner(
 		cfg.Music.Directory,
 		cfg.Music.SupportedFormats,
 		cfg.Music.CacheTTLMinutes,
-	)
+		opts...,
+	), nil
 }
 
 // ProvidePlaylistHandler 提供播放列表处理器
-func ProvidePlaylistHandler(scanner services.Scanner) *handlers.PlaylistHandler {
-	return handlers.NewPlaylistHandler(scanner)
+func ProvidePlaylistHandler(scanner services.Scanner, pins services.PinStore, tags services.TagStore, cfg *config.Config) *handlers.PlaylistHandler {
+	return handlers.NewPlaylistHandler(scanner, pins, tags, cfg)
+}
+
+// ProvidePinStore 提供歌曲置顶标记存储
+func ProvidePinStore(cfg *config.Config) (services.PinStore, error) {
+	return services.NewFilePinStore(filepath.Join(cfg.Storage.DataDir, "pins.json"))
 }
 
 // ProvideStreamHandler 提供流处理器
@@ -68,77 +146,327 @@ func ProvideStreamHandler(scanner services.Scanner, cfg *config.Config) *handler
 	return handlers.NewStreamHandler(scanner, cfg)
 }
 
+// ProvideTagStore 提供歌曲自定义标签存储
+func ProvideTagStore(cfg *config.Config) (services.TagStore, error) {
+	return services.NewFileTagStore(filepath.Join(cfg.Storage.DataDir, "tags.json"))
+}
+
+// ProvideProgressStore 提供播放进度存储
+func ProvideProgressStore(cfg *config.Config) (services.ProgressStore, error) {
+	return services.NewFileProgressStore(filepath.Join(cfg.Storage.DataDir, "progress.json"))
+}
+
+// ProvideProgressHandler 提供播放进度处理器
+func ProvideProgressHandler(store services.ProgressStore) *handlers.ProgressHandler {
+	return handlers.NewProgressHandler(store)
+}
+
+// ProvideDiskCache 提供封面等提取结果的磁盘缓存，未配置缓存目录时返回 nil（不缓存）
+func ProvideDiskCache(cfg *config.Config) (*services.DiskCache, error) {
+	if cfg.Music.CacheDir == "" {
+		return nil, nil
+	}
+	return services.NewDiskCache(cfg.Music.CacheDir)
+}
+
+// ProvideCoverHandler 提供封面处理器
+func ProvideCoverHandler(scanner services.Scanner, cache *services.DiskCache) *handlers.CoverHandler {
+	return handlers.NewCoverHandler(scanner, cache)
+}
+
+// ProvideGenreHandler 提供流派处理器
+func ProvideGenreHandler(scanner services.Scanner, cfg *config.Config) *handlers.GenreHandler {
+	return handlers.NewGenreHandler(scanner, cfg)
+}
+
+// ProvideRecommendHandler 提供推荐处理器
+func ProvideRecommendHandler(scanner services.Scanner, progress services.ProgressStore, pins services.PinStore) *handlers.RecommendHandler {
+	return handlers.NewRecommendHandler(scanner, progress, pins)
+}
+
+// ProvideArtistHandler 提供艺术家处理器
+func ProvideArtistHandler(scanner services.Scanner, cfg *config.Config) *handlers.ArtistHandler {
+	return handlers.NewArtistHandler(scanner, cfg)
+}
+
+// ProvideAlbumHandler 提供专辑处理器
+func ProvideAlbumHandler(scanner services.Scanner, streams *handlers.StreamHandler) *handlers.AlbumHandler {
+	return handlers.NewAlbumHandler(scanner, streams)
+}
+
+// ProvideBrowseHandler 提供目录浏览处理器
+func ProvideBrowseHandler(scanner services.Scanner, cfg *config.Config) *handlers.BrowseHandler {
+	return handlers.NewBrowseHandler(scanner, cfg)
+}
+
+// ProvideAdminHandler 提供运维处理器
+func ProvideAdminHandler(scanner services.Scanner) *handlers.AdminHandler {
+	return handlers.NewAdminHandler(scanner)
+}
+
+// ProvideHealthHandler 提供健康检查处理器
+func ProvideHealthHandler(scanner services.Scanner, streamHandler *handlers.StreamHandler, cfg *config.Config) *handlers.HealthHandler {
+	return handlers.NewHealthHandler(scanner, streamHandler, cfg.Music.Directory, cfg.Server.HealthChecks)
+}
+
+// ProvideStaticHandler 提供内置网页播放器的静态资源处理器，未启用时返回 nil
+func ProvideStaticHandler(cfg *config.Config) *handlers.StaticHandler {
+	if !cfg.Server.EnableWebUI {
+		return nil
+	}
+	return handlers.NewStaticHandler(web.FS())
+}
+
 // ProvideRouter 提供 Gin 路由器
 func ProvideRouter(
 	cfg *config.Config,
 	playlistHandler *handlers.PlaylistHandler,
 	streamHandler *handlers.StreamHandler,
-) *gin.Engine {
-	router := gin.Default()
+	progressHandler *handlers.ProgressHandler,
+	coverHandler *handlers.CoverHandler,
+	genreHandler *handlers.GenreHandler,
+	artistHandler *handlers.ArtistHandler,
+	albumHandler *handlers.AlbumHandler,
+	browseHandler *handlers.BrowseHandler,
+	adminHandler *handlers.AdminHandler,
+	healthHandler *handlers.HealthHandler,
+	recommendHandler *handlers.RecommendHandler,
+	staticHandler *handlers.StaticHandler,
+) (*gin.Engine, error) {
+	router, err := newBaseRouter(cfg)
+	if err != nil {
+		return nil, err
+	}
 
-	// 添加请求 ID 中间件
-	router.Use(middleware.RequestID())
+	// 添加安全相关的响应头，Content-Security-Policy 只作用于静态页面
+	if !cfg.Server.DisableSecurityHeaders {
+		router.Use(middleware.SecurityHeaders(cfg.Server.CSP()))
+	}
 
 	// 健康检查端点
-	router.GET("/health", func(c *gin.Context) {
-		// 检查音乐目录是否可访问。
-		musicDirAccessible := true
-		if _, err := os.Stat(cfg.Music.Directory); err != nil {
-			musicDirAccessible = false
-		}
-
-		status := "ok"
-		httpStatus := http.StatusOK
-		if !musicDirAccessible {
-			status = "degraded"
-			httpStatus = http.StatusServiceUnavailable
-		}
+	router.GET("/health", healthHandler.Health)
+	router.GET("/health/detail", healthHandler.Detail)
 
-		c.JSON(httpStatus, gin.H{
-			"status":               status,
-			"message":              "zero music服务器正在运行",
-			"music_dir_accessible": musicDirAccessible,
-			"music_directory":      cfg.Music.Directory,
-		})
-	})
-
-	// API 根端点
-	router.GET("/", func(c *gin.Context) {
+	// API 信息端点，启用网页播放器时根路径让给前端，仅保留 /api
+	endpoints := []string{
+		"GET /health - 健康检查",
+		"GET /health/detail - 详细健康检查（磁盘空间、流连接数、扫描状态）",
+		"GET /api - API 信息",
+		"GET /api/songs - 获取所有歌曲列表",
+		"GET /api/song/:id - 获取指定歌曲信息",
+		"POST /api/songs/batch - 批量获取歌曲信息",
+		"POST /api/song/:id/pin - 设置或取消歌曲置顶",
+		"POST /api/song/:id/tags - 为歌曲添加自定义标签",
+		"DELETE /api/song/:id/tags/:tag - 移除歌曲的自定义标签",
+		"GET /api/tags - 获取所有自定义标签及歌曲数",
+		"GET /api/duplicates - 获取内容指纹相同的重复歌曲",
+		"GET /api/recommend?limit= - 根据播放记录与收藏推荐歌曲",
+		"GET /api/stream/:id - 流式传输音频",
+		"GET /api/radio?format=&seed=&loop= - 随机电台连续音频流",
+		"GET /api/formats - 获取支持的音频格式及转码能力",
+		"GET /api/cover/:id - 获取歌曲封面",
+		"GET /api/genres - 获取所有流派及歌曲数",
+		"GET /api/genre/:name - 获取流派下的歌曲",
+		"GET /api/artists - 获取所有艺术家及歌曲数（合作歌曲计入每位艺术家）",
+		"GET /api/artist/:name - 获取艺术家参与的歌曲",
+		"GET /api/album/:name/download - 打包下载专辑（zip）",
+		"GET /api/album/:name/cover - 获取专辑封面（目录封面优先）",
+		"GET /api/browse?path= - 按目录逐层浏览歌曲",
+		"GET /api/progress/:id?device= - 获取播放进度",
+		"PUT /api/progress/:id - 保存播放进度",
+	}
+	// 配置了独立的运维端口时，主端口不暴露 /admin
+	if cfg.Server.AdminAddr == "" {
+		endpoints = append(endpoints,
+			"GET /admin/scan/info - 获取扫描缓存状态",
+			"GET /admin/scan/progress - 订阅扫描进度（SSE）",
+		)
+	}
+	apiInfo := func(c *gin.Context) {
 		c.JSON(200, gin.H{
-			"name":    "zero music API",
-			"version": "1.0.0",
-			"endpoints": []string{
-				"GET /health - 健康检查",
-				"GET /api/songs - 获取所有歌曲列表",
-				"GET /api/song/:id - 获取指定歌曲信息",
-				"GET /api/stream/:id - 流式传输音频",
-			},
+			"name":      "zero music API",
+			"version":   "1.0.0",
+			"endpoints": endpoints,
 		})
-	})
+	}
+	router.GET("/api", apiInfo)
+	if staticHandler != nil {
+		// 网页播放器的静态资源与前端路由回退
+		router.NoRoute(staticHandler.Serve)
+	} else {
+		router.GET("/", apiInfo)
+	}
 
 	// API 路由组
-	api := router.Group("/api")
+	api := router.Group("/api", handlers.TimeFormat(cfg.Server.TimeFormat))
 	{
 		// 播放列表路由
 		api.GET("/songs", playlistHandler.GetAllSongs)
 		api.GET("/song/:id", playlistHandler.GetSongByID)
+		api.POST("/songs/batch", playlistHandler.GetSongsBatch)
+		api.POST("/song/:id/pin", playlistHandler.SetPin)
+		api.POST("/song/:id/tags", playlistHandler.AddTags)
+		api.DELETE("/song/:id/tags/:tag", playlistHandler.RemoveTag)
+		api.GET("/tags", playlistHandler.GetTags)
+		api.GET("/duplicates", playlistHandler.GetDuplicates)
+		api.GET("/recommend", recommendHandler.Recommend)
 
 		// 音频流路由
 		api.GET("/stream/:id", streamHandler.StreamAudio)
+		api.GET("/radio", streamHandler.Radio)
+		api.GET("/formats", streamHandler.GetFormats)
+
+		// 封面路由
+		api.GET("/cover/:id", coverHandler.GetCover)
+
+		// 流派路由，名称使用通配参数以支持包含 "/" 的流派
+		api.GET("/genres", genreHandler.GetGenres)
+		api.GET("/genre/*name", genreHandler.GetSongsByGenre)
+
+		// 艺术家路由
+		api.GET("/artists", artistHandler.GetArtists)
+		api.GET("/artist/*name", artistHandler.GetSongsByArtist)
+
+		// 专辑路由
+		api.GET("/album/:name/download", albumHandler.DownloadAlbum)
+		api.GET("/album/:name/cover", albumHandler.GetCover)
+
+		// 目录浏览路由
+		api.GET("/browse", browseHandler.Browse)
+
+		// 播放进度路由
+		api.GET("/progress/:id", progressHandler.GetProgress)
+		api.PUT("/progress/:id", progressHandler.SaveProgress)
+	}
+
+	// 运维路由组，配置了独立的运维端口时只在该端口注册
+	if cfg.Server.AdminAddr == "" {
+		registerAdminRoutes(router, adminHandler)
+	}
+
+	if cfg.Server.EnablePprof {
+		registerPprof(router)
+	}
+	if cfg.Server.EnableSwagger {
+		registerSwagger(router)
 	}
 
-	return router
+	return router, nil
+}
+
+// newBaseRouter 创建注册了公共中间件的 Gin 路由器，主端口与运维端口共用
+func newBaseRouter(cfg *config.Config) (*gin.Engine, error) {
+	router := gin.Default()
+
+	// 配置受信任的反向代理，使 ClientIP 能正确解析 X-Forwarded-For
+	if err := configureTrustedProxies(router, cfg.Server.TrustedProxies); err != nil {
+		return nil, err
+	}
+
+	// 添加请求 ID 中间件，并在其后注册带请求 ID 的 panic 恢复中间件
+	router.Use(middleware.RequestID())
+	router.Use(handlers.Recovery())
+
+	// 按 pretty 参数或配置输出带缩进的 JSON 响应
+	router.Use(middleware.PrettyJSON(cfg.Server.PrettyJSON))
+
+	return router, nil
+}
+
+// registerAdminRoutes 在 /admin 下注册运维端点，无论注册在主端口还是运维端口都仅允许本地访问。
+func registerAdminRoutes(router *gin.Engine, adminHandler *handlers.AdminHandler) {
+	admin := router.Group("/admin", handlers.LocalOnly())
+	{
+		admin.GET("/scan/info", adminHandler.GetScanInfo)
+		admin.GET("/scan/progress", adminHandler.StreamScanProgress)
+	}
+}
+
+// registerSwagger 在 /swagger 下提供 OpenAPI 规格（/swagger/doc.json）与 Swagger UI（/swagger/index.html）。
+// Swagger UI 依赖内联脚本，因此移除静态页面的 Content-Security-Policy 头。
+func registerSwagger(router *gin.Engine) {
+	router.GET("/swagger/*any", func(c *gin.Context) {
+		c.Writer.Header().Del("Content-Security-Policy")
+		c.Next()
+	}, ginSwagger.WrapHandler(swaggerFiles.Handler))
+}
+
+// registerPprof 在 /debug/pprof 下注册性能分析端点，仅允许本地访问。
+func registerPprof(router *gin.Engine) {
+	debug := router.Group("/debug/pprof", handlers.LocalOnly())
+	{
+		debug.GET("/", gin.WrapF(pprof.Index))
+		debug.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+		debug.GET("/profile", gin.WrapF(pprof.Profile))
+		debug.GET("/symbol", gin.WrapF(pprof.Symbol))
+		debug.POST("/symbol", gin.WrapF(pprof.Symbol))
+		debug.GET("/trace", gin.WrapF(pprof.Trace))
+		// heap、goroutine 等命名 profile 由 pprof.Index 根据路径分派。
+		debug.GET("/:name", gin.WrapF(pprof.Index))
+	}
+}
+
+// configureTrustedProxies 设置路由器信任的反向代理。
+// proxies 为空时不信任任何代理，ClientIP 始终返回连接的对端地址。
+func configureTrustedProxies(router *gin.Engine, proxies []string) error {
+	if len(proxies) == 0 {
+		return router.SetTrustedProxies(nil)
+	}
+	if err := router.SetTrustedProxies(proxies); err != nil {
+		return fmt.Errorf("配置受信任代理失败: %v", err)
+	}
+	return nil
 }
 
 // ProvideHTTPServer 提供 HTTP 服务器
 func ProvideHTTPServer(cfg *config.Config, router *gin.Engine) *http.Server {
-	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
+	return newHTTPServer(cfg, fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port), router)
+}
+
+// AdminServer 是只提供运维路由的 HTTP 服务器。单独定义类型以便与主端口的 *http.Server 一起由 fx 注入。
+type AdminServer struct {
+	*http.Server
+}
+
+// ProvideAdminServer 提供运维端口的 HTTP 服务器，与主端口共享处理器及其依赖；未配置 AdminAddr 时返回 nil
+func ProvideAdminServer(cfg *config.Config, adminHandler *handlers.AdminHandler) (*AdminServer, error) {
+	if cfg.Server.AdminAddr == "" {
+		return nil, nil
+	}
+
+	router, err := newBaseRouter(cfg)
+	if err != nil {
+		return nil, err
+	}
+	registerAdminRoutes(router, adminHandler)
+	return &AdminServer{Server: newHTTPServer(cfg, cfg.Server.AdminAddr, router)}, nil
+}
+
+// newHTTPServer 按配置的超时与请求头大小创建监听 addr 的 HTTP 服务器
+func newHTTPServer(cfg *config.Config, addr string, router *gin.Engine) *http.Server {
+	var handler http.Handler = router
+	if cfg.Server.EnableH2C {
+		// 明文 HTTP/2 不经过 TLS 协商，需要由 h2c 处理 "PRI *" 前言与 Upgrade: h2c 请求。
+		handler = h2c.NewHandler(router, &http2.Server{
+			IdleTimeout: time.Duration(cfg.Server.IdleTimeoutSeconds) * time.Second,
+		})
+	}
 	return &http.Server{
-		Addr:    addr,
-		Handler: router,
+		Addr:              addr,
+		Handler:           handler,
+		ReadTimeout:       time.Duration(cfg.Server.ReadTimeoutSeconds) * time.Second,
+		WriteTimeout:      time.Duration(cfg.Server.WriteTimeoutSeconds) * time.Second,
+		IdleTimeout:       time.Duration(cfg.Server.IdleTimeoutSeconds) * time.Second,
+		ReadHeaderTimeout: time.Duration(cfg.Server.ReadHeaderTimeoutSeconds) * time.Second,
+		MaxHeaderBytes:    cfg.Server.MaxHeaderBytes,
 	}
 }
 
+// ProvideGRPCServer 提供与 HTTP 服务共用 Scanner 与并发流上限的 gRPC 服务器
+func ProvideGRPCServer(scanner services.Scanner, streams *handlers.StreamHandler) *grpc.Server {
+	return grpcserver.Register(grpcserver.NewServer(scanner, streams))
+}
+
 // initLogger 初始化日志系统
 func initLogger(lc fx.Lifecycle, params *Params) error {
 	logFileHandle, err := logger.Init(params.LogFile)
@@ -152,11 +480,19 @@ func initLogger(lc fx.Lifecycle, params *Params) error {
 			return nil
 		},
 		OnStop: func(ctx context.Context) error {
-			if logFileHandle != nil {
-				logger.Info("正在关闭日志文件...")
-				if err := logFileHandle.Close(); err != nil {
-					logger.Errorf("关闭日志文件时出错: %v", err)
-				}
+			// 日志文件打开失败时句柄为 nil，日志只输出到标准输出，无需关闭。
+			if logFileHandle == nil {
+				return nil
+			}
+			logger.Info("正在关闭日志文件...")
+			if err := logFileHandle.Sync(); err != nil {
+				logger.Errorf("刷新日志文件时出错: %v", err)
+			}
+			// 先切回标准输出，避免关闭后的日志写入已关闭的文件。
+			logger.GetLogger().SetOutput(os.Stdout)
+			if err := logFileHandle.Close(); err != nil {
+				logger.Errorf("关闭日志文件时出错: %v", err)
+			}
 			return nil
 		},
 	})
@@ -191,22 +527,225 @@ func startHTTPServer(lc fx.Lifecycle, srv *http.Server, cfg *config.Config) {
 	})
 }
 
+// startAdminServer 启动运维端口的 HTTP 服务器，未配置 AdminAddr 时不启动。
+// 在启动阶段完成监听，端口被占用等错误会使整个应用启动失败。
+func startAdminServer(lc fx.Lifecycle, srv *AdminServer) {
+	if srv == nil {
+		return
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			lis, err := net.Listen("tcp", srv.Addr)
+			if err != nil {
+				return fmt.Errorf("运维服务监听 %s 失败: %v", srv.Addr, err)
+			}
+			logger.Infof("运维服务地址: http://%s", lis.Addr())
+
+			go func() {
+				if err := srv.Serve(lis); err != nil && err != http.ErrServerClosed {
+					logger.Errorf("运维服务器运行失败: %v", err)
+				}
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			logger.Info("正在关闭运维服务器...")
+			if err := srv.Shutdown(ctx); err != nil {
+				logger.Errorf("运维服务器强制关闭: %v", err)
+				return err
+			}
+			logger.Info("运维服务器已关闭")
+			return nil
+		},
+	})
+}
+
+// initAuditLogger 初始化独立的流式传输审计日志，未配置审计日志文件时审计记录写入主日志
+func initAuditLogger(lc fx.Lifecycle, cfg *config.Config) {
+	auditFile, err := logger.InitAudit(cfg.Audit.LogFile, cfg.Audit.MaxSizeMB, cfg.Audit.MaxBackups)
+	if err != nil {
+		logger.Warnf("审计日志初始化警告: %v，审计记录将写入主日志", err)
+		return
+	}
+	if auditFile == nil {
+		return
+	}
+
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			if err := auditFile.Close(); err != nil {
+				logger.Errorf("关闭审计日志文件时出错: %v", err)
+			}
+			return nil
+		},
+	})
+}
+
+// warmupScanner 在启用预热时于启动后异步刷新一次扫描缓存。
+// 预热失败只记录日志，不阻止启动；预热期间到达的请求会等待这次扫描完成并复用其结果。
+func warmupScanner(lc fx.Lifecycle, scanner services.Scanner, cfg *config.Config) {
+	if !cfg.Music.WarmupOnStart {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				start := time.Now()
+				if _, err := scanner.Refresh(ctx); err != nil {
+					logger.Warnf("启动预热扫描失败: %v", err)
+					return
+				}
+				logger.Infof("启动预热扫描完成，共 %d 首歌曲，耗时 %v", scanner.GetSongCount(), time.Since(start))
+			}()
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			return nil
+		},
+	})
+}
+
+// startGRPCServer 启动 gRPC 服务器，未配置 gRPC 端口时不启动
+func startGRPCServer(lc fx.Lifecycle, srv *grpc.Server, cfg *config.Config) {
+	if cfg.Server.GRPCPort == 0 {
+		return
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.GRPCPort)
+			lis, err := net.Listen("tcp", addr)
+			if err != nil {
+				return fmt.Errorf("gRPC 服务监听 %s 失败: %v", addr, err)
+			}
+			logger.Infof("gRPC 服务地址: %s", addr)
+
+			go func() {
+				if err := srv.Serve(lis); err != nil {
+					logger.Errorf("gRPC 服务器运行失败: %v", err)
+				}
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			logger.Info("正在关闭 gRPC 服务器...")
+			stopped := make(chan struct{})
+			go func() {
+				srv.GracefulStop()
+				close(stopped)
+			}()
+			select {
+			case <-stopped:
+			case <-ctx.Done():
+				// 仍有活动的流时强制关闭。
+				srv.Stop()
+			}
+			logger.Info("gRPC 服务器已关闭")
+			return nil
+		},
+	})
+}
+
+// scanOnlyResult 是 --scan-only 模式输出的扫描结果。
+type scanOnlyResult struct {
+	Directory string         `json:"directory"`
+	SongCount int            `json:"song_count"`
+	Songs     []*models.Song `json:"songs"`
+}
+
+// runScanOnly 加载配置并扫描一次音乐目录，将结果以 JSON 写入 out，返回进程退出码。
+// 日志改为输出到标准错误，保证 out 中只有 JSON。
+func runScanOnly(params *Params, out io.Writer) int {
+	logger.GetLogger().SetOutput(os.Stderr)
+
+	// 与服务模式一致，配置文件不存在时使用默认配置。
+	cfg, err := ProvideConfig(params)
+	if err != nil {
+		logger.Error(err)
+		return 1
+	}
+	cache, err := ProvideDiskCache(cfg)
+	if err != nil {
+		logger.Errorf("创建磁盘缓存失败: %v", err)
+		return 1
+	}
+
+	scanner, err := ProvideScanner(cfg, cache)
+	if err != nil {
+		logger.Errorf("创建扫描器失败: %v", err)
+		return 1
+	}
+	songs, err := scanner.Scan(context.Background())
+	if err != nil {
+		logger.Errorf("扫描音乐目录失败: %v", err)
+		return 1
+	}
+	if songs == nil {
+		songs = []*models.Song{}
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(scanOnlyResult{
+		Directory: cfg.Music.Directory,
+		SongCount: len(songs),
+		Songs:     songs,
+	}); err != nil {
+		logger.Errorf("输出扫描结果失败: %v", err)
+		return 1
+	}
+	return 0
+}
+
+// @title Zero Music API
+// @version 1.0
+// @description Zero Music 音乐服务器的 HTTP API：歌曲列表、音频流、封面、播放进度等。
+// @BasePath /
 func main() {
+	params := parseFlags()
+	if params.ScanOnly {
+		os.Exit(runScanOnly(params, os.Stdout))
+	}
+
 	app := fx.New(
 		// 提供依赖
+		fx.Supply(params),
 		fx.Provide(
-			ProvideParams,
 			ProvideConfig,
 			ProvideScanner,
 			ProvidePlaylistHandler,
 			ProvideStreamHandler,
+			ProvidePinStore,
+			ProvideTagStore,
+			ProvideProgressStore,
+			ProvideProgressHandler,
+			ProvideDiskCache,
+			ProvideCoverHandler,
+			ProvideGenreHandler,
+			ProvideArtistHandler,
+			ProvideAlbumHandler,
+			ProvideBrowseHandler,
+			ProvideAdminHandler,
+			ProvideHealthHandler,
+			ProvideRecommendHandler,
+			ProvideStaticHandler,
 			ProvideRouter,
 			ProvideHTTPServer,
+			ProvideAdminServer,
+			ProvideGRPCServer,
 		),
 		// 调用初始化函数
 		fx.Invoke(
 			initLogger,
+			initAuditLogger,
+			warmupScanner,
 			startHTTPServer,
+			startAdminServer,
+			startGRPCServer,
 		),
 	)
 
@@ -0,0 +1,621 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+	"zero-music/config"
+	"zero-music/handlers"
+	"zero-music/services"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/fx/fxtest"
+	"golang.org/x/net/http2"
+)
+
+// TestProvideHTTPServer 测试 HTTP 服务器是否按配置设置了超时与请求头大小。
+func TestProvideHTTPServer(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{
+		Server: config.ServerConfig{
+			Host:                     "127.0.0.1",
+			Port:                     9000,
+			ReadTimeoutSeconds:       15,
+			WriteTimeoutSeconds:      0,
+			IdleTimeoutSeconds:       90,
+			ReadHeaderTimeoutSeconds: 5,
+			MaxHeaderBytes:           64 * 1024,
+		},
+	}
+
+	srv := ProvideHTTPServer(cfg, gin.New())
+
+	if srv.Addr != "127.0.0.1:9000" {
+		t.Errorf("期望监听地址为 127.0.0.1:9000, 得到 %s", srv.Addr)
+	}
+	if srv.ReadTimeout != 15*time.Second {
+		t.Errorf("期望 ReadTimeout 为 15s, 得到 %v", srv.ReadTimeout)
+	}
+	if srv.WriteTimeout != 0 {
+		t.Errorf("期望 WriteTimeout 为 0（不限制），得到 %v", srv.WriteTimeout)
+	}
+	if srv.IdleTimeout != 90*time.Second {
+		t.Errorf("期望 IdleTimeout 为 90s, 得到 %v", srv.IdleTimeout)
+	}
+	if srv.ReadHeaderTimeout != 5*time.Second {
+		t.Errorf("期望 ReadHeaderTimeout 为 5s, 得到 %v", srv.ReadHeaderTimeout)
+	}
+	if srv.MaxHeaderBytes != 64*1024 {
+		t.Errorf("期望 MaxHeaderBytes 为 65536, 得到 %d", srv.MaxHeaderBytes)
+	}
+}
+
+// TestProvideHTTPServer_Defaults 测试默认配置下长时间的音频流不会被写超时中断。
+func TestProvideHTTPServer_Defaults(t *testing.T) {
+	srv := ProvideHTTPServer(config.GetDefaultConfig(), gin.New())
+
+	if srv.WriteTimeout != 0 {
+		t.Errorf("期望默认 WriteTimeout 为 0, 得到 %v", srv.WriteTimeout)
+	}
+	if srv.ReadHeaderTimeout != config.DefaultReadHeaderTimeoutSeconds*time.Second {
+		t.Errorf("期望默认 ReadHeaderTimeout 为 %ds, 得到 %v", config.DefaultReadHeaderTimeoutSeconds, srv.ReadHeaderTimeout)
+	}
+	if srv.IdleTimeout != config.DefaultIdleTimeoutSeconds*time.Second {
+		t.Errorf("期望默认 IdleTimeout 为 %ds, 得到 %v", config.DefaultIdleTimeoutSeconds, srv.IdleTimeout)
+	}
+}
+
+// TestConfigureTrustedProxies 测试 ClientIP 仅在代理受信任时才采用 X-Forwarded-For。
+func TestConfigureTrustedProxies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		proxies []string
+		want    string
+	}{
+		{"未配置代理", nil, "10.0.0.1"},
+		{"代理受信任", []string{"10.0.0.0/8"}, "203.0.113.7"},
+		{"代理不受信任", []string{"192.168.1.1"}, "10.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			if err := configureTrustedProxies(router, tt.proxies); err != nil {
+				t.Fatalf("配置受信任代理失败: %v", err)
+			}
+			router.GET("/ip", func(c *gin.Context) {
+				c.String(http.StatusOK, c.ClientIP())
+			})
+
+			req := httptest.NewRequest("GET", "/ip", nil)
+			req.RemoteAddr = "10.0.0.1:12345"
+			req.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.2")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("期望客户端 IP 为 %s, 得到 %s", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestConfigureTrustedProxies_Invalid 测试无效的代理地址会返回错误。
+func TestConfigureTrustedProxies_Invalid(t *testing.T) {
+	if err := configureTrustedProxies(gin.New(), []string{"not-an-ip"}); err == nil {
+		t.Error("期望无效的代理地址返回错误")
+	}
+}
+
+// TestWarmupScanner 测试开启预热后启动不久即有歌曲缓存，预热失败不阻止启动。
+func TestWarmupScanner(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "test.mp3"), []byte("fake mp3 data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		directory string
+		wantSongs int
+	}{
+		{"预热成功", tmpDir, 1},
+		{"目录不存在", filepath.Join(tmpDir, "missing"), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				Music: config.MusicConfig{
+					Directory:        tt.directory,
+					SupportedFormats: []string{".mp3"},
+					CacheTTLMinutes:  5,
+					WarmupOnStart:    true,
+				},
+			}
+			scanner := services.NewMusicScanner(cfg.Music.Directory, cfg.Music.SupportedFormats, cfg.Music.CacheTTLMinutes)
+
+			lc := fxtest.NewLifecycle(t)
+			warmupScanner(lc, scanner, cfg)
+			lc.RequireStart()
+			defer lc.RequireStop()
+
+			deadline := time.Now().Add(2 * time.Second)
+			for scanner.Stats().LastScan.IsZero() && scanner.GetSongCount() < tt.wantSongs && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+			if got := scanner.GetSongCount(); got != tt.wantSongs {
+				t.Errorf("期望预热后缓存 %d 首歌曲, 得到 %d", tt.wantSongs, got)
+			}
+		})
+	}
+}
+
+// TestProvideHTTPServer_H2C 测试开启 h2c 后服务器能以明文 HTTP/2 响应请求。
+func TestProvideHTTPServer_H2C(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		enableH2C bool
+	}{
+		{"开启 h2c", true},
+		{"默认关闭", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/proto", func(c *gin.Context) {
+				c.String(http.StatusOK, c.Request.Proto)
+			})
+			cfg := &config.Config{Server: config.ServerConfig{EnableH2C: tt.enableH2C}}
+			srv := httptest.NewServer(ProvideHTTPServer(cfg, router).Handler)
+			defer srv.Close()
+
+			// 使用先验知识（prior knowledge）方式直接发起明文 HTTP/2 连接。
+			client := &http.Client{Transport: &http2.Transport{
+				AllowHTTP: true,
+				DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, network, addr)
+				},
+			}}
+			resp, err := client.Get(srv.URL + "/proto")
+			if !tt.enableH2C {
+				if err == nil {
+					resp.Body.Close()
+					t.Fatal("期望未开启 h2c 时明文 HTTP/2 请求失败")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("h2c 请求失败: %v", err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != http.StatusOK || resp.ProtoMajor != 2 {
+				t.Errorf("期望 HTTP/2 200, 得到 %s %d", resp.Proto, resp.StatusCode)
+			}
+			if string(body) != "HTTP/2.0" {
+				t.Errorf("期望处理器看到的协议为 HTTP/2.0, 得到 %s", body)
+			}
+		})
+	}
+}
+
+// TestProvideStaticHandler 测试启用网页播放器后根路径返回内置页面，未启用时不提供。
+func TestProvideStaticHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	if ProvideStaticHandler(&config.Config{}) != nil {
+		t.Error("期望默认不启用网页播放器")
+	}
+
+	handler := ProvideStaticHandler(&config.Config{Server: config.ServerConfig{EnableWebUI: true}})
+	if handler == nil {
+		t.Fatal("期望启用后返回静态资源处理器")
+	}
+	router := gin.New()
+	router.NoRoute(handler.Serve)
+
+	for path, wantType := range map[string]string{
+		"/":       "text/html",
+		"/app.js": "text/javascript",
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: 期望状态码 200, 得到 %d", path, w.Code)
+		}
+		if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, wantType) {
+			t.Errorf("%s: 期望 Content-Type 为 %s, 得到 %s", path, wantType, contentType)
+		}
+	}
+}
+
+// TestRegisterPprof 测试开启后本地可访问 /debug/pprof/，非本地访问返回 403，关闭时返回 404。
+func TestRegisterPprof(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		enabled    bool
+		remoteAddr string
+		path       string
+		wantStatus int
+	}{
+		{"开启后本地访问", true, "127.0.0.1:40000", "/debug/pprof/", http.StatusOK},
+		{"开启后访问命名 profile", true, "[::1]:40000", "/debug/pprof/goroutine", http.StatusOK},
+		{"开启后非本地访问", true, "192.0.2.1:40000", "/debug/pprof/", http.StatusForbidden},
+		{"关闭", false, "127.0.0.1:40000", "/debug/pprof/", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			if err := configureTrustedProxies(router, nil); err != nil {
+				t.Fatal(err)
+			}
+			if tt.enabled {
+				registerPprof(router)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.RemoteAddr = tt.remoteAddr
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Errorf("期望状态码 %d, 得到 %d", tt.wantStatus, w.Code)
+			}
+		})
+	}
+}
+
+// TestRunScanOnly 测试 scan-only 模式输出合法的 JSON 扫描结果，扫描失败时退出码非零。
+func TestRunScanOnly(t *testing.T) {
+	tmpDir := t.TempDir()
+	musicDir := filepath.Join(tmpDir, "music")
+	if err := os.MkdirAll(musicDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(musicDir, "test.mp3"), []byte("fake mp3 data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		directory string
+		wantCode  int
+		wantSongs int
+	}{
+		{"扫描成功", musicDir, 0, 1},
+		{"目录不存在", filepath.Join(tmpDir, "missing"), 1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configJSON, _ := json.Marshal(map[string]interface{}{
+				"server": map[string]interface{}{"port": 8080},
+				"music":  map[string]interface{}{"directory": tt.directory},
+			})
+			configPath := filepath.Join(t.TempDir(), "config.json")
+			if err := os.WriteFile(configPath, configJSON, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			var out bytes.Buffer
+			code := runScanOnly(&Params{ConfigPath: configPath, ScanOnly: true}, &out)
+			if code != tt.wantCode {
+				t.Fatalf("期望退出码 %d, 得到 %d", tt.wantCode, code)
+			}
+			if tt.wantCode != 0 {
+				return
+			}
+
+			var result scanOnlyResult
+			if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+				t.Fatalf("输出不是合法的 JSON: %v\n%s", err, out.String())
+			}
+			if result.SongCount != tt.wantSongs || len(result.Songs) != tt.wantSongs {
+				t.Errorf("期望 %d 首歌曲, 得到 song_count=%d, songs=%d", tt.wantSongs, result.SongCount, len(result.Songs))
+			}
+		})
+	}
+}
+
+// TestRunScanOnly_MissingConfig 测试 scan-only 模式在配置文件不存在时与服务模式一样使用默认配置。
+func TestRunScanOnly_MissingConfig(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	musicDir := filepath.Join(homeDir, "Music")
+	if err := os.MkdirAll(musicDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(musicDir, "test.mp3"), []byte("fake mp3 data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	code := runScanOnly(&Params{ConfigPath: filepath.Join(homeDir, "missing.json"), ScanOnly: true}, &out)
+	if code != 0 {
+		t.Fatalf("期望退出码 0, 得到 %d", code)
+	}
+
+	var result scanOnlyResult
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		t.Fatalf("输出不是合法的 JSON: %v\n%s", err, out.String())
+	}
+	if result.SongCount != 1 {
+		t.Errorf("期望 1 首歌曲, 得到 song_count=%d", result.SongCount)
+	}
+}
+
+// TestInitLogger 测试停止时刷新并关闭日志文件，日志文件无法打开（句柄为 nil）时 OnStop 也不会 panic。
+func TestInitLogger(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		logFile string
+		wantLog bool
+	}{
+		{"日志文件可用", filepath.Join(tmpDir, "app.log"), true},
+		{"日志文件无法打开", filepath.Join(tmpDir, "missing", "app.log"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lc := fxtest.NewLifecycle(t)
+			if err := initLogger(lc, &Params{LogFile: tt.logFile}); err != nil {
+				t.Fatalf("初始化日志失败: %v", err)
+			}
+			lc.RequireStart()
+			lc.RequireStop()
+
+			data, err := os.ReadFile(tt.logFile)
+			if !tt.wantLog {
+				if err == nil {
+					t.Error("期望日志文件不存在")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("读取日志文件失败: %v", err)
+			}
+			if !strings.Contains(string(data), "正在关闭日志文件") {
+				t.Errorf("期望关闭前的日志已写入文件, 得到 %s", data)
+			}
+		})
+	}
+}
+
+// TestRegisterSwagger 测试开启后 /swagger/doc.json 返回合法的 OpenAPI 规格，
+// 并且规格覆盖了所有 /api 与 /admin 路由，新增端点后需要运行 go generate 更新规格。
+func TestRegisterSwagger(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{Server: config.ServerConfig{EnableSwagger: true}}
+	// 注册路由时只取处理器的方法值，不会调用处理器。
+	router, err := ProvideRouter(cfg, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/swagger/doc.json", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("期望状态码 200, 得到 %d", w.Code)
+	}
+	var spec struct {
+		Swagger string                                `json:"swagger"`
+		Info    struct{ Title string }                `json:"info"`
+		Paths   map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("解析 OpenAPI 规格失败: %v", err)
+	}
+	if spec.Swagger != "2.0" || spec.Info.Title == "" {
+		t.Errorf("期望 swagger 2.0 规格且包含标题, 得到 %q, %q", spec.Swagger, spec.Info.Title)
+	}
+
+	// gin 的 :name 与 *name 参数对应规格中的 {name}。
+	param := regexp.MustCompile(`[:*]([^/]+)`)
+	for _, route := range router.Routes() {
+		if !strings.HasPrefix(route.Path, "/api/") && !strings.HasPrefix(route.Path, "/admin/") {
+			continue
+		}
+		path := param.ReplaceAllString(route.Path, "{$1}")
+		if _, ok := spec.Paths[path][strings.ToLower(route.Method)]; !ok {
+			t.Errorf("OpenAPI 规格缺少 %s %s，请运行 go generate 更新", route.Method, path)
+		}
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/swagger/index.html", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "swagger-ui") {
+		t.Errorf("期望返回 Swagger UI 页面, 得到 %d", w.Code)
+	}
+	if csp := w.Header().Get("Content-Security-Policy"); csp != "" {
+		t.Errorf("期望 Swagger UI 不设置 Content-Security-Policy, 得到 %q", csp)
+	}
+
+	// 默认关闭。
+	router, err = ProvideRouter(&config.Config{}, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/swagger/doc.json", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("期望默认关闭时返回 404, 得到 %d", w.Code)
+	}
+}
+
+// TestAdminServer 测试配置 AdminAddr 后 /admin 只在运维端口可达，主端口返回 404；未配置时仍在主端口提供；
+// 两种情况下非本地地址访问 /admin 都返回 403。
+func TestAdminServer(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	scanner := services.NewMusicScanner(t.TempDir(), []string{".mp3"}, 5)
+	adminHandler := handlers.NewAdminHandler(scanner)
+
+	// 先占用一个空闲端口再释放，得到可供运维服务监听的地址。
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	adminAddr := lis.Addr().String()
+	lis.Close()
+
+	cfg := &config.Config{Server: config.ServerConfig{AdminAddr: adminAddr}}
+	router, err := ProvideRouter(cfg, nil, nil, nil, nil, nil, nil, nil, nil, adminHandler, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/admin/scan/info", nil)
+	req.RemoteAddr = "127.0.0.1:40000"
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("期望主端口访问 /admin 返回 404, 得到 %d", w.Code)
+	}
+
+	srv, err := ProvideAdminServer(cfg, adminHandler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lc := fxtest.NewLifecycle(t)
+	startAdminServer(lc, srv)
+	lc.RequireStart()
+	defer lc.RequireStop()
+
+	resp, err := http.Get("http://" + adminAddr + "/admin/scan/info")
+	if err != nil {
+		t.Fatalf("请求运维端口失败: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("期望运维端口访问 /admin 返回 200, 得到 %d", resp.StatusCode)
+	}
+	if resp.Header.Get("X-Request-ID") == "" {
+		t.Error("期望运维端口同样经过请求 ID 中间件")
+	}
+
+	// 运维端口同样只允许本地访问。
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/admin/scan/info", nil)
+	req.RemoteAddr = "192.0.2.1:40000"
+	srv.Handler.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("期望非本地地址访问运维端口返回 403, 得到 %d", w.Code)
+	}
+
+	// 未配置 AdminAddr 时不创建运维服务器，/admin 仍注册在主端口。
+	if srv, err := ProvideAdminServer(&config.Config{}, adminHandler); err != nil || srv != nil {
+		t.Errorf("期望未配置时不创建运维服务器, 得到 %v, %v", srv, err)
+	}
+	router, err = ProvideRouter(&config.Config{}, nil, nil, nil, nil, nil, nil, nil, nil, adminHandler, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		remoteAddr string
+		wantStatus int
+	}{
+		{"127.0.0.1:40000", http.StatusOK},
+		{"192.0.2.1:40000", http.StatusForbidden},
+	} {
+		w = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, "/admin/scan/info", nil)
+		req.RemoteAddr = tt.remoteAddr
+		router.ServeHTTP(w, req)
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: 期望未配置时主端口访问 /admin 返回 %d, 得到 %d", tt.remoteAddr, tt.wantStatus, w.Code)
+		}
+	}
+}
+
+// TestProvideConfig 测试配置文件不存在时使用默认配置，无法解析或验证失败时返回错误而不是回退到默认配置。
+func TestProvideConfig(t *testing.T) {
+	t.Setenv(config.ConfigJSONEnv, "")
+	dir := t.TempDir()
+	musicDir := filepath.Join(dir, "music")
+	if err := os.MkdirAll(musicDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	musicJSON, _ := json.Marshal(musicDir)
+
+	tests := []struct {
+		name     string
+		content  string // 为空时不创建配置文件
+		wantErr  bool
+		wantPort int
+	}{
+		{"配置文件不存在", "", false, config.DefaultServerPort},
+		{"有效配置", `{"server":{"port":9000},"music":{"directory":` + string(musicJSON) + `}}`, false, 9000},
+		{"未知字段", `{"server":{"prot":9000},"music":{"directory":` + string(musicJSON) + `}}`, true, 0},
+		{"验证失败", `{"server":{"port":70000},"music":{"directory":` + string(musicJSON) + `}}`, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			if tt.content != "" {
+				if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			cfg, err := ProvideConfig(&Params{ConfigPath: path})
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("期望返回错误, 得到配置 %+v", cfg.Server)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("期望加载成功, 得到 %v", err)
+			}
+			if cfg.Server.Port != tt.wantPort {
+				t.Errorf("期望端口 %d, 得到 %d", tt.wantPort, cfg.Server.Port)
+			}
+		})
+	}
+}
+
+// TestProvideScanner_InvalidConfig 测试无法生效的扫描配置（如超出范围的 ID 长度）使启动失败，而不是静默使用默认值。
+func TestProvideScanner_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *config.Config)
+	}{
+		{"ID 长度超出范围", func(cfg *config.Config) { cfg.Music.IDLength = 100 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.GetDefaultConfig()
+			cfg.Music.Directory = t.TempDir()
+			tt.modify(cfg)
+			if _, err := ProvideScanner(cfg, nil); err == nil {
+				t.Error("期望无效的扫描配置返回错误")
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"zero-music/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PrettyJSONIndent 是美化输出时每级缩进使用的字符串。
+const PrettyJSONIndent = "  "
+
+// prettyJSONWriterKey 在 Gin Context 中存储本次请求的 prettyJSONWriter 的键名，只在需要美化输出时设置。
+const prettyJSONWriterKey = "pretty_json_writer"
+
+// PrettyJSON 是一个 Gin 中间件，按需将 JSON 响应格式化为带缩进的形式，便于调试时阅读。
+// pretty 查询参数（true/false）优先于 defaultPretty；需要美化时缓冲整个 JSON 响应体，
+// 在处理器返回后统一缩进再写出。其他类型的响应（如音频流）照常直接写出；
+// 流式输出的 JSON 端点通过 BypassPrettyJSON 跳过缓冲并自行缩进。
+func PrettyJSON(defaultPretty bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pretty := defaultPretty
+		if b, err := strconv.ParseBool(c.Query("pretty")); err == nil {
+			pretty = b
+		}
+		if !pretty {
+			c.Next()
+			return
+		}
+
+		writer := &prettyJSONWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Set(prettyJSONWriterKey, writer)
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		if !writer.buffering {
+			return
+		}
+		var indented bytes.Buffer
+		body := writer.body.Bytes()
+		if err := json.Indent(&indented, body, "", PrettyJSONIndent); err == nil {
+			indented.WriteByte('\n')
+			body = indented.Bytes()
+		}
+		if _, err := writer.ResponseWriter.Write(body); err != nil {
+			logger.WithRequestID(GetRequestID(c)).Warnf("写出美化后的 JSON 响应失败: %v", err)
+		}
+	}
+}
+
+// BypassPrettyJSON 让流式输出 JSON 的 handler 绕过 PrettyJSON 的整体缓冲，返回本次响应是否需要美化。
+// 返回 true 时响应体直接写出，由调用方自行以 PrettyJSONIndent 缩进；须在写出响应体之前调用。
+func BypassPrettyJSON(c *gin.Context) bool {
+	value, ok := c.Get(prettyJSONWriterKey)
+	if !ok {
+		return false
+	}
+	writer := value.(*prettyJSONWriter)
+	writer.decided = true
+	writer.buffering = false
+	return true
+}
+
+// prettyJSONWriter 缓冲 JSON 响应体，非 JSON 响应直接写入底层 ResponseWriter。
+// 是否缓冲在第一次写入时根据 Content-Type 决定。
+type prettyJSONWriter struct {
+	gin.ResponseWriter
+	decided   bool
+	buffering bool
+	body      bytes.Buffer
+}
+
+// Write 实现 io.Writer。
+func (w *prettyJSONWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		w.decided = true
+		w.buffering = strings.HasPrefix(w.Header().Get("Content-Type"), "application/json")
+	}
+	if w.buffering {
+		return w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// Unwrap 返回被包装的 ResponseWriter，使 http.ResponseController（写超时）与音频流的 sendfile
+// 能穿过本中间件找到底层连接。
+func (w *prettyJSONWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// WriteString 实现 io.StringWriter，gin 的部分渲染器通过它写出响应体。
+func (w *prettyJSONWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
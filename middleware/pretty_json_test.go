@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestPrettyJSON 测试 pretty 参数与默认配置控制 JSON 响应是否缩进，非 JSON 响应不受影响。
+func TestPrettyJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(defaultPretty bool) *gin.Engine {
+		router := gin.New()
+		router.Use(PrettyJSON(defaultPretty))
+		router.GET("/api/song", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"id": "abc", "tags": []string{"rock"}})
+		})
+		// 模拟流式列表端点：分多次写出 JSON。
+		router.GET("/api/songs", func(c *gin.Context) {
+			c.Header("Content-Type", "application/json; charset=utf-8")
+			c.Status(http.StatusOK)
+			c.Writer.WriteString(`{"total":1,`)
+			c.Writer.WriteString(`"songs":[{"id":"abc"}` + "\n" + `]}`)
+		})
+		router.GET("/api/stream", func(c *gin.Context) {
+			c.Data(http.StatusOK, "audio/mpeg", []byte(`{"id":"abc"}`))
+		})
+		return router
+	}
+
+	const prettySong = "{\n  \"id\": \"abc\",\n  \"tags\": [\n    \"rock\"\n  ]\n}\n"
+	const prettySongs = "{\n  \"total\": 1,\n  \"songs\": [\n    {\n      \"id\": \"abc\"\n    }\n  ]\n}\n"
+
+	testCases := []struct {
+		name          string
+		defaultPretty bool
+		path          string
+		expected      string
+	}{
+		{"默认紧凑", false, "/api/song", `{"id":"abc","tags":["rock"]}`},
+		{"详情缩进", false, "/api/song?pretty=true", prettySong},
+		{"列表缩进", false, "/api/songs?pretty=1", prettySongs},
+		{"配置默认缩进", true, "/api/song", prettySong},
+		{"参数关闭缩进", true, "/api/song?pretty=false", `{"id":"abc","tags":["rock"]}`},
+		{"非 JSON 响应不变", true, "/api/stream", `{"id":"abc"}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			w := httptest.NewRecorder()
+			newRouter(tc.defaultPretty).ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("期望状态码 200, 得到 %d", w.Code)
+			}
+			if body := w.Body.String(); body != tc.expected {
+				t.Errorf("期望响应体 %q, 得到 %q", tc.expected, body)
+			}
+		})
+	}
+}
+
+// TestBypassPrettyJSON 测试流式端点调用 BypassPrettyJSON 后响应体不再被缓冲与缩进，返回值反映是否需要美化。
+func TestBypassPrettyJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, tc := range []struct {
+		path       string
+		wantPretty bool
+	}{
+		{"/api/songs", false},
+		{"/api/songs?pretty=true", true},
+	} {
+		t.Run(tc.path, func(t *testing.T) {
+			var pretty bool
+			var bufferedBytes int
+			router := gin.New()
+			router.Use(PrettyJSON(false))
+			router.GET("/api/songs", func(c *gin.Context) {
+				pretty = BypassPrettyJSON(c)
+				c.Header("Content-Type", "application/json; charset=utf-8")
+				c.Status(http.StatusOK)
+				c.Writer.WriteString(`{"total":1,`)
+				if w, ok := c.Writer.(*prettyJSONWriter); ok {
+					bufferedBytes = w.body.Len()
+				}
+				c.Writer.WriteString(`"songs":[]}`)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if pretty != tc.wantPretty {
+				t.Errorf("期望 BypassPrettyJSON 返回 %v, 得到 %v", tc.wantPretty, pretty)
+			}
+			if bufferedBytes != 0 {
+				t.Errorf("期望绕过后不再缓冲响应体, 已缓冲 %d 字节", bufferedBytes)
+			}
+			if body := w.Body.String(); body != `{"total":1,"songs":[]}` {
+				t.Errorf("期望响应体原样写出, 得到 %q", body)
+			}
+		})
+	}
+}
@@ -53,6 +53,7 @@ func RequestID() gin.HandlerFunc {
 		logger.WithRequestID(requestID).WithFields(map[string]interface{}{
 			"method":     method,
 			"path":       path,
+			"proto":      c.Request.Proto,
 			"client_ip":  c.ClientIP(),
 			"user_agent": c.Request.UserAgent(),
 		}).Info("请求开始")
@@ -67,6 +68,7 @@ func RequestID() gin.HandlerFunc {
 		logEntry := logger.WithRequestID(requestID).WithFields(map[string]interface{}{
 			"method":     method,
 			"path":       path,
+			"proto":      c.Request.Proto,
 			"status":     status,
 			"latency_ms": latency.Milliseconds(),
 			"client_ip":  c.ClientIP(),
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SecurityHeaders 是一个 Gin 中间件，为所有响应设置通用的安全相关 HTTP 头：
+// X-Content-Type-Options、X-Frame-Options 与 Referrer-Policy。
+// csp 不为空时，为 /api 以外的路径（静态页面等）设置 Content-Security-Policy；
+// API 响应为 JSON 或音频流，不需要 CSP。
+// 音频流本身带有正确的 Content-Type，nosniff 不会影响播放。
+func SecurityHeaders(csp string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.Writer.Header()
+		header.Set("X-Content-Type-Options", "nosniff")
+		header.Set("X-Frame-Options", "DENY")
+		header.Set("Referrer-Policy", "strict-origin-when-cross-origin")
+		if csp != "" && !isAPIPath(c.Request.URL.Path) {
+			header.Set("Content-Security-Policy", csp)
+		}
+		c.Next()
+	}
+}
+
+// isAPIPath 判断请求路径是否为 /api 或其下的接口。
+func isAPIPath(path string) bool {
+	return path == "/api" || strings.HasPrefix(path, "/api/")
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestSecurityHeaders 测试响应包含预期的安全头，CSP 只作用于 /api 以外的路径，音频流保留自身的 Content-Type。
+func TestSecurityHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const csp = "default-src 'self'"
+
+	newRouter := func(csp string) *gin.Engine {
+		router := gin.New()
+		router.Use(SecurityHeaders(csp))
+		router.GET("/", func(c *gin.Context) {
+			c.Data(http.StatusOK, "text/html; charset=utf-8", []byte("<html></html>"))
+		})
+		router.GET("/api/songs", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"songs": []string{}})
+		})
+		router.GET("/api/stream/:id", func(c *gin.Context) {
+			c.Data(http.StatusOK, "audio/mpeg", []byte("fake mp3"))
+		})
+		return router
+	}
+
+	testCases := []struct {
+		name        string
+		csp         string
+		path        string
+		expectedCSP string
+		contentType string
+	}{
+		{"静态页面", csp, "/", csp, "text/html; charset=utf-8"},
+		{"API 不设置 CSP", csp, "/api/songs", "", "application/json; charset=utf-8"},
+		{"音频流", csp, "/api/stream/abc", "", "audio/mpeg"},
+		{"关闭 CSP", "", "/", "", "text/html; charset=utf-8"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tc.path, nil)
+			w := httptest.NewRecorder()
+			newRouter(tc.csp).ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("期望状态码 200, 得到 %d", w.Code)
+			}
+			expected := map[string]string{
+				"X-Content-Type-Options":  "nosniff",
+				"X-Frame-Options":         "DENY",
+				"Referrer-Policy":         "strict-origin-when-cross-origin",
+				"Content-Security-Policy": tc.expectedCSP,
+				"Content-Type":            tc.contentType,
+			}
+			for name, value := range expected {
+				if got := w.Header().Get(name); got != value {
+					t.Errorf("期望 %s 为 %q, 得到 %q", name, value, got)
+				}
+			}
+		})
+	}
+}
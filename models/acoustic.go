@@ -0,0 +1,107 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/bits"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const (
+	// AcousticFingerprintLength 是计算声学指纹时分析的音频时长（秒），与 fpcalc 的默认值一致。
+	AcousticFingerprintLength = 120
+	// acousticFingerprintTimeout 是单个文件运行 fpcalc 的超时时间。
+	acousticFingerprintTimeout = time.Minute
+	// acousticMaxOffset 是比较两个声学指纹时尝试对齐的最大偏移（指纹项数，每项约 0.12 秒），
+	// 用于抵消不同编码器在开头引入的延迟。
+	acousticMaxOffset = 8
+	// acousticMinOverlap 是比较时两个指纹至少需要重叠的项数，重叠过少时结果没有意义。
+	acousticMinOverlap = 16
+)
+
+// ComputeAcousticFingerprint 调用 chromaprint 的 fpcalc 命令行计算文件开头 AcousticFingerprintLength 秒的声学指纹。
+// 与内容指纹不同，声学指纹基于解码后的音频特征，同一首歌的不同编码（格式、码率、采样率）得到相近的指纹，
+// 可用 AcousticSimilarity 比较。返回值是原始指纹（小端 uint32 数组）的 base64 编码。
+func ComputeAcousticFingerprint(ctx context.Context, fpcalcPath, filePath string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, acousticFingerprintTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, fpcalcPath, "-raw", "-json", "-length", fmt.Sprint(AcousticFingerprintLength), filePath)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("fpcalc 处理 %s 失败: %v: %s", filePath, err, strings.TrimSpace(stderr.String()))
+	}
+
+	// 不同版本的 fpcalc 输出的原始指纹可能是有符号或无符号整数，统一按 32 位解释。
+	var result struct {
+		Fingerprint []int64 `json:"fingerprint"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		return "", fmt.Errorf("解析 fpcalc 输出失败: %v", err)
+	}
+	if len(result.Fingerprint) == 0 {
+		return "", fmt.Errorf("fpcalc 没有为 %s 生成指纹", filePath)
+	}
+
+	raw := make([]byte, 4*len(result.Fingerprint))
+	for i, v := range result.Fingerprint {
+		binary.LittleEndian.PutUint32(raw[4*i:], uint32(v))
+	}
+	return base64.StdEncoding.EncodeToString(raw), nil
+}
+
+// decodeAcousticFingerprint 将 ComputeAcousticFingerprint 返回的字符串还原为原始指纹。
+func decodeAcousticFingerprint(fingerprint string) ([]uint32, error) {
+	raw, err := base64.StdEncoding.DecodeString(fingerprint)
+	if err != nil || len(raw)%4 != 0 {
+		return nil, errors.New("无效的声学指纹")
+	}
+	values := make([]uint32, len(raw)/4)
+	for i := range values {
+		values[i] = binary.LittleEndian.Uint32(raw[4*i:])
+	}
+	return values, nil
+}
+
+// AcousticSimilarity 比较两个声学指纹，返回 0 到 1 之间的相似度：重叠部分相同比特所占的比例。
+// 在 ±acousticMaxOffset 的偏移范围内取最高值。同一音频的不同编码通常高于 0.9，
+// 无关的音频约为 0.5。指纹无效或重叠过短时返回错误。
+func AcousticSimilarity(a, b string) (float64, error) {
+	fa, err := decodeAcousticFingerprint(a)
+	if err != nil {
+		return 0, err
+	}
+	fb, err := decodeAcousticFingerprint(b)
+	if err != nil {
+		return 0, err
+	}
+
+	best, compared := 0.0, false
+	for offset := -acousticMaxOffset; offset <= acousticMaxOffset; offset++ {
+		// offset 为正时 a 向后错开 offset 项与 b 对齐。
+		ia, ib := max(offset, 0), max(-offset, 0)
+		n := min(len(fa)-ia, len(fb)-ib)
+		if n < acousticMinOverlap {
+			continue
+		}
+		differing := 0
+		for i := 0; i < n; i++ {
+			differing += bits.OnesCount32(fa[ia+i] ^ fb[ib+i])
+		}
+		compared = true
+		best = max(best, 1-float64(differing)/float64(32*n))
+	}
+	if !compared {
+		return 0, errors.New("声学指纹过短，无法比较")
+	}
+	return best, nil
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// DefaultArtistSeparators 是拆分多艺术家字符串时默认使用的分隔符，英文字母不区分大小写。
+// 注意 "/" 会把 "AC/DC" 这类名称拆开，可以通过配置去掉。
+var DefaultArtistSeparators = []string{";", "/", "feat.", "ft."}
+
+// ArtistSplitter 按一组分隔符将标签中的艺术家字符串（如 "A; B"、"A feat. B"）拆分为艺术家列表。
+// nil 表示不拆分。
+type ArtistSplitter struct {
+	re *regexp.Regexp
+}
+
+// NewArtistSplitter 创建按 separators 拆分的 ArtistSplitter，分隔符中的英文字母不区分大小写。
+// 没有非空分隔符时返回 nil。
+func NewArtistSplitter(separators []string) *ArtistSplitter {
+	quoted := make([]string, 0, len(separators))
+	for _, sep := range separators {
+		if sep = strings.TrimSpace(sep); sep != "" {
+			quoted = append(quoted, regexp.QuoteMeta(sep))
+		}
+	}
+	if len(quoted) == 0 {
+		return nil
+	}
+	return &ArtistSplitter{re: regexp.MustCompile(`(?i)` + strings.Join(quoted, "|"))}
+}
+
+// Split 拆分艺术家字符串。每个片段去除首尾空白与括号（如 "A (feat. B)" 中的括号），
+// 空片段被丢弃，按 GroupKey 重复的名称只保留首次出现的写法。
+// 没有可拆分的内容时返回只包含原字符串（去除首尾空白）的列表，空字符串返回 nil。
+func (s *ArtistSplitter) Split(artist string) []string {
+	artist = strings.TrimSpace(artist)
+	if artist == "" {
+		return nil
+	}
+	if s == nil {
+		return []string{artist}
+	}
+
+	artists := make([]string, 0, 2)
+	seen := make(map[string]bool)
+	for _, part := range s.re.Split(artist, -1) {
+		part = strings.TrimFunc(part, func(r rune) bool {
+			return unicode.IsSpace(r) || strings.ContainsRune("()[]", r)
+		})
+		key := GroupKey(part)
+		if part == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		artists = append(artists, part)
+	}
+	if len(artists) == 0 {
+		return []string{artist}
+	}
+	return artists
+}
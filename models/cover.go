@@ -0,0 +1,105 @@
+package models
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNoCover 表示音频文件中没有嵌入封面图片，同目录下也没有封面文件。
+var ErrNoCover = errors.New("没有找到封面")
+
+// folderCoverNames 是同目录封面文件的候选文件名（不区分大小写），按优先级排列。
+var folderCoverNames = []string{
+	"cover.jpg", "cover.jpeg", "cover.png",
+	"folder.jpg", "folder.jpeg", "folder.png",
+	"front.jpg", "front.jpeg", "front.png",
+	"album.jpg", "album.jpeg", "album.png",
+}
+
+// FindFolderCover 返回目录中的封面文件路径（如 cover.jpg、folder.png），文件名不区分大小写。
+// 没有封面文件或目录无法读取时返回空字符串。
+func FindFolderCover(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	found := make(map[string]string)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			found[strings.ToLower(entry.Name())] = entry.Name()
+		}
+	}
+	for _, name := range folderCoverNames {
+		if actual, ok := found[name]; ok {
+			return filepath.Join(dir, actual)
+		}
+	}
+	return ""
+}
+
+// Cover 是从音频文件标签中提取的封面图片。
+type Cover struct {
+	// MIMEType 是图片的 MIME 类型（如 image/jpeg）。
+	MIMEType string
+	// Data 是图片的原始数据。
+	Data []byte
+}
+
+// ReadCover 从音频文件的标签中读取嵌入的封面图片。
+// 文件没有标签或标签中没有图片时回退到同目录的封面文件，都没有时返回 ErrNoCover。
+func ReadCover(filePath string) (*Cover, error) {
+	cover, err := ReadEmbeddedCover(filePath)
+	if errors.Is(err, ErrNoCover) {
+		return readFolderCover(filepath.Dir(filePath))
+	}
+	return cover, err
+}
+
+// ReadEmbeddedCover 只读取音频文件标签中嵌入的封面图片，不回退到同目录的封面文件。
+// 文件没有标签或标签中没有图片时返回 ErrNoCover。
+func ReadEmbeddedCover(filePath string) (*Cover, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	metadata, err := readTags(file)
+	if err != nil || metadata.Picture() == nil || len(metadata.Picture().Data) == 0 {
+		return nil, ErrNoCover
+	}
+
+	picture := metadata.Picture()
+	mimeType := picture.MIMEType
+	if mimeType == "" {
+		mimeType = "image/jpeg"
+	}
+	return &Cover{
+		MIMEType: mimeType,
+		Data:     picture.Data,
+	}, nil
+}
+
+// ReadCoverFile 读取封面图片文件，文件为空或无法读取时返回 ErrNoCover。
+func ReadCoverFile(path string) (*Cover, error) {
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) == 0 {
+		return nil, ErrNoCover
+	}
+	return &Cover{
+		MIMEType: http.DetectContentType(data),
+		Data:     data,
+	}, nil
+}
+
+// readFolderCover 读取目录中的封面文件，没有封面文件时返回 ErrNoCover。
+func readFolderCover(dir string) (*Cover, error) {
+	path := FindFolderCover(dir)
+	if path == "" {
+		return nil, ErrNoCover
+	}
+	return ReadCoverFile(path)
+}
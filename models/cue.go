@@ -0,0 +1,258 @@
+package models
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cueFramesPerSecond 是 cue 时间戳 MM:SS:FF 中每秒的帧数（CD 扇区数）。
+const cueFramesPerSecond = 75
+
+// ErrMultiFileCue 表示 cue 文件引用了多个音频文件，目前只支持单文件整轨专辑。
+var ErrMultiFileCue = errors.New("暂不支持引用多个音频文件的 cue")
+
+// CueSheet 是解析后的 .cue 文件内容。
+type CueSheet struct {
+	// Title 是专辑标题。
+	Title string
+	// Performer 是专辑艺术家。
+	Performer string
+	// Genre 来自 "REM GENRE" 注释，可能为空。
+	Genre string
+	// File 是 cue 引用的音频文件名。
+	File string
+	// Tracks 是按出现顺序排列的音轨。
+	Tracks []CueTrack
+}
+
+// CueTrack 是 cue 文件中的一条音轨。
+type CueTrack struct {
+	// Number 是音轨号。
+	Number int
+	// Title 是音轨标题，可能为空。
+	Title string
+	// Performer 是音轨艺术家，可能为空。
+	Performer string
+	// Start 是音轨在音频文件中的起始时间（INDEX 01）。
+	Start time.Duration
+}
+
+// ParseCue 解析 .cue 文件内容。
+// 只关心单文件整轨专辑需要的字段，未知命令会被忽略；音轨起点取 INDEX 01。
+func ParseCue(r io.Reader) (*CueSheet, error) {
+	sheet := &CueSheet{}
+	var track *CueTrack
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if lineNo == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		fields := cueFields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch strings.ToUpper(fields[0]) {
+		case "FILE":
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("第 %d 行: FILE 缺少文件名", lineNo)
+			}
+			if sheet.File != "" {
+				return nil, ErrMultiFileCue
+			}
+			sheet.File = fields[1]
+		case "TRACK":
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("第 %d 行: TRACK 缺少音轨号", lineNo)
+			}
+			number, err := strconv.Atoi(fields[1])
+			if err != nil || number <= 0 {
+				return nil, fmt.Errorf("第 %d 行: 无效的音轨号 %q", lineNo, fields[1])
+			}
+			sheet.Tracks = append(sheet.Tracks, CueTrack{Number: number, Start: -1})
+			track = &sheet.Tracks[len(sheet.Tracks)-1]
+		case "TITLE":
+			if len(fields) < 2 {
+				continue
+			}
+			if track != nil {
+				track.Title = fields[1]
+			} else {
+				sheet.Title = fields[1]
+			}
+		case "PERFORMER":
+			if len(fields) < 2 {
+				continue
+			}
+			if track != nil {
+				track.Performer = fields[1]
+			} else {
+				sheet.Performer = fields[1]
+			}
+		case "REM":
+			if len(fields) >= 3 && strings.EqualFold(fields[1], "GENRE") && track == nil {
+				sheet.Genre = fields[2]
+			}
+		case "INDEX":
+			if track == nil || len(fields) < 3 || fields[1] != "01" {
+				continue
+			}
+			start, err := parseCueTime(fields[2])
+			if err != nil {
+				return nil, fmt.Errorf("第 %d 行: %v", lineNo, err)
+			}
+			track.Start = start
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(sheet.Tracks) == 0 {
+		return nil, errors.New("cue 文件中没有音轨")
+	}
+	for i, t := range sheet.Tracks {
+		if t.Start < 0 {
+			return nil, fmt.Errorf("音轨 %d 缺少 INDEX 01", t.Number)
+		}
+		if i > 0 && t.Start <= sheet.Tracks[i-1].Start {
+			return nil, fmt.Errorf("音轨 %d 的起始时间早于上一音轨", t.Number)
+		}
+	}
+	return sheet, nil
+}
+
+// cueFields 将一行 cue 命令拆分为字段，双引号包围的内容视为一个字段。
+func cueFields(line string) []string {
+	fields := make([]string, 0, 4)
+	for line = strings.TrimSpace(line); line != ""; line = strings.TrimSpace(line) {
+		if line[0] == '"' {
+			end := strings.IndexByte(line[1:], '"')
+			if end < 0 {
+				fields = append(fields, line[1:])
+				break
+			}
+			fields = append(fields, line[1:end+1])
+			line = line[end+2:]
+			continue
+		}
+		end := strings.IndexAny(line, " \t")
+		if end < 0 {
+			fields = append(fields, line)
+			break
+		}
+		fields = append(fields, line[:end])
+		line = line[end:]
+	}
+	return fields
+}
+
+// parseCueTime 解析 MM:SS:FF 格式的 cue 时间戳。
+func parseCueTime(s string) (time.Duration, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("无效的时间戳 %q", s)
+	}
+	var values [3]int
+	for i, part := range parts {
+		v, err := strconv.Atoi(part)
+		if err != nil || v < 0 {
+			return 0, fmt.Errorf("无效的时间戳 %q", s)
+		}
+		values[i] = v
+	}
+	if values[1] >= 60 || values[2] >= cueFramesPerSecond {
+		return 0, fmt.Errorf("无效的时间戳 %q", s)
+	}
+	frames := (values[0]*60+values[1])*cueFramesPerSecond + values[2]
+	return time.Duration(frames) * time.Second / cueFramesPerSecond, nil
+}
+
+// ReadCueTracks 读取 cuePath 指向的 .cue 文件，并将整轨歌曲 song 拆分为每条音轨一首的虚拟歌曲。
+// 虚拟歌曲共享源文件路径，通过 StartMS/EndMS 记录起止时间，ID 基于文件路径和音轨号生成。
+// 源文件格式必须能确定总时长（目前支持 FLAC 与 WAV），否则返回错误。
+func ReadCueTracks(song *Song, cuePath string) ([]*Song, error) {
+	cueFile, err := os.Open(cuePath)
+	if err != nil {
+		return nil, err
+	}
+	sheet, err := ParseCue(cueFile)
+	cueFile.Close()
+	if err != nil {
+		return nil, fmt.Errorf("解析 %s 失败: %w", cuePath, err)
+	}
+
+	audio, err := os.Open(song.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer audio.Close()
+	layout, err := readAudioLayout(audio, song.FileSize, song.Format)
+	if err != nil {
+		return nil, fmt.Errorf("无法拆分 %s: %w", song.FilePath, err)
+	}
+
+	tracks := make([]*Song, 0, len(sheet.Tracks))
+	for i, t := range sheet.Tracks {
+		if t.Start >= layout.duration {
+			break
+		}
+		// 起止时间以毫秒精度保存，这里同样按毫秒换算，保证 FileSize 与 OpenTrack 的输出一致。
+		start := t.Start.Truncate(time.Millisecond)
+		end := layout.duration
+		var endMS int64
+		if i < len(sheet.Tracks)-1 && sheet.Tracks[i+1].Start < layout.duration {
+			endMS = sheet.Tracks[i+1].Start.Milliseconds()
+			end = time.Duration(endMS) * time.Millisecond
+		}
+
+		track := *song
+		track.ID = generateTrackID(song.FilePath, t.Number)
+		track.Title = normalizeText(firstNonEmpty(t.Title, fmt.Sprintf("Track %02d", t.Number)))
+		track.Artist = normalizeText(firstNonEmpty(t.Performer, sheet.Performer, song.Artist))
+		track.Album = normalizeText(firstNonEmpty(sheet.Title, song.Album))
+		track.Genre = normalizeText(firstNonEmpty(song.Genre, sheet.Genre))
+		track.TrackNumber = t.Number
+		track.Duration = int((end - start) / time.Second)
+		track.StartMS = start.Milliseconds()
+		track.EndMS = endMS
+		track.FileSize = layout.trackSize(start, end)
+		if song.Fingerprint != "" {
+			track.Fingerprint = trackFingerprint(song.Fingerprint, track.StartMS)
+		}
+		if !track.IsCueTrack() {
+			// 只有一条音轨时虚拟歌曲即为整个文件。
+			track.FileSize = song.FileSize
+		} else {
+			// 整个文件的声学指纹不能代表其中的单条音轨。
+			track.AcousticFingerprint = ""
+		}
+		tracks = append(tracks, &track)
+	}
+	return tracks, nil
+}
+
+// generateTrackID 使用“文件路径 + 音轨号”生成 cue 虚拟歌曲的 ID，格式与 generateID 相同。
+func generateTrackID(filePath string, trackNumber int) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s#%d", filePath, trackNumber)))
+	return hex.EncodeToString(hash[:SongIDLength()])
+}
+
+// firstNonEmpty 返回第一个非空且不为 "Unknown" 的字符串，都不满足时返回最后一个参数。
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" && v != "Unknown" {
+			return v
+		}
+	}
+	return values[len(values)-1]
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// LookupFilenameEncoding 按名称（如 "gbk"、"shift-jis"，不区分大小写）查找文件名使用的字符编码。
+// 名称为空或表示 UTF-8 时返回 nil，表示文件名不需要转码。
+func LookupFilenameEncoding(name string) (encoding.Encoding, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, nil
+	}
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("不支持的文件名编码: %s", name)
+	}
+	if canonical, _ := htmlindex.Name(enc); canonical == "utf-8" {
+		return nil, nil
+	}
+	return enc, nil
+}
+
+// decodeFilename 将按 enc 编码的文件名转码为 UTF-8，用于标题等展示字段。
+// enc 为 nil、文件名已是合法的 UTF-8 或转码失败时原样返回。
+func decodeFilename(name string, enc encoding.Encoding) string {
+	if enc == nil || utf8.ValidString(name) {
+		return name
+	}
+	decoded, err := enc.NewDecoder().String(name)
+	if err != nil {
+		return name
+	}
+	return decoded
+}
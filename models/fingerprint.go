@@ -0,0 +1,79 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+)
+
+// fingerprintPrefixSize 是计算内容指纹时读取的文件开头字节数。
+const fingerprintPrefixSize = 1 << 20
+
+// ComputeFingerprint 计算文件的内容指纹：文件前 1MB 与文件大小的 SHA256。
+// 只读取文件开头，开销与文件大小无关；内容相同的文件即使路径不同也得到相同的指纹。
+func ComputeFingerprint(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	if _, err := io.CopyN(hash, file, fingerprintPrefixSize); err != nil && err != io.EOF {
+		return "", fmt.Errorf("读取 %s 失败: %v", filePath, err)
+	}
+	var size [8]byte
+	binary.BigEndian.PutUint64(size[:], uint64(info.Size()))
+	hash.Write(size[:])
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// trackFingerprint 由源文件指纹和起始时间派生 cue 虚拟歌曲的指纹，使同一文件的各音轨互不相同。
+func trackFingerprint(fileFingerprint string, startMS int64) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s#%d", fileFingerprint, startMS)))
+	return hex.EncodeToString(hash[:])
+}
+
+// ComputeContentHash 计算整个文件内容的 SHA256，用于判断文件内容是否真正发生变化。
+// 与 ComputeFingerprint 不同，它读取整个文件，文件任意位置（包括末尾的 ID3v1 标签）的修改都会改变结果。
+func ComputeContentHash(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("读取 %s 失败: %v", filePath, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// ComputeTrackChecksum 计算歌曲音频内容的 SHA256，即 /api/stream 未转码时返回的字节。
+// 普通歌曲等同于 ComputeContentHash；cue 虚拟歌曲只覆盖对应的音轨片段（含 OpenTrack 拼接的文件头）。
+func ComputeTrackChecksum(song *Song) (string, error) {
+	file, err := os.Open(song.FilePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	content, _, err := OpenTrack(file, song)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(hash, content); err != nil {
+		return "", fmt.Errorf("读取 %s 失败: %v", song.FilePath, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
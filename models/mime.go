@@ -0,0 +1,36 @@
+package models
+
+import (
+	"mime"
+	"path/filepath"
+	"strings"
+)
+
+// audioMimeTypes 为常见音频格式提供固定的 MIME 类型。
+// 系统的 MIME 数据库往往缺少部分音频格式（如 .opus、.aiff）或在不同平台上取值不一致，
+// 因此音频格式优先使用该映射。
+var audioMimeTypes = map[string]string{
+	".mp3":  "audio/mpeg",
+	".flac": "audio/flac",
+	".wav":  "audio/wav",
+	".m4a":  "audio/mp4",
+	".m4b":  "audio/mp4",
+	".ogg":  "audio/ogg",
+	".opus": "audio/opus",
+	".aac":  "audio/aac",
+	".wma":  "audio/x-ms-wma",
+	".aiff": "audio/aiff",
+	".aif":  "audio/aiff",
+}
+
+// MIMEType 根据文件扩展名返回对应的 MIME 类型，未知类型返回 application/octet-stream。
+func MIMEType(filename string) string {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if mimeType, ok := audioMimeTypes[ext]; ok {
+		return mimeType
+	}
+	if mimeType := mime.TypeByExtension(ext); mimeType != "" {
+		return mimeType
+	}
+	return "application/octet-stream"
+}
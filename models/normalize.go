@@ -0,0 +1,21 @@
+package models
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// normalizeText 规范化标签中的展示文本：去除首尾空白并进行 Unicode NFC 归一化。
+// 其余差异（全角字符、大小写）保留原样，只在 GroupKey 中折叠。
+func normalizeText(s string) string {
+	return norm.NFC.String(strings.TrimSpace(s))
+}
+
+// GroupKey 返回用于分组比较的规范化键。
+// 它使用 NFKC 兼容归一化将全角字符折叠为半角，合并连续空白，并进行大小写折叠，
+// 使 "  Artist Name "、"Ａｒｔｉｓｔ　Name" 与 "artist name" 得到相同的键。
+// 键只用于比较，不应用于展示。
+func GroupKey(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(norm.NFKC.String(s)), " "))
+}
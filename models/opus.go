@@ -0,0 +1,92 @@
+package models
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	// opusGranuleRate 是 Ogg/Opus 粒度位置的时钟频率，与原始采样率无关，固定为 48kHz。
+	opusGranuleRate = 48000
+	// oggPageHeaderSize 是 Ogg 页头（不含分段表）的字节数。
+	oggPageHeaderSize = 27
+	// oggTailSize 是查找最后一个 Ogg 页时从文件末尾读取的字节数，足以覆盖一个最大的 Ogg 页。
+	oggTailSize = 64 * 1024
+)
+
+// opusInfo 是从 Ogg/Opus 文件头解析出的音频信息。
+type opusInfo struct {
+	// duration 是音频时长，由最后一页的粒度位置减去预跳过采样数估算。
+	duration time.Duration
+	// sampleRate 是 OpusHead 中记录的原始输入采样率，未记录时为 48000。
+	sampleRate int
+}
+
+// readOpusInfo 解析 Ogg/Opus 文件：从首页的 OpusHead 读取预跳过采样数与采样率，
+// 再从文件末尾的最后一个 Ogg 页读取粒度位置估算时长。
+func readOpusInfo(r io.ReaderAt, size int64) (*opusInfo, error) {
+	header := make([]byte, oggPageHeaderSize)
+	if _, err := r.ReadAt(header, 0); err != nil || string(header[:4]) != "OggS" {
+		return nil, errors.New("不是有效的 Ogg 文件")
+	}
+	segments := int(header[26])
+	segmentTable := make([]byte, segments)
+	if _, err := r.ReadAt(segmentTable, oggPageHeaderSize); err != nil {
+		return nil, errors.New("读取 Ogg 分段表失败")
+	}
+
+	// OpusHead: 8 字节魔数、版本、声道数、2 字节预跳过采样数、4 字节输入采样率（均为小端）。
+	opusHead := make([]byte, 19)
+	if _, err := r.ReadAt(opusHead, int64(oggPageHeaderSize+segments)); err != nil || string(opusHead[:8]) != "OpusHead" {
+		return nil, errors.New("不是 Opus 音频")
+	}
+	preSkip := int64(binary.LittleEndian.Uint16(opusHead[10:12]))
+	sampleRate := int(binary.LittleEndian.Uint32(opusHead[12:16]))
+	if sampleRate == 0 {
+		sampleRate = opusGranuleRate
+	}
+
+	// 从文件末尾向前查找最后一个 Ogg 页的页头。
+	tailSize := min(size, oggTailSize)
+	tail := make([]byte, tailSize)
+	if _, err := r.ReadAt(tail, size-tailSize); err != nil && err != io.EOF {
+		return nil, err
+	}
+	for i := bytes.LastIndex(tail, []byte("OggS")); i >= 0; i = bytes.LastIndex(tail[:i], []byte("OggS")) {
+		if len(tail)-i < oggPageHeaderSize {
+			continue
+		}
+		granule := int64(binary.LittleEndian.Uint64(tail[i+6 : i+14]))
+		// 粒度位置为 -1 表示该页没有完整的数据包结束。
+		if granule < 0 {
+			continue
+		}
+		samples := max(granule-preSkip, 0)
+		return &opusInfo{
+			duration:   time.Duration(samples) * time.Second / opusGranuleRate,
+			sampleRate: sampleRate,
+		}, nil
+	}
+	return nil, errors.New("找不到有效的 Ogg 页")
+}
+
+// isOggFormat 判断扩展名是否为可能包含 Opus 音频的 Ogg 容器。
+func isOggFormat(ext string) bool {
+	switch strings.ToLower(ext) {
+	case ".opus", ".ogg", ".oga":
+		return true
+	}
+	return false
+}
+
+// averageBitrate 根据文件大小（字节）与时长（秒）估算平均比特率（kbps），时长未知时返回 0。
+func averageBitrate(fileSize int64, duration int) int {
+	if duration <= 0 {
+		return 0
+	}
+	return int(fileSize * 8 / int64(duration) / 1000)
+}
@@ -3,31 +3,87 @@ package models
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/dhowden/tag"
+	"golang.org/x/text/encoding"
 )
 
 const (
-	// SongIDLength 是歌曲 ID 的字节长度（SHA256 哈希的前 16 字节）
-	SongIDLength = 16
+	// DefaultSongIDLength 是歌曲 ID 的默认字节长度（SHA256 哈希的前 16 字节）
+	DefaultSongIDLength = 16
+	// MinSongIDLength 是允许配置的最小歌曲 ID 字节长度。
+	MinSongIDLength = 8
+	// MaxSongIDLength 是允许配置的最大歌曲 ID 字节长度，即完整的 SHA256 哈希。
+	MaxSongIDLength = sha256.Size
 )
 
+var (
+	// songIDLength 是当前使用的歌曲 ID 字节长度。
+	songIDLength atomic.Int32
+	// songIDPattern 是与 songIDLength 对应的已编译 ID 校验正则表达式。
+	songIDPattern atomic.Pointer[regexp.Regexp]
+)
+
+func init() {
+	if err := SetSongIDLength(DefaultSongIDLength); err != nil {
+		panic(err)
+	}
+}
+
+// SetSongIDLength 设置歌曲 ID 的字节长度，之后生成与校验的 ID 均为 2*length 个十六进制字符。
+// 修改长度会使之前生成的 ID 全部失效，应在启动时、首次扫描前调用。
+func SetSongIDLength(length int) error {
+	if length < MinSongIDLength || length > MaxSongIDLength {
+		return fmt.Errorf("歌曲 ID 长度必须在 %d 到 %d 字节之间，当前值: %d", MinSongIDLength, MaxSongIDLength, length)
+	}
+	songIDPattern.Store(regexp.MustCompile(fmt.Sprintf(`^[a-f0-9]{%d}$`, length*2)))
+	songIDLength.Store(int32(length))
+	return nil
+}
+
+// SongIDLength 返回当前使用的歌曲 ID 字节长度。
+func SongIDLength() int {
+	return int(songIDLength.Load())
+}
+
 // Song 定义了歌曲的基本信息结构。
 type Song struct {
 	// ID 是歌曲的唯一标识符，通过文件路径的 SHA256 哈希生成。
 	ID string `json:"id"`
 	// Title 是歌曲的标题，通常从文件名中提取。
 	Title string `json:"title"`
-	// Artist 是歌曲的艺术家，默认为 "Unknown"。
+	// Artist 是歌曲的艺术家，默认为 "Unknown"。保留标签中的原始字符串（如 "A; B"）。
 	Artist string `json:"artist"`
+	// Artists 是按分隔符拆分 Artist 得到的艺术家列表（如 ["A", "B"]），由扫描器填充。
+	Artists []string `json:"artists,omitempty"`
 	// Album 是歌曲所属的专辑，默认为 "Unknown"。
 	Album string `json:"album"`
+	// Genre 是歌曲的流派，从标签读取，未知时为空。
+	Genre string `json:"genre"`
+	// TrackNumber 是歌曲在专辑中的音轨号，未知时为 0。
+	TrackNumber int `json:"track_number"`
+	// DiscNumber 是歌曲所在的碟号，未知时为 0。
+	DiscNumber int `json:"disc_number,omitempty"`
+	// Composer 是歌曲的作曲者，未知时为空。
+	Composer string `json:"composer,omitempty"`
+	// Comment 是标签中的注释，未知时为空。
+	Comment string `json:"comment,omitempty"`
 	// Duration 是歌曲的时长（以秒为单位），默认为 0。
 	Duration int `json:"duration"`
+	// SampleRate 是音频的采样率（Hz），目前仅对 Ogg/Opus 文件解析，未知时为 0。
+	SampleRate int `json:"sample_rate,omitempty"`
+	// Bitrate 是音频的平均比特率（kbps），由文件大小与时长估算，未知时为 0。
+	Bitrate int `json:"bitrate,omitempty"`
 	// FilePath 是歌曲文件的绝对路径。
 	FilePath string `json:"file_path"`
 	// FileName 是歌曲的文件名。
@@ -38,11 +94,56 @@ type Song struct {
 	AddedAt time.Time `json:"added_at"`
 	// Format 是音频文件的格式/扩展名（如 .mp3, .flac）。
 	Format string `json:"format"`
+	// StartMS 是 cue 虚拟歌曲在源文件中的起始时间（毫秒），普通歌曲为 0。
+	StartMS int64 `json:"start_ms,omitempty"`
+	// EndMS 是 cue 虚拟歌曲在源文件中的结束时间（毫秒），为 0 表示到文件末尾。
+	EndMS int64 `json:"end_ms,omitempty"`
+	// HasCover 表示歌曲是否有封面：标签中嵌入了封面，或同目录下有封面文件（由扫描器检测）。
+	HasCover bool `json:"has_cover"`
+	// Fingerprint 是歌曲的内容指纹（文件前 1MB 与大小的 SHA256），仅在开启指纹计算时填充。
+	// 内容相同的文件具有相同的指纹，可用于重复检测。
+	Fingerprint string `json:"fingerprint,omitempty"`
+	// AcousticFingerprint 是由 fpcalc 计算的声学指纹（见 ComputeAcousticFingerprint），仅在开启声学指纹计算时填充。
+	// 同一首歌的不同编码得到相近的指纹，可用 AcousticSimilarity 比较；cue 虚拟歌曲不计算。
+	AcousticFingerprint string `json:"acoustic_fingerprint,omitempty"`
+	// StreamURL 是可直接用于播放的完整音频流地址，仅在请求时填充。
+	StreamURL string `json:"stream_url,omitempty"`
+	// CoverURL 是歌曲封面的完整地址，仅在请求时填充。
+	CoverURL string `json:"cover_url,omitempty"`
 }
 
 // NewSong 根据给定的文件路径和文件大小创建一个新的 Song 实例。
+// 标签读取失败时使用默认元数据，不返回错误。
 func NewSong(filePath string, fileSize int64) *Song {
-	fileName := filepath.Base(filePath)
+	song, _ := ReadSong(filePath, fileSize)
+	return song
+}
+
+// ErrTagReadTimeout 表示标签解析在超时时间内未能完成。
+var ErrTagReadTimeout = errors.New("读取标签超时")
+
+// TagReadOptions 控制 ReadSongWithOptions 读取标签的方式。
+type TagReadOptions struct {
+	// Timeout 是单个文件标签解析的超时时间，0 表示不限制。
+	// 超时后放弃标签，使用文件名等默认元数据；仍在运行的解析在结束时自行关闭文件。
+	Timeout time.Duration
+	// Parse 解析标签元数据，为 nil 时使用 tag.ReadFrom。
+	Parse func(io.ReadSeeker) (tag.Metadata, error)
+	// FilenameEncoding 是非 UTF-8 文件名使用的编码，文件名转码为 UTF-8 后用作 FileName 与默认标题；
+	// FilePath 保留原始字节用于打开文件。为 nil 时不转码。
+	FilenameEncoding encoding.Encoding
+}
+
+// ReadSong 与 NewSong 相同，但会额外返回标签解析失败的错误。
+// 即使返回错误，Song 仍然有效（使用文件名作为标题等默认元数据）；
+// 文件本身不包含标签不视为错误。
+func ReadSong(filePath string, fileSize int64) (*Song, error) {
+	return ReadSongWithOptions(filePath, fileSize, TagReadOptions{})
+}
+
+// ReadSongWithOptions 与 ReadSong 相同，但按 opts 控制标签解析的超时与解析器。
+func ReadSongWithOptions(filePath string, fileSize int64, opts TagReadOptions) (*Song, error) {
+	fileName := decodeFilename(filepath.Base(filePath), opts.FilenameEncoding)
 	ext := filepath.Ext(fileName)
 	// 默认使用移除了扩展名的文件名作为标题。
 	title := strings.TrimSuffix(fileName, ext)
@@ -56,49 +157,241 @@ func NewSong(filePath string, fileSize int64) *Song {
 	// 默认值
 	artist := "Unknown"
 	album := "Unknown"
+	genre := ""
+	trackNumber := 0
+	discNumber := 0
+	composer := ""
+	comment := ""
 	duration := 0
+	sampleRate := 0
+	hasCover := false
 
 	// 尝试从 ID3 标签读取元数据
+	var tagErr error
 	file, err := os.Open(filePath)
-	if err == nil {
-		metadata, metaErr := tag.ReadFrom(file)
-		file.Close() // 立即关闭文件，避免在循环中积累文件句柄
+	if err != nil {
+		tagErr = err
+	} else {
+		metadata, metaErr := readTagsTimeout(file, opts)
+		// 超时后文件归仍在运行的解析 goroutine 所有，由它在结束时关闭，这里不能再读取或关闭。
+		timedOut := errors.Is(metaErr, ErrTagReadTimeout)
+		// tag 库对 Vorbis comment 的扩展字段覆盖不全，FLAC 与 Ogg 文件另外直接解析，结果优先于 tag 库。
+		var comments vorbisComments
+		if !timedOut && isVorbisCommentFormat(ext) {
+			comments, _ = readVorbisComments(file, fileSize, ext)
+		}
+		// tag 库不提供时长，Opus 文件从 Ogg 页头解析时长与采样率，解析失败时保持为 0。
+		if !timedOut && isOggFormat(ext) {
+			if info, err := readOpusInfo(file, fileSize); err == nil {
+				duration = int(info.duration / time.Second)
+				sampleRate = info.sampleRate
+			}
+		}
+		if !timedOut {
+			file.Close() // 立即关闭文件，避免在循环中积累文件句柄
+		}
+		if metaErr != nil && metaErr != tag.ErrNoTagsFound {
+			tagErr = fmt.Errorf("读取 %s 的标签失败: %w", filePath, metaErr)
+		}
 		if metaErr == nil {
-			if metadata.Title() != "" {
+			if strings.TrimSpace(metadata.Title()) != "" {
 				title = metadata.Title()
 			}
-			if metadata.Artist() != "" {
+			if strings.TrimSpace(metadata.Artist()) != "" {
 				artist = metadata.Artist()
 			}
-			if metadata.Album() != "" {
+			if strings.TrimSpace(metadata.Album()) != "" {
 				album = metadata.Album()
 			}
-			// tag 库不直接提供时长，保持为 0
+			genre = metadata.Genre()
+			trackNumber, _ = metadata.Track()
+			discNumber, _ = metadata.Disc()
+			composer = metadata.Composer()
+			comment = metadata.Comment()
+			hasCover = metadata.Picture() != nil && len(metadata.Picture().Data) > 0
+		}
+		if comments != nil {
+			title = firstNonEmpty(comments.get("TITLE"), title)
+			if artists := comments["ARTIST"]; len(artists) > 1 {
+				artist = strings.Join(artists, "; ")
+			} else {
+				artist = firstNonEmpty(comments.get("ARTIST"), artist)
+			}
+			album = firstNonEmpty(comments.get("ALBUM"), album)
+			genre = firstNonEmpty(comments.get("GENRE"), genre)
+			composer = firstNonEmpty(comments.get("COMPOSER"), composer)
+			comment = firstNonEmpty(comments.get("COMMENT", "DESCRIPTION"), comment)
+			if n := comments.number("TRACKNUMBER"); n > 0 {
+				trackNumber = n
+			}
+			if n := comments.number("DISCNUMBER"); n > 0 {
+				discNumber = n
+			}
 		}
 	}
 
-	return &Song{
-		ID:       generateID(filePath),
-		Title:    title,
-		Artist:   artist,
-		Album:    album,
-		Duration: duration,
-		FilePath: filePath,
-		FileName: fileName,
-		FileSize: fileSize,
-		AddedAt:  addedAt,
-		Format:   strings.ToLower(ext),
+	song := &Song{
+		ID:          generateID(filePath),
+		Title:       normalizeText(title),
+		Artist:      normalizeText(artist),
+		Album:       normalizeText(album),
+		Genre:       normalizeText(genre),
+		TrackNumber: trackNumber,
+		DiscNumber:  discNumber,
+		Composer:    normalizeText(composer),
+		Comment:     strings.TrimSpace(comment),
+		Duration:    duration,
+		SampleRate:  sampleRate,
+		Bitrate:     averageBitrate(fileSize, duration),
+		FilePath:    filePath,
+		FileName:    fileName,
+		FileSize:    fileSize,
+		AddedAt:     addedAt,
+		Format:      strings.ToLower(ext),
+		HasCover:    hasCover,
+	}
+	return song, tagErr
+}
+
+// Clone 返回歌曲的深度拷贝，修改拷贝不会影响原歌曲。
+func (s *Song) Clone() *Song {
+	copied := *s
+	if s.Artists != nil {
+		copied.Artists = append([]string(nil), s.Artists...)
 	}
+	return &copied
 }
 
-// generateID 使用文件路径的 SHA256 哈希值的前 16 字节生成一个唯一的歌曲 ID。
+// ApplyFilenamePattern 使用包含命名捕获组（track、artist、title、album）的正则表达式
+// 从不含扩展名的文件名中解析元数据，只补全标签缺失的字段：标题仍为文件名、艺术家或专辑为 "Unknown"、音轨号为 0。
+// 文件名不匹配时保持不变。
+func (s *Song) ApplyFilenamePattern(pattern *regexp.Regexp) {
+	base := strings.TrimSuffix(s.FileName, filepath.Ext(s.FileName))
+	match := pattern.FindStringSubmatch(base)
+	if match == nil {
+		return
+	}
+
+	for i, name := range pattern.SubexpNames() {
+		value := strings.TrimSpace(match[i])
+		if value == "" {
+			continue
+		}
+		switch name {
+		case "title":
+			if s.Title == normalizeText(base) {
+				s.Title = normalizeText(value)
+			}
+		case "artist":
+			if s.Artist == "Unknown" {
+				s.Artist = normalizeText(value)
+			}
+		case "album":
+			if s.Album == "Unknown" {
+				s.Album = normalizeText(value)
+			}
+		case "track":
+			if n, err := strconv.Atoi(value); err == nil && s.TrackNumber == 0 {
+				s.TrackNumber = n
+			}
+		}
+	}
+}
+
+// InferFromPath 根据文件相对于音乐根目录 root 的父级目录名补全缺失（为 "Unknown"）的艺术家与专辑。
+// 目录结构按 "艺术家/专辑/曲目" 推断：上一级目录视为专辑，再上一级视为艺术家；
+// 只有一级目录时视为艺术家。标签中已有的值保持不变，位于根目录或根目录之外的文件不做推断。
+func (s *Song) InferFromPath(root string) {
+	rel, err := filepath.Rel(root, filepath.Dir(s.FilePath))
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return
+	}
+
+	dirs := strings.Split(rel, string(filepath.Separator))
+	var artist, album string
+	if len(dirs) >= 2 {
+		artist, album = dirs[len(dirs)-2], dirs[len(dirs)-1]
+	} else {
+		artist = dirs[0]
+	}
+	if s.Artist == "Unknown" && artist != "" {
+		s.Artist = normalizeText(artist)
+	}
+	if s.Album == "Unknown" && album != "" {
+		s.Album = normalizeText(album)
+	}
+}
+
+// readTagsTimeout 在独立的 goroutine 中读取文件的标签元数据，超过 opts.Timeout 时返回 ErrTagReadTimeout。
+// 按时完成时文件仍归调用方所有；超时后文件的所有权转交给解析 goroutine，由它在解析结束时关闭，
+// 调用方只是放弃结果，不能再使用该文件。这样不会在解析器仍在读取时关闭文件，也不会重复关闭。
+func readTagsTimeout(file *os.File, opts TagReadOptions) (tag.Metadata, error) {
+	parse := opts.Parse
+	if parse == nil {
+		parse = tag.ReadFrom
+	}
+	if opts.Timeout <= 0 {
+		return readTagsWith(file, parse)
+	}
+
+	type result struct {
+		metadata tag.Metadata
+		err      error
+	}
+	// abandoned 在超时后关闭。解析 goroutine 与调用方通过 select 竞争，只有一方能拿到文件的所有权：
+	// 结果被接收时归调用方，abandoned 先关闭时归解析 goroutine。
+	done := make(chan result)
+	abandoned := make(chan struct{})
+	go func() {
+		metadata, err := readTagsWith(file, parse)
+		select {
+		case done <- result{metadata, err}:
+		case <-abandoned:
+			file.Close()
+		}
+	}()
+
+	timer := time.NewTimer(opts.Timeout)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r.metadata, r.err
+	case <-timer.C:
+		close(abandoned)
+		return nil, ErrTagReadTimeout
+	}
+}
+
+// readTags 读取文件的标签元数据。
+func readTags(file *os.File) (tag.Metadata, error) {
+	return readTagsWith(file, tag.ReadFrom)
+}
+
+// readTagsWith 使用 parse 读取文件的标签元数据。
+// 损坏的文件可能导致标签解析库 panic，此时将其转换为错误返回，调用方保留默认值即可。
+func readTagsWith(file *os.File, parse func(io.ReadSeeker) (tag.Metadata, error)) (metadata tag.Metadata, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			metadata = nil
+			err = fmt.Errorf("解析标签时发生 panic: %v", r)
+		}
+	}()
+	return parse(file)
+}
+
+// generateID 使用文件路径的 SHA256 哈希值的前 SongIDLength() 字节生成一个唯一的歌曲 ID。
 func generateID(filePath string) string {
 	hash := sha256.Sum256([]byte(filePath))
-	return hex.EncodeToString(hash[:SongIDLength])
+	return hex.EncodeToString(hash[:SongIDLength()])
 }
 
 // ValidIDPattern 返回用于验证歌曲 ID 格式的正则表达式字符串
-// ID 应为 32 个十六进制字符（16 字节的十六进制编码）
+// ID 应为 2*SongIDLength() 个十六进制字符（默认 32 个）
 func ValidIDPattern() string {
-	return `^[a-f0-9]{32}$`
+	return songIDPattern.Load().String()
+}
+
+// IsValidID 判断 id 是否符合当前长度的歌曲 ID 格式，可用于防止路径遍历。
+func IsValidID(id string) bool {
+	return songIDPattern.Load().MatchString(id)
 }
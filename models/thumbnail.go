@@ -0,0 +1,93 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+
+	// 注册 GIF 解码器，以支持少数使用 GIF 作为封面的文件。
+	_ "image/gif"
+)
+
+// thumbnailJPEGQuality 是缩略图以 JPEG 编码时的质量。
+const thumbnailJPEGQuality = 85
+
+// Thumbnail 返回将封面缩放到最长边不超过 maxSize 像素的缩略图，保持宽高比。
+// 原图不超过 maxSize 时直接返回原封面，不会放大。
+// PNG 封面缩放后仍编码为 PNG（保留透明度），其他格式编码为 JPEG。
+func (c *Cover) Thumbnail(maxSize int) (*Cover, error) {
+	src, format, err := image.Decode(bytes.NewReader(c.Data))
+	if err != nil {
+		return nil, fmt.Errorf("解码封面图片失败: %v", err)
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= maxSize && height <= maxSize {
+		return c, nil
+	}
+
+	// 按最长边计算目标尺寸，保证至少 1 像素。
+	dstWidth, dstHeight := maxSize, maxSize
+	if width >= height {
+		dstHeight = max(1, height*maxSize/width)
+	} else {
+		dstWidth = max(1, width*maxSize/height)
+	}
+	dst := resizeImage(src, dstWidth, dstHeight)
+
+	var buf bytes.Buffer
+	if format == "png" {
+		err = png.Encode(&buf, dst)
+		format = "image/png"
+	} else {
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: thumbnailJPEGQuality})
+		format = "image/jpeg"
+	}
+	if err != nil {
+		return nil, fmt.Errorf("编码缩略图失败: %v", err)
+	}
+	return &Cover{MIMEType: format, Data: buf.Bytes()}, nil
+}
+
+// resizeImage 使用区域平均（box filter）将图片缩小到指定尺寸。
+func resizeImage(src image.Image, width, height int) *image.RGBA {
+	// 先转换为 RGBA，便于直接访问像素数据。
+	bounds := src.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+
+	srcWidth, srcHeight := rgba.Bounds().Dx(), rgba.Bounds().Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := y * srcHeight / height
+		y1 := max(y0+1, (y+1)*srcHeight/height)
+		for x := 0; x < width; x++ {
+			x0 := x * srcWidth / width
+			x1 := max(x0+1, (x+1)*srcWidth/width)
+
+			var r, g, b, a, n int
+			for sy := y0; sy < y1; sy++ {
+				offset := rgba.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					r += int(rgba.Pix[offset])
+					g += int(rgba.Pix[offset+1])
+					b += int(rgba.Pix[offset+2])
+					a += int(rgba.Pix[offset+3])
+					offset += 4
+					n++
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
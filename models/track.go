@@ -0,0 +1,248 @@
+package models
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// IsCueTrack 判断歌曲是否为由 .cue 拆分出的虚拟歌曲，即只对应源文件中的一个时间片段。
+func (s *Song) IsCueTrack() bool {
+	return s.StartMS > 0 || s.EndMS > 0
+}
+
+// audioLayout 描述了音频文件中音频数据所在的字节范围及其总时长，
+// 用于将时间换算为字节偏移。
+type audioLayout struct {
+	dataStart int64
+	dataEnd   int64
+	duration  time.Duration
+	// align 是字节偏移的对齐单位（WAV 的 block align），保证片段从完整的采样帧开始。
+	align int64
+	// header 根据片段的数据长度生成拼接在片段前的文件头，使片段可被独立解码。
+	header func(dataLen int64) []byte
+}
+
+// readAudioLayout 解析音频文件头，目前支持 FLAC 与 WAV。
+func readAudioLayout(r io.ReaderAt, size int64, format string) (*audioLayout, error) {
+	switch format {
+	case ".flac":
+		return readFLACLayout(r, size)
+	case ".wav":
+		return readWAVLayout(r, size)
+	default:
+		return nil, fmt.Errorf("不支持按时间拆分 %s 格式", format)
+	}
+}
+
+// offset 将时间换算为音频数据中的字节偏移（按码率线性估算）。
+func (l *audioLayout) offset(t time.Duration) int64 {
+	if t <= 0 {
+		return l.dataStart
+	}
+	if t >= l.duration {
+		return l.dataEnd
+	}
+	dataLen := l.dataEnd - l.dataStart
+	off := int64(float64(dataLen) * float64(t) / float64(l.duration))
+	return l.dataStart + off - off%l.align
+}
+
+// trackSize 返回 [start, end) 时间片段输出时的总字节数（含文件头）。
+func (l *audioLayout) trackSize(start, end time.Duration) int64 {
+	dataLen := l.offset(end) - l.offset(start)
+	return int64(len(l.header(dataLen))) + dataLen
+}
+
+// readFLACLayout 解析 FLAC 的 STREAMINFO 块获取总时长。
+// 片段前只拼接 STREAMINFO，并将总采样数与 MD5 置零（表示未知），
+// 解码器会从片段中的下一个帧同步码开始解码。
+func readFLACLayout(r io.ReaderAt, size int64) (*audioLayout, error) {
+	marker := make([]byte, 4)
+	if _, err := r.ReadAt(marker, 0); err != nil || string(marker) != "fLaC" {
+		return nil, errors.New("不是有效的 FLAC 文件")
+	}
+
+	var streamInfo []byte
+	pos := int64(4)
+	for {
+		blockHeader := make([]byte, 4)
+		if _, err := r.ReadAt(blockHeader, pos); err != nil {
+			return nil, fmt.Errorf("读取 FLAC 元数据块失败: %w", err)
+		}
+		last := blockHeader[0]&0x80 != 0
+		blockType := blockHeader[0] & 0x7f
+		blockLen := int64(blockHeader[1])<<16 | int64(blockHeader[2])<<8 | int64(blockHeader[3])
+		if blockType == 0 {
+			streamInfo = make([]byte, blockLen)
+			if blockLen < 34 {
+				return nil, errors.New("FLAC STREAMINFO 块过短")
+			}
+			if _, err := r.ReadAt(streamInfo, pos+4); err != nil {
+				return nil, fmt.Errorf("读取 FLAC STREAMINFO 失败: %w", err)
+			}
+		}
+		pos += 4 + blockLen
+		if last {
+			break
+		}
+	}
+	if streamInfo == nil {
+		return nil, errors.New("FLAC 文件缺少 STREAMINFO 块")
+	}
+
+	// STREAMINFO 第 10 字节起：20 位采样率、3 位声道数、5 位位深、36 位总采样数。
+	packed := binary.BigEndian.Uint64(streamInfo[10:18])
+	sampleRate := packed >> 44
+	totalSamples := packed & (1<<36 - 1)
+	if sampleRate == 0 || totalSamples == 0 {
+		return nil, errors.New("FLAC 文件缺少时长信息")
+	}
+
+	header := make([]byte, 0, 4+4+34)
+	header = append(header, "fLaC"...)
+	header = append(header, 0x80, 0, 0, 34)
+	header = append(header, streamInfo[:34]...)
+	binary.BigEndian.PutUint64(header[8+10:], packed&^(1<<36-1))
+	clear(header[8+18 : 8+34])
+
+	return &audioLayout{
+		dataStart: pos,
+		dataEnd:   size,
+		duration:  time.Duration(totalSamples) * time.Second / time.Duration(sampleRate),
+		align:     1,
+		header:    func(int64) []byte { return header },
+	}, nil
+}
+
+// readWAVLayout 解析 WAV 的 fmt 与 data 块获取码率和数据范围。
+// 片段前拼接原始文件头，并按片段长度修正 RIFF 与 data 块的大小字段。
+func readWAVLayout(r io.ReaderAt, size int64) (*audioLayout, error) {
+	riff := make([]byte, 12)
+	if _, err := r.ReadAt(riff, 0); err != nil || string(riff[:4]) != "RIFF" || string(riff[8:]) != "WAVE" {
+		return nil, errors.New("不是有效的 WAV 文件")
+	}
+
+	var byteRate, blockAlign int64
+	pos := int64(12)
+	for {
+		chunkHeader := make([]byte, 8)
+		if _, err := r.ReadAt(chunkHeader, pos); err != nil {
+			return nil, fmt.Errorf("WAV 文件缺少 data 块: %w", err)
+		}
+		chunkLen := int64(binary.LittleEndian.Uint32(chunkHeader[4:]))
+		switch string(chunkHeader[:4]) {
+		case "fmt ":
+			fmtChunk := make([]byte, 16)
+			if _, err := r.ReadAt(fmtChunk, pos+8); err != nil {
+				return nil, fmt.Errorf("读取 WAV fmt 块失败: %w", err)
+			}
+			byteRate = int64(binary.LittleEndian.Uint32(fmtChunk[8:12]))
+			blockAlign = int64(binary.LittleEndian.Uint16(fmtChunk[12:14]))
+		case "data":
+			if byteRate == 0 || blockAlign == 0 {
+				return nil, errors.New("WAV 文件缺少有效的 fmt 块")
+			}
+			dataStart := pos + 8
+			dataEnd := min(dataStart+chunkLen, size)
+			header := make([]byte, dataStart)
+			if _, err := r.ReadAt(header, 0); err != nil {
+				return nil, fmt.Errorf("读取 WAV 文件头失败: %w", err)
+			}
+			return &audioLayout{
+				dataStart: dataStart,
+				dataEnd:   dataEnd,
+				duration:  time.Duration(float64(dataEnd-dataStart) / float64(byteRate) * float64(time.Second)),
+				align:     blockAlign,
+				header: func(dataLen int64) []byte {
+					h := bytes.Clone(header)
+					binary.LittleEndian.PutUint32(h[4:8], uint32(int64(len(h))-8+dataLen))
+					binary.LittleEndian.PutUint32(h[len(h)-4:], uint32(dataLen))
+					return h
+				},
+			}, nil
+		}
+		// RIFF 块按偶数字节对齐。
+		pos += 8 + chunkLen + chunkLen%2
+	}
+}
+
+// OpenTrack 返回歌曲音频内容的 ReadSeeker 及其总字节数。
+// 普通歌曲直接返回文件本身，使 http.ServeContent 写出时底层连接能识别出 *os.File 并使用 sendfile，
+// 因此调用方不能在多个读取器之间共享同一个文件句柄；cue 虚拟歌曲返回“文件头 + 对应时间片段”的拼接视图，
+// 通过 ReadAt 读取，定位不会 Seek 文件句柄。两者都可配合 http.ServeContent 支持 Range 请求。
+func OpenTrack(file *os.File, song *Song) (io.ReadSeeker, int64, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+	if !song.IsCueTrack() {
+		return file, info.Size(), nil
+	}
+
+	layout, err := readAudioLayout(file, info.Size(), song.Format)
+	if err != nil {
+		return nil, 0, err
+	}
+	end := layout.duration
+	if song.EndMS > 0 {
+		end = time.Duration(song.EndMS) * time.Millisecond
+	}
+	start := layout.offset(time.Duration(song.StartMS) * time.Millisecond)
+	dataLen := layout.offset(end) - start
+	if dataLen < 0 {
+		dataLen = 0
+	}
+	header := layout.header(dataLen)
+	return &trackReader{
+		header: header,
+		body:   io.NewSectionReader(file, start, dataLen),
+		size:   int64(len(header)) + dataLen,
+	}, int64(len(header)) + dataLen, nil
+}
+
+// trackReader 是文件头与文件片段拼接而成的可定位读取器。
+type trackReader struct {
+	header []byte
+	body   *io.SectionReader
+	offset int64
+	size   int64
+}
+
+// Read 实现 io.Reader。
+func (r *trackReader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+	headerLen := int64(len(r.header))
+	if r.offset < headerLen {
+		n := copy(p, r.header[r.offset:])
+		r.offset += int64(n)
+		return n, nil
+	}
+	n, err := r.body.ReadAt(p, r.offset-headerLen)
+	r.offset += int64(n)
+	return n, err
+}
+
+// Seek 实现 io.Seeker。
+func (r *trackReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	default:
+		return 0, errors.New("无效的 whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("偏移量不能为负数")
+	}
+	r.offset = offset
+	return offset, nil
+}
@@ -0,0 +1,178 @@
+package models
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// flacVorbisCommentBlock 是 FLAC 元数据块中 VORBIS_COMMENT 的块类型。
+	flacVorbisCommentBlock = 4
+	// maxVorbisCommentSize 是 Vorbis comment 允许的最大字节数，超过时视为损坏的文件。
+	// 嵌入的 METADATA_BLOCK_PICTURE 封面也在 comment 中，因此上限较宽松。
+	maxVorbisCommentSize = 16 << 20
+)
+
+// vorbisComments 是 Vorbis comment 中的字段，键为大写的字段名，同名字段可以出现多次。
+type vorbisComments map[string][]string
+
+// get 返回字段的第一个非空值，不存在时返回空字符串。
+func (vc vorbisComments) get(keys ...string) string {
+	for _, key := range keys {
+		for _, value := range vc[key] {
+			if value = strings.TrimSpace(value); value != "" {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// number 返回字段中的数字部分（如 "2/3" 中的 2），不存在或无法解析时返回 0。
+func (vc vorbisComments) number(key string) int {
+	value, _, _ := strings.Cut(vc.get(key), "/")
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// isVorbisCommentFormat 判断扩展名对应的格式是否使用 Vorbis comment 存储标签。
+func isVorbisCommentFormat(ext string) bool {
+	return strings.EqualFold(ext, ".flac") || isOggFormat(ext)
+}
+
+// readVorbisComments 直接解析 FLAC 或 Ogg（Vorbis/Opus）文件中的 Vorbis comment。
+func readVorbisComments(r io.ReaderAt, size int64, ext string) (vorbisComments, error) {
+	if strings.EqualFold(ext, ".flac") {
+		return readFLACVorbisComments(r)
+	}
+	return readOggVorbisComments(r, size)
+}
+
+// readFLACVorbisComments 遍历 FLAC 元数据块，解析其中的 VORBIS_COMMENT 块。
+func readFLACVorbisComments(r io.ReaderAt) (vorbisComments, error) {
+	marker := make([]byte, 4)
+	if _, err := r.ReadAt(marker, 0); err != nil || string(marker) != "fLaC" {
+		return nil, errors.New("不是有效的 FLAC 文件")
+	}
+
+	pos := int64(4)
+	for {
+		blockHeader := make([]byte, 4)
+		if _, err := r.ReadAt(blockHeader, pos); err != nil {
+			return nil, fmt.Errorf("读取 FLAC 元数据块失败: %w", err)
+		}
+		last := blockHeader[0]&0x80 != 0
+		blockType := blockHeader[0] & 0x7f
+		blockLen := int64(blockHeader[1])<<16 | int64(blockHeader[2])<<8 | int64(blockHeader[3])
+		if blockType == flacVorbisCommentBlock {
+			data := make([]byte, blockLen)
+			if _, err := r.ReadAt(data, pos+4); err != nil {
+				return nil, fmt.Errorf("读取 FLAC VORBIS_COMMENT 失败: %w", err)
+			}
+			return parseVorbisComment(data)
+		}
+		pos += 4 + blockLen
+		if last {
+			return nil, errors.New("FLAC 文件没有 VORBIS_COMMENT 块")
+		}
+	}
+}
+
+// readOggVorbisComments 读取 Ogg 流的第二个数据包（comment 头），支持 Vorbis 与 Opus。
+// 数据包可能跨越多个 Ogg 页，按分段表重新拼接。
+func readOggVorbisComments(r io.ReaderAt, size int64) (vorbisComments, error) {
+	var packets [][]byte
+	var current []byte
+	pos := int64(0)
+	for len(packets) < 2 {
+		if pos+oggPageHeaderSize > size {
+			return nil, errors.New("Ogg 文件在 comment 头之前结束")
+		}
+		header := make([]byte, oggPageHeaderSize)
+		if _, err := r.ReadAt(header, pos); err != nil || string(header[:4]) != "OggS" {
+			return nil, errors.New("不是有效的 Ogg 文件")
+		}
+		segmentTable := make([]byte, int(header[26]))
+		if _, err := r.ReadAt(segmentTable, pos+oggPageHeaderSize); err != nil {
+			return nil, errors.New("读取 Ogg 分段表失败")
+		}
+		pos += oggPageHeaderSize + int64(len(segmentTable))
+
+		for _, segment := range segmentTable {
+			data := make([]byte, int(segment))
+			if _, err := r.ReadAt(data, pos); err != nil {
+				return nil, errors.New("读取 Ogg 数据包失败")
+			}
+			pos += int64(segment)
+			current = append(current, data...)
+			if len(current) > maxVorbisCommentSize {
+				return nil, errors.New("Ogg comment 头过大")
+			}
+			// 长度小于 255 的分段表示数据包结束。
+			if segment < 255 {
+				packets = append(packets, current)
+				current = nil
+				if len(packets) == 2 {
+					break
+				}
+			}
+		}
+	}
+
+	packet := packets[1]
+	switch {
+	case bytes.HasPrefix(packet, []byte("\x03vorbis")):
+		return parseVorbisComment(packet[7:])
+	case bytes.HasPrefix(packet, []byte("OpusTags")):
+		return parseVorbisComment(packet[8:])
+	}
+	return nil, errors.New("Ogg 流的第二个数据包不是 comment 头")
+}
+
+// parseVorbisComment 解析 Vorbis comment 结构：厂商字符串与若干 "KEY=value" 字段，长度均为小端 32 位整数。
+func parseVorbisComment(data []byte) (vorbisComments, error) {
+	readString := func() (string, bool) {
+		if len(data) < 4 {
+			return "", false
+		}
+		n := binary.LittleEndian.Uint32(data)
+		if uint64(n) > uint64(len(data)-4) {
+			return "", false
+		}
+		s := string(data[4 : 4+n])
+		data = data[4+n:]
+		return s, true
+	}
+
+	if _, ok := readString(); !ok {
+		return nil, errors.New("Vorbis comment 厂商字符串损坏")
+	}
+	if len(data) < 4 {
+		return nil, errors.New("Vorbis comment 缺少字段数量")
+	}
+	count := binary.LittleEndian.Uint32(data)
+	data = data[4:]
+
+	comments := make(vorbisComments)
+	for i := uint32(0); i < count; i++ {
+		field, ok := readString()
+		if !ok {
+			return nil, fmt.Errorf("Vorbis comment 第 %d 个字段损坏", i+1)
+		}
+		key, value, found := strings.Cut(field, "=")
+		if !found {
+			continue
+		}
+		key = strings.ToUpper(key)
+		comments[key] = append(comments[key], value)
+	}
+	return comments, nil
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DiskCache 是按歌曲 ID 存放提取结果（如封面、缩略图、波形）的磁盘缓存。
+// 每个缓存条目文件的修改时间被设置为生成它时源文件的修改时间，
+// 源文件被更新后两者不再一致，条目即视为失效。
+// nil 的 *DiskCache 表示禁用缓存：Get 总是未命中，Put 不做任何事。
+type DiskCache struct {
+	dir string
+}
+
+// NewDiskCache 创建一个以 dir 为根目录的磁盘缓存，目录不存在时会自动创建。
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建缓存目录失败: %v", err)
+	}
+	return &DiskCache{dir: dir}, nil
+}
+
+// entryPath 返回缓存条目的路径。条目按歌曲 ID 的前两个字符分目录存放，避免单个目录文件过多。
+func (c *DiskCache) entryPath(songID, name string) string {
+	prefix := songID
+	if len(prefix) > 2 {
+		prefix = prefix[:2]
+	}
+	return filepath.Join(c.dir, prefix, songID+"_"+name)
+}
+
+// Get 读取缓存条目。条目不存在或生成时的源文件修改时间与 sourceModTime 不一致时返回 false。
+func (c *DiskCache) Get(songID, name string, sourceModTime time.Time) ([]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+	path := c.entryPath(songID, name)
+	info, err := os.Stat(path)
+	if err != nil || !info.ModTime().Equal(sourceModTime) {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// Put 写入缓存条目，并记录源文件的修改时间。
+// 数据先写入同目录下的临时文件再重命名，并发写入同一条目时读者不会看到不完整的内容。
+func (c *DiskCache) Put(songID, name string, sourceModTime time.Time, data []byte) error {
+	if c == nil {
+		return nil
+	}
+	path := c.entryPath(songID, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建缓存目录失败: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("创建缓存临时文件失败: %v", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // 重命名成功后临时文件已不存在，删除失败可以忽略。
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入缓存文件失败: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("写入缓存文件失败: %v", err)
+	}
+	if err := os.Chtimes(tmpPath, sourceModTime, sourceModTime); err != nil {
+		return fmt.Errorf("设置缓存文件时间失败: %v", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("写入缓存文件失败: %v", err)
+	}
+	return nil
+}
+
+// GetJSON 读取 JSON 格式的缓存条目并解析到 v，未命中或解析失败时返回 false。
+func (c *DiskCache) GetJSON(songID, name string, sourceModTime time.Time, v interface{}) bool {
+	data, ok := c.Get(songID, name, sourceModTime)
+	if !ok {
+		return false
+	}
+	return json.Unmarshal(data, v) == nil
+}
+
+// PutJSON 将 v 序列化为 JSON 后写入缓存条目。
+func (c *DiskCache) PutJSON(songID, name string, sourceModTime time.Time, v interface{}) error {
+	if c == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("序列化缓存数据失败: %v", err)
+	}
+	return c.Put(songID, name, sourceModTime, data)
+}
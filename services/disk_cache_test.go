@@ -0,0 +1,115 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestDiskCache_MissHitInvalidate 测试首次未命中、写入后命中、源文件更新后失效。
+func TestDiskCache_MissHitInvalidate(t *testing.T) {
+	cache, err := NewDiskCache(filepath.Join(t.TempDir(), "cache"))
+	if err != nil {
+		t.Fatalf("创建缓存失败: %v", err)
+	}
+
+	songID := "0123456789abcdef0123456789abcdef"
+	modTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if _, ok := cache.Get(songID, "cover", modTime); ok {
+		t.Fatal("期望首次读取未命中")
+	}
+
+	if err := cache.Put(songID, "cover", modTime, []byte("cover data")); err != nil {
+		t.Fatalf("写入缓存失败: %v", err)
+	}
+	data, ok := cache.Get(songID, "cover", modTime)
+	if !ok || string(data) != "cover data" {
+		t.Fatalf("期望命中缓存并得到 %q, 得到 %q (命中: %v)", "cover data", data, ok)
+	}
+
+	// 源文件被更新后缓存失效。
+	if _, ok := cache.Get(songID, "cover", modTime.Add(time.Second)); ok {
+		t.Error("期望源文件更新后缓存失效")
+	}
+
+	// 不同条目互不影响。
+	if _, ok := cache.Get(songID, "waveform", modTime); ok {
+		t.Error("期望不同条目名之间互不影响")
+	}
+}
+
+// TestDiskCache_JSON 测试 JSON 条目的读写。
+func TestDiskCache_JSON(t *testing.T) {
+	cache, err := NewDiskCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("创建缓存失败: %v", err)
+	}
+
+	songID := "0123456789abcdef0123456789abcdef"
+	modTime := time.Now()
+	peaks := []float64{0.1, 0.5, 1}
+	if err := cache.PutJSON(songID, "waveform.json", modTime, peaks); err != nil {
+		t.Fatalf("写入缓存失败: %v", err)
+	}
+
+	var got []float64
+	if !cache.GetJSON(songID, "waveform.json", modTime, &got) {
+		t.Fatal("期望命中 JSON 缓存")
+	}
+	if len(got) != 3 || got[1] != 0.5 {
+		t.Errorf("期望得到 %v, 得到 %v", peaks, got)
+	}
+}
+
+// TestDiskCache_ConcurrentPut 测试并发写入同一条目时不会留下不完整的文件或临时文件。
+func TestDiskCache_ConcurrentPut(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewDiskCache(dir)
+	if err != nil {
+		t.Fatalf("创建缓存失败: %v", err)
+	}
+
+	songID := "0123456789abcdef0123456789abcdef"
+	modTime := time.Now()
+	data := make([]byte, 64*1024)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := cache.Put(songID, "cover", modTime, data); err != nil {
+				t.Errorf("写入缓存失败: %v", err)
+			}
+			if got, ok := cache.Get(songID, "cover", modTime); ok && len(got) != len(data) {
+				t.Errorf("读到不完整的缓存条目: %d 字节", len(got))
+			}
+		}()
+	}
+	wg.Wait()
+
+	entries, err := os.ReadDir(filepath.Join(dir, songID[:2]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("期望只留下 1 个缓存文件, 得到 %d", len(entries))
+	}
+}
+
+// TestDiskCache_Nil 测试 nil 缓存表现为禁用状态。
+func TestDiskCache_Nil(t *testing.T) {
+	var cache *DiskCache
+	if err := cache.Put("id", "cover", time.Now(), []byte("x")); err != nil {
+		t.Errorf("期望 nil 缓存写入不报错, 得到 %v", err)
+	}
+	if _, ok := cache.Get("id", "cover", time.Now()); ok {
+		t.Error("期望 nil 缓存总是未命中")
+	}
+}
@@ -0,0 +1,22 @@
+package services
+
+import "errors"
+
+// ErrDiskSpaceUnsupported 表示当前平台不支持查询磁盘空间。
+var ErrDiskSpaceUnsupported = errors.New("当前平台不支持查询磁盘空间")
+
+// DiskSpace 描述了某个路径所在分区的空间使用情况，单位均为字节。
+type DiskSpace struct {
+	// Total 是分区的总容量。
+	Total uint64 `json:"total_bytes"`
+	// Free 是分区的剩余空间（包括只对 root 保留的部分）。
+	Free uint64 `json:"free_bytes"`
+	// Available 是非特权用户可用的剩余空间。
+	Available uint64 `json:"available_bytes"`
+}
+
+// GetDiskSpace 返回 path 所在分区的空间使用情况。
+// 在不支持 statfs 的平台上返回 ErrDiskSpaceUnsupported。
+func GetDiskSpace(path string) (DiskSpace, error) {
+	return diskSpace(path)
+}
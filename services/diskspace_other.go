@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !freebsd
+
+package services
+
+// diskSpace 在不支持 statfs 的平台上总是返回 ErrDiskSpaceUnsupported。
+func diskSpace(path string) (DiskSpace, error) {
+	return DiskSpace{}, ErrDiskSpaceUnsupported
+}
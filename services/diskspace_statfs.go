@@ -0,0 +1,20 @@
+//go:build linux || darwin || freebsd
+
+package services
+
+import "syscall"
+
+// diskSpace 使用 statfs 查询 path 所在分区的空间使用情况。
+// 各平台 Statfs_t 的字段类型不同，统一转换为 uint64。
+func diskSpace(path string) (DiskSpace, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return DiskSpace{}, err
+	}
+	blockSize := uint64(stat.Bsize)
+	return DiskSpace{
+		Total:     uint64(stat.Blocks) * blockSize,
+		Free:      uint64(stat.Bfree) * blockSize,
+		Available: uint64(stat.Bavail) * blockSize,
+	}, nil
+}
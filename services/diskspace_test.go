@@ -0,0 +1,25 @@
+package services
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// TestGetDiskSpace 测试查询目录所在分区的磁盘空间。
+func TestGetDiskSpace(t *testing.T) {
+	space, err := GetDiskSpace(t.TempDir())
+	if errors.Is(err, ErrDiskSpaceUnsupported) {
+		t.Skip("当前平台不支持查询磁盘空间")
+	}
+	if err != nil {
+		t.Fatalf("查询磁盘空间失败: %v", err)
+	}
+	if space.Total == 0 || space.Available > space.Total || space.Free > space.Total {
+		t.Errorf("磁盘空间数据不合理: %+v", space)
+	}
+
+	if _, err := GetDiskSpace(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("期望查询不存在的路径时返回错误")
+	}
+}
@@ -0,0 +1,50 @@
+package services
+
+import "zero-music/models"
+
+// LibraryDiff 描述了两次扫描之间音乐库的变化，供事件推送与统计使用。
+type LibraryDiff struct {
+	// Added 是本次扫描新出现的歌曲。
+	Added []*models.Song `json:"added"`
+	// Removed 是上次扫描存在、本次扫描已不存在的歌曲。
+	Removed []*models.Song `json:"removed"`
+	// Modified 是两次扫描都存在但文件修改时间发生变化的歌曲。
+	Modified []*models.Song `json:"modified"`
+}
+
+// Empty 判断两次扫描之间是否没有任何变化。
+func (d LibraryDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0
+}
+
+// diffLibrary 基于歌曲 ID 集合与文件修改时间（AddedAt）比较前后两次扫描的结果。
+// Added 与 Modified 按 current 中的顺序排列，Removed 按 previous 中的顺序排列。
+func diffLibrary(previous, current []*models.Song) LibraryDiff {
+	before := make(map[string]*models.Song, len(previous))
+	for _, song := range previous {
+		before[song.ID] = song
+	}
+
+	diff := LibraryDiff{
+		Added:    make([]*models.Song, 0),
+		Removed:  make([]*models.Song, 0),
+		Modified: make([]*models.Song, 0),
+	}
+	seen := make(map[string]bool, len(current))
+	for _, song := range current {
+		seen[song.ID] = true
+		old, ok := before[song.ID]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, song)
+		case !old.AddedAt.Equal(song.AddedAt):
+			diff.Modified = append(diff.Modified, song)
+		}
+	}
+	for _, song := range previous {
+		if !seen[song.ID] {
+			diff.Removed = append(diff.Removed, song)
+		}
+	}
+	return diff
+}
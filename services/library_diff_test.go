@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"zero-music/models"
+)
+
+// diffNames 返回 diff 中各类歌曲的文件名，便于比较。
+func diffNames(songs []*models.Song) []string {
+	names := make([]string, 0, len(songs))
+	for _, song := range songs {
+		names = append(names, filepath.Base(song.FilePath))
+	}
+	return names
+}
+
+// TestMusicScanner_RefreshDiff 测试 Refresh 返回相对于上次缓存的新增、删除与修改的歌曲。
+func TestMusicScanner_RefreshDiff(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"a.mp3", "b.mp3", "c.mp3"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("fake mp3 "+name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	scanner := NewMusicScanner(tmpDir, []string{".mp3"}, 5)
+
+	// 首次刷新时所有歌曲都是新增。
+	diff, err := scanner.Refresh(context.Background())
+	if err != nil {
+		t.Fatalf("刷新失败: %v", err)
+	}
+	if len(diff.Added) != 3 || len(diff.Removed) != 0 || len(diff.Modified) != 0 {
+		t.Errorf("期望首次刷新新增 3 首歌曲, 得到 %+v", diff)
+	}
+
+	// 新增 d、删除 a、修改 b，c 保持不变。
+	if err := os.WriteFile(filepath.Join(tmpDir, "d.mp3"), []byte("fake mp3 d"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(tmpDir, "a.mp3")); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(tmpDir, "b.mp3"), modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	diff, err = scanner.Refresh(context.Background())
+	if err != nil {
+		t.Fatalf("刷新失败: %v", err)
+	}
+	testCases := []struct {
+		name     string
+		songs    []*models.Song
+		expected string
+	}{
+		{"新增", diff.Added, "d.mp3"},
+		{"删除", diff.Removed, "a.mp3"},
+		{"修改", diff.Modified, "b.mp3"},
+	}
+	for _, tc := range testCases {
+		names := diffNames(tc.songs)
+		if len(names) != 1 || names[0] != tc.expected {
+			t.Errorf("期望%s的歌曲为 [%s], 得到 %v", tc.name, tc.expected, names)
+		}
+	}
+
+	// 没有变化时 diff 为空。
+	diff, err = scanner.Refresh(context.Background())
+	if err != nil {
+		t.Fatalf("刷新失败: %v", err)
+	}
+	if !diff.Empty() {
+		t.Errorf("期望没有变化时 diff 为空, 得到 %+v", diff)
+	}
+}
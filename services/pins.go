@@ -0,0 +1,103 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// PinStore 定义了歌曲置顶标记存储的接口。
+// 置顶权重越大越靠前，未置顶的歌曲没有记录。
+type PinStore interface {
+	// Weights 返回所有置顶歌曲 ID 到权重的映射副本。
+	Weights() map[string]int
+
+	// Pin 将歌曲以指定权重置顶，已置顶时更新权重。
+	Pin(songID string, weight int) error
+
+	// Unpin 取消歌曲的置顶，歌曲未置顶时不做任何事。
+	Unpin(songID string) error
+}
+
+// FilePinStore 是将置顶标记持久化到 JSON 文件的 PinStore 实现。
+type FilePinStore struct {
+	path    string
+	mu      sync.RWMutex
+	weights map[string]int
+}
+
+// NewFilePinStore 创建一个新的 FilePinStore，并从 path 加载已有的标记。
+// 文件不存在时从空记录开始。
+func NewFilePinStore(path string) (*FilePinStore, error) {
+	store := &FilePinStore{
+		path:    path,
+		weights: make(map[string]int),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, fmt.Errorf("读取置顶标记文件失败: %v", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &store.weights); err != nil {
+			return nil, fmt.Errorf("解析置顶标记文件失败: %v", err)
+		}
+	}
+	return store, nil
+}
+
+// Weights 返回所有置顶歌曲 ID 到权重的映射副本。
+func (s *FilePinStore) Weights() map[string]int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	weights := make(map[string]int, len(s.weights))
+	for id, weight := range s.weights {
+		weights[id] = weight
+	}
+	return weights
+}
+
+// Pin 将歌曲以指定权重置顶，并立即写入文件。
+func (s *FilePinStore) Pin(songID string, weight int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.weights[songID] = weight
+	return s.save()
+}
+
+// Unpin 取消歌曲的置顶，并立即写入文件。
+func (s *FilePinStore) Unpin(songID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.weights[songID]; !ok {
+		return nil
+	}
+	delete(s.weights, songID)
+	return s.save()
+}
+
+// save 将所有标记写入文件。
+// 先写入临时文件再重命名，避免写入中途崩溃导致文件损坏。
+// 调用此函数前必须获取写锁。
+func (s *FilePinStore) save() error {
+	data, err := json.Marshal(s.weights)
+	if err != nil {
+		return fmt.Errorf("序列化置顶标记失败: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("创建置顶标记目录失败: %v", err)
+	}
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("写入置顶标记文件失败: %v", err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("写入置顶标记文件失败: %v", err)
+	}
+	return nil
+}
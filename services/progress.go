@@ -0,0 +1,127 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Progress 记录了某个设备上一首歌曲的播放位置。
+type Progress struct {
+	// PositionSeconds 是播放位置（秒）。
+	PositionSeconds float64 `json:"position_seconds"`
+	// UpdatedAt 是该记录最后更新的时间。
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ProgressStore 定义了播放进度存储的接口。
+type ProgressStore interface {
+	// Get 返回指定设备上指定歌曲的播放进度，没有记录时返回 false。
+	Get(songID, device string) (Progress, bool)
+
+	// Set 保存指定设备上指定歌曲的播放进度。
+	Set(songID, device string, positionSeconds float64) (Progress, error)
+
+	// Played 返回所有有播放记录的歌曲 ID 到最近一次（任一设备）更新时间的映射副本。
+	Played() map[string]time.Time
+}
+
+// FileProgressStore 是将播放进度持久化到 JSON 文件的 ProgressStore 实现。
+// 数据按 歌曲 ID -> 设备标识 -> 进度 的结构存储，不同设备之间互相隔离。
+type FileProgressStore struct {
+	path    string
+	mu      sync.RWMutex
+	records map[string]map[string]Progress
+}
+
+// NewFileProgressStore 创建一个新的 FileProgressStore，并从 path 加载已有的记录。
+// 文件不存在时从空记录开始。
+func NewFileProgressStore(path string) (*FileProgressStore, error) {
+	store := &FileProgressStore{
+		path:    path,
+		records: make(map[string]map[string]Progress),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, fmt.Errorf("读取播放进度文件失败: %v", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &store.records); err != nil {
+			return nil, fmt.Errorf("解析播放进度文件失败: %v", err)
+		}
+	}
+	return store, nil
+}
+
+// Get 返回指定设备上指定歌曲的播放进度，没有记录时返回 false。
+func (s *FileProgressStore) Get(songID, device string) (Progress, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	progress, ok := s.records[songID][device]
+	return progress, ok
+}
+
+// Played 返回所有有播放记录的歌曲 ID 到最近一次（任一设备）更新时间的映射副本。
+func (s *FileProgressStore) Played() map[string]time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	played := make(map[string]time.Time, len(s.records))
+	for songID, devices := range s.records {
+		for _, progress := range devices {
+			if progress.UpdatedAt.After(played[songID]) {
+				played[songID] = progress.UpdatedAt
+			}
+		}
+	}
+	return played
+}
+
+// Set 保存指定设备上指定歌曲的播放进度，并立即写入文件。
+func (s *FileProgressStore) Set(songID, device string, positionSeconds float64) (Progress, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	progress := Progress{
+		PositionSeconds: positionSeconds,
+		UpdatedAt:       time.Now(),
+	}
+	devices, ok := s.records[songID]
+	if !ok {
+		devices = make(map[string]Progress)
+		s.records[songID] = devices
+	}
+	devices[device] = progress
+
+	if err := s.save(); err != nil {
+		return Progress{}, err
+	}
+	return progress, nil
+}
+
+// save 将所有记录写入文件。
+// 先写入临时文件再重命名，避免写入中途崩溃导致文件损坏。
+// 调用此函数前必须获取写锁。
+func (s *FileProgressStore) save() error {
+	data, err := json.Marshal(s.records)
+	if err != nil {
+		return fmt.Errorf("序列化播放进度失败: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("创建播放进度目录失败: %v", err)
+	}
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("写入播放进度文件失败: %v", err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("写入播放进度文件失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestFileProgressStore_SetGet 测试写入后读取的一致性以及不同设备之间的隔离。
+func TestFileProgressStore_SetGet(t *testing.T) {
+	store, err := NewFileProgressStore(filepath.Join(t.TempDir(), "progress.json"))
+	if err != nil {
+		t.Fatalf("创建存储失败: %v", err)
+	}
+
+	if _, ok := store.Get("song1", "phone"); ok {
+		t.Error("期望没有记录时返回 false")
+	}
+
+	if _, err := store.Set("song1", "phone", 42.5); err != nil {
+		t.Fatalf("保存进度失败: %v", err)
+	}
+	if _, err := store.Set("song1", "laptop", 10); err != nil {
+		t.Fatalf("保存进度失败: %v", err)
+	}
+
+	if progress, ok := store.Get("song1", "phone"); !ok || progress.PositionSeconds != 42.5 {
+		t.Errorf("期望 phone 的进度为 42.5, 得到 %v (%v)", progress.PositionSeconds, ok)
+	}
+	if progress, ok := store.Get("song1", "laptop"); !ok || progress.PositionSeconds != 10 {
+		t.Errorf("期望 laptop 的进度为 10, 得到 %v (%v)", progress.PositionSeconds, ok)
+	}
+	if _, ok := store.Get("song1", "tablet"); ok {
+		t.Error("期望未写入的设备没有记录")
+	}
+}
+
+// TestFileProgressStore_Persistence 测试进度在重新加载后仍然存在。
+func TestFileProgressStore_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "progress.json")
+
+	store, err := NewFileProgressStore(path)
+	if err != nil {
+		t.Fatalf("创建存储失败: %v", err)
+	}
+	if _, err := store.Set("song1", "phone", 99); err != nil {
+		t.Fatalf("保存进度失败: %v", err)
+	}
+
+	reloaded, err := NewFileProgressStore(path)
+	if err != nil {
+		t.Fatalf("重新加载存储失败: %v", err)
+	}
+	if progress, ok := reloaded.Get("song1", "phone"); !ok || progress.PositionSeconds != 99 {
+		t.Errorf("期望重新加载后进度为 99, 得到 %v (%v)", progress.PositionSeconds, ok)
+	}
+}
+
+// TestFileProgressStore_Played 测试 Played 汇总所有设备的播放记录，取最近一次更新时间。
+func TestFileProgressStore_Played(t *testing.T) {
+	store, err := NewFileProgressStore(filepath.Join(t.TempDir(), "progress.json"))
+	if err != nil {
+		t.Fatalf("创建存储失败: %v", err)
+	}
+	if played := store.Played(); len(played) != 0 {
+		t.Errorf("期望没有记录时返回空映射, 得到 %v", played)
+	}
+
+	if _, err := store.Set("song1", "phone", 1); err != nil {
+		t.Fatalf("保存进度失败: %v", err)
+	}
+	latest, err := store.Set("song1", "laptop", 2)
+	if err != nil {
+		t.Fatalf("保存进度失败: %v", err)
+	}
+	if _, err := store.Set("song2", "phone", 3); err != nil {
+		t.Fatalf("保存进度失败: %v", err)
+	}
+
+	played := store.Played()
+	if len(played) != 2 {
+		t.Fatalf("期望 2 首歌曲有播放记录, 得到 %d", len(played))
+	}
+	if !played["song1"].Equal(latest.UpdatedAt) {
+		t.Errorf("期望 song1 的时间为最近一次更新 %v, 得到 %v", latest.UpdatedAt, played["song1"])
+	}
+}
@@ -2,13 +2,25 @@ package services
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	"zero-music/logger"
 	"zero-music/models"
+
+	"github.com/dhowden/tag"
+	"golang.org/x/text/encoding"
 )
 
 // MusicScanner 负责扫描音乐目录并管理歌曲列表缓存。
@@ -17,27 +29,237 @@ type MusicScanner struct {
 	directory        string
 	supportedFormats []string
 	songs            []*models.Song
-	songIndex        map[string]*models.Song // ID -> Song 的索引，用于快速查找
+	songIndex        atomic.Pointer[map[string]*models.Song] // ID -> Song 的索引，每次扫描构建新的 map 后整体替换，发布后不再修改，读取时不需要获取 mu
 	mu               sync.RWMutex
 	lastScan         time.Time
 	cacheTTL         time.Duration
+	includeHidden    bool                   // 是否收录隐藏文件与隐藏目录
+	minFileSize      int64                  // 被收录文件的最小字节数，0 表示不限制
+	scanTimeout      time.Duration          // 单次扫描的超时时间，0 表示不限制
+	tagTimeout       time.Duration          // 单个文件标签解析的超时时间，0 表示不限制
+	scanMode         string                 // 扫描模式，ScanModeFull 或 ScanModeAdditive
+	changeDetection  string                 // 变更检测方式，ChangeDetectionMTime 或 ChangeDetectionHash
+	parsedFiles      map[string]parsedFile  // 上次扫描各文件的标签解析结果，文件未变化时直接复用
+	ignoreMarkers    []string               // 目录黑名单标记文件名，目录中存在任一标记时跳过整个子树
+	artistSplitter   *models.ArtistSplitter // 拆分多艺术家字符串，为 nil 时不拆分
+	fingerprint      bool                   // 是否为每首歌曲计算内容指纹
+	fingerprintCache *DiskCache             // 内容指纹的持久缓存，为 nil 时每次扫描都重新计算
+	fpcalcPath       string                 // 计算声学指纹的 fpcalc 可执行文件路径，为空时不计算
+	inferFromPath    bool                   // 标签缺失时是否按目录结构推断艺术家与专辑
+	filenamePattern  *regexp.Regexp         // 标签缺失时从文件名解析元数据的正则表达式，为 nil 时不解析
+	filenameEncoding encoding.Encoding      // 非 UTF-8 文件名的编码，为 nil 时不转码
+	lastStats        ScanStats
+	lastScanErr      error  // 最近一次扫描的错误，扫描成功后清空
+	libraryVersion   string // 最近一次成功扫描的音乐库版本标识
+
+	// 扫描进度，在扫描持有写锁期间更新，因此使用原子变量供其他 goroutine 无锁读取。
+	scanning       atomic.Bool
+	scanProcessed  atomic.Int64
+	scanTotalGuess atomic.Int64
+
+	// walk 用于遍历目录，默认为 filepath.Walk，测试时可替换以模拟慢速文件系统。
+	walk func(root string, fn filepath.WalkFunc) error
+	// parseTags 用于解析标签，为 nil 时使用 tag.ReadFrom，测试时可替换以模拟卡住的解析器。
+	parseTags func(io.ReadSeeker) (tag.Metadata, error)
+}
+
+const (
+	// ScanModeFull 表示每次扫描都以磁盘上的实际文件为准，移除不再存在的歌曲。
+	ScanModeFull = "full"
+	// ScanModeAdditive 表示扫描只新增发现的歌曲，不移除当前不可见的条目，
+	// 适用于外接硬盘等可能临时不可用的音乐目录。显式调用 Refresh 时仍执行完整扫描。
+	ScanModeAdditive = "additive"
+)
+
+const (
+	// ChangeDetectionMTime 表示文件大小与修改时间都未变化时视为未修改，复用上次解析的标签。
+	ChangeDetectionMTime = "mtime"
+	// ChangeDetectionHash 表示按整个文件内容的哈希判断是否修改：只修改了 mtime 的文件不会重新解析标签，
+	// 保留 mtime 但内容已变的文件会重新解析。每次扫描都要读取所有文件的完整内容，IO 开销较大。
+	ChangeDetectionHash = "hash"
+)
+
+// parsedFile 是一个音频文件的标签解析结果，以及用于判断文件是否变化的信息。
+type parsedFile struct {
+	size    int64
+	modTime time.Time
+	hash    string       // 文件内容的哈希，仅在 ChangeDetectionHash 模式下计算
+	song    *models.Song // 标签解析得到的歌曲，尚未经过文件名解析、路径推断与 cue 拆分等后处理
+	tagErr  error
+	// acousticFingerprint 是文件的声学指纹，开启声学指纹计算后首次需要时计算，文件未变化时随解析结果复用。
+	acousticFingerprint string
+}
+
+// DefaultIgnoreMarkers 是默认的目录黑名单标记文件名。
+// Android 风格的 .nomedia 表示该目录不应被媒体库索引。
+var DefaultIgnoreMarkers = []string{".nomedia", ".ignore"}
+
+// ErrDirectoryUnavailable 表示音乐目录暂时不可访问（如外接硬盘被拔出），此时保留上次成功扫描的缓存。
+var ErrDirectoryUnavailable = errors.New("音乐目录不可用")
+
+// ScanOrCached 扫描音乐目录并返回歌曲列表。音乐目录暂时不可用（如外接硬盘被拔出）且有上次成功扫描的缓存时，
+// 返回缓存与 stale=true；没有缓存时返回包装了 ErrDirectoryUnavailable 的错误。HTTP 与 gRPC 共用该回退逻辑。
+func ScanOrCached(ctx context.Context, scanner Scanner) (songs []*models.Song, stale bool, err error) {
+	songs, err = scanner.Scan(ctx)
+	if err == nil {
+		return songs, false, nil
+	}
+	if errors.Is(err, ErrDirectoryUnavailable) {
+		if cached := scanner.GetSongs(); len(cached) > 0 {
+			return cached, true, nil
+		}
+	}
+	return nil, false, err
+}
+
+// ErrScanTimeout 表示扫描在配置的超时时间内未能完成。
+var ErrScanTimeout = errors.New("扫描超时")
+
+// ScanStats 记录了一次扫描的性能指标。
+type ScanStats struct {
+	// FilesScanned 是扫描过程中检查过的文件数量（不含被跳过的隐藏文件）。
+	FilesScanned int `json:"files_scanned"`
+	// SongsFound 是被收录为歌曲的文件数量。
+	SongsFound int `json:"songs_found"`
+	// TagErrors 是标签解析失败的文件数量。
+	TagErrors int `json:"tag_errors"`
+	// SkippedPaths 是因无法访问（如权限不足、读取失败）而被跳过的文件和目录数量。
+	SkippedPaths int `json:"skipped_paths"`
+	// Duration 是扫描耗时。
+	Duration time.Duration `json:"duration"`
+}
+
+// ScannerOption 定义了 MusicScanner 的可选配置项。
+type ScannerOption func(*MusicScanner)
+
+// WithIncludeHidden 设置扫描时是否收录以 "." 开头的隐藏文件和目录
+// （包括 macOS 的 "._" AppleDouble 资源派生文件）。默认不收录。
+func WithIncludeHidden(include bool) ScannerOption {
+	return func(s *MusicScanner) {
+		s.includeHidden = include
+	}
+}
+
+// WithMinFileSize 设置被收录为歌曲的最小文件大小（字节）。
+// 小于该值的文件（如下载失败残留的 0 字节文件）会被跳过。
+func WithMinFileSize(size int64) ScannerOption {
+	return func(s *MusicScanner) {
+		s.minFileSize = size
+	}
+}
+
+// WithScanTimeout 设置单次扫描的超时时间，0 表示不限制。
+func WithScanTimeout(timeout time.Duration) ScannerOption {
+	return func(s *MusicScanner) {
+		s.scanTimeout = timeout
+	}
+}
+
+// WithTagTimeout 设置单个文件标签解析的超时时间，0 表示不限制。
+// 超时的文件放弃标签，使用文件名等默认元数据，避免个别损坏文件拖垮整次扫描。
+func WithTagTimeout(timeout time.Duration) ScannerOption {
+	return func(s *MusicScanner) {
+		s.tagTimeout = timeout
+	}
+}
+
+// WithScanMode 设置扫描模式（ScanModeFull 或 ScanModeAdditive），默认为 ScanModeFull。
+func WithScanMode(mode string) ScannerOption {
+	return func(s *MusicScanner) {
+		s.scanMode = mode
+	}
+}
+
+// WithChangeDetection 设置判断文件是否修改的方式（ChangeDetectionMTime 或 ChangeDetectionHash），
+// 默认为 ChangeDetectionMTime。未修改的文件复用上次扫描解析的标签，不重新读取。
+func WithChangeDetection(mode string) ScannerOption {
+	return func(s *MusicScanner) {
+		s.changeDetection = mode
+	}
+}
+
+// WithIgnoreMarkers 设置目录黑名单标记文件名，默认为 DefaultIgnoreMarkers。
+// 传入空列表表示不跳过任何目录。
+func WithIgnoreMarkers(markers []string) ScannerOption {
+	return func(s *MusicScanner) {
+		s.ignoreMarkers = markers
+	}
+}
+
+// WithArtistSeparators 设置拆分多艺术家字符串的分隔符，默认为 models.DefaultArtistSeparators。
+// 传入空列表表示不拆分，Artists 只包含原始的 Artist。
+func WithArtistSeparators(separators []string) ScannerOption {
+	return func(s *MusicScanner) {
+		s.artistSplitter = models.NewArtistSplitter(separators)
+	}
+}
+
+// WithFingerprint 开启内容指纹计算。计算需要读取每个文件的开头，因此默认关闭。
+// cache 用于持久化指纹，源文件未修改时直接复用，可以为 nil。
+func WithFingerprint(cache *DiskCache) ScannerOption {
+	return func(s *MusicScanner) {
+		s.fingerprint = true
+		s.fingerprintCache = cache
+	}
+}
+
+// WithAcousticFingerprint 开启声学指纹计算，使用 fpcalcPath 指定的 chromaprint fpcalc 命令行。
+// 需要解码每个文件的开头，开销远大于内容指纹，因此默认关闭；cache 不为 nil 时指纹会被持久缓存。
+func WithAcousticFingerprint(fpcalcPath string, cache *DiskCache) ScannerOption {
+	return func(s *MusicScanner) {
+		s.fpcalcPath = fpcalcPath
+		s.fingerprintCache = cache
+	}
+}
+
+// WithInferFromPath 设置标签缺失时是否按 "艺术家/专辑/曲目" 的目录结构推断艺术家与专辑。默认不推断。
+func WithInferFromPath(infer bool) ScannerOption {
+	return func(s *MusicScanner) {
+		s.inferFromPath = infer
+	}
+}
+
+// WithFilenamePattern 设置标签缺失时从文件名解析元数据的正则表达式，
+// 支持 track、artist、title、album 命名捕获组。为 nil 时不解析。
+func WithFilenamePattern(pattern *regexp.Regexp) ScannerOption {
+	return func(s *MusicScanner) {
+		s.filenamePattern = pattern
+	}
+}
+
+// WithFilenameEncoding 设置非 UTF-8 文件名使用的编码（见 models.LookupFilenameEncoding），
+// 扫描时将文件名转码为 UTF-8 后用于标题与展示。为 nil 时不转码。
+func WithFilenameEncoding(enc encoding.Encoding) ScannerOption {
+	return func(s *MusicScanner) {
+		s.filenameEncoding = enc
+	}
 }
 
 // NewMusicScanner 创建并返回一个新的 MusicScanner 实例。
-func NewMusicScanner(directory string, supportedFormats []string, cacheTTLMinutes int) *MusicScanner {
+func NewMusicScanner(directory string, supportedFormats []string, cacheTTLMinutes int, opts ...ScannerOption) *MusicScanner {
 	if len(supportedFormats) == 0 {
 		supportedFormats = []string{".mp3"}
 	}
 	if cacheTTLMinutes <= 0 {
 		cacheTTLMinutes = 5
 	}
-	return &MusicScanner{
+	s := &MusicScanner{
 		directory:        directory,
 		supportedFormats: supportedFormats,
 		songs:            make([]*models.Song, 0),
-		songIndex:        make(map[string]*models.Song),
 		cacheTTL:         time.Duration(cacheTTLMinutes) * time.Minute,
+		scanMode:         ScanModeFull,
+		changeDetection:  ChangeDetectionMTime,
+		parsedFiles:      make(map[string]parsedFile),
+		ignoreMarkers:    DefaultIgnoreMarkers,
+		artistSplitter:   models.NewArtistSplitter(models.DefaultArtistSeparators),
+		walk:             filepath.Walk,
+	}
+	s.songIndex.Store(&map[string]*models.Song{})
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 // Scan 扫描音乐目录并返回歌曲列表。
@@ -65,22 +287,53 @@ func (s *MusicScanner) Scan(ctx context.Context) ([]*models.Song, error) {
 	}
 
 	// 执行实际的扫描操作。
-	return s.scanInternal(ctx)
+	songs, err := s.scanInternal(ctx, s.scanMode == ScanModeAdditive)
+	s.lastScanErr = err
+	return songs, err
 }
 
 // scanInternal 是实际的扫描逻辑。
+// additive 为 true 时，新的扫描结果会与现有缓存合并，而不是替换它。
 // 调用此函数前必须获取写锁。
-func (s *MusicScanner) scanInternal(ctx context.Context) ([]*models.Song, error) {
-	s.songs = make([]*models.Song, 0)
-	s.songIndex = make(map[string]*models.Song)
+func (s *MusicScanner) scanInternal(ctx context.Context, additive bool) ([]*models.Song, error) {
+	// 为扫描设置超时，避免挂载的网络盘卡住时请求无限等待。
+	if s.scanTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.scanTimeout)
+		defer cancel()
+	}
+
+	songs := make([]*models.Song, 0)
+	index := make(map[string]*models.Song)
+	parsedFiles := make(map[string]parsedFile)
+	// 同目录封面文件的检测结果，每个目录在一次扫描中只检测一次。
+	folderCovers := make(map[string]bool)
+	start := time.Now()
+	var stats ScanStats
+
+	// 扫描是单次遍历，使用上一次扫描检查过的文件数估算总数。
+	s.scanProcessed.Store(0)
+	s.scanTotalGuess.Store(int64(s.lastStats.FilesScanned))
+	s.scanning.Store(true)
+	defer s.scanning.Store(false)
 
-	// 确保音乐目录存在。
-	if _, err := os.Stat(s.directory); os.IsNotExist(err) {
-		return nil, fmt.Errorf("音乐目录不存在: %s", s.directory)
+	// 确保音乐目录可以访问。
+	if _, err := os.Stat(s.directory); err != nil {
+		// 合并模式下目录暂时不可用（如外接硬盘被拔出）时保留现有缓存。
+		if additive {
+			logger.Warnf("音乐目录暂时不可用，保留现有的 %d 首歌曲: %s", len(s.songs), s.directory)
+			result := make([]*models.Song, len(s.songs))
+			copy(result, s.songs)
+			return result, nil
+		}
+		// 不清空缓存，调用方可以在目录恢复前继续使用上次成功扫描的结果；
+		// 同时将缓存标记为过期，使后续请求继续探测目录是否恢复。
+		s.lastScan = time.Time{}
+		return nil, fmt.Errorf("%w: %v", ErrDirectoryUnavailable, err)
 	}
 
 	// 遍历目录下的所有文件。
-	err := filepath.Walk(s.directory, func(path string, info os.FileInfo, err error) error {
+	err := s.walk(s.directory, func(path string, info os.FileInfo, err error) error {
 		// 检查 context 是否被取消
 		select {
 		case <-ctx.Done():
@@ -88,12 +341,42 @@ func (s *MusicScanner) scanInternal(ctx context.Context) ([]*models.Song, error)
 		default:
 		}
 
+		// 单个文件或子目录无法访问（如权限不足）时记录并跳过，不影响其余部分的扫描；
+		// 只有音乐根目录本身无法读取时才中断扫描。
 		if err != nil {
-			return err
+			if path == s.directory {
+				return err
+			}
+			stats.SkippedPaths++
+			logger.Warnf("无法访问 %s，已跳过: %v", path, err)
+			if info != nil && info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
 		}
 
-		// 忽略目录。
+		// 跳过隐藏文件和隐藏目录（音乐根目录本身除外）。
+		if !s.includeHidden && path != s.directory && isHidden(info.Name()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// 忽略目录，包含黑名单标记文件的目录（音乐根目录本身除外）连同子树一起跳过。
 		if info.IsDir() {
+			if path != s.directory && s.hasIgnoreMarker(path) {
+				logger.Debugf("目录包含黑名单标记文件，跳过: %s", path)
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		stats.FilesScanned++
+		s.scanProcessed.Add(1)
+
+		// 跳过过小的文件，它们通常是损坏或下载失败的残留。
+		if info.Size() < s.minFileSize {
+			logger.Debugf("跳过过小的文件 %s (%d 字节，最小 %d 字节)", path, info.Size(), s.minFileSize)
 			return nil
 		}
 
@@ -101,9 +384,42 @@ func (s *MusicScanner) scanInternal(ctx context.Context) ([]*models.Song, error)
 		ext := strings.ToLower(filepath.Ext(path))
 		for _, supported := range s.supportedFormats {
 			if ext == strings.ToLower(supported) {
-				song := models.NewSong(path, info.Size())
-				s.songs = append(s.songs, song)
-				s.songIndex[song.ID] = song
+				parsed := s.readSong(path, info)
+				parsedFiles[path] = parsed
+				if parsed.tagErr != nil {
+					stats.TagErrors++
+				}
+				song := parsed.song.Clone()
+				if s.filenamePattern != nil {
+					song.ApplyFilenamePattern(s.filenamePattern)
+				}
+				if s.inferFromPath {
+					song.InferFromPath(s.directory)
+				}
+				if s.fingerprint {
+					song.Fingerprint = s.fileFingerprint(song, info)
+				}
+				if s.fpcalcPath != "" {
+					if parsed.acousticFingerprint == "" {
+						parsed.acousticFingerprint = s.acousticFingerprint(ctx, song, info)
+						parsedFiles[path] = parsed
+					}
+					song.AcousticFingerprint = parsed.acousticFingerprint
+				}
+				if !song.HasCover {
+					dir := filepath.Dir(path)
+					hasCover, ok := folderCovers[dir]
+					if !ok {
+						hasCover = models.FindFolderCover(dir) != ""
+						folderCovers[dir] = hasCover
+					}
+					song.HasCover = hasCover
+				}
+				for _, song := range splitCue(path, song) {
+					song.Artists = s.artistSplitter.Split(song.Artist)
+					songs = append(songs, song)
+					index[song.ID] = song
+				}
 				break
 			}
 		}
@@ -111,21 +427,202 @@ func (s *MusicScanner) scanInternal(ctx context.Context) ([]*models.Song, error)
 		return nil
 	})
 
+	// 致命错误（根目录无法读取、context 取消或超时）时扫描结果不完整，保留上次成功扫描的缓存。
 	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) && s.scanTimeout > 0 {
+			return nil, fmt.Errorf("%w: 超过 %v 仍未完成", ErrScanTimeout, s.scanTimeout)
+		}
 		return nil, fmt.Errorf("扫描目录时出错: %v", err)
 	}
 
+	// 合并模式下保留本次未能发现的已有歌曲。
+	if additive {
+		for _, song := range s.songs {
+			if _, ok := index[song.ID]; !ok {
+				songs = append(songs, song)
+				index[song.ID] = song
+			}
+		}
+	}
+	s.songs = songs
+	s.songIndex.Store(&index)
+	s.parsedFiles = parsedFiles
+	s.libraryVersion = libraryVersion(songs)
+
 	s.lastScan = time.Now()
+	stats.SongsFound = len(s.songs)
+	stats.Duration = time.Since(start)
+	s.lastStats = stats
+	s.scanTotalGuess.Store(int64(stats.FilesScanned))
+
+	logger.WithFields(map[string]interface{}{
+		"directory":     s.directory,
+		"files_scanned": stats.FilesScanned,
+		"songs_found":   stats.SongsFound,
+		"tag_errors":    stats.TagErrors,
+		"skipped_paths": stats.SkippedPaths,
+		"duration_ms":   stats.Duration.Milliseconds(),
+	}).Info("音乐目录扫描完成")
+
 	return s.songs, nil
 }
 
+// readSong 返回文件的标签解析结果。文件自上次扫描后未修改时直接复用上次的结果，否则重新解析标签。
+// 调用此函数前必须获取写锁。
+func (s *MusicScanner) readSong(path string, info os.FileInfo) parsedFile {
+	// 标签解析超时可能只是暂时的（如网络盘卡顿），这样的结果不复用。
+	previous, ok := s.parsedFiles[path]
+	ok = ok && !errors.Is(previous.tagErr, models.ErrTagReadTimeout)
+	current := parsedFile{size: info.Size(), modTime: info.ModTime()}
+
+	if s.changeDetection == ChangeDetectionHash {
+		hash, err := models.ComputeContentHash(path)
+		if err != nil {
+			logger.Warnf("计算文件内容哈希失败，重新解析标签: %v", err)
+		}
+		current.hash = hash
+		if ok && hash != "" && hash == previous.hash {
+			return previous
+		}
+	} else if ok && previous.size == current.size && previous.modTime.Equal(current.modTime) {
+		return previous
+	}
+
+	current.song, current.tagErr = models.ReadSongWithOptions(path, info.Size(), models.TagReadOptions{
+		Timeout:          s.tagTimeout,
+		Parse:            s.parseTags,
+		FilenameEncoding: s.filenameEncoding,
+	})
+	if errors.Is(current.tagErr, models.ErrTagReadTimeout) {
+		logger.Warnf("标签解析超过 %v，使用文件名作为标题: %s", s.tagTimeout, path)
+	} else if current.tagErr != nil {
+		logger.Debugf("标签解析失败，使用默认元数据: %v", current.tagErr)
+	}
+	return current
+}
+
+// fingerprintCacheName 是内容指纹在磁盘缓存中的条目名。
+const fingerprintCacheName = "fingerprint"
+
+// fileFingerprint 返回文件的内容指纹，优先使用持久缓存。计算失败时返回空字符串。
+func (s *MusicScanner) fileFingerprint(song *models.Song, info os.FileInfo) string {
+	var fingerprint string
+	if s.fingerprintCache.GetJSON(song.ID, fingerprintCacheName, info.ModTime(), &fingerprint) {
+		return fingerprint
+	}
+
+	fingerprint, err := models.ComputeFingerprint(song.FilePath)
+	if err != nil {
+		logger.Warnf("计算内容指纹失败: %v", err)
+		return ""
+	}
+	if err := s.fingerprintCache.PutJSON(song.ID, fingerprintCacheName, info.ModTime(), fingerprint); err != nil {
+		logger.Warnf("写入内容指纹缓存失败: %v", err)
+	}
+	return fingerprint
+}
+
+// acousticFingerprintCacheName 是声学指纹在磁盘缓存中的条目名。
+const acousticFingerprintCacheName = "acoustic_fingerprint"
+
+// acousticFingerprint 返回文件的声学指纹，优先使用持久缓存。计算失败时返回空字符串。
+func (s *MusicScanner) acousticFingerprint(ctx context.Context, song *models.Song, info os.FileInfo) string {
+	var fingerprint string
+	if s.fingerprintCache.GetJSON(song.ID, acousticFingerprintCacheName, info.ModTime(), &fingerprint) {
+		return fingerprint
+	}
+
+	fingerprint, err := models.ComputeAcousticFingerprint(ctx, s.fpcalcPath, song.FilePath)
+	if err != nil {
+		logger.Warnf("计算声学指纹失败: %v", err)
+		return ""
+	}
+	if err := s.fingerprintCache.PutJSON(song.ID, acousticFingerprintCacheName, info.ModTime(), fingerprint); err != nil {
+		logger.Warnf("写入声学指纹缓存失败: %v", err)
+	}
+	return fingerprint
+}
+
+// splitCue 在音频文件旁存在同名 .cue 文件时，将整轨歌曲拆分为每条音轨一首的虚拟歌曲。
+// 没有 cue 文件或拆分失败时返回原歌曲本身。
+func splitCue(path string, song *models.Song) []*models.Song {
+	cuePath := strings.TrimSuffix(path, filepath.Ext(path)) + ".cue"
+	tracks, err := models.ReadCueTracks(song, cuePath)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			logger.Warnf("cue 拆分失败，按整轨处理: %v", err)
+		}
+		return []*models.Song{song}
+	}
+	return tracks
+}
+
+// LastScanStats 返回最近一次成功扫描的性能指标。
+func (s *MusicScanner) LastScanStats() ScanStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lastStats
+}
+
+// LibraryVersion 返回最近一次成功扫描得到的音乐库版本标识。
+func (s *MusicScanner) LibraryVersion() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.libraryVersion
+}
+
+// libraryVersion 根据所有歌曲的 ID（由路径决定）、文件大小与修改时间计算音乐库的版本标识。
+// 歌曲按 ID 排序后再哈希，因此版本与遍历顺序无关；任一文件新增、删除或修改都会改变版本。
+func libraryVersion(songs []*models.Song) string {
+	sorted := make([]*models.Song, len(songs))
+	copy(sorted, songs)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	hash := sha256.New()
+	for _, song := range sorted {
+		fmt.Fprintf(hash, "%s\x00%d\x00%d\n", song.ID, song.FileSize, song.AddedAt.UnixNano())
+	}
+	return hex.EncodeToString(hash.Sum(nil)[:8])
+}
+
+// hasIgnoreMarker 判断目录中是否存在任一黑名单标记文件。
+func (s *MusicScanner) hasIgnoreMarker(dir string) bool {
+	for _, marker := range s.ignoreMarkers {
+		if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// isHidden 判断文件或目录名是否为隐藏项。
+// 以 "." 开头的名称（如 .DS_Store）以及 macOS 的 AppleDouble 文件（如 ._song.mp3）都视为隐藏。
+func isHidden(name string) bool {
+	return strings.HasPrefix(name, ".")
+}
+
 // Refresh 强制执行一次新的扫描,并刷新歌曲列表缓存。
-func (s *MusicScanner) Refresh(ctx context.Context) error {
+// 无论扫描模式如何，Refresh 总是执行完整扫描，移除已不存在的歌曲。
+// 返回本次扫描相对于上次缓存的新增、删除与修改的歌曲；扫描失败时缓存保持不变，返回空的 diff。
+func (s *MusicScanner) Refresh(ctx context.Context) (LibraryDiff, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	_, err := s.scanInternal(ctx)
-	return err
+	previous := s.songs
+	songs, err := s.scanInternal(ctx, false)
+	s.lastScanErr = err
+	if err != nil {
+		return LibraryDiff{}, err
+	}
+	diff := diffLibrary(previous, songs)
+	logger.WithFields(map[string]interface{}{
+		"added":    len(diff.Added),
+		"removed":  len(diff.Removed),
+		"modified": len(diff.Modified),
+	}).Info("音乐库刷新完成")
+	return diff, nil
 }
 
 // GetSongs 返回当前缓存的歌曲列表的深度拷贝。
@@ -138,10 +635,7 @@ func (s *MusicScanner) GetSongs() []*models.Song {
 	songs := make([]*models.Song, len(s.songs))
 	for i, song := range s.songs {
 		if song != nil {
-			// 拷贝 Song 结构体
-			copiedSong := *song
-			// 拷贝 SupportedFormats 切片（如果 Song 中有的话）
-			songs[i] = &copiedSong
+			songs[i] = song.Clone()
 		}
 	}
 	return songs
@@ -154,16 +648,64 @@ func (s *MusicScanner) GetSongCount() int {
 	return len(s.songs)
 }
 
+// Stats 返回扫描器缓存的当前状态。
+func (s *MusicScanner) Stats() CacheStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return CacheStats{
+		LastScan:         s.lastScan,
+		LastScanDuration: s.lastStats.Duration,
+		SongCount:        len(s.songs),
+		CacheTTL:         s.cacheTTL,
+		Stale:            time.Since(s.lastScan) >= s.cacheTTL,
+		LastScanError:    errorString(s.lastScanErr),
+	}
+}
+
+// errorString 返回错误的描述，err 为 nil 时返回空字符串。
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// Progress 返回当前扫描的进度，不需要获取扫描锁，扫描进行中也可以调用。
+func (s *MusicScanner) Progress() ScanProgress {
+	progress := ScanProgress{
+		Scanning:  s.scanning.Load(),
+		Processed: int(s.scanProcessed.Load()),
+		Total:     int(s.scanTotalGuess.Load()),
+	}
+	progress.Total = max(progress.Total, progress.Processed)
+	if progress.Total > 0 {
+		progress.Percent = progress.Processed * 100 / progress.Total
+		if progress.Scanning {
+			progress.Percent = min(progress.Percent, 99)
+		}
+	}
+	return progress
+}
+
 // GetSongByID 根据 ID 查找并返回指定的歌曲。
 // 如果未找到歌曲，则返回 nil。
-// 此方法使用索引进行高效查找。
+// 此方法在不可变的索引快照中查找，不获取扫描锁，扫描进行中返回上次扫描的结果。
 func (s *MusicScanner) GetSongByID(id string) *models.Song {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	song, ok := s.songIndex[id]
+	song, ok := (*s.songIndex.Load())[id]
 	if !ok || song == nil {
 		return nil
 	}
-	copiedSong := *song
-	return &copiedSong
+	return song.Clone()
+}
+
+// GetSongsByIDs 根据 ID 批量查找歌曲，所有 ID 都在同一个索引快照中查找。
+func (s *MusicScanner) GetSongsByIDs(ids []string) map[string]*models.Song {
+	index := *s.songIndex.Load()
+	result := make(map[string]*models.Song, len(ids))
+	for _, id := range ids {
+		if song, ok := index[id]; ok && song != nil {
+			result[id] = song.Clone()
+		}
+	}
+	return result
 }
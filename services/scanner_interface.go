@@ -2,9 +2,39 @@ package services
 
 import (
 	"context"
+	"time"
 	"zero-music/models"
 )
 
+// CacheStats 描述了扫描器缓存的当前状态。
+type CacheStats struct {
+	// LastScan 是最近一次成功扫描的时间，从未扫描时为零值。
+	LastScan time.Time
+	// LastScanDuration 是最近一次成功扫描的耗时，从未扫描时为 0。
+	LastScanDuration time.Duration
+	// SongCount 是当前缓存的歌曲数量。
+	SongCount int
+	// CacheTTL 是缓存的有效期。
+	CacheTTL time.Duration
+	// Stale 表示缓存是否已过期（time.Since(LastScan) >= CacheTTL）。
+	Stale bool
+	// LastScanError 是最近一次扫描失败的原因，最近一次扫描成功或从未扫描时为空。
+	LastScanError string
+}
+
+// ScanProgress 描述了当前扫描的进度。
+// 扫描是单次遍历，总数使用上一次扫描检查过的文件数估算，首次扫描时为 0（未知）。
+type ScanProgress struct {
+	// Scanning 表示当前是否正在扫描。
+	Scanning bool `json:"scanning"`
+	// Processed 是本次扫描已检查过的文件数量，未在扫描时为最近一次扫描的结果。
+	Processed int `json:"processed"`
+	// Total 是预计需要检查的文件总数，扫描中发现的文件超出预计时随已处理数量增长。
+	Total int `json:"total"`
+	// Percent 是完成百分比（0-100），扫描进行中最多为 99，总数未知时为 0。
+	Percent int `json:"percent"`
+}
+
 // Scanner 定义了音乐扫描器的接口。
 // 该接口提供了扫描音乐文件和管理歌曲列表缓存的抽象。
 type Scanner interface {
@@ -12,8 +42,8 @@ type Scanner interface {
 	// 为了提高性能，实现应该缓存扫描结果。
 	Scan(ctx context.Context) ([]*models.Song, error)
 
-	// Refresh 强制执行一次新的扫描，并刷新歌曲列表缓存。
-	Refresh(ctx context.Context) error
+	// Refresh 强制执行一次新的扫描，并刷新歌曲列表缓存，返回相对于上次缓存的变化。
+	Refresh(ctx context.Context) (LibraryDiff, error)
 
 	// GetSongs 返回当前缓存的歌曲列表。
 	GetSongs() []*models.Song
@@ -24,4 +54,17 @@ type Scanner interface {
 	// GetSongByID 根据 ID 查找并返回指定的歌曲。
 	// 如果未找到歌曲，则返回 nil。
 	GetSongByID(id string) *models.Song
+
+	// GetSongsByIDs 根据 ID 批量查找歌曲，返回以 ID 为键的歌曲副本，未找到的 ID 不出现在结果中。
+	GetSongsByIDs(ids []string) map[string]*models.Song
+
+	// Stats 返回扫描器缓存的当前状态。
+	Stats() CacheStats
+
+	// Progress 返回当前扫描的进度。
+	Progress() ScanProgress
+
+	// LibraryVersion 返回最近一次成功扫描得到的音乐库版本标识，库中文件未变化时保持不变。
+	// 尚未扫描时返回空字符串。
+	LibraryVersion() string
 }
@@ -2,10 +2,32 @@ package services
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"math/rand"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+	"zero-music/models"
+
+	"github.com/dhowden/tag"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/simplifiedchinese"
 )
 
 // TestNewMusicScanner 测试 NewMusicScanner 是否能正确创建一个扫描器实例。
@@ -127,7 +149,7 @@ func TestMusicScanner_Refresh(t *testing.T) {
 	time.Sleep(10 * time.Millisecond)
 
 	// 手动刷新缓存。
-	err = scanner.Refresh(context.Background())
+	_, err = scanner.Refresh(context.Background())
 	if err != nil {
 		t.Fatalf("刷新失败: %v", err)
 	}
@@ -244,3 +266,1563 @@ func TestMusicScanner_ConcurrentAccess(t *testing.T) {
 		<-done
 	}
 }
+
+// TestMusicScanner_SkipHidden 测试扫描器默认跳过隐藏文件、AppleDouble 文件和隐藏目录。
+func TestMusicScanner_SkipHidden(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	files := []string{
+		"a.mp3",
+		"._a.mp3",
+		".DS_Store",
+		filepath.Join(".hidden", "b.mp3"),
+	}
+	for _, name := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("fake mp3"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 默认行为：仅收录 a.mp3。
+	scanner := NewMusicScanner(tmpDir, []string{".mp3"}, 5)
+	songs, err := scanner.Scan(context.Background())
+	if err != nil {
+		t.Fatalf("扫描失败: %v", err)
+	}
+	if len(songs) != 1 || songs[0].FileName != "a.mp3" {
+		t.Errorf("期望只收录 a.mp3, 得到 %d 首歌曲", len(songs))
+	}
+
+	// 开启 IncludeHidden 后，隐藏文件和隐藏目录中的文件都应被收录。
+	scanner = NewMusicScanner(tmpDir, []string{".mp3"}, 5, WithIncludeHidden(true))
+	songs, err = scanner.Scan(context.Background())
+	if err != nil {
+		t.Fatalf("扫描失败: %v", err)
+	}
+	if len(songs) != 3 {
+		t.Errorf("期望收录 3 首歌曲, 得到 %d", len(songs))
+	}
+}
+
+// TestMusicScanner_MinFileSize 测试小于最小文件大小的文件是否被排除。
+func TestMusicScanner_MinFileSize(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "empty.mp3"), []byte{}, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "small.mp3"), []byte("abc"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "normal.mp3"), []byte("fake mp3 content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 默认不限制，所有文件（包括空文件）都被收录。
+	scanner := NewMusicScanner(tmpDir, []string{".mp3"}, 5)
+	songs, err := scanner.Scan(context.Background())
+	if err != nil {
+		t.Fatalf("扫描失败: %v", err)
+	}
+	if len(songs) != 3 {
+		t.Errorf("期望收录 3 首歌曲, 得到 %d", len(songs))
+	}
+
+	// 设置阈值后，小于阈值的文件被跳过。
+	scanner = NewMusicScanner(tmpDir, []string{".mp3"}, 5, WithMinFileSize(4))
+	songs, err = scanner.Scan(context.Background())
+	if err != nil {
+		t.Fatalf("扫描失败: %v", err)
+	}
+	if len(songs) != 1 || songs[0].FileName != "normal.mp3" {
+		t.Errorf("期望只收录 normal.mp3, 得到 %d 首歌曲", len(songs))
+	}
+}
+
+// TestMusicScanner_ScanStats 测试扫描指标是否正确统计文件数、歌曲数和标签解析失败数。
+func TestMusicScanner_ScanStats(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// 不含标签的文件不计为标签错误。
+	if err := os.WriteFile(filepath.Join(tmpDir, "plain.mp3"), make([]byte, 200), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// 截断的 ID3 头会导致标签解析失败。
+	if err := os.WriteFile(filepath.Join(tmpDir, "broken.mp3"), []byte("ID3\x03\x00\x00\x7f\x7f\x7f\x7f"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "notes.txt"), []byte("text"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	scanner := NewMusicScanner(tmpDir, []string{".mp3"}, 5)
+	if _, err := scanner.Scan(context.Background()); err != nil {
+		t.Fatalf("扫描失败: %v", err)
+	}
+
+	stats := scanner.LastScanStats()
+	if stats.FilesScanned != 3 {
+		t.Errorf("期望扫描 3 个文件, 得到 %d", stats.FilesScanned)
+	}
+	if stats.SongsFound != 2 {
+		t.Errorf("期望找到 2 首歌曲, 得到 %d", stats.SongsFound)
+	}
+	if stats.TagErrors != 1 {
+		t.Errorf("期望 1 个标签解析失败, 得到 %d", stats.TagErrors)
+	}
+}
+
+// TestMusicScanner_ScanTimeout 测试扫描超过超时时间时是否返回超时错误。
+func TestMusicScanner_ScanTimeout(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"a.mp3", "b.mp3", "c.mp3"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("fake mp3"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scanner := NewMusicScanner(tmpDir, []string{".mp3"}, 5, WithScanTimeout(20*time.Millisecond))
+	// 注入一个慢速的遍历函数，模拟卡顿的网络文件系统。
+	scanner.walk = func(root string, fn filepath.WalkFunc) error {
+		return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			time.Sleep(50 * time.Millisecond)
+			return fn(path, info, err)
+		})
+	}
+
+	start := time.Now()
+	_, err := scanner.Scan(context.Background())
+	if !errors.Is(err, ErrScanTimeout) {
+		t.Fatalf("期望返回 ErrScanTimeout, 得到 %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("扫描应在超时后尽快返回, 实际耗时 %v", elapsed)
+	}
+
+	// Refresh 同样受超时限制。
+	if _, err := scanner.Refresh(context.Background()); !errors.Is(err, ErrScanTimeout) {
+		t.Errorf("期望 Refresh 返回 ErrScanTimeout, 得到 %v", err)
+	}
+}
+
+// TestMusicScanner_TagTimeout 测试标签解析卡住的文件在超时后使用文件名作为标题，扫描仍然完成；
+// 超时后文件句柄仍归解析 goroutine 所有，解析中的读取不会因文件被关闭而失败，解析结束后由它关闭文件。
+func TestMusicScanner_TagTimeout(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"stuck.flac", "normal.mp3"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("fake audio"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scanner := NewMusicScanner(tmpDir, []string{".mp3", ".flac"}, 5, WithTagTimeout(50*time.Millisecond))
+	unblock := make(chan struct{})
+	stuckFile := make(chan *os.File, 1)
+	readErr := make(chan error, 1)
+	// 注入一个对 stuck.flac 阻塞到 unblock 关闭的解析器，之后再读取一次文件。
+	scanner.parseTags = func(r io.ReadSeeker) (tag.Metadata, error) {
+		file := r.(*os.File)
+		if filepath.Base(file.Name()) != "stuck.flac" {
+			return nil, tag.ErrNoTagsFound
+		}
+		stuckFile <- file
+		<-unblock
+		_, err := file.ReadAt(make([]byte, 1), 0)
+		readErr <- err
+		return nil, tag.ErrNoTagsFound
+	}
+
+	songs, err := scanner.Scan(context.Background())
+	if err != nil {
+		t.Fatalf("扫描失败: %v", err)
+	}
+	if len(songs) != 2 {
+		t.Fatalf("期望扫描到 2 首歌曲, 得到 %d", len(songs))
+	}
+	for _, song := range songs {
+		if want := strings.TrimSuffix(song.FileName, filepath.Ext(song.FileName)); song.Title != want {
+			t.Errorf("期望标题为文件名 %s, 得到 %s", want, song.Title)
+		}
+	}
+	if stats := scanner.LastScanStats(); stats.TagErrors != 1 {
+		t.Errorf("期望 1 个标签错误, 得到 %d", stats.TagErrors)
+	}
+
+	file := <-stuckFile
+	close(unblock)
+	if err := <-readErr; err != nil {
+		t.Errorf("期望超时后解析器仍可读取文件, 得到 %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, err := file.Stat(); errors.Is(err, os.ErrClosed) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("期望解析 goroutine 结束后关闭文件")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// TestMusicScanner_SkipsUnreadablePaths 测试无法访问的子目录和文件被跳过，其余文件仍被正常收录；
+// 只有根目录无法读取时扫描才失败，并保留上次成功扫描的缓存。
+func TestMusicScanner_SkipsUnreadablePaths(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"good.mp3", "broken.mp3", "locked/hidden.mp3", "ok/song.mp3"} {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("fake mp3"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scanner := NewMusicScanner(tmpDir, []string{".mp3"}, 5)
+	// 测试通常以 root 运行，chmod 无法制造权限错误，因此注入遍历错误：
+	// locked 目录无法读取，broken.mp3 无法获取文件信息。
+	rootErr := false
+	scanner.walk = func(root string, fn filepath.WalkFunc) error {
+		return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			switch {
+			case path == root && rootErr:
+				return fn(path, info, fs.ErrPermission)
+			case filepath.Base(path) == "locked":
+				return fn(path, info, fs.ErrPermission)
+			case filepath.Base(path) == "broken.mp3":
+				return fn(path, nil, fs.ErrPermission)
+			}
+			return fn(path, info, err)
+		})
+	}
+
+	songs, err := scanner.Scan(context.Background())
+	if err != nil {
+		t.Fatalf("期望跳过无法访问的路径后扫描成功, 得到 %v", err)
+	}
+	names := make([]string, 0, len(songs))
+	for _, song := range songs {
+		names = append(names, song.FileName)
+	}
+	sort.Strings(names)
+	if got := strings.Join(names, ","); got != "good.mp3,song.mp3" {
+		t.Errorf("期望收录 good.mp3,song.mp3, 得到 %s", got)
+	}
+	if stats := scanner.LastScanStats(); stats.SkippedPaths != 2 {
+		t.Errorf("期望跳过 2 个路径, 得到 %d", stats.SkippedPaths)
+	}
+
+	// 根目录无法读取是致命错误，扫描失败但保留上次的结果。
+	rootErr = true
+	if _, err := scanner.Refresh(context.Background()); err == nil {
+		t.Fatal("期望根目录无法读取时扫描失败")
+	}
+	if count := scanner.GetSongCount(); count != 2 {
+		t.Errorf("期望保留上次扫描的 2 首歌曲, 得到 %d", count)
+	}
+}
+
+// TestMusicScanner_LibraryVersion 测试音乐库不变时版本稳定，文件修改或新增后版本改变。
+func TestMusicScanner_LibraryVersion(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "a.mp3")
+	if err := os.WriteFile(testFile, []byte("fake mp3"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	scanner := NewMusicScanner(tmpDir, []string{".mp3"}, 5)
+	if version := scanner.LibraryVersion(); version != "" {
+		t.Errorf("期望扫描前版本为空, 得到 %s", version)
+	}
+
+	refresh := func() string {
+		t.Helper()
+		if _, err := scanner.Refresh(context.Background()); err != nil {
+			t.Fatalf("扫描失败: %v", err)
+		}
+		return scanner.LibraryVersion()
+	}
+
+	initial := refresh()
+	if initial == "" {
+		t.Fatal("期望扫描后版本不为空")
+	}
+	if version := refresh(); version != initial {
+		t.Errorf("期望库不变时版本稳定, 得到 %s 与 %s", initial, version)
+	}
+
+	if err := os.WriteFile(testFile, []byte("modified fake mp3"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	modified := refresh()
+	if modified == initial {
+		t.Error("期望文件修改后版本改变")
+	}
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "b.mp3"), []byte("fake mp3"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if version := refresh(); version == modified {
+		t.Error("期望新增文件后版本改变")
+	}
+}
+
+// TestMusicScanner_Stats 测试 Stats 方法返回的缓存状态，以及缓存过期后 Stale 为 true。
+func TestMusicScanner_Stats(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	testFile := filepath.Join(tmpDir, "test.mp3")
+	if err := os.WriteFile(testFile, []byte("fake mp3"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	scanner := NewMusicScanner(tmpDir, []string{".mp3"}, 5)
+
+	// 扫描前缓存视为过期。
+	if stats := scanner.Stats(); !stats.Stale || !stats.LastScan.IsZero() {
+		t.Errorf("期望扫描前缓存已过期且 LastScan 为零值, 得到 %+v", stats)
+	}
+
+	if _, err := scanner.Scan(context.Background()); err != nil {
+		t.Fatalf("扫描失败: %v", err)
+	}
+
+	stats := scanner.Stats()
+	if stats.Stale {
+		t.Error("期望扫描后缓存未过期")
+	}
+	if stats.SongCount != 1 {
+		t.Errorf("期望 SongCount 为 1, 得到 %d", stats.SongCount)
+	}
+	if stats.CacheTTL != 5*time.Minute {
+		t.Errorf("期望 CacheTTL 为 5m, 得到 %v", stats.CacheTTL)
+	}
+
+	// 缩短缓存有效期以模拟过期。
+	scanner.mu.Lock()
+	scanner.cacheTTL = 10 * time.Millisecond
+	scanner.mu.Unlock()
+	time.Sleep(20 * time.Millisecond)
+
+	if !scanner.Stats().Stale {
+		t.Error("期望缓存过期后 Stale 为 true")
+	}
+}
+
+// TestMusicScanner_AdditiveMode 测试 additive 模式下暂时消失的文件仍保留在缓存中，显式刷新后才被移除。
+func TestMusicScanner_AdditiveMode(t *testing.T) {
+	tmpDir := t.TempDir()
+	externalDir := filepath.Join(tmpDir, "external")
+	if err := os.MkdirAll(externalDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "local.mp3"), []byte("fake mp3"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(externalDir, "external.mp3"), []byte("fake mp3"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	scanner := NewMusicScanner(tmpDir, []string{".mp3"}, 5, WithScanMode(ScanModeAdditive))
+	if _, err := scanner.Scan(context.Background()); err != nil {
+		t.Fatalf("扫描失败: %v", err)
+	}
+
+	// 模拟外接硬盘被拔出，并让缓存过期以触发重新扫描。
+	if err := os.RemoveAll(externalDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "new.mp3"), []byte("fake mp3"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	scanner.lastScan = time.Time{}
+
+	songs, err := scanner.Scan(context.Background())
+	if err != nil {
+		t.Fatalf("重新扫描失败: %v", err)
+	}
+	if len(songs) != 3 {
+		t.Errorf("期望 additive 模式下保留消失的歌曲并新增歌曲，共 3 首, 得到 %d", len(songs))
+	}
+
+	// 显式刷新后，已不存在的歌曲被移除。
+	if _, err := scanner.Refresh(context.Background()); err != nil {
+		t.Fatalf("刷新失败: %v", err)
+	}
+	if count := scanner.GetSongCount(); count != 2 {
+		t.Errorf("期望刷新后剩余 2 首歌曲, 得到 %d", count)
+	}
+}
+
+// TestMusicScanner_FullModeRemovesMissing 测试默认的 full 模式下消失的文件会从缓存中移除。
+func TestMusicScanner_FullModeRemovesMissing(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "a.mp3")
+	if err := os.WriteFile(testFile, []byte("fake mp3"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "b.mp3"), []byte("fake mp3"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	scanner := NewMusicScanner(tmpDir, []string{".mp3"}, 5)
+	if _, err := scanner.Scan(context.Background()); err != nil {
+		t.Fatalf("扫描失败: %v", err)
+	}
+
+	if err := os.Remove(testFile); err != nil {
+		t.Fatal(err)
+	}
+	scanner.lastScan = time.Time{}
+
+	songs, err := scanner.Scan(context.Background())
+	if err != nil {
+		t.Fatalf("重新扫描失败: %v", err)
+	}
+	if len(songs) != 1 {
+		t.Errorf("期望 full 模式下只剩 1 首歌曲, 得到 %d", len(songs))
+	}
+}
+
+// buildWAV 生成一个指定时长的静音 WAV 文件内容（8kHz、16 位、单声道）。
+func buildWAV(seconds int) []byte {
+	const sampleRate, blockAlign = 8000, 2
+	dataLen := seconds * sampleRate * blockAlign
+	buf := make([]byte, 44+dataLen)
+	copy(buf[0:], "RIFF")
+	binary.LittleEndian.PutUint32(buf[4:], uint32(36+dataLen))
+	copy(buf[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(buf[16:], 16)
+	binary.LittleEndian.PutUint16(buf[20:], 1)
+	binary.LittleEndian.PutUint16(buf[22:], 1)
+	binary.LittleEndian.PutUint32(buf[24:], sampleRate)
+	binary.LittleEndian.PutUint32(buf[28:], sampleRate*blockAlign)
+	binary.LittleEndian.PutUint16(buf[32:], blockAlign)
+	binary.LittleEndian.PutUint16(buf[34:], 16)
+	copy(buf[36:], "data")
+	binary.LittleEndian.PutUint32(buf[40:], uint32(dataLen))
+	return buf
+}
+
+// TestMusicScanner_CueSplit 测试带同名 .cue 的整轨专辑被拆分为多首虚拟歌曲。
+func TestMusicScanner_CueSplit(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "album.wav"), buildWAV(10), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cue := `REM GENRE Jazz
+PERFORMER "Band"
+TITLE "Live Album"
+FILE "album.wav" WAVE
+  TRACK 01 AUDIO
+    TITLE "Intro"
+    INDEX 01 00:00:00
+  TRACK 02 AUDIO
+    TITLE "Song"
+    PERFORMER "Guest"
+    INDEX 00 00:02:50
+    INDEX 01 00:03:00
+  TRACK 03 AUDIO
+    TITLE "Outro"
+    INDEX 01 00:07:37
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "album.cue"), []byte(cue), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	scanner := NewMusicScanner(tmpDir, []string{".wav"}, 5)
+	songs, err := scanner.Scan(context.Background())
+	if err != nil {
+		t.Fatalf("扫描失败: %v", err)
+	}
+	if len(songs) != 3 {
+		t.Fatalf("期望拆分为 3 首歌曲, 得到 %d", len(songs))
+	}
+
+	tests := []struct {
+		title    string
+		artist   string
+		track    int
+		startMS  int64
+		endMS    int64
+		duration int
+	}{
+		{"Intro", "Band", 1, 0, 3000, 3},
+		{"Song", "Guest", 2, 3000, 7493, 4},
+		{"Outro", "Band", 3, 7493, 0, 2},
+	}
+	ids := make(map[string]bool)
+	for i, tt := range tests {
+		song := songs[i]
+		if song.Title != tt.title || song.Artist != tt.artist || song.TrackNumber != tt.track {
+			t.Errorf("音轨 %d: 期望 %s/%s/%d, 得到 %s/%s/%d", i+1, tt.title, tt.artist, tt.track, song.Title, song.Artist, song.TrackNumber)
+		}
+		if song.StartMS != tt.startMS || song.EndMS != tt.endMS {
+			t.Errorf("音轨 %d: 期望起止时间 %d-%d, 得到 %d-%d", i+1, tt.startMS, tt.endMS, song.StartMS, song.EndMS)
+		}
+		if song.Duration != tt.duration {
+			t.Errorf("音轨 %d: 期望时长 %d, 得到 %d", i+1, tt.duration, song.Duration)
+		}
+		if song.Album != "Live Album" || song.Genre != "Jazz" {
+			t.Errorf("音轨 %d: 期望专辑 Live Album / 流派 Jazz, 得到 %s / %s", i+1, song.Album, song.Genre)
+		}
+		if ids[song.ID] {
+			t.Errorf("音轨 %d 的 ID 重复: %s", i+1, song.ID)
+		}
+		ids[song.ID] = true
+
+		// 片段的实际输出大小应与 FileSize 一致。
+		file, err := os.Open(song.FilePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, size, err := models.OpenTrack(file, song)
+		if err != nil {
+			t.Fatalf("打开音轨 %d 失败: %v", i+1, err)
+		}
+		data, err := io.ReadAll(content)
+		file.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if int64(len(data)) != size || size != song.FileSize {
+			t.Errorf("音轨 %d: 期望输出 %d 字节, 得到 %d (OpenTrack 报告 %d)", i+1, song.FileSize, len(data), size)
+		}
+		if string(data[:4]) != "RIFF" || int(binary.LittleEndian.Uint32(data[40:])) != len(data)-44 {
+			t.Errorf("音轨 %d: 片段的 WAV 文件头无效", i+1)
+		}
+	}
+}
+
+// TestMusicScanner_IgnoreMarkers 测试包含 .nomedia 等标记文件的目录被整体跳过。
+func TestMusicScanner_IgnoreMarkers(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	files := []string{
+		"a.mp3",
+		filepath.Join("ringtones", ".nomedia"),
+		filepath.Join("ringtones", "r.mp3"),
+		filepath.Join("ringtones", "sub", "s.mp3"),
+		filepath.Join("custom", "SKIP"),
+		filepath.Join("custom", "c.mp3"),
+	}
+	for _, name := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("fake mp3"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		opts      []ScannerOption
+		wantSongs int
+	}{
+		{"默认跳过 .nomedia 目录", nil, 2},
+		{"自定义标记文件名", []ScannerOption{WithIgnoreMarkers([]string{"SKIP"})}, 3},
+		{"禁用黑名单", []ScannerOption{WithIgnoreMarkers(nil)}, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewMusicScanner(tmpDir, []string{".mp3"}, 5, tt.opts...)
+			songs, err := scanner.Scan(context.Background())
+			if err != nil {
+				t.Fatalf("扫描失败: %v", err)
+			}
+			if len(songs) != tt.wantSongs {
+				t.Errorf("期望收录 %d 首歌曲, 得到 %d", tt.wantSongs, len(songs))
+			}
+		})
+	}
+}
+
+// TestMusicScanner_Fingerprint 测试内容指纹的稳定性：同一文件两次扫描一致，不同内容的文件不同，
+// 相同内容的文件相同，并且指纹被写入持久缓存。
+func TestMusicScanner_Fingerprint(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"a.mp3":      "song a content",
+		"b.mp3":      "song b content",
+		"copy/a.mp3": "song a content",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cache, err := NewDiskCache(filepath.Join(t.TempDir(), "cache"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fingerprints := func() map[string]string {
+		scanner := NewMusicScanner(tmpDir, []string{".mp3"}, 5, WithFingerprint(cache))
+		songs, err := scanner.Scan(context.Background())
+		if err != nil {
+			t.Fatalf("扫描失败: %v", err)
+		}
+		result := make(map[string]string)
+		for _, song := range songs {
+			rel, _ := filepath.Rel(tmpDir, song.FilePath)
+			result[filepath.ToSlash(rel)] = song.Fingerprint
+		}
+		return result
+	}
+
+	first := fingerprints()
+	second := fingerprints()
+	for name, fp := range first {
+		if fp == "" {
+			t.Errorf("%s: 期望计算出内容指纹", name)
+		}
+		if second[name] != fp {
+			t.Errorf("%s: 期望两次扫描的指纹一致, 得到 %s 与 %s", name, fp, second[name])
+		}
+	}
+	if first["a.mp3"] == first["b.mp3"] {
+		t.Error("期望不同内容的文件指纹不同")
+	}
+	if first["a.mp3"] != first["copy/a.mp3"] {
+		t.Error("期望相同内容的文件指纹相同")
+	}
+
+	// 指纹已写入缓存。
+	song := models.NewSong(filepath.Join(tmpDir, "a.mp3"), int64(len(files["a.mp3"])))
+	info, err := os.Stat(song.FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cached string
+	if !cache.GetJSON(song.ID, fingerprintCacheName, info.ModTime(), &cached) || cached != first["a.mp3"] {
+		t.Errorf("期望指纹被持久缓存, 得到 %q", cached)
+	}
+
+	// 默认不计算指纹。
+	songs, err := NewMusicScanner(tmpDir, []string{".mp3"}, 5).Scan(context.Background())
+	if err != nil {
+		t.Fatalf("扫描失败: %v", err)
+	}
+	for _, song := range songs {
+		if song.Fingerprint != "" {
+			t.Errorf("期望默认不计算指纹, 得到 %s", song.Fingerprint)
+		}
+	}
+}
+
+// encodeAcousticFingerprint 按 ComputeAcousticFingerprint 的格式编码原始指纹。
+func encodeAcousticFingerprint(values []uint32) string {
+	raw := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(raw[4*i:], v)
+	}
+	return base64.StdEncoding.EncodeToString(raw)
+}
+
+// TestAcousticSimilarity 测试声学指纹的相似度：相同或少量比特不同时接近 1，
+// 开头错开若干项时仍能对齐，无关的指纹约为 0.5，无效或过短的指纹返回错误。
+func TestAcousticSimilarity(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	randomFingerprint := func(n int) []uint32 {
+		values := make([]uint32, n)
+		for i := range values {
+			values[i] = rng.Uint32()
+		}
+		return values
+	}
+	base := randomFingerprint(200)
+	noisy := append([]uint32(nil), base...)
+	for i := range noisy {
+		noisy[i] ^= 1 << uint(i%32) // 每项翻转 1 个比特
+	}
+	shifted := append([]uint32{rng.Uint32(), rng.Uint32(), rng.Uint32()}, base...)
+
+	tests := []struct {
+		name    string
+		a, b    string
+		min     float64
+		max     float64
+		wantErr bool
+	}{
+		{"相同指纹", encodeAcousticFingerprint(base), encodeAcousticFingerprint(base), 1, 1, false},
+		{"少量比特不同", encodeAcousticFingerprint(base), encodeAcousticFingerprint(noisy), 0.95, 0.98, false},
+		{"开头错开", encodeAcousticFingerprint(base), encodeAcousticFingerprint(shifted), 1, 1, false},
+		{"无关指纹", encodeAcousticFingerprint(base), encodeAcousticFingerprint(randomFingerprint(200)), 0.4, 0.6, false},
+		{"无效指纹", "not base64!", encodeAcousticFingerprint(base), 0, 0, true},
+		{"过短", encodeAcousticFingerprint(base[:4]), encodeAcousticFingerprint(base[:4]), 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			similarity, err := models.AcousticSimilarity(tt.a, tt.b)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("期望错误 %v, 得到 %v", tt.wantErr, err)
+			}
+			if !tt.wantErr && (similarity < tt.min || similarity > tt.max) {
+				t.Errorf("期望相似度在 [%.2f, %.2f] 之间, 得到 %.3f", tt.min, tt.max, similarity)
+			}
+		})
+	}
+}
+
+// TestMusicScanner_AcousticFingerprint 测试开启声学指纹后调用 fpcalc 并解析其原始指纹，
+// 文件未变化时重新扫描不再调用 fpcalc。使用输出固定指纹的脚本代替 fpcalc。
+func TestMusicScanner_AcousticFingerprint(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "a.wav"), buildWAV(1), 0644); err != nil {
+		t.Fatal(err)
+	}
+	binDir := t.TempDir()
+	fakeFpcalc := filepath.Join(binDir, "fpcalc")
+	script := "#!/bin/sh\necho call >> \"$0.calls\"\necho '{\"duration\": 1.0, \"fingerprint\": [1, -2, 3]}'\n"
+	if err := os.WriteFile(fakeFpcalc, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	scanner := NewMusicScanner(tmpDir, []string{".wav"}, 0, WithAcousticFingerprint(fakeFpcalc, nil))
+	for i := 0; i < 2; i++ {
+		songs, err := scanner.Scan(context.Background())
+		if err != nil {
+			t.Fatalf("扫描失败: %v", err)
+		}
+		// 有符号的输出按 32 位无符号解释。
+		expected := encodeAcousticFingerprint([]uint32{1, 0xfffffffe, 3})
+		if len(songs) != 1 || songs[0].AcousticFingerprint != expected {
+			t.Fatalf("期望声学指纹为 %s, 得到 %v", expected, songs)
+		}
+	}
+	calls, err := os.ReadFile(fakeFpcalc + ".calls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(calls), "call"); n != 1 {
+		t.Errorf("期望文件未变化时只调用 1 次 fpcalc, 得到 %d 次", n)
+	}
+
+	// 默认不计算声学指纹。
+	songs, err := NewMusicScanner(tmpDir, []string{".wav"}, 5).Scan(context.Background())
+	if err != nil {
+		t.Fatalf("扫描失败: %v", err)
+	}
+	if songs[0].AcousticFingerprint != "" {
+		t.Errorf("期望默认不计算声学指纹, 得到 %s", songs[0].AcousticFingerprint)
+	}
+}
+
+// buildMelodyWAV 生成按 notes 依次演奏正弦音符的 WAV 文件内容（16 位、单声道），每个音符持续 noteSeconds 秒。
+func buildMelodyWAV(sampleRate int, notes []float64, noteSeconds float64) []byte {
+	const blockAlign = 2
+	samplesPerNote := int(float64(sampleRate) * noteSeconds)
+	dataLen := len(notes) * samplesPerNote * blockAlign
+	buf := make([]byte, 44+dataLen)
+	copy(buf[0:], "RIFF")
+	binary.LittleEndian.PutUint32(buf[4:], uint32(36+dataLen))
+	copy(buf[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(buf[16:], 16)
+	binary.LittleEndian.PutUint16(buf[20:], 1)
+	binary.LittleEndian.PutUint16(buf[22:], 1)
+	binary.LittleEndian.PutUint32(buf[24:], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(buf[28:], uint32(sampleRate*blockAlign))
+	binary.LittleEndian.PutUint16(buf[32:], blockAlign)
+	binary.LittleEndian.PutUint16(buf[34:], 16)
+	copy(buf[36:], "data")
+	binary.LittleEndian.PutUint32(buf[40:], uint32(dataLen))
+
+	pos := 44
+	for _, freq := range notes {
+		for i := 0; i < samplesPerNote; i++ {
+			sample := 0.5 * math.Sin(2*math.Pi*freq*float64(i)/float64(sampleRate))
+			binary.LittleEndian.PutUint16(buf[pos:], uint16(int16(sample*math.MaxInt16)))
+			pos += blockAlign
+		}
+	}
+	return buf
+}
+
+// TestComputeAcousticFingerprint_Encodings 测试同一段音频以不同采样率编码时声学指纹相近，
+// 不同的音频相差明显（需要 fpcalc）。
+func TestComputeAcousticFingerprint_Encodings(t *testing.T) {
+	fpcalcPath, err := exec.LookPath("fpcalc")
+	if err != nil {
+		t.Skip("未找到 fpcalc，跳过声学指纹测试")
+	}
+
+	// 两段由随机音高组成的旋律，每个音符 0.5 秒，共 20 秒。
+	rng := rand.New(rand.NewSource(1))
+	melody := func() []float64 {
+		notes := make([]float64, 40)
+		for i := range notes {
+			notes[i] = 220 * math.Pow(2, float64(rng.Intn(24))/12)
+		}
+		return notes
+	}
+	tune, other := melody(), melody()
+
+	tmpDir := t.TempDir()
+	files := map[string][]byte{
+		"tune-44k.wav":  buildMelodyWAV(44100, tune, 0.5),
+		"tune-22k.wav":  buildMelodyWAV(22050, tune, 0.5),
+		"other-44k.wav": buildMelodyWAV(44100, other, 0.5),
+	}
+	fingerprints := make(map[string]string)
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+		fingerprint, err := models.ComputeAcousticFingerprint(context.Background(), fpcalcPath, path)
+		if err != nil {
+			t.Fatalf("计算 %s 的声学指纹失败: %v", name, err)
+		}
+		fingerprints[name] = fingerprint
+	}
+
+	same, err := models.AcousticSimilarity(fingerprints["tune-44k.wav"], fingerprints["tune-22k.wav"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	different, err := models.AcousticSimilarity(fingerprints["tune-44k.wav"], fingerprints["other-44k.wav"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if same < 0.8 {
+		t.Errorf("期望同一音频不同编码的相似度不低于 0.8, 得到 %.3f", same)
+	}
+	if different >= same || different > 0.7 {
+		t.Errorf("期望不同音频的相似度明显较低, 得到 %.3f（同一音频 %.3f）", different, same)
+	}
+}
+
+// buildID3 生成只包含给定文本帧的 ID3v2.3 标签，frames 的键为帧 ID（如 TPE1），值为 ASCII 文本。
+func buildID3(frames map[string]string) []byte {
+	var body []byte
+	for id, text := range frames {
+		frame := make([]byte, 10, 10+1+len(text))
+		copy(frame, id)
+		binary.BigEndian.PutUint32(frame[4:8], uint32(1+len(text)))
+		frame = append(frame, 0x00)
+		frame = append(frame, text...)
+		body = append(body, frame...)
+	}
+	size := len(body)
+	header := []byte{'I', 'D', '3', 0x03, 0x00, 0x00,
+		byte(size >> 21 & 0x7f), byte(size >> 14 & 0x7f), byte(size >> 7 & 0x7f), byte(size & 0x7f)}
+	return append(header, body...)
+}
+
+// TestMusicScanner_InferFromPath 测试开启后无标签文件按目录结构推断艺术家与专辑，标签存在时优先使用标签。
+func TestMusicScanner_InferFromPath(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string][]byte{
+		"root.mp3":                      []byte("no tags"),
+		"Solo Artist/single.mp3":        []byte("no tags"),
+		"The Band/Greatest Hits/01.mp3": []byte("no tags"),
+		"The Band/Live/02.mp3":          append(buildID3(map[string]string{"TPE1": "Tagged Artist"}), "audio"...),
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		file   string
+		infer  bool
+		artist string
+		album  string
+	}{
+		{"root.mp3", true, "Unknown", "Unknown"},
+		{"Solo Artist/single.mp3", true, "Solo Artist", "Unknown"},
+		{"The Band/Greatest Hits/01.mp3", true, "The Band", "Greatest Hits"},
+		{"The Band/Live/02.mp3", true, "Tagged Artist", "Live"},
+		{"The Band/Greatest Hits/01.mp3", false, "Unknown", "Unknown"},
+	}
+
+	for _, tt := range tests {
+		scanner := NewMusicScanner(tmpDir, []string{".mp3"}, 5, WithInferFromPath(tt.infer))
+		songs, err := scanner.Scan(context.Background())
+		if err != nil {
+			t.Fatalf("扫描失败: %v", err)
+		}
+		var song *models.Song
+		for _, s := range songs {
+			if rel, _ := filepath.Rel(tmpDir, s.FilePath); filepath.ToSlash(rel) == tt.file {
+				song = s
+			}
+		}
+		if song == nil {
+			t.Fatalf("未找到歌曲 %s", tt.file)
+		}
+		if song.Artist != tt.artist || song.Album != tt.album {
+			t.Errorf("%s (infer=%v): 期望艺术家/专辑为 %s/%s, 得到 %s/%s", tt.file, tt.infer, tt.artist, tt.album, song.Artist, song.Album)
+		}
+	}
+}
+
+// TestMusicScanner_DirectoryUnavailable 测试音乐目录消失后返回 ErrDirectoryUnavailable，且保留上次成功扫描的缓存。
+func TestMusicScanner_DirectoryUnavailable(t *testing.T) {
+	musicDir := filepath.Join(t.TempDir(), "music")
+	if err := os.Mkdir(musicDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(musicDir, "a.mp3"), []byte("fake mp3"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	scanner := NewMusicScanner(musicDir, []string{".mp3"}, 5)
+	if _, err := scanner.Scan(context.Background()); err != nil {
+		t.Fatalf("扫描失败: %v", err)
+	}
+
+	if err := os.RemoveAll(musicDir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := scanner.Refresh(context.Background()); !errors.Is(err, ErrDirectoryUnavailable) {
+		t.Fatalf("期望返回 ErrDirectoryUnavailable, 得到 %v", err)
+	}
+	if count := scanner.GetSongCount(); count != 1 {
+		t.Errorf("期望保留缓存中的 1 首歌曲, 得到 %d", count)
+	}
+	if _, err := scanner.Scan(context.Background()); !errors.Is(err, ErrDirectoryUnavailable) {
+		t.Errorf("期望后续扫描继续探测目录并返回 ErrDirectoryUnavailable, 得到 %v", err)
+	}
+}
+
+// TestMusicScanner_FilenamePattern 测试标签缺失时按文件名模式解析音轨号、艺术家、标题与专辑，
+// TestMusicScanner_FilenameEncoding 测试配置 GBK 编码后，GBK 文件名被转码为 UTF-8 标题，
+// 文件路径保留原始字节仍可打开；UTF-8 文件名与未配置编码时保持不变。
+func TestMusicScanner_FilenameEncoding(t *testing.T) {
+	tmpDir := t.TempDir()
+	gbkName, err := simplifiedchinese.GBK.NewEncoder().String("晴天 - 周杰伦.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{gbkName, "稻香.mp3"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("no tags"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	gbk, err := models.LookupFilenameEncoding("gbk")
+	if err != nil || gbk == nil {
+		t.Fatalf("期望找到 gbk 编码, 得到 %v, %v", gbk, err)
+	}
+
+	tests := []struct {
+		name     string
+		encoding encoding.Encoding
+		titles   []string
+	}{
+		{"GBK 转码", gbk, []string{"晴天 - 周杰伦", "稻香"}},
+		{"默认不转换", nil, []string{strings.TrimSuffix(gbkName, ".mp3"), "稻香"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewMusicScanner(tmpDir, []string{".mp3"}, 5, WithFilenameEncoding(tt.encoding))
+			songs, err := scanner.Scan(context.Background())
+			if err != nil {
+				t.Fatalf("扫描失败: %v", err)
+			}
+			var titles []string
+			for _, song := range songs {
+				titles = append(titles, song.Title)
+				if song.FileName != song.Title+".mp3" {
+					t.Errorf("期望文件名与标题一致, 得到 %q 与 %q", song.FileName, song.Title)
+				}
+				if _, err := os.Stat(song.FilePath); err != nil {
+					t.Errorf("期望文件路径保留原始字节可以访问, 得到 %v", err)
+				}
+			}
+			sort.Strings(titles)
+			want := append([]string(nil), tt.titles...)
+			sort.Strings(want)
+			if !reflect.DeepEqual(titles, want) {
+				t.Errorf("期望标题为 %q, 得到 %q", want, titles)
+			}
+		})
+	}
+}
+
+// TestLookupFilenameEncoding 测试文件名编码名称的解析。
+func TestLookupFilenameEncoding(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantNil bool
+		wantErr bool
+	}{
+		{"", true, false},
+		{"utf-8", true, false},
+		{"UTF8", true, false},
+		{"gbk", false, false},
+		{"shift-jis", false, false},
+		{"Shift_JIS", false, false},
+		{"bogus", true, true},
+	}
+
+	for _, tt := range tests {
+		enc, err := models.LookupFilenameEncoding(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: 期望错误 %v, 得到 %v", tt.name, tt.wantErr, err)
+		}
+		if (enc == nil) != tt.wantNil {
+			t.Errorf("%q: 期望编码为空 %v, 得到 %v", tt.name, tt.wantNil, enc)
+		}
+	}
+}
+
+// 不匹配时回退为以文件名作为标题，已有标签优先。
+func TestMusicScanner_FilenamePattern(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string][]byte{
+		"01 - The Artist - Song Title.mp3":    []byte("no tags"),
+		"Greatest Hits - 02 - Other Song.mp3": []byte("no tags"),
+		"no pattern here.mp3":                 []byte("no tags"),
+		"03 - Wrong Artist - Tagged.mp3":      append(buildID3(map[string]string{"TPE1": "Tagged Artist"}), "audio"...),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pattern := regexp.MustCompile(`^(?:(?P<album>.+) - )?(?P<track>\d+) - (?:(?P<artist>.+) - )?(?P<title>.+)$`)
+	scanner := NewMusicScanner(tmpDir, []string{".mp3"}, 5, WithFilenamePattern(pattern))
+	songs, err := scanner.Scan(context.Background())
+	if err != nil {
+		t.Fatalf("扫描失败: %v", err)
+	}
+	byName := make(map[string]*models.Song)
+	for _, song := range songs {
+		byName[song.FileName] = song
+	}
+
+	tests := []struct {
+		file   string
+		track  int
+		artist string
+		title  string
+		album  string
+	}{
+		{"01 - The Artist - Song Title.mp3", 1, "The Artist", "Song Title", "Unknown"},
+		{"Greatest Hits - 02 - Other Song.mp3", 2, "Unknown", "Other Song", "Greatest Hits"},
+		{"no pattern here.mp3", 0, "Unknown", "no pattern here", "Unknown"},
+		{"03 - Wrong Artist - Tagged.mp3", 3, "Tagged Artist", "Tagged", "Unknown"},
+	}
+	for _, tt := range tests {
+		song := byName[tt.file]
+		if song == nil {
+			t.Fatalf("未找到歌曲 %s", tt.file)
+		}
+		if song.TrackNumber != tt.track || song.Artist != tt.artist || song.Title != tt.title || song.Album != tt.album {
+			t.Errorf("%s: 期望 %d/%s/%s/%s, 得到 %d/%s/%s/%s", tt.file,
+				tt.track, tt.artist, tt.title, tt.album,
+				song.TrackNumber, song.Artist, song.Title, song.Album)
+		}
+	}
+}
+
+// oggPage 构造一个单数据包的 Ogg 页，测试中不校验 CRC。
+func oggPage(headerType byte, granule int64, sequence uint32, packet []byte) []byte {
+	page := make([]byte, 27, 27+1+len(packet))
+	copy(page, "OggS")
+	page[5] = headerType
+	binary.LittleEndian.PutUint64(page[6:], uint64(granule))
+	binary.LittleEndian.PutUint32(page[14:], 1)
+	binary.LittleEndian.PutUint32(page[18:], sequence)
+	page[26] = 1
+	page = append(page, byte(len(packet)))
+	return append(page, packet...)
+}
+
+// buildOpus 构造一个最小的 Ogg/Opus 文件：OpusHead、OpusTags 与一个粒度位置为 granule 的音频页。
+func buildOpus(preSkip uint16, sampleRate uint32, granule int64) []byte {
+	return buildOpusWithTags(preSkip, sampleRate, granule, vorbisComment())
+}
+
+// buildOpusWithTags 与 buildOpus 相同，但 OpusTags 中包含指定的 Vorbis comment。
+func buildOpusWithTags(preSkip uint16, sampleRate uint32, granule int64, comment []byte) []byte {
+	head := make([]byte, 19)
+	copy(head, "OpusHead")
+	head[8] = 1
+	head[9] = 2
+	binary.LittleEndian.PutUint16(head[10:], preSkip)
+	binary.LittleEndian.PutUint32(head[12:], sampleRate)
+	tags := append([]byte("OpusTags"), comment...)
+
+	var buf []byte
+	buf = append(buf, oggPage(0x02, 0, 0, head)...)
+	buf = append(buf, oggPage(0x00, 0, 1, tags)...)
+	buf = append(buf, oggPage(0x04, granule, 2, make([]byte, 200))...)
+	return buf
+}
+
+// vorbisComment 按 Vorbis comment 格式编码厂商字符串与 "KEY=value" 字段。
+func vorbisComment(fields ...string) []byte {
+	var buf []byte
+	appendString := func(s string) {
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(s)))
+		buf = append(buf, s...)
+	}
+	appendString("test vendor")
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(fields)))
+	for _, field := range fields {
+		appendString(field)
+	}
+	return buf
+}
+
+// buildFLACWithComments 构造一个只有 STREAMINFO 与 VORBIS_COMMENT 元数据块的 FLAC 文件。
+func buildFLACWithComments(fields ...string) []byte {
+	block := func(last bool, blockType byte, data []byte) []byte {
+		header := []byte{blockType, byte(len(data) >> 16), byte(len(data) >> 8), byte(len(data))}
+		if last {
+			header[0] |= 0x80
+		}
+		return append(header, data...)
+	}
+	buf := []byte("fLaC")
+	buf = append(buf, block(false, 0, make([]byte, 34))...)
+	buf = append(buf, block(true, 4, vorbisComment(fields...))...)
+	return append(buf, make([]byte, 64)...)
+}
+
+// TestMusicScanner_VorbisComments 测试直接解析 FLAC 与 Ogg/Opus 的 Vorbis comment，填充作曲者、碟号与注释等扩展字段。
+func TestMusicScanner_VorbisComments(t *testing.T) {
+	tmpDir := t.TempDir()
+	opus := buildOpusWithTags(0, 48000, 48000, vorbisComment("TITLE=Opus Song", "COMPOSER=Opus Composer", "DISCNUMBER=3"))
+
+	files := map[string][]byte{
+		"full.flac": buildFLACWithComments(
+			"TITLE=Goldberg Variations: Aria",
+			"ARTIST=Glenn Gould",
+			"ARTIST=Bach Ensemble",
+			"ALBUM=Goldberg Variations",
+			"GENRE=Classical",
+			"TRACKNUMBER=1/32",
+			"discnumber=2/2",
+			"COMPOSER=J. S. Bach",
+			"COMMENT=1981 recording",
+		),
+		"song.opus": opus,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	songs, err := NewMusicScanner(tmpDir, []string{".flac", ".opus"}, 5).Scan(context.Background())
+	if err != nil {
+		t.Fatalf("扫描失败: %v", err)
+	}
+	byName := make(map[string]*models.Song)
+	for _, song := range songs {
+		byName[song.FileName] = song
+	}
+
+	flac := byName["full.flac"]
+	if flac == nil {
+		t.Fatal("未找到歌曲 full.flac")
+	}
+	expected := models.Song{
+		Title:       "Goldberg Variations: Aria",
+		Artist:      "Glenn Gould; Bach Ensemble",
+		Album:       "Goldberg Variations",
+		Genre:       "Classical",
+		TrackNumber: 1,
+		DiscNumber:  2,
+		Composer:    "J. S. Bach",
+		Comment:     "1981 recording",
+	}
+	got := models.Song{
+		Title:       flac.Title,
+		Artist:      flac.Artist,
+		Album:       flac.Album,
+		Genre:       flac.Genre,
+		TrackNumber: flac.TrackNumber,
+		DiscNumber:  flac.DiscNumber,
+		Composer:    flac.Composer,
+		Comment:     flac.Comment,
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("期望 FLAC 元数据 %+v, 得到 %+v", expected, got)
+	}
+
+	opusSong := byName["song.opus"]
+	if opusSong == nil {
+		t.Fatal("未找到歌曲 song.opus")
+	}
+	if opusSong.Title != "Opus Song" || opusSong.Composer != "Opus Composer" || opusSong.DiscNumber != 3 || opusSong.Duration != 1 {
+		t.Errorf("期望 Opus 元数据 Opus Song/Opus Composer/3/1s, 得到 %s/%s/%d/%ds",
+			opusSong.Title, opusSong.Composer, opusSong.DiscNumber, opusSong.Duration)
+	}
+}
+
+// TestMusicScanner_OpusDuration 测试从 Ogg/Opus 文件解析时长与采样率，无法解析时保持为 0。
+func TestMusicScanner_OpusDuration(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string][]byte{
+		// 7 秒音频，粒度位置包含 312 个预跳过采样。
+		"song.opus": buildOpus(312, 44100, 312+7*48000+100),
+		// 未记录原始采样率时使用 48kHz。
+		"default.opus": buildOpus(0, 0, 3*48000),
+		"broken.opus":  []byte("not an ogg file"),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	songs, err := NewMusicScanner(tmpDir, []string{".opus"}, 5).Scan(context.Background())
+	if err != nil {
+		t.Fatalf("扫描失败: %v", err)
+	}
+	byName := make(map[string]*models.Song)
+	for _, song := range songs {
+		byName[song.FileName] = song
+	}
+
+	tests := []struct {
+		file       string
+		duration   int
+		sampleRate int
+	}{
+		{"song.opus", 7, 44100},
+		{"default.opus", 3, 48000},
+		{"broken.opus", 0, 0},
+	}
+	for _, tt := range tests {
+		song := byName[tt.file]
+		if song == nil {
+			t.Fatalf("未找到歌曲 %s", tt.file)
+		}
+		if song.Duration != tt.duration {
+			t.Errorf("%s: 期望时长 %d, 得到 %d", tt.file, tt.duration, song.Duration)
+		}
+		if song.SampleRate != tt.sampleRate {
+			t.Errorf("%s: 期望采样率 %d, 得到 %d", tt.file, tt.sampleRate, song.SampleRate)
+		}
+	}
+}
+
+// TestMusicScanner_IDLength 测试不同 ID 长度下生成的歌曲 ID 与校验规则一致。
+func TestMusicScanner_IDLength(t *testing.T) {
+	t.Cleanup(func() {
+		models.SetSongIDLength(models.DefaultSongIDLength)
+	})
+
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "song.mp3"), []byte("fake mp3"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, length := range []int{models.MinSongIDLength, models.DefaultSongIDLength, models.MaxSongIDLength} {
+		if err := models.SetSongIDLength(length); err != nil {
+			t.Fatalf("设置 ID 长度 %d 失败: %v", length, err)
+		}
+		songs, err := NewMusicScanner(tmpDir, []string{".mp3"}, 5).Scan(context.Background())
+		if err != nil {
+			t.Fatalf("扫描失败: %v", err)
+		}
+		id := songs[0].ID
+		if len(id) != length*2 {
+			t.Errorf("长度 %d: 期望 ID 为 %d 个字符, 得到 %q", length, length*2, id)
+		}
+		if !models.IsValidID(id) {
+			t.Errorf("长度 %d: 期望生成的 ID %q 通过校验", length, id)
+		}
+		if models.IsValidID(id + "00") {
+			t.Errorf("长度 %d: 期望其他长度的 ID 无法通过校验", length)
+		}
+	}
+
+	for _, length := range []int{models.MinSongIDLength - 1, models.MaxSongIDLength + 1} {
+		if err := models.SetSongIDLength(length); err == nil {
+			t.Errorf("期望 ID 长度 %d 被拒绝", length)
+		}
+	}
+}
+
+// TestMusicScanner_Progress 测试扫描进行中的进度，以及第二次扫描使用上次的文件数作为预计总数。
+func TestMusicScanner_Progress(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"a.mp3", "b.mp3", "c.mp3", "d.mp3"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("fake mp3"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scanner := NewMusicScanner(tmpDir, []string{".mp3"}, 5)
+	if progress := scanner.Progress(); progress != (ScanProgress{}) {
+		t.Errorf("期望扫描前进度为零值, 得到 %+v", progress)
+	}
+	if _, err := scanner.Refresh(context.Background()); err != nil {
+		t.Fatalf("扫描失败: %v", err)
+	}
+	if progress := scanner.Progress(); progress != (ScanProgress{Processed: 4, Total: 4, Percent: 100}) {
+		t.Errorf("期望扫描完成后进度为 4/4, 得到 %+v", progress)
+	}
+
+	// 在处理第二个文件时记录进度。
+	var during ScanProgress
+	scanner.walk = func(root string, fn filepath.WalkFunc) error {
+		return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err := fn(path, info, err); err != nil {
+				return err
+			}
+			if scanner.Progress().Processed == 2 && !during.Scanning {
+				during = scanner.Progress()
+			}
+			return nil
+		})
+	}
+	if _, err := scanner.Refresh(context.Background()); err != nil {
+		t.Fatalf("扫描失败: %v", err)
+	}
+	if during != (ScanProgress{Scanning: true, Processed: 2, Total: 4, Percent: 50}) {
+		t.Errorf("期望扫描中进度为 2/4, 得到 %+v", during)
+	}
+}
+
+// TestMusicScanner_ArtistSeparators 测试扫描时按分隔符拆分多艺术家，Artist 保留原始字符串。
+func TestMusicScanner_ArtistSeparators(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "A & B; c feat. C - Song.mp3"), []byte("fake mp3"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	pattern := WithFilenamePattern(regexp.MustCompile(`(?P<artist>.+) - (?P<title>.+)`))
+
+	tests := []struct {
+		name        string
+		opts        []ScannerOption
+		wantArtists []string
+	}{
+		{"默认分隔符，重复名称合并", nil, []string{"A & B", "c"}},
+		{"自定义分隔符", []ScannerOption{WithArtistSeparators([]string{"&"})}, []string{"A", "B; c feat. C"}},
+		{"不拆分", []ScannerOption{WithArtistSeparators(nil)}, []string{"A & B; c feat. C"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewMusicScanner(tmpDir, []string{".mp3"}, 5, append(tt.opts, pattern)...)
+			songs, err := scanner.Scan(context.Background())
+			if err != nil {
+				t.Fatalf("扫描失败: %v", err)
+			}
+			if len(songs) != 1 {
+				t.Fatalf("期望 1 首歌曲, 得到 %d", len(songs))
+			}
+			if songs[0].Artist != "A & B; c feat. C" {
+				t.Errorf("期望 Artist 保留原始字符串, 得到 %q", songs[0].Artist)
+			}
+			if !reflect.DeepEqual(songs[0].Artists, tt.wantArtists) {
+				t.Errorf("期望 Artists 为 %q, 得到 %q", tt.wantArtists, songs[0].Artists)
+			}
+		})
+	}
+}
+
+// TestMusicScanner_ChangeDetection 测试两种变更检测方式下，未修改的文件复用上次解析的标签，
+// hash 模式只在内容真正变化时重新解析，不受 mtime 影响。
+func TestMusicScanner_ChangeDetection(t *testing.T) {
+	testCases := []struct {
+		name        string
+		mode        string
+		change      string // "none"、"touch"（只修改 mtime）或 "content"（修改内容但保留 mtime）
+		wantReparse bool
+	}{
+		{"mtime 未修改", ChangeDetectionMTime, "none", false},
+		{"mtime 只修改 mtime", ChangeDetectionMTime, "touch", true},
+		{"mtime 保留 mtime 修改内容", ChangeDetectionMTime, "content", false},
+		{"hash 未修改", ChangeDetectionHash, "none", false},
+		{"hash 只修改 mtime", ChangeDetectionHash, "touch", false},
+		{"hash 保留 mtime 修改内容", ChangeDetectionHash, "content", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "song.mp3")
+			if err := os.WriteFile(path, []byte("fake mp3 v1"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+			if err := os.Chtimes(path, modTime, modTime); err != nil {
+				t.Fatal(err)
+			}
+
+			scanner := NewMusicScanner(filepath.Dir(path), []string{".mp3"}, 5, WithChangeDetection(tc.mode))
+			parses := 0
+			scanner.parseTags = func(r io.ReadSeeker) (tag.Metadata, error) {
+				parses++
+				return nil, tag.ErrNoTagsFound
+			}
+			if _, err := scanner.Refresh(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			switch tc.change {
+			case "touch":
+				touched := modTime.Add(time.Minute)
+				if err := os.Chtimes(path, touched, touched); err != nil {
+					t.Fatal(err)
+				}
+			case "content":
+				if err := os.WriteFile(path, []byte("fake mp3 v2"), 0644); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Chtimes(path, modTime, modTime); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err := scanner.Refresh(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			want := 1
+			if tc.wantReparse {
+				want = 2
+			}
+			if parses != want {
+				t.Errorf("期望解析标签 %d 次, 得到 %d", want, parses)
+			}
+			if songs := scanner.GetSongs(); len(songs) != 1 {
+				t.Errorf("期望 1 首歌曲, 得到 %d", len(songs))
+			}
+		})
+	}
+}
+
+// TestMusicScanner_GetSongByIDDuringScan 测试扫描持有写锁期间 GetSongByID 不被阻塞，返回上次扫描的结果。
+func TestMusicScanner_GetSongByIDDuringScan(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "a.mp3"), []byte("fake mp3 a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	scanner := NewMusicScanner(tmpDir, []string{".mp3"}, 5)
+	songs, err := scanner.Scan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scanner.mu.Lock()
+	defer scanner.mu.Unlock()
+	song := scanner.GetSongByID(songs[0].ID)
+	if song == nil || song.ID != songs[0].ID {
+		t.Errorf("期望找到歌曲 %s, 得到 %v", songs[0].ID, song)
+	}
+	if found := scanner.GetSongsByIDs([]string{songs[0].ID, "missing"}); len(found) != 1 {
+		t.Errorf("期望批量查找到 1 首歌曲, 得到 %d", len(found))
+	}
+}
+
+// benchmarkSongs 生成 n 首 ID 格式与真实歌曲相同的歌曲。
+func benchmarkSongs(n int) []*models.Song {
+	songs := make([]*models.Song, n)
+	for i := range songs {
+		hash := sha256.Sum256([]byte(fmt.Sprintf("song-%d", i)))
+		songs[i] = &models.Song{ID: hex.EncodeToString(hash[:16]), Title: fmt.Sprintf("song-%d", i)}
+	}
+	return songs
+}
+
+// rwMutexSongIndex 是改造前的索引：扫描持有写锁原地重建 map，查找时获取读锁，作为不可变索引的对照。
+type rwMutexSongIndex struct {
+	mu    sync.RWMutex
+	songs map[string]*models.Song
+}
+
+// rebuild 在写锁内清空并重建索引。
+func (idx *rwMutexSongIndex) rebuild(songs []*models.Song) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	clear(idx.songs)
+	for _, song := range songs {
+		idx.songs[song.ID] = song
+	}
+}
+
+// get 在读锁内查找歌曲并返回副本。
+func (idx *rwMutexSongIndex) get(id string) *models.Song {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	if song, ok := idx.songs[id]; ok {
+		return song.Clone()
+	}
+	return nil
+}
+
+// benchmarkLookupDuringRebuild 在后台不断重建索引的同时并发查找歌曲，统计查找的吞吐。
+func benchmarkLookupDuringRebuild(b *testing.B, songs []*models.Song, rebuild func(), get func(id string) *models.Song) {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				rebuild()
+			}
+		}
+	}()
+
+	var worker atomic.Int64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := int(worker.Add(1)) * 997
+		for pb.Next() {
+			if get(songs[i%len(songs)].ID) == nil {
+				b.Error("期望找到歌曲")
+				return
+			}
+			i++
+		}
+	})
+	b.StopTimer()
+	close(stop)
+	<-done
+}
+
+// BenchmarkSongIndex_LookupDuringRebuild 对比扫描重建索引期间，不可变索引（原子替换）与读写锁索引的并发查找吞吐。
+func BenchmarkSongIndex_LookupDuringRebuild(b *testing.B) {
+	songs := benchmarkSongs(4096)
+
+	b.Run("immutable", func(b *testing.B) {
+		scanner := NewMusicScanner(b.TempDir(), []string{".mp3"}, 5)
+		rebuild := func() {
+			index := make(map[string]*models.Song, len(songs))
+			for _, song := range songs {
+				index[song.ID] = song
+			}
+			scanner.songIndex.Store(&index)
+		}
+		rebuild()
+		benchmarkLookupDuringRebuild(b, songs, rebuild, scanner.GetSongByID)
+	})
+
+	b.Run("rwmutex", func(b *testing.B) {
+		idx := &rwMutexSongIndex{songs: make(map[string]*models.Song, len(songs))}
+		rebuild := func() { idx.rebuild(songs) }
+		rebuild()
+		benchmarkLookupDuringRebuild(b, songs, rebuild, idx.get)
+	})
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxTagLength 是单个自定义标签允许的最大字符数。
+const MaxTagLength = 32
+
+// NormalizeTag 规范化自定义标签：去除首尾空白并转换为小写。
+// 标签为空、超过 MaxTagLength 个字符、包含 "/" 或控制字符时返回错误。
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" {
+		return "", errors.New("标签不能为空")
+	}
+	if utf8.RuneCountInString(tag) > MaxTagLength {
+		return "", fmt.Errorf("标签不能超过 %d 个字符", MaxTagLength)
+	}
+	if strings.ContainsFunc(tag, func(r rune) bool { return r == '/' || unicode.IsControl(r) }) {
+		return "", errors.New("标签不能包含 / 或控制字符")
+	}
+	return tag, nil
+}
+
+// TagStore 定义了歌曲自定义标签存储的接口。
+// 标签与扫描结果通过歌曲 ID 关联，没有标签的歌曲没有记录。
+type TagStore interface {
+	// SongTags 返回所有歌曲 ID 到其标签列表的映射副本，标签按名称排序。
+	SongTags() map[string][]string
+
+	// AddTags 为歌曲添加标签，已存在的标签被忽略。标签应已通过 NormalizeTag 规范化。
+	AddTags(songID string, tags ...string) error
+
+	// RemoveTag 移除歌曲的一个标签，标签不存在时不做任何事。
+	RemoveTag(songID, tag string) error
+}
+
+// FileTagStore 是将自定义标签持久化到 JSON 文件的 TagStore 实现。
+type FileTagStore struct {
+	path string
+	mu   sync.RWMutex
+	tags map[string][]string
+}
+
+// NewFileTagStore 创建一个新的 FileTagStore，并从 path 加载已有的标签。
+// 文件不存在时从空记录开始。
+func NewFileTagStore(path string) (*FileTagStore, error) {
+	store := &FileTagStore{
+		path: path,
+		tags: make(map[string][]string),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, fmt.Errorf("读取标签文件失败: %v", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &store.tags); err != nil {
+			return nil, fmt.Errorf("解析标签文件失败: %v", err)
+		}
+	}
+	return store, nil
+}
+
+// SongTags 返回所有歌曲 ID 到其标签列表的映射副本。
+func (s *FileTagStore) SongTags() map[string][]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	tags := make(map[string][]string, len(s.tags))
+	for id, songTags := range s.tags {
+		tags[id] = slices.Clone(songTags)
+	}
+	return tags
+}
+
+// AddTags 为歌曲添加标签，并立即写入文件。
+func (s *FileTagStore) AddTags(songID string, tags ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	songTags := slices.Clone(s.tags[songID])
+	for _, tag := range tags {
+		if !slices.Contains(songTags, tag) {
+			songTags = append(songTags, tag)
+		}
+	}
+	if len(songTags) == len(s.tags[songID]) {
+		return nil
+	}
+	slices.Sort(songTags)
+	s.tags[songID] = songTags
+	return s.save()
+}
+
+// RemoveTag 移除歌曲的一个标签，并立即写入文件。歌曲的最后一个标签被移除时删除整条记录。
+func (s *FileTagStore) RemoveTag(songID, tag string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	index := slices.Index(s.tags[songID], tag)
+	if index < 0 {
+		return nil
+	}
+	songTags := slices.Delete(slices.Clone(s.tags[songID]), index, index+1)
+	if len(songTags) == 0 {
+		delete(s.tags, songID)
+	} else {
+		s.tags[songID] = songTags
+	}
+	return s.save()
+}
+
+// save 将所有标签写入文件。
+// 先写入临时文件再重命名，避免写入中途崩溃导致文件损坏。
+// 调用此函数前必须获取写锁。
+func (s *FileTagStore) save() error {
+	data, err := json.Marshal(s.tags)
+	if err != nil {
+		return fmt.Errorf("序列化标签失败: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("创建标签目录失败: %v", err)
+	}
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("写入标签文件失败: %v", err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("写入标签文件失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+// Package web 打包了内置的极简网页播放器静态资源。
+package web
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed static
+var files embed.FS
+
+// FS 返回以静态资源目录为根的文件系统，根目录下包含 index.html。
+func FS() fs.FS {
+	sub, err := fs.Sub(files, "static")
+	if err != nil {
+		// static 目录在编译期嵌入，不可能不存在。
+		panic(err)
+	}
+	return sub
+}
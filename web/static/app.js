@@ -0,0 +1,65 @@
+(function () {
+  "use strict";
+
+  var list = document.getElementById("songs");
+  var search = document.getElementById("search");
+  var player = document.getElementById("player");
+  var nowPlaying = document.getElementById("now-playing");
+  var songs = [];
+  var current = -1;
+
+  function render() {
+    var keyword = search.value.trim().toLowerCase();
+    list.innerHTML = "";
+    songs.forEach(function (song, index) {
+      var text = [song.title, song.artist, song.album].join(" ").toLowerCase();
+      if (keyword && text.indexOf(keyword) < 0) {
+        return;
+      }
+      var item = document.createElement("li");
+      var title = document.createElement("div");
+      var meta = document.createElement("div");
+      title.textContent = song.title;
+      meta.className = "meta";
+      meta.textContent = song.artist + " · " + song.album;
+      item.appendChild(title);
+      item.appendChild(meta);
+      if (index === current) {
+        item.className = "playing";
+      }
+      item.addEventListener("click", function () {
+        play(index);
+      });
+      list.appendChild(item);
+    });
+  }
+
+  function play(index) {
+    var song = songs[index];
+    if (!song) {
+      return;
+    }
+    current = index;
+    player.src = "/api/stream/" + encodeURIComponent(song.id);
+    player.play();
+    nowPlaying.textContent = song.title + " - " + song.artist;
+    render();
+  }
+
+  player.addEventListener("ended", function () {
+    play(current + 1);
+  });
+  search.addEventListener("input", render);
+
+  fetch("/api/songs?fields=id,title,artist,album")
+    .then(function (resp) {
+      return resp.json();
+    })
+    .then(function (data) {
+      songs = data.songs || [];
+      render();
+    })
+    .catch(function (err) {
+      nowPlaying.textContent = "加载歌曲列表失败: " + err;
+    });
+})();